@@ -65,6 +65,12 @@ func main() {
 	// be added before calling pflag.Parse().
 	pflag.CommandLine.AddFlagSet(zap.FlagSet())
 
+	// Traces are exported over OTLP/HTTP if an endpoint is set by the flag or the OTEL_EXPORTER_OTLP_ENDPOINT
+	// environment variable
+	var tracingConfig common.TracingConfig
+	pflag.StringVar(&tracingConfig.Endpoint, "otlp-endpoint", "", "host:port of the OTLP/HTTP collector to export traces to")
+	pflag.BoolVar(&tracingConfig.Insecure, "otlp-insecure", false, "Export traces to the OTLP/HTTP collector without TLS")
+
 	// Add flags registered by imported packages (e.g. glog and
 	// controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
@@ -82,6 +88,20 @@ func main() {
 
 	printVersion()
 
+	if tracingConfig.Enabled() {
+		shutdownTracing, err := common.InitTracing(context.Background(), tracingConfig)
+		if err != nil {
+			log.Error(err, "Failed to initialize tracing")
+			os.Exit(1)
+		}
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Error(err, "Failed to export the remaining traces")
+			}
+		}()
+		log.Info("Exporting traces over OTLP/HTTP")
+	}
+
 	namespace, err := k8sutil.GetWatchNamespace()
 	if err != nil {
 		log.Error(err, "Failed to get watch namespace")
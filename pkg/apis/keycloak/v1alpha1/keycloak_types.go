@@ -23,16 +23,32 @@ type KeycloakSpec struct {
 	// Contains configuration for external Keycloak instances. Unmanaged needs to be set to true to use this.
 	// +optional
 	External KeycloakExternal `json:"external"`
+	// Authentication of the operator against the admin API with a confidential client instead of the password of
+	// the master realm admin user in the credential-<name> secret.
+	// +optional
+	OperatorAuthentication *KeycloakOperatorAuthentication `json:"operatorAuthentication,omitempty"`
 	// A list of extensions, where each one is a URL to a JAR files that will be deployed in Keycloak.
+	// Deprecated: use extensionSources, which supports checksums, authenticated downloads and OCI images.
 	// +listType=set
 	// +optional
 	Extensions []string `json:"extensions,omitempty"`
+	// A list of extensions that will be deployed in Keycloak. Each one is either downloaded from a URL,
+	// optionally verified with a sha256 checksum and using credentials from a Secret, or copied from an OCI image.
+	// +optional
+	ExtensionSources []KeycloakExtension `json:"extensionSources,omitempty"`
+	// A list of custom themes deployed to the Keycloak themes directory, where they can be selected
+	// in realm settings such as loginTheme. Keycloak pods are restarted when a theme changes.
+	// +optional
+	Themes []KeycloakTheme `json:"themes,omitempty"`
 	// Number of Keycloak instances in HA mode. Default is 1.
 	// +optional
 	Instances int `json:"instances,omitempty"`
 	// Controls external Ingress/Route settings.
 	// +optional
 	ExternalAccess KeycloakExternalAccess `json:"externalAccess,omitempty"`
+	// Controls the cert-manager integration, issuing the certificate Keycloak serves HTTPS with.
+	// +optional
+	CertManager KeycloakCertManager `json:"certManager,omitempty"`
 	// Controls external database settings.
 	// Using an external database requires providing a secret containing credentials
 	// as well as connection details. Here's an example of such secret:
@@ -61,6 +77,21 @@ type KeycloakSpec struct {
 	// For more information, please refer to the Operator documentation.
 	// +optional
 	ExternalDatabase KeycloakExternalDatabase `json:"externalDatabase,omitempty"`
+	// Controls the rotation of the database credentials. Keycloak pods are restarted whenever the credentials in
+	// the keycloak-db-secret change.
+	// +optional
+	DatabaseCredentials KeycloakDatabaseCredentials `json:"databaseCredentials,omitempty"`
+	// Controls the clustering of the Keycloak pods: how they discover each other, how many of them hold a copy of
+	// the entries of the distributed caches, the encryption of the cluster traffic and remote caches in an
+	// external Infinispan or Data Grid. Keycloak pods are restarted when it changes.
+	// +optional
+	Clustering KeycloakClustering `json:"clustering,omitempty"`
+	// Runs Keycloak in one of several sites, e.g. clusters in different regions, sharing the external database
+	// and replicating the caches between the Infinispan or Data Grid servers of the sites. Needs
+	// externalDatabase and clustering.externalInfinispan to be set. A failover to another site is requested by
+	// setting the keycloak.org/cross-site-failover annotation to the name of the site.
+	// +optional
+	CrossSite KeycloakCrossSite `json:"crossSite,omitempty"`
 	// Profile used for controlling Operator behavior. Default is empty.
 	// +optional
 	Profile string `json:"profile,omitempty"`
@@ -87,6 +118,11 @@ type KeycloakSpec struct {
 	// objects and users will have to create them manually, if needed.
 	// +optional
 	DisableMonitoringServices bool `json:"DisableDefaultServiceMonitor,omitempty"`
+	// Tunes the alerts of the PrometheusRule created by the operator: thresholds, for durations, severities and
+	// labels per alert, disabled alerts and additional rules. Groups and labels others added to the
+	// PrometheusRule are kept.
+	// +optional
+	Monitoring KeycloakMonitoring `json:"monitoring,omitempty"`
 	// Specify whether disabling the syncing of instances from the Keycloak CR to the statefulset replicas
 	// should be enabled or disabled. This option could be used when enabling HPA(horizontal pod autoscaler).
 	// Defaults to false.
@@ -94,6 +130,75 @@ type KeycloakSpec struct {
 	DisableReplicasSyncing bool `json:"disableReplicasSyncing,omitempty"`
 }
 
+type KeycloakExtension struct {
+	// File name the extension is deployed under in the Keycloak deployments directory, e.g. my-spi.jar.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9][A-Za-z0-9._-]*$`
+	Name string `json:"name"`
+	// URL of the extension to download. Either url or image needs to be set.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Hex-encoded sha256 checksum of the file downloaded from url. If set, the download is rejected
+	// and the Keycloak pod doesn't start when the checksum doesn't match.
+	// +kubebuilder:validation:Pattern=`^[a-fA-F0-9]{64}$`
+	// +optional
+	Sha256 string `json:"sha256,omitempty"`
+	// Name of a Secret in the Keycloak namespace holding the credentials for downloading from url.
+	// The Secret may contain either a "token" key, sent as a bearer token, or "username" and "password" keys,
+	// used for basic authentication.
+	// +optional
+	PullSecret string `json:"pullSecret,omitempty"`
+	// OCI image containing the extension. The image needs to provide a cp binary.
+	// Either url or image needs to be set.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Path of the extension file inside the image. Required when image is set.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Name of a kubernetes.io/dockerconfigjson Secret in the Keycloak namespace for pulling image.
+	// It's added to the image pull secrets of the Keycloak pods.
+	// +optional
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
+	// Security context of the init container fetching the extension. Defaults to the container security
+	// context of Keycloak, which for example fails images that need to run as root.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+type KeycloakTheme struct {
+	// Name of the theme directory, as referenced by realm settings such as loginTheme.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9][A-Za-z0-9._-]*$`
+	Name string `json:"name"`
+	// Key of a ConfigMap in the Keycloak namespace holding a gzipped tar archive of the theme directory,
+	// usually as binaryData. Either configMap or image needs to be set.
+	// +optional
+	ConfigMap *corev1.ConfigMapKeySelector `json:"configMap,omitempty"`
+	// OCI image containing the theme. The image needs to provide a cp binary.
+	// Either configMap or image needs to be set.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Path of the theme directory inside the image. Required when image is set.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Name of a kubernetes.io/dockerconfigjson Secret in the Keycloak namespace for pulling image.
+	// It's added to the image pull secrets of the Keycloak pods.
+	// +optional
+	ImagePullSecret string `json:"imagePullSecret,omitempty"`
+	// Security context of the init container unpacking the theme. Defaults to the container security
+	// context of Keycloak.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+type KeycloakExtensionStatus struct {
+	// File name of the extension.
+	Name string `json:"name"`
+	// URL or image the extension was loaded from.
+	Source string `json:"source"`
+	// Checksum the extension was verified with, if any.
+	// +optional
+	Sha256 string `json:"sha256,omitempty"`
+}
+
 type DeploymentSpec struct {
 	// Resources (Requests and Limits) for the Pods.
 	// +optional
@@ -113,6 +218,20 @@ type KeycloakDeploymentSpec struct {
 	// List of labels to set in the keycloak pods
 	// +optional
 	PodLabels map[string]string `json:"podlabels,omitempty"`
+	// Security context of the keycloak pods. If not set, a default compliant with the
+	// Pod Security Admission "restricted" profile is used.
+	// +optional
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// Security context of the keycloak and init containers. If not set, a default compliant with the
+	// Pod Security Admission "restricted" profile is used, including a read-only root filesystem.
+	// +optional
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+	// List of additional containers to run in the keycloak pods, next to the keycloak container.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// List of additional init containers to run in the keycloak pods, after the ones added by the operator.
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
 
 	// Experimental section
 	// NOTE: This section might change or get removed without any notice. It may also cause
@@ -123,8 +242,46 @@ type KeycloakDeploymentSpec struct {
 
 type PostgresqlDeploymentSpec struct {
 	DeploymentSpec `json:",inline"`
+	// Major version of the embedded PostgreSQL database. Defaults to 10. Raising it upgrades the database:
+	// Keycloak is scaled down, the database is dumped and restored into a new volume running the new version,
+	// then Keycloak is scaled back up. The upgrade is reported in the migration status. Downgrades aren't supported.
+	// Not used by the highly available cluster.
+	// +kubebuilder:validation:Enum="10";"12";"13";"15"
+	// +optional
+	PostgresVersion string `json:"postgresVersion,omitempty"`
+	// Runs the embedded database as a highly available cluster of a Postgres operator.
+	// +optional
+	HighAvailability PostgresqlHighAvailabilitySpec `json:"highAvailability,omitempty"`
+}
+
+type PostgresqlHighAvailabilitySpec struct {
+	// If set to true, a cluster of a primary and streaming replicas is created through a Postgres operator
+	// installed on the cluster instead of the single replica Deployment. The Postgres operator handles the
+	// failover, the keycloak-postgresql Service always points at the read-write endpoint of the cluster.
+	// Can't be enabled while the single replica Deployment exists, back the database up, delete the
+	// Deployment and restore the backup once the cluster is ready.
+	Enabled bool `json:"enabled,omitempty"`
+	// Postgres operator creating the cluster. Defaults to the one installed on the cluster, preferring
+	// CloudNativePG.
+	// +kubebuilder:validation:Enum=cloudnativepg;zalando
+	// +optional
+	Provider PostgresqlProvider `json:"provider,omitempty"`
+	// Number of PostgreSQL instances, including the primary. Defaults to 3.
+	// +kubebuilder:validation:Minimum=2
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+	// Size of the volume of each instance. Defaults to 1Gi.
+	// +optional
+	StorageSize string `json:"storageSize,omitempty"`
 }
 
+type PostgresqlProvider string
+
+var (
+	PostgresqlProviderCloudNativePG PostgresqlProvider = "cloudnativepg"
+	PostgresqlProviderZalando       PostgresqlProvider = "zalando"
+)
+
 type ExperimentalSpec struct {
 	// Arguments to the entrypoint. Translates into Container CMD.
 	// +optional
@@ -183,6 +340,65 @@ type KeycloakExternal struct {
 	ContextRoot string `json:"contextRoot,omitempty"`
 }
 
+type KeycloakOperatorAuthenticationMethod string
+
+const (
+	// The client authenticates with its client secret
+	OperatorAuthenticationClientSecret KeycloakOperatorAuthenticationMethod = "client-secret"
+	// The client authenticates with a JWT signed by its RSA private key
+	OperatorAuthenticationPrivateKeyJWT KeycloakOperatorAuthenticationMethod = "private-key-jwt"
+	// The client authenticates with its X.509 certificate in the TLS handshake
+	OperatorAuthenticationTLS KeycloakOperatorAuthenticationMethod = "tls"
+)
+
+// The operator requests tokens for the service account of the client with the client credentials grant. The
+// service account needs the realm-management roles of the realms managed through this Keycloak, or the admin
+// role of the master realm to create realms.
+type KeycloakOperatorAuthentication struct {
+	// Realm of the client. A client of another realm than master can only manage its own realm.
+	// Defaults to master.
+	// +optional
+	Realm string `json:"realm,omitempty"`
+	// Client ID of the confidential client with service accounts enabled.
+	ClientID string `json:"clientId"`
+	// How the client authenticates, one of client-secret, private-key-jwt and tls. Defaults to client-secret.
+	// +kubebuilder:validation:Enum=client-secret;private-key-jwt;tls
+	// +optional
+	Method KeycloakOperatorAuthenticationMethod `json:"method,omitempty"`
+	// Secret in the namespace of the Keycloak CR holding the credentials of the client: the client secret under
+	// clientSecret, or the PEM encoded private key under tls.key for private-key-jwt, or the PEM encoded
+	// certificate and private key under tls.crt and tls.key for tls.
+	SecretName string `json:"secretName"`
+}
+
+type KeycloakCertManager struct {
+	// If set to true, the operator creates a cert-manager Certificate covering the Keycloak service
+	// and the external access host. Keycloak pods are restarted when the certificate is renewed.
+	// Requires cert-manager to be installed on the cluster.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Issuer of the certificate.
+	// +optional
+	IssuerRef KeycloakCertManagerIssuerRef `json:"issuerRef,omitempty"`
+	// Requested lifetime of the certificate, e.g. 2160h. Defaults to the cert-manager default.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// How long before expiry the certificate is renewed, e.g. 360h. Defaults to the cert-manager default.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+type KeycloakCertManagerIssuerRef struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+	// Kind of the issuer. Defaults to Issuer.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer, for external issuers. Defaults to cert-manager.io.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
 type KeycloakExternalAccess struct {
 	// If set to true, the Operator will create an Ingress or a Route
 	// pointing to Keycloak.
@@ -206,6 +422,224 @@ type KeycloakExternalAccess struct {
 type KeycloakExternalDatabase struct {
 	// If set to true, the Operator will use an external database pointing to Keycloak. The embedded database (externalDatabase.enabled = false) is deprecated.
 	Enabled bool `json:"enabled,omitempty"`
+	// Vendor of the external database. Defaults to postgres. Host, port, database and credentials are read from
+	// the keycloak-db-secret, the port defaults to the one of the vendor. The Oracle JDBC driver isn't part of the
+	// Keycloak image and needs to be added as extension.
+	// +kubebuilder:validation:Enum=postgres;mysql;mariadb;oracle;mssql
+	// +optional
+	Vendor DatabaseVendor `json:"vendor,omitempty"`
+}
+
+type DatabaseVendor string
+
+var (
+	DatabaseVendorPostgres DatabaseVendor = "postgres"
+	DatabaseVendorMySQL    DatabaseVendor = "mysql"
+	DatabaseVendorMariaDB  DatabaseVendor = "mariadb"
+	DatabaseVendorOracle   DatabaseVendor = "oracle"
+	DatabaseVendorMSSQL    DatabaseVendor = "mssql"
+)
+
+type KeycloakDatabaseCredentials struct {
+	// How often the password of the embedded database is rotated, e.g. 720h. A rotation can also be requested
+	// at any time by changing the keycloak.org/rotate-database-credentials annotation of the Keycloak CR.
+	// Not supported with postgresDeploymentSpec.highAvailability, the password is managed by the cluster then.
+	// +optional
+	RotationPeriod *metav1.Duration `json:"rotationPeriod,omitempty"`
+	// Issues dynamic credentials for the external database from the database secrets engine of Vault. The
+	// operator logs in with its service account and writes the credentials into the keycloak-db-secret. New
+	// credentials are issued after two thirds of their lease, the lease of the previous ones is revoked once the
+	// Keycloak pods were rolled.
+	// +optional
+	Vault *KeycloakDatabaseCredentialsVault `json:"vault,omitempty"`
+}
+
+type KeycloakDatabaseCredentialsVault struct {
+	// Address of Vault, e.g. https://vault.vault.svc:8200.
+	Address string `json:"address"`
+	// Role of the database secrets engine the credentials are issued for.
+	Role string `json:"role"`
+	// Mount path of the database secrets engine. Defaults to database.
+	// +optional
+	Mount string `json:"mount,omitempty"`
+	// Role of the Kubernetes auth method the operator logs in with.
+	AuthRole string `json:"authRole"`
+	// Mount path of the Kubernetes auth method. Defaults to kubernetes.
+	// +optional
+	AuthMount string `json:"authMount,omitempty"`
+	// Name of a Secret in the Keycloak namespace holding the CA certificate of Vault under ca.crt. The system
+	// trust store is used if it's not set.
+	// +optional
+	CASecretName string `json:"caSecretName,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=DNS_PING;KUBE_PING
+type KeycloakDiscoveryProtocol string
+
+const (
+	// The pods are discovered through the DNS records of the headless keycloak-discovery Service
+	DiscoveryProtocolDNSPing KeycloakDiscoveryProtocol = "DNS_PING"
+	// The pods are discovered by listing them with the Kubernetes API
+	DiscoveryProtocolKubePing KeycloakDiscoveryProtocol = "KUBE_PING"
+)
+
+type KeycloakClustering struct {
+	// Protocol the Keycloak pods discover each other with. KUBE_PING lists the pods with the Kubernetes API, the
+	// operator grants the service account of the pods a Role to read them. If
+	// keycloakDeploymentSpec.experimental.serviceAccountName isn't set, a keycloak service account is created.
+	// Defaults to DNS_PING.
+	// +optional
+	Discovery KeycloakDiscoveryProtocol `json:"discovery,omitempty"`
+	// Number of pods holding a copy of every entry of the distributed caches. Sessions survive the loss of one
+	// pod less than the number of owners. Defaults to 2.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CacheOwners *int32 `json:"cacheOwners,omitempty"`
+	// Number of owners per distributed cache, overriding cacheOwners. The distributed caches are sessions,
+	// clientSessions, offlineSessions, offlineClientSessions, loginFailures, authenticationSessions and
+	// actionTokens.
+	// +optional
+	CacheOwnersPerCache map[string]int32 `json:"cacheOwnersPerCache,omitempty"`
+	// Encrypts the traffic between the pods with a shared AES key, kept in a keystore in the
+	// keycloak-jgroups-keystore Secret managed by the operator. Deleting the Secret rotates the key, the
+	// pods are restarted then.
+	// +optional
+	Encryption KeycloakClusterEncryption `json:"encryption,omitempty"`
+	// Stores the sessions and the work cache in an external Infinispan or Data Grid, keeping them when all
+	// Keycloak pods are restarted at once. The caches need to exist in the server.
+	// +optional
+	ExternalInfinispan *KeycloakExternalInfinispan `json:"externalInfinispan,omitempty"`
+}
+
+type KeycloakClusterEncryption struct {
+	// If set to true, the traffic between the Keycloak pods is encrypted.
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+type KeycloakExternalInfinispan struct {
+	// Host of the Hot Rod endpoint, e.g. infinispan.infinispan.svc.
+	Host string `json:"host"`
+	// Port of the Hot Rod endpoint. Defaults to 11222.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Caches stored in the server, under the same name. Defaults to work, sessions, clientSessions,
+	// offlineSessions, offlineClientSessions, loginFailures and actionTokens.
+	// +optional
+	Caches []string `json:"caches,omitempty"`
+	// Name of a Secret in the Keycloak namespace holding the username and password keys Keycloak
+	// authenticates with. Not authenticating if it isn't set.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Version of the Hot Rod protocol. Defaults to 2.9.
+	// +optional
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=ActivePassive;ActiveActive
+type KeycloakCrossSiteMode string
+
+const (
+	// Only the Keycloak pods of the active site run, the pods of the other sites are scaled down
+	CrossSiteModeActivePassive KeycloakCrossSiteMode = "ActivePassive"
+	// The Keycloak pods of all sites run and serve requests
+	CrossSiteModeActiveActive KeycloakCrossSiteMode = "ActiveActive"
+)
+
+type KeycloakCrossSite struct {
+	// If set to true, Keycloak runs as one site of a cross-site deployment.
+	Enabled bool `json:"enabled,omitempty"`
+	// Name of the site of this Keycloak. It's also the name of the site in the Infinispan or Data Grid
+	// cross-site configuration.
+	Site string `json:"site,omitempty"`
+	// Whether only the active site or all sites run Keycloak pods. Defaults to ActivePassive.
+	// +optional
+	Mode KeycloakCrossSiteMode `json:"mode,omitempty"`
+	// Site that is active until the first failover. Defaults to the first site in alphabetical order.
+	// +optional
+	PrimarySite string `json:"primarySite,omitempty"`
+	// The other sites. The operators of the sites share the active site and the health of every site through the
+	// keycloak-cross-site-<name> Secret, which the operator writes into the namespace of the Keycloak of every
+	// site. The Keycloak of every site needs to have the same name.
+	RemoteSites []KeycloakRemoteSite `json:"remoteSites,omitempty"`
+	// Name of the Infinispan resource of the Infinispan Operator in the Keycloak namespace. If it's set, the
+	// operator creates a Cache resource with backups to the remote sites for every cache stored in the
+	// external Infinispan. Else the caches and their backups need to be configured in the server.
+	// +optional
+	InfinispanCluster string `json:"infinispanCluster,omitempty"`
+	// Strategy the caches are backed up to the remote sites with, SYNC or ASYNC. Defaults to ASYNC in
+	// ActivePassive mode and to SYNC in ActiveActive mode.
+	// +kubebuilder:validation:Enum=SYNC;ASYNC
+	// +optional
+	BackupStrategy string `json:"backupStrategy,omitempty"`
+}
+
+type KeycloakRemoteSite struct {
+	// Name of the site.
+	Name string `json:"name"`
+	// Name of a Secret in the Keycloak namespace holding the kubeconfig of the cluster of the site under the
+	// kubeconfig key. The site isn't reachable for the operator if it's not set, its operator needs to
+	// reach this site then.
+	// +optional
+	KubeconfigSecret string `json:"kubeconfigSecret,omitempty"`
+	// Namespace of the Keycloak of the site. Defaults to the namespace of this Keycloak.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type KeycloakMonitoring struct {
+	// Labels added to every alert, e.g. the team the alerts are routed to.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Overrides of the alerts created by the operator, by alert name, e.g. KeycloakLoginFailedThresholdExceeded.
+	// +optional
+	Alerts map[string]KeycloakAlertOverride `json:"alerts,omitempty"`
+	// Additional alerts, added to the custom.rules group of the PrometheusRule.
+	// +optional
+	CustomRules []KeycloakAlertRule `json:"customRules,omitempty"`
+}
+
+type KeycloakAlertOverride struct {
+	// If set to true, the alert isn't created.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// Number the alert compares the metric with, e.g. 100 failed logins for KeycloakLoginFailedThresholdExceeded.
+	// Only alerts with a threshold accept it: KeycloakJavaNonHeapThresholdExceeded (percent),
+	// KeycloakJavaGCTimePerMinuteScavenge and KeycloakJavaGCTimePerMinuteMarkSweep (fraction of a minute),
+	// KeycloakJavaDeadlockedThreads (threads), KeycloakLoginFailedThresholdExceeded (failed logins in 5 minutes),
+	// KeycloakAPIRequestDuration90PercThresholdExceeded and KeycloakAPIRequestDuration99.5PercThresholdExceeded
+	// (fraction of fast requests).
+	// +optional
+	Threshold string `json:"threshold,omitempty"`
+	// How long the condition needs to hold before the alert fires, e.g. 10m.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(ms|s|m|h|d|w|y)$`
+	// +optional
+	For string `json:"for,omitempty"`
+	// Severity label of the alert, e.g. critical.
+	// +optional
+	Severity string `json:"severity,omitempty"`
+	// Labels added to the alert.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the alert, replacing the ones of the operator with the same name, e.g. message.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type KeycloakAlertRule struct {
+	// Name of the alert.
+	Alert string `json:"alert"`
+	// PromQL expression of the alert.
+	Expr string `json:"expr"`
+	// How long the expression needs to hold before the alert fires, e.g. 5m.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(ms|s|m|h|d|w|y)$`
+	// +optional
+	For string `json:"for,omitempty"`
+	// Labels of the alert.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations of the alert.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 type PodDisruptionBudgetConfig struct {
@@ -225,6 +659,16 @@ type MigrateConfig struct {
 	// Set it to config backup policy for migration
 	// +optional
 	Backups BackupConfig `json:"backups,omitempty"`
+	// Seconds the Keycloak pods have to become ready after an image upgrade. If they're crash looping
+	// afterwards, the database backup taken before the upgrade is restored and the previous image is
+	// pinned until the operator provides a different one. Requires backups to be enabled.
+	// Defaults to 600.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RollbackDeadlineSeconds *int32 `json:"rollbackDeadlineSeconds,omitempty"`
+	// Settings of the bluegreen strategy.
+	// +optional
+	BlueGreen BlueGreenConfig `json:"blueGreen,omitempty"`
 }
 
 type MigrationStrategy string
@@ -233,8 +677,24 @@ var (
 	NoStrategy       MigrationStrategy
 	StrategyRecreate MigrationStrategy = "recreate"
 	StrategyRolling  MigrationStrategy = "rolling"
+	// A fresh backup of the database is restored into a new database and a second StatefulSet is started on
+	// the new image against it. Once it passed the smoke checks, the Keycloak Service, and with it the Ingress
+	// or Route, is switched to it. Changes made to the previous pods after the backup are not carried over.
+	// The database user needs to be allowed to create databases.
+	StrategyBlueGreen MigrationStrategy = "bluegreen"
 )
 
+type BlueGreenConfig struct {
+	// Seconds the previous pods keep running on the previous database after the Keycloak Service was
+	// switched to the upgraded ones. Until then, the upgraded pods are rolled back if they're crash looping,
+	// fail the smoke checks or the Keycloak CR is annotated with keycloak.org/migration-rollback=true. Changes
+	// made to the upgraded pods are lost on a rollback. Once the window has passed, the previous pods are
+	// upgraded onto the cloned database as well. Defaults to 3600.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RollbackWindowSeconds *int32 `json:"rollbackWindowSeconds,omitempty"`
+}
+
 type BackupConfig struct {
 	// If set to true, the operator will do database backup before doing migration
 	Enabled bool `json:"enabled,omitempty"`
@@ -259,8 +719,149 @@ type KeycloakStatus struct {
 	ExternalURL string `json:"externalURL,omitempty"`
 	// The secret where the admin credentials are to be found.
 	CredentialSecret string `json:"credentialSecret"`
+	// Extensions from extensionSources loaded by the running Keycloak pods.
+	// +optional
+	Extensions []KeycloakExtensionStatus `json:"extensions,omitempty"`
+	// Progress of the last image migration.
+	// +optional
+	Migration *KeycloakMigrationStatus `json:"migration,omitempty"`
+	// Progress of the last major version upgrade of the embedded PostgreSQL database.
+	// +optional
+	DatabaseUpgrade *KeycloakMigrationStatus `json:"databaseUpgrade,omitempty"`
+	// Databases cloned by bluegreen migrations that haven't been dropped yet. The operator only ever drops
+	// these databases.
+	// +optional
+	ClonedDatabases []string `json:"clonedDatabases,omitempty"`
+	// Last rotation of the database credentials.
+	// +optional
+	DatabaseCredentials *KeycloakDatabaseCredentialsStatus `json:"databaseCredentials,omitempty"`
+	// State of the sites of a cross-site deployment.
+	// +optional
+	CrossSite *KeycloakCrossSiteStatus `json:"crossSite,omitempty"`
+}
+
+// KeycloakCrossSiteStatus defines the observed state of a cross-site deployment.
+// +k8s:openapi-gen=true
+type KeycloakCrossSiteStatus struct {
+	// Site that is currently active.
+	ActiveSite string `json:"activeSite,omitempty"`
+	// Number of failovers since the sites were set up, the failover with the highest number wins.
+	Generation int64 `json:"generation,omitempty"`
+	// Value of the keycloak.org/cross-site-failover annotation the last failover was requested with.
+	// +optional
+	LastFailoverRequest string `json:"lastFailoverRequest,omitempty"`
+	// Health of every site as last reported by its operator.
+	// +optional
+	Sites []KeycloakSiteStatus `json:"sites,omitempty"`
+}
+
+// KeycloakSiteStatus defines the observed state of one site of a cross-site deployment.
+// +k8s:openapi-gen=true
+type KeycloakSiteStatus struct {
+	// Name of the site.
+	Name string `json:"name"`
+	// True if the Keycloak pods of the site are ready, or if the site is passive and doesn't run any, and the
+	// operator of the site reported recently.
+	Healthy bool `json:"healthy"`
+	// Number of ready Keycloak pods of the site.
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Time the operator of the site last reported.
+	// +optional
+	LastHeartbeatTime *metav1.Time `json:"lastHeartbeatTime,omitempty"`
+	// Why the site isn't healthy.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// KeycloakDatabaseCredentialsStatus defines the observed state of the database credentials.
+// +k8s:openapi-gen=true
+type KeycloakDatabaseCredentialsStatus struct {
+	// Time the credentials were last rotated.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// Time the last rotation of the password of the embedded database failed, the previous password is kept.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// Value of the keycloak.org/rotate-database-credentials annotation the last rotation was requested with.
+	// +optional
+	LastRotationRequest string `json:"lastRotationRequest,omitempty"`
+	// Username of the credentials issued by Vault.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Lease of the credentials issued by Vault.
+	// +optional
+	LeaseID string `json:"leaseID,omitempty"`
+	// Time the lease of the credentials issued by Vault expires.
+	// +optional
+	LeaseExpirationTime *metav1.Time `json:"leaseExpirationTime,omitempty"`
+	// Lease of the credentials issued by Vault before the current ones. It's revoked once the Keycloak pods
+	// were rolled onto the current credentials.
+	// +optional
+	PreviousLeaseID string `json:"previousLeaseID,omitempty"`
 }
 
+// KeycloakMigrationStatus defines the observed state of an image migration.
+// +k8s:openapi-gen=true
+type KeycloakMigrationStatus struct {
+	// Current phase of the migration.
+	Phase MigrationPhase `json:"phase"`
+	// Image the migration started from.
+	FromImage string `json:"fromImage"`
+	// Image the migration upgrades to.
+	ToImage string `json:"toImage"`
+	// Name of the KeycloakBackup taken before the upgrade.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// Time the upgraded image was rolled out.
+	// +optional
+	UpgradeTime *metav1.Time `json:"upgradeTime,omitempty"`
+	// Database the upgraded pods of a bluegreen migration run against.
+	// +optional
+	Database string `json:"database,omitempty"`
+	// Time the Keycloak Service was switched to the upgraded pods of a bluegreen migration.
+	// +optional
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+	// Major version of the embedded PostgreSQL database the upgrade started from. Only set in databaseUpgrade,
+	// the images are the ones of the database there.
+	// +optional
+	FromDatabaseVersion string `json:"fromDatabaseVersion,omitempty"`
+	// Major version of the embedded PostgreSQL database the upgrade upgrades to.
+	// +optional
+	ToDatabaseVersion string `json:"toDatabaseVersion,omitempty"`
+	// Steps the migration went through, oldest first.
+	// +optional
+	Steps []KeycloakMigrationStep `json:"steps,omitempty"`
+}
+
+// KeycloakMigrationStep defines a step of an image migration.
+// +k8s:openapi-gen=true
+type KeycloakMigrationStep struct {
+	// Phase the migration entered.
+	Phase MigrationPhase `json:"phase"`
+	// Time the phase was entered.
+	Time metav1.Time `json:"time"`
+	// Human-readable details about the step.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type MigrationPhase string
+
+var (
+	MigrationPhaseScalingDown MigrationPhase = "ScalingDown"
+	MigrationPhaseBackingUp   MigrationPhase = "BackingUp"
+	MigrationPhaseUpgrading   MigrationPhase = "Upgrading"
+	MigrationPhaseSucceeded   MigrationPhase = "Succeeded"
+	MigrationPhaseRollingBack MigrationPhase = "RollingBack"
+	MigrationPhaseRestoring   MigrationPhase = "Restoring"
+	MigrationPhaseRolledBack  MigrationPhase = "RolledBack"
+	MigrationPhaseFailed      MigrationPhase = "Failed"
+	MigrationPhaseCloning     MigrationPhase = "Cloning"
+	MigrationPhaseDeploying   MigrationPhase = "Deploying"
+	MigrationPhaseSwitched    MigrationPhase = "Switched"
+	MigrationPhasePromoting   MigrationPhase = "Promoting"
+)
+
 type StatusPhase string
 
 var (
@@ -8,20 +8,46 @@ import (
 // +k8s:openapi-gen=true
 type KeycloakBackupSpec struct {
 	// Controls automatic restore behavior.
-	// Currently not implemented.
+	// Currently only implemented for the realms mode, where the most recent realm export (or the
+	// one selected by realmExport.restoreFrom) is imported once into the Keycloak instance.
 	//
-	// In the future this will be used to trigger automatic restore for a given KeycloakBackup.
-	// Each backup will correspond to a single snapshot of the database (stored either in a
-	// Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to
+	// For database backups this will be used in the future to trigger automatic restore for a given
+	// KeycloakBackup. Each backup will correspond to a single snapshot of the database (stored either
+	// in a Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to
 	// change this flag to true.
 	// Potentially, it will be possible to restore a single backup multiple times.
 	// +optional
 	Restore bool `json:"restore,omitempty"`
+	// What is backed up: the whole database (database) or the configuration of the realms exported
+	// through the admin API (realms). Realm backups work with external Keycloak instances as well.
+	// Defaults to database.
+	// +kubebuilder:validation:Enum={database,realms}
+	// +optional
+	Mode BackupMode `json:"mode,omitempty"`
+	// Configures the realm export of the realms mode.
+	// +optional
+	RealmExport KeycloakBackupRealmExport `json:"realmExport,omitempty"`
 	// If provided, an automatic database backup will be created on AWS S3 instead of
 	// a local Persistent Volume. If this property is not provided - a local
 	// Persistent Volume backup will be chosen.
 	// +optional
 	AWS KeycloakAWSSpec `json:"aws,omitempty"`
+	// If provided, the database backup will be uploaded to the given destination. Takes
+	// precedence over aws, exactly one destination can be set.
+	// +optional
+	Destination *KeycloakBackupDestination `json:"destination,omitempty"`
+	// If specified, it will be used as a schedule for creating a CronJob, in Cron format.
+	// Local backups with a schedule are stored as separate files in the backup Persistent Volume.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// If specified, older backups are pruned by the backup Job after every run. Not supported
+	// with aws, use destination instead.
+	// +optional
+	Retention *KeycloakBackupRetention `json:"retention,omitempty"`
+	// If enabled, every dump is restored into a scratch PostgreSQL instance and checked with a
+	// query before it is stored. Not supported with aws, use destination instead.
+	// +optional
+	Verification KeycloakBackupVerification `json:"verification,omitempty"`
 	// Selector for looking up Keycloak Custom Resources.
 	// +kubebuilder:validation:Required
 	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
@@ -67,10 +93,188 @@ type KeycloakAWSSpec struct {
 	// +kubebuilder:validation:Required
 	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
 	// If specified, it will be used as a schedule for creating a CronJob.
+	// Deprecated: use schedule of the KeycloakBackupSpec instead.
 	// +optional
 	Schedule string `json:"schedule,omitempty"`
 }
 
+// KeycloakBackupDestination defines where the database backup is stored. Exactly one
+// of the destinations has to be set.
+// +k8s:openapi-gen=true
+type KeycloakBackupDestination struct {
+	// Stores the backup in AWS S3 or any S3-compatible object storage, like MinIO or Ceph.
+	// +optional
+	S3 *KeycloakBackupS3Destination `json:"s3,omitempty"`
+	// Stores the backup in a Google Cloud Storage bucket.
+	// +optional
+	GCS *KeycloakBackupGCSDestination `json:"gcs,omitempty"`
+	// Stores the backup in an Azure Blob Storage container.
+	// +optional
+	Azure *KeycloakBackupAzureDestination `json:"azure,omitempty"`
+	// Stores the backup in a Persistent Volume.
+	// +optional
+	PersistentVolumeClaim *KeycloakBackupPVCDestination `json:"persistentVolumeClaim,omitempty"`
+}
+
+// KeycloakBackupS3Destination defines an S3-compatible backup destination.
+// +k8s:openapi-gen=true
+type KeycloakBackupS3Destination struct {
+	// Name of the bucket.
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	// Prefix prepended to the name of the backup files, e.g. "keycloak/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// URL of an S3-compatible service, e.g. https://minio.example.com:9000.
+	// Defaults to AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region of the bucket.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Use path-style instead of virtual-hosted-style bucket addressing,
+	// which most MinIO and Ceph deployments need.
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// Provides a secret name used for connecting to the service.
+	// The secret needs to be in the following form:
+	//
+	//     apiVersion: v1
+	//     kind: Secret
+	//     metadata:
+	//       name: <Secret name>
+	//     type: Opaque
+	//     stringData:
+	//       AWS_ACCESS_KEY_ID: <Access Key ID>
+	//       AWS_SECRET_ACCESS_KEY: <Secret Key>
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupGCSDestination defines a Google Cloud Storage backup destination.
+// +k8s:openapi-gen=true
+type KeycloakBackupGCSDestination struct {
+	// Name of the bucket.
+	// +kubebuilder:validation:Required
+	Bucket string `json:"bucket"`
+	// Prefix prepended to the name of the backup files, e.g. "keycloak/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Provides a secret name holding the key of a service account allowed to write to the bucket.
+	// The secret needs to be in the following form:
+	//
+	//     apiVersion: v1
+	//     kind: Secret
+	//     metadata:
+	//       name: <Secret name>
+	//     type: Opaque
+	//     stringData:
+	//       service-account.json: <Service Account Key>
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupAzureDestination defines an Azure Blob Storage backup destination.
+// +k8s:openapi-gen=true
+type KeycloakBackupAzureDestination struct {
+	// Name of the storage account.
+	// +kubebuilder:validation:Required
+	StorageAccount string `json:"storageAccount"`
+	// Name of the blob container.
+	// +kubebuilder:validation:Required
+	Container string `json:"container"`
+	// Prefix prepended to the name of the backup files, e.g. "keycloak/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Provides a secret name used for connecting to the storage account, holding either
+	// an account key or a SAS token.
+	// The secret needs to be in the following form:
+	//
+	//     apiVersion: v1
+	//     kind: Secret
+	//     metadata:
+	//       name: <Secret name>
+	//     type: Opaque
+	//     stringData:
+	//       AZURE_STORAGE_KEY: <Account Key>
+	//       AZURE_STORAGE_SAS_TOKEN: <SAS Token>
+	//
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// KeycloakBackupPVCDestination defines a Persistent Volume backup destination.
+// +k8s:openapi-gen=true
+type KeycloakBackupPVCDestination struct {
+	// Name of an existing Persistent Volume Claim to store the backups in. If not provided,
+	// a claim is created by the operator using storageClassName.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+}
+
+// KeycloakBackupRetention defines which backups are kept. A backup is kept if any of the
+// rules keeps it, if no rule is set all backups are kept.
+// +k8s:openapi-gen=true
+type KeycloakBackupRetention struct {
+	// Number of most recent backups to keep.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepLast int32 `json:"keepLast,omitempty"`
+	// Number of days for which the most recent backup of the day is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+	// Number of weeks for which the most recent backup of the week is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepWeekly int32 `json:"keepWeekly,omitempty"`
+	// Number of months for which the most recent backup of the month is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepMonthly int32 `json:"keepMonthly,omitempty"`
+}
+
+// KeycloakBackupVerification defines how backups are verified.
+// +k8s:openapi-gen=true
+type KeycloakBackupVerification struct {
+	// Enables the verification of backups.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// SQL query run against the restored database. The backup is considered broken if the
+	// query fails or returns nothing or 0. Defaults to counting the realms.
+	// +optional
+	Query string `json:"query,omitempty"`
+}
+
+// KeycloakBackupRealmExport defines which realms are exported and how they are restored.
+// Keycloak masks client secrets in exports, they have to be regenerated after restoring a client.
+// +k8s:openapi-gen=true
+type KeycloakBackupRealmExport struct {
+	// Names of the realms to export. Defaults to all realms.
+	// +optional
+	Realms []string `json:"realms,omitempty"`
+	// Exports the users of the realms as well, without their credentials.
+	// +optional
+	IncludeUsers bool `json:"includeUsers,omitempty"`
+	// What the restore does with resources which already exist in the realm: OVERWRITE, SKIP or FAIL.
+	// Defaults to SKIP.
+	// +kubebuilder:validation:Enum={OVERWRITE,SKIP,FAIL}
+	// +optional
+	IfResourceExists string `json:"ifResourceExists,omitempty"`
+	// Timestamp of the export to restore, e.g. 20230101T000000Z. Defaults to the most recent one.
+	// +optional
+	RestoreFrom string `json:"restoreFrom,omitempty"`
+}
+
+type BackupMode string
+
+var (
+	BackupModeDatabase BackupMode = "database"
+	BackupModeRealms   BackupMode = "realms"
+)
+
 type BackupStatusPhase string
 
 var (
@@ -92,6 +296,65 @@ type KeycloakBackupStatus struct {
 	Ready bool `json:"ready"`
 	// A map of all the secondary resources types and names created for this CR. e.g "Deployment": [ "DeploymentName1", "DeploymentName2" ]
 	SecondaryResources map[string][]string `json:"secondaryResources,omitempty"`
+	// Backups retained in the destination after the last successful run, newest first.
+	// +optional
+	Artifacts []KeycloakBackupArtifact `json:"artifacts,omitempty"`
+	// Time the last backup Job was scheduled at.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// Time the last successful backup Job completed at.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// The most recent backup runs, newest first.
+	// +optional
+	History []KeycloakBackupRun `json:"history,omitempty"`
+}
+
+type BackupRunPhase string
+
+var (
+	BackupRunPhaseRunning   BackupRunPhase = "Running"
+	BackupRunPhaseSucceeded BackupRunPhase = "Succeeded"
+	BackupRunPhaseFailed    BackupRunPhase = "Failed"
+)
+
+type BackupVerificationResult string
+
+var (
+	BackupVerificationPassed BackupVerificationResult = "Passed"
+	BackupVerificationFailed BackupVerificationResult = "Failed"
+)
+
+// KeycloakBackupRun describes a single run of a backup Job.
+// +k8s:openapi-gen=true
+type KeycloakBackupRun struct {
+	// Name of the backup Job.
+	JobName string `json:"jobName"`
+	// Outcome of the Job.
+	Phase BackupRunPhase `json:"phase"`
+	// Time the Job was started at.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Time the Job completed at.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Size of the created backup in bytes, if reported by the Job.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// Result of the verification, if enabled.
+	// +optional
+	Verification BackupVerificationResult `json:"verification,omitempty"`
+}
+
+// KeycloakBackupArtifact describes a single stored backup.
+// +k8s:openapi-gen=true
+type KeycloakBackupArtifact struct {
+	// Name of the backup file or object.
+	Name string `json:"name"`
+	// Time the backup was taken at.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Size of the backup in bytes.
+	Size int64 `json:"size"`
 }
 
 // KeycloakBackup is the Schema for the keycloakbackups API.
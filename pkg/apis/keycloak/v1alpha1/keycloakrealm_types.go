@@ -20,6 +20,21 @@ type KeycloakRealmSpec struct {
 	// A list of overrides to the default Realm behavior.
 	// +listType=atomic
 	RealmOverrides []*RedirectorIdentityProviderOverride `json:"realmOverrides,omitempty"`
+	// Polls the login and admin events of the realm. Logins are counted by client in the
+	// keycloak_operator_realm_logins_total metric, changes to the realm made outside of the operator are mirrored
+	// as Kubernetes Events on this KeycloakRealm or the KeycloakClient or KeycloakUser owning the changed resource.
+	// Requires eventsEnabled and adminEventsEnabled on the realm.
+	// +optional
+	EventBridge KeycloakRealmEventBridge `json:"eventBridge,omitempty"`
+}
+
+type KeycloakRealmEventBridge struct {
+	// Poll the events of the realm. Events recorded before the bridge was enabled are skipped.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval between two polls, defaults to 1m.
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
 }
 
 type KeycloakAPIRealm struct {
@@ -635,6 +650,74 @@ type TokenResponse struct {
 	ErrorDescription string `json:"error_description"`
 }
 
+// KeycloakAPIEvent is a login or other user event recorded by Keycloak.
+type KeycloakAPIEvent struct {
+	// Event time in milliseconds.
+	// +optional
+	Time int64 `json:"time,omitempty"`
+	// Event type, e.g. LOGIN or LOGIN_ERROR.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// Event realm ID.
+	// +optional
+	RealmID string `json:"realmId,omitempty"`
+	// Client ID of the client the event was recorded for.
+	// +optional
+	ClientID string `json:"clientId,omitempty"`
+	// Event user ID.
+	// +optional
+	UserID string `json:"userId,omitempty"`
+	// Event IP address.
+	// +optional
+	IPAddress string `json:"ipAddress,omitempty"`
+	// Event error, e.g. invalid_user_credentials.
+	// +optional
+	Error string `json:"error,omitempty"`
+	// Event details.
+	// +optional
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// KeycloakAPIAdminEvent is a change made through the admin API recorded by Keycloak.
+type KeycloakAPIAdminEvent struct {
+	// Event time in milliseconds.
+	// +optional
+	Time int64 `json:"time,omitempty"`
+	// Event realm ID.
+	// +optional
+	RealmID string `json:"realmId,omitempty"`
+	// Who made the change.
+	// +optional
+	AuthDetails KeycloakAPIAuthDetails `json:"authDetails,omitempty"`
+	// Operation type, one of CREATE, UPDATE, DELETE or ACTION.
+	// +optional
+	OperationType string `json:"operationType,omitempty"`
+	// Resource type, e.g. REALM, CLIENT or USER.
+	// +optional
+	ResourceType string `json:"resourceType,omitempty"`
+	// Path of the changed resource relative to the realm, e.g. clients/<id>.
+	// +optional
+	ResourcePath string `json:"resourcePath,omitempty"`
+	// Event error.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+type KeycloakAPIAuthDetails struct {
+	// Realm ID of the authenticated user.
+	// +optional
+	RealmID string `json:"realmId,omitempty"`
+	// ID of the client used.
+	// +optional
+	ClientID string `json:"clientId,omitempty"`
+	// ID of the authenticated user.
+	// +optional
+	UserID string `json:"userId,omitempty"`
+	// IP address of the authenticated user.
+	// +optional
+	IPAddress string `json:"ipAddress,omitempty"`
+}
+
 // KeycloakRealmStatus defines the observed state of KeycloakRealm
 // +k8s:openapi-gen=true
 type KeycloakRealmStatus struct {
@@ -648,6 +731,21 @@ type KeycloakRealmStatus struct {
 	SecondaryResources map[string][]string `json:"secondaryResources,omitempty"`
 	// TODO
 	LoginURL string `json:"loginURL"`
+	// Progress of the event bridge by Keycloak instance, keyed by namespace/name of the Keycloak CR.
+	// +optional
+	EventBridge map[string]KeycloakRealmEventBridgeStatus `json:"eventBridge,omitempty"`
+}
+
+type KeycloakRealmEventBridgeStatus struct {
+	// Time in milliseconds of the newest login event exported.
+	// +optional
+	EventsWatermark int64 `json:"eventsWatermark,omitempty"`
+	// Time in milliseconds of the newest admin event mirrored.
+	// +optional
+	AdminEventsWatermark int64 `json:"adminEventsWatermark,omitempty"`
+	// Time of the last successful poll.
+	// +optional
+	LastPollTime *metav1.Time `json:"lastPollTime,omitempty"`
 }
 
 // KeycloakRealm is the Schema for the keycloakrealms API
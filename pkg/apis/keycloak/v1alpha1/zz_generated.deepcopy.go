@@ -71,6 +71,27 @@ func (in *BackupConfig) DeepCopy() *BackupConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlueGreenConfig) DeepCopyInto(out *BlueGreenConfig) {
+	*out = *in
+	if in.RollbackWindowSeconds != nil {
+		in, out := &in.RollbackWindowSeconds, &out.RollbackWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlueGreenConfig.
+func (in *BlueGreenConfig) DeepCopy() *BlueGreenConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMappingsRepresentation) DeepCopyInto(out *ClientMappingsRepresentation) {
 	*out = *in
@@ -194,6 +215,39 @@ func (in *Keycloak) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAPIAdminEvent) DeepCopyInto(out *KeycloakAPIAdminEvent) {
+	*out = *in
+	out.AuthDetails = in.AuthDetails
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAPIAdminEvent.
+func (in *KeycloakAPIAdminEvent) DeepCopy() *KeycloakAPIAdminEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAPIAdminEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAPIAuthDetails) DeepCopyInto(out *KeycloakAPIAuthDetails) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAPIAuthDetails.
+func (in *KeycloakAPIAuthDetails) DeepCopy() *KeycloakAPIAuthDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAPIAuthDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakAPIAuthenticationExecution) DeepCopyInto(out *KeycloakAPIAuthenticationExecution) {
 	*out = *in
@@ -333,6 +387,29 @@ func (in *KeycloakAPIClient) DeepCopy() *KeycloakAPIClient {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAPIEvent) DeepCopyInto(out *KeycloakAPIEvent) {
+	*out = *in
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAPIEvent.
+func (in *KeycloakAPIEvent) DeepCopy() *KeycloakAPIEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAPIEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakAPIPasswordReset) DeepCopyInto(out *KeycloakAPIPasswordReset) {
 	*out = *in
@@ -793,6 +870,66 @@ func (in *KeycloakAWSSpec) DeepCopy() *KeycloakAWSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAlertOverride) DeepCopyInto(out *KeycloakAlertOverride) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAlertOverride.
+func (in *KeycloakAlertOverride) DeepCopy() *KeycloakAlertOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAlertOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakAlertRule) DeepCopyInto(out *KeycloakAlertRule) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakAlertRule.
+func (in *KeycloakAlertRule) DeepCopy() *KeycloakAlertRule {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakAlertRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackup) DeepCopyInto(out *KeycloakBackup) {
 	*out = *in
@@ -821,6 +958,91 @@ func (in *KeycloakBackup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupArtifact) DeepCopyInto(out *KeycloakBackupArtifact) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupArtifact.
+func (in *KeycloakBackupArtifact) DeepCopy() *KeycloakBackupArtifact {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupArtifact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupAzureDestination) DeepCopyInto(out *KeycloakBackupAzureDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupAzureDestination.
+func (in *KeycloakBackupAzureDestination) DeepCopy() *KeycloakBackupAzureDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupAzureDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupDestination) DeepCopyInto(out *KeycloakBackupDestination) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(KeycloakBackupS3Destination)
+		**out = **in
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(KeycloakBackupGCSDestination)
+		**out = **in
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(KeycloakBackupAzureDestination)
+		**out = **in
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(KeycloakBackupPVCDestination)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupDestination.
+func (in *KeycloakBackupDestination) DeepCopy() *KeycloakBackupDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupGCSDestination) DeepCopyInto(out *KeycloakBackupGCSDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupGCSDestination.
+func (in *KeycloakBackupGCSDestination) DeepCopy() *KeycloakBackupGCSDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupGCSDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackupList) DeepCopyInto(out *KeycloakBackupList) {
 	*out = *in
@@ -854,10 +1076,115 @@ func (in *KeycloakBackupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupPVCDestination) DeepCopyInto(out *KeycloakBackupPVCDestination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupPVCDestination.
+func (in *KeycloakBackupPVCDestination) DeepCopy() *KeycloakBackupPVCDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupPVCDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupRealmExport) DeepCopyInto(out *KeycloakBackupRealmExport) {
+	*out = *in
+	if in.Realms != nil {
+		in, out := &in.Realms, &out.Realms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupRealmExport.
+func (in *KeycloakBackupRealmExport) DeepCopy() *KeycloakBackupRealmExport {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupRealmExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupRetention) DeepCopyInto(out *KeycloakBackupRetention) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupRetention.
+func (in *KeycloakBackupRetention) DeepCopy() *KeycloakBackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupRun) DeepCopyInto(out *KeycloakBackupRun) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupRun.
+func (in *KeycloakBackupRun) DeepCopy() *KeycloakBackupRun {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakBackupS3Destination) DeepCopyInto(out *KeycloakBackupS3Destination) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupS3Destination.
+func (in *KeycloakBackupS3Destination) DeepCopy() *KeycloakBackupS3Destination {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakBackupS3Destination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakBackupSpec) DeepCopyInto(out *KeycloakBackupSpec) {
 	*out = *in
+	in.RealmExport.DeepCopyInto(&out.RealmExport)
 	out.AWS = in.AWS
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(KeycloakBackupDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(KeycloakBackupRetention)
+		**out = **in
+	}
+	out.Verification = in.Verification
 	if in.InstanceSelector != nil {
 		in, out := &in.InstanceSelector, &out.InstanceSelector
 		*out = new(metav1.LabelSelector)
@@ -899,6 +1226,28 @@ func (in *KeycloakBackupStatus) DeepCopyInto(out *KeycloakBackupStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make([]KeycloakBackupArtifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]KeycloakBackupRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -913,35 +1262,94 @@ func (in *KeycloakBackupStatus) DeepCopy() *KeycloakBackupStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeycloakClient) DeepCopyInto(out *KeycloakClient) {
+func (in *KeycloakBackupVerification) DeepCopyInto(out *KeycloakBackupVerification) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakClient.
-func (in *KeycloakClient) DeepCopy() *KeycloakClient {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakBackupVerification.
+func (in *KeycloakBackupVerification) DeepCopy() *KeycloakBackupVerification {
 	if in == nil {
 		return nil
 	}
-	out := new(KeycloakClient)
+	out := new(KeycloakBackupVerification)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeycloakClient) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakCertManager) DeepCopyInto(out *KeycloakCertManager) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
 	}
-	return nil
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
 }
 
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeycloakClientList) DeepCopyInto(out *KeycloakClientList) {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakCertManager.
+func (in *KeycloakCertManager) DeepCopy() *KeycloakCertManager {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakCertManager)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakCertManagerIssuerRef) DeepCopyInto(out *KeycloakCertManagerIssuerRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakCertManagerIssuerRef.
+func (in *KeycloakCertManagerIssuerRef) DeepCopy() *KeycloakCertManagerIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakCertManagerIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakClient) DeepCopyInto(out *KeycloakClient) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakClient.
+func (in *KeycloakClient) DeepCopy() *KeycloakClient {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeycloakClient) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakClientList) DeepCopyInto(out *KeycloakClientList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
@@ -1092,6 +1500,56 @@ func (in *KeycloakClientStatus) DeepCopy() *KeycloakClientStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakClusterEncryption) DeepCopyInto(out *KeycloakClusterEncryption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakClusterEncryption.
+func (in *KeycloakClusterEncryption) DeepCopy() *KeycloakClusterEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakClusterEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakClustering) DeepCopyInto(out *KeycloakClustering) {
+	*out = *in
+	if in.CacheOwners != nil {
+		in, out := &in.CacheOwners, &out.CacheOwners
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CacheOwnersPerCache != nil {
+		in, out := &in.CacheOwnersPerCache, &out.CacheOwnersPerCache
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.Encryption = in.Encryption
+	if in.ExternalInfinispan != nil {
+		in, out := &in.ExternalInfinispan, &out.ExternalInfinispan
+		*out = new(KeycloakExternalInfinispan)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakClustering.
+func (in *KeycloakClustering) DeepCopy() *KeycloakClustering {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakClustering)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakCredential) DeepCopyInto(out *KeycloakCredential) {
 	*out = *in
@@ -1108,6 +1566,120 @@ func (in *KeycloakCredential) DeepCopy() *KeycloakCredential {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakCrossSite) DeepCopyInto(out *KeycloakCrossSite) {
+	*out = *in
+	if in.RemoteSites != nil {
+		in, out := &in.RemoteSites, &out.RemoteSites
+		*out = make([]KeycloakRemoteSite, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakCrossSite.
+func (in *KeycloakCrossSite) DeepCopy() *KeycloakCrossSite {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakCrossSite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakCrossSiteStatus) DeepCopyInto(out *KeycloakCrossSiteStatus) {
+	*out = *in
+	if in.Sites != nil {
+		in, out := &in.Sites, &out.Sites
+		*out = make([]KeycloakSiteStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakCrossSiteStatus.
+func (in *KeycloakCrossSiteStatus) DeepCopy() *KeycloakCrossSiteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakCrossSiteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakDatabaseCredentials) DeepCopyInto(out *KeycloakDatabaseCredentials) {
+	*out = *in
+	if in.RotationPeriod != nil {
+		in, out := &in.RotationPeriod, &out.RotationPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(KeycloakDatabaseCredentialsVault)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakDatabaseCredentials.
+func (in *KeycloakDatabaseCredentials) DeepCopy() *KeycloakDatabaseCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakDatabaseCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakDatabaseCredentialsStatus) DeepCopyInto(out *KeycloakDatabaseCredentialsStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LeaseExpirationTime != nil {
+		in, out := &in.LeaseExpirationTime, &out.LeaseExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakDatabaseCredentialsStatus.
+func (in *KeycloakDatabaseCredentialsStatus) DeepCopy() *KeycloakDatabaseCredentialsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakDatabaseCredentialsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakDatabaseCredentialsVault) DeepCopyInto(out *KeycloakDatabaseCredentialsVault) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakDatabaseCredentialsVault.
+func (in *KeycloakDatabaseCredentialsVault) DeepCopy() *KeycloakDatabaseCredentialsVault {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakDatabaseCredentialsVault)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakDeploymentSpec) DeepCopyInto(out *KeycloakDeploymentSpec) {
 	*out = *in
@@ -1126,6 +1698,30 @@ func (in *KeycloakDeploymentSpec) DeepCopyInto(out *KeycloakDeploymentSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Experimental.DeepCopyInto(&out.Experimental)
 	return
 }
@@ -1140,6 +1736,43 @@ func (in *KeycloakDeploymentSpec) DeepCopy() *KeycloakDeploymentSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakExtension) DeepCopyInto(out *KeycloakExtension) {
+	*out = *in
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakExtension.
+func (in *KeycloakExtension) DeepCopy() *KeycloakExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakExtensionStatus) DeepCopyInto(out *KeycloakExtensionStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakExtensionStatus.
+func (in *KeycloakExtensionStatus) DeepCopy() *KeycloakExtensionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakExtensionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakExternal) DeepCopyInto(out *KeycloakExternal) {
 	*out = *in
@@ -1188,6 +1821,27 @@ func (in *KeycloakExternalDatabase) DeepCopy() *KeycloakExternalDatabase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakExternalInfinispan) DeepCopyInto(out *KeycloakExternalInfinispan) {
+	*out = *in
+	if in.Caches != nil {
+		in, out := &in.Caches, &out.Caches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakExternalInfinispan.
+func (in *KeycloakExternalInfinispan) DeepCopy() *KeycloakExternalInfinispan {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakExternalInfinispan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakIdentityProvider) DeepCopyInto(out *KeycloakIdentityProvider) {
 	*out = *in
@@ -1267,6 +1921,107 @@ func (in *KeycloakList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakMigrationStatus) DeepCopyInto(out *KeycloakMigrationStatus) {
+	*out = *in
+	if in.UpgradeTime != nil {
+		in, out := &in.UpgradeTime, &out.UpgradeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchTime != nil {
+		in, out := &in.SwitchTime, &out.SwitchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]KeycloakMigrationStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakMigrationStatus.
+func (in *KeycloakMigrationStatus) DeepCopy() *KeycloakMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakMigrationStep) DeepCopyInto(out *KeycloakMigrationStep) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakMigrationStep.
+func (in *KeycloakMigrationStep) DeepCopy() *KeycloakMigrationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakMigrationStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakMonitoring) DeepCopyInto(out *KeycloakMonitoring) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Alerts != nil {
+		in, out := &in.Alerts, &out.Alerts
+		*out = make(map[string]KeycloakAlertOverride, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.CustomRules != nil {
+		in, out := &in.CustomRules, &out.CustomRules
+		*out = make([]KeycloakAlertRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakMonitoring.
+func (in *KeycloakMonitoring) DeepCopy() *KeycloakMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakOperatorAuthentication) DeepCopyInto(out *KeycloakOperatorAuthentication) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakOperatorAuthentication.
+func (in *KeycloakOperatorAuthentication) DeepCopy() *KeycloakOperatorAuthentication {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakOperatorAuthentication)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakPolicy) DeepCopyInto(out *KeycloakPolicy) {
 	*out = *in
@@ -1370,6 +2125,47 @@ func (in *KeycloakRealm) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakRealmEventBridge) DeepCopyInto(out *KeycloakRealmEventBridge) {
+	*out = *in
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakRealmEventBridge.
+func (in *KeycloakRealmEventBridge) DeepCopy() *KeycloakRealmEventBridge {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakRealmEventBridge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakRealmEventBridgeStatus) DeepCopyInto(out *KeycloakRealmEventBridgeStatus) {
+	*out = *in
+	if in.LastPollTime != nil {
+		in, out := &in.LastPollTime, &out.LastPollTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakRealmEventBridgeStatus.
+func (in *KeycloakRealmEventBridgeStatus) DeepCopy() *KeycloakRealmEventBridgeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakRealmEventBridgeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakRealmList) DeepCopyInto(out *KeycloakRealmList) {
 	*out = *in
@@ -1427,6 +2223,7 @@ func (in *KeycloakRealmSpec) DeepCopyInto(out *KeycloakRealmSpec) {
 			}
 		}
 	}
+	in.EventBridge.DeepCopyInto(&out.EventBridge)
 	return
 }
 
@@ -1458,6 +2255,13 @@ func (in *KeycloakRealmStatus) DeepCopyInto(out *KeycloakRealmStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.EventBridge != nil {
+		in, out := &in.EventBridge, &out.EventBridge
+		*out = make(map[string]KeycloakRealmEventBridgeStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -1471,6 +2275,22 @@ func (in *KeycloakRealmStatus) DeepCopy() *KeycloakRealmStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakRemoteSite) DeepCopyInto(out *KeycloakRemoteSite) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakRemoteSite.
+func (in *KeycloakRemoteSite) DeepCopy() *KeycloakRemoteSite {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakRemoteSite)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakResource) DeepCopyInto(out *KeycloakResource) {
 	*out = *in
@@ -1573,27 +2393,71 @@ func (in *KeycloakScope) DeepCopy() *KeycloakScope {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakSiteStatus) DeepCopyInto(out *KeycloakSiteStatus) {
+	*out = *in
+	if in.LastHeartbeatTime != nil {
+		in, out := &in.LastHeartbeatTime, &out.LastHeartbeatTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakSiteStatus.
+func (in *KeycloakSiteStatus) DeepCopy() *KeycloakSiteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakSiteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakSpec) DeepCopyInto(out *KeycloakSpec) {
 	*out = *in
 	out.External = in.External
+	if in.OperatorAuthentication != nil {
+		in, out := &in.OperatorAuthentication, &out.OperatorAuthentication
+		*out = new(KeycloakOperatorAuthentication)
+		**out = **in
+	}
 	if in.Extensions != nil {
 		in, out := &in.Extensions, &out.Extensions
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExtensionSources != nil {
+		in, out := &in.ExtensionSources, &out.ExtensionSources
+		*out = make([]KeycloakExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Themes != nil {
+		in, out := &in.Themes, &out.Themes
+		*out = make([]KeycloakTheme, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	out.ExternalAccess = in.ExternalAccess
+	in.CertManager.DeepCopyInto(&out.CertManager)
 	out.ExternalDatabase = in.ExternalDatabase
+	in.DatabaseCredentials.DeepCopyInto(&out.DatabaseCredentials)
+	in.Clustering.DeepCopyInto(&out.Clustering)
+	in.CrossSite.DeepCopyInto(&out.CrossSite)
 	out.PodDisruptionBudget = in.PodDisruptionBudget
 	in.KeycloakDeploymentSpec.DeepCopyInto(&out.KeycloakDeploymentSpec)
 	in.PostgresDeploymentSpec.DeepCopyInto(&out.PostgresDeploymentSpec)
-	out.Migration = in.Migration
+	in.Migration.DeepCopyInto(&out.Migration)
 	if in.StorageClassName != nil {
 		in, out := &in.StorageClassName, &out.StorageClassName
 		*out = new(string)
 		**out = **in
 	}
 	out.MultiAvailablityZones = in.MultiAvailablityZones
+	in.Monitoring.DeepCopyInto(&out.Monitoring)
 	return
 }
 
@@ -1625,6 +2489,36 @@ func (in *KeycloakStatus) DeepCopyInto(out *KeycloakStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]KeycloakExtensionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(KeycloakMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DatabaseUpgrade != nil {
+		in, out := &in.DatabaseUpgrade, &out.DatabaseUpgrade
+		*out = new(KeycloakMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClonedDatabases != nil {
+		in, out := &in.ClonedDatabases, &out.ClonedDatabases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DatabaseCredentials != nil {
+		in, out := &in.DatabaseCredentials, &out.DatabaseCredentials
+		*out = new(KeycloakDatabaseCredentialsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CrossSite != nil {
+		in, out := &in.CrossSite, &out.CrossSite
+		*out = new(KeycloakCrossSiteStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1638,6 +2532,32 @@ func (in *KeycloakStatus) DeepCopy() *KeycloakStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeycloakTheme) DeepCopyInto(out *KeycloakTheme) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeycloakTheme.
+func (in *KeycloakTheme) DeepCopy() *KeycloakTheme {
+	if in == nil {
+		return nil
+	}
+	out := new(KeycloakTheme)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeycloakUser) DeepCopyInto(out *KeycloakUser) {
 	*out = *in
@@ -1787,6 +2707,12 @@ func (in *MappingsRepresentation) DeepCopy() *MappingsRepresentation {
 func (in *MigrateConfig) DeepCopyInto(out *MigrateConfig) {
 	*out = *in
 	out.Backups = in.Backups
+	if in.RollbackDeadlineSeconds != nil {
+		in, out := &in.RollbackDeadlineSeconds, &out.RollbackDeadlineSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	in.BlueGreen.DeepCopyInto(&out.BlueGreen)
 	return
 }
 
@@ -1836,6 +2762,7 @@ func (in *PodDisruptionBudgetConfig) DeepCopy() *PodDisruptionBudgetConfig {
 func (in *PostgresqlDeploymentSpec) DeepCopyInto(out *PostgresqlDeploymentSpec) {
 	*out = *in
 	in.DeploymentSpec.DeepCopyInto(&out.DeploymentSpec)
+	out.HighAvailability = in.HighAvailability
 	return
 }
 
@@ -1849,6 +2776,22 @@ func (in *PostgresqlDeploymentSpec) DeepCopy() *PostgresqlDeploymentSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresqlHighAvailabilitySpec) DeepCopyInto(out *PostgresqlHighAvailabilitySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresqlHighAvailabilitySpec.
+func (in *PostgresqlHighAvailabilitySpec) DeepCopy() *PostgresqlHighAvailabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresqlHighAvailabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedirectorIdentityProviderOverride) DeepCopyInto(out *RedirectorIdentityProviderOverride) {
 	*out = *in
@@ -13,22 +13,37 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"./pkg/apis/keycloak/v1alpha1.Keycloak":             schema_pkg_apis_keycloak_v1alpha1_Keycloak(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec":      schema_pkg_apis_keycloak_v1alpha1_KeycloakAWSSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakBackup":       schema_pkg_apis_keycloak_v1alpha1_KeycloakBackup(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupSpec":   schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupStatus": schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakClient":       schema_pkg_apis_keycloak_v1alpha1_KeycloakClient(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec":   schema_pkg_apis_keycloak_v1alpha1_KeycloakClientSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus": schema_pkg_apis_keycloak_v1alpha1_KeycloakClientStatus(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakRealm":        schema_pkg_apis_keycloak_v1alpha1_KeycloakRealm(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakRealmSpec":    schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakRealmStatus":  schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmStatus(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakSpec":         schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakStatus":       schema_pkg_apis_keycloak_v1alpha1_KeycloakStatus(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakUser":         schema_pkg_apis_keycloak_v1alpha1_KeycloakUser(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakUserSpec":     schema_pkg_apis_keycloak_v1alpha1_KeycloakUserSpec(ref),
-		"./pkg/apis/keycloak/v1alpha1.KeycloakUserStatus":   schema_pkg_apis_keycloak_v1alpha1_KeycloakUserStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.Keycloak":                          schema_pkg_apis_keycloak_v1alpha1_Keycloak(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec":                   schema_pkg_apis_keycloak_v1alpha1_KeycloakAWSSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackup":                    schema_pkg_apis_keycloak_v1alpha1_KeycloakBackup(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupArtifact":            schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupArtifact(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupAzureDestination":    schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupAzureDestination(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupDestination":         schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupDestination(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupGCSDestination":      schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupGCSDestination(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupPVCDestination":      schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupPVCDestination(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupRealmExport":         schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRealmExport(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupRetention":           schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRetention(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupRun":                 schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRun(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupS3Destination":       schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupS3Destination(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupSpec":                schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupStatus":              schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakBackupVerification":        schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupVerification(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakClient":                    schema_pkg_apis_keycloak_v1alpha1_KeycloakClient(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec":                schema_pkg_apis_keycloak_v1alpha1_KeycloakClientSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus":              schema_pkg_apis_keycloak_v1alpha1_KeycloakClientStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakCrossSiteStatus":           schema_pkg_apis_keycloak_v1alpha1_KeycloakCrossSiteStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakDatabaseCredentialsStatus": schema_pkg_apis_keycloak_v1alpha1_KeycloakDatabaseCredentialsStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStatus":           schema_pkg_apis_keycloak_v1alpha1_KeycloakMigrationStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStep":             schema_pkg_apis_keycloak_v1alpha1_KeycloakMigrationStep(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakRealm":                     schema_pkg_apis_keycloak_v1alpha1_KeycloakRealm(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakRealmSpec":                 schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakRealmStatus":               schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakSiteStatus":                schema_pkg_apis_keycloak_v1alpha1_KeycloakSiteStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakSpec":                      schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakStatus":                    schema_pkg_apis_keycloak_v1alpha1_KeycloakStatus(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakUser":                      schema_pkg_apis_keycloak_v1alpha1_KeycloakUser(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakUserSpec":                  schema_pkg_apis_keycloak_v1alpha1_KeycloakUserSpec(ref),
+		"./pkg/apis/keycloak/v1alpha1.KeycloakUserStatus":                schema_pkg_apis_keycloak_v1alpha1_KeycloakUserStatus(ref),
 	}
 }
 
@@ -102,7 +117,7 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakAWSSpec(ref common.ReferenceCallb
 					},
 					"schedule": {
 						SchemaProps: spec.SchemaProps{
-							Description: "If specified, it will be used as a schedule for creating a CronJob.",
+							Description: "If specified, it will be used as a schedule for creating a CronJob. Deprecated: use schedule of the KeycloakBackupSpec instead.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -160,53 +175,750 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackup(ref common.ReferenceCallba
 	}
 }
 
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupArtifact(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupArtifact describes a single stored backup.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the backup file or object.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"timestamp": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the backup was taken at.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size of the backup in bytes.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+				Required: []string{"name", "timestamp", "size"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupAzureDestination(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupAzureDestination defines an Azure Blob Storage backup destination.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"storageAccount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the storage account.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"container": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the blob container.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"prefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prefix prepended to the name of the backup files, e.g. \"keycloak/\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Provides a secret name used for connecting to the storage account, holding either an account key or a SAS token. The secret needs to be in the following form:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n      name: <Secret name>\n    type: Opaque\n    stringData:\n      AZURE_STORAGE_KEY: <Account Key>\n      AZURE_STORAGE_SAS_TOKEN: <SAS Token>",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"storageAccount", "container", "credentialsSecretName"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupDestination(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupDestination defines where the database backup is stored. Exactly one of the destinations has to be set.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"s3": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Stores the backup in AWS S3 or any S3-compatible object storage, like MinIO or Ceph.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupS3Destination"),
+						},
+					},
+					"gcs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Stores the backup in a Google Cloud Storage bucket.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupGCSDestination"),
+						},
+					},
+					"azure": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Stores the backup in an Azure Blob Storage container.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupAzureDestination"),
+						},
+					},
+					"persistentVolumeClaim": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Stores the backup in a Persistent Volume.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupPVCDestination"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakBackupAzureDestination", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupGCSDestination", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupPVCDestination", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupS3Destination"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupGCSDestination(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupGCSDestination defines a Google Cloud Storage backup destination.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"bucket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the bucket.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"prefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prefix prepended to the name of the backup files, e.g. \"keycloak/\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Provides a secret name holding the key of a service account allowed to write to the bucket. The secret needs to be in the following form:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n      name: <Secret name>\n    type: Opaque\n    stringData:\n      service-account.json: <Service Account Key>",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"bucket", "credentialsSecretName"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupPVCDestination(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupPVCDestination defines a Persistent Volume backup destination.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"claimName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of an existing Persistent Volume Claim to store the backups in. If not provided, a claim is created by the operator using storageClassName.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRealmExport(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupRealmExport defines which realms are exported and how they are restored. Keycloak masks client secrets in exports, they have to be regenerated after restoring a client.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"realms": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Names of the realms to export. Defaults to all realms.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"includeUsers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Exports the users of the realms as well, without their credentials.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"ifResourceExists": {
+						SchemaProps: spec.SchemaProps{
+							Description: "What the restore does with resources which already exist in the realm: OVERWRITE, SKIP or FAIL. Defaults to SKIP.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"restoreFrom": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Timestamp of the export to restore, e.g. 20230101T000000Z. Defaults to the most recent one.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRetention(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupRetention defines which backups are kept. A backup is kept if any of the rules keeps it, if no rule is set all backups are kept.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"keepLast": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of most recent backups to keep.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepDaily": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of days for which the most recent backup of the day is kept.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepWeekly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of weeks for which the most recent backup of the week is kept.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"keepMonthly": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of months for which the most recent backup of the month is kept.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupRun(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupRun describes a single run of a backup Job.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"jobName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the backup Job.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Outcome of the Job.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the Job was started at.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"completionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the Job completed at.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Size of the created backup in bytes, if reported by the Job.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"verification": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Result of the verification, if enabled.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"jobName", "phase"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupS3Destination(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupS3Destination defines an S3-compatible backup destination.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"bucket": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the bucket.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"prefix": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Prefix prepended to the name of the backup files, e.g. \"keycloak/\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"endpoint": {
+						SchemaProps: spec.SchemaProps{
+							Description: "URL of an S3-compatible service, e.g. https://minio.example.com:9000. Defaults to AWS S3.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"region": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Region of the bucket.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"forcePathStyle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Use path-style instead of virtual-hosted-style bucket addressing, which most MinIO and Ceph deployments need.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"credentialsSecretName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Provides a secret name used for connecting to the service. The secret needs to be in the following form:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n      name: <Secret name>\n    type: Opaque\n    stringData:\n      AWS_ACCESS_KEY_ID: <Access Key ID>\n      AWS_SECRET_ACCESS_KEY: <Secret Key>",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"bucket", "credentialsSecretName"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KeycloakBackupSpec defines the desired state of KeycloakBackup.",
+				Description: "KeycloakBackupSpec defines the desired state of KeycloakBackup.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"restore": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls automatic restore behavior. Currently only implemented for the realms mode, where the most recent realm export (or the one selected by realmExport.restoreFrom) is imported once into the Keycloak instance.\n\nFor database backups this will be used in the future to trigger automatic restore for a given KeycloakBackup. Each backup will correspond to a single snapshot of the database (stored either in a Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to change this flag to true. Potentially, it will be possible to restore a single backup multiple times.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "What is backed up: the whole database (database) or the configuration of the realms exported through the admin API (realms). Realm backups work with external Keycloak instances as well. Defaults to database.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"realmExport": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Configures the realm export of the realms mode.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupRealmExport"),
+						},
+					},
+					"aws": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If provided, an automatic database backup will be created on AWS S3 instead of a local Persistent Volume. If this property is not provided - a local Persistent Volume backup will be chosen.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec"),
+						},
+					},
+					"destination": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If provided, the database backup will be uploaded to the given destination. Takes precedence over aws, exactly one destination can be set.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupDestination"),
+						},
+					},
+					"schedule": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If specified, it will be used as a schedule for creating a CronJob, in Cron format. Local backups with a schedule are stored as separate files in the backup Persistent Volume.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"retention": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If specified, older backups are pruned by the backup Job after every run. Not supported with aws, use destination instead.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupRetention"),
+						},
+					},
+					"verification": {
+						SchemaProps: spec.SchemaProps{
+							Description: "If enabled, every dump is restored into a scratch PostgreSQL instance and checked with a query before it is stored. Not supported with aws, use destination instead.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupVerification"),
+						},
+					},
+					"instanceSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Selector for looking up Keycloak Custom Resources.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"storageClassName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the StorageClass for Postgresql Backup Persistent Volume Claim",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupDestination", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupRealmExport", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupRetention", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupVerification", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupStatus defines the observed state of KeycloakBackup.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Current phase of the operator.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Human-readable message indicating details about current operator phase or error.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"ready": {
+						SchemaProps: spec.SchemaProps{
+							Description: "True if all resources are in a ready state and all work is done.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"secondaryResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A map of all the secondary resources types and names created for this CR. e.g \"Deployment\": [ \"DeploymentName1\", \"DeploymentName2\" ]",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type: []string{"array"},
+										Items: &spec.SchemaOrArray{
+											Schema: &spec.Schema{
+												SchemaProps: spec.SchemaProps{
+													Default: "",
+													Type:    []string{"string"},
+													Format:  "",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					"artifacts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Backups retained in the destination after the last successful run, newest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupArtifact"),
+									},
+								},
+							},
+						},
+					},
+					"lastScheduleTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the last backup Job was scheduled at.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"lastSuccessfulTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the last successful backup Job completed at.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"history": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The most recent backup runs, newest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakBackupRun"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"phase", "message", "ready"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakBackupArtifact", "./pkg/apis/keycloak/v1alpha1.KeycloakBackupRun", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupVerification(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakBackupVerification defines how backups are verified.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"restore": {
+					"enabled": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Controls automatic restore behavior. Currently not implemented.\n\nIn the future this will be used to trigger automatic restore for a given KeycloakBackup. Each backup will correspond to a single snapshot of the database (stored either in a Persistent Volume or AWS). If a user wants to restore it, all he/she needs to do is to change this flag to true. Potentially, it will be possible to restore a single backup multiple times.",
+							Description: "Enables the verification of backups.",
 							Type:        []string{"boolean"},
 							Format:      "",
 						},
 					},
-					"aws": {
+					"query": {
 						SchemaProps: spec.SchemaProps{
-							Description: "If provided, an automatic database backup will be created on AWS S3 instead of a local Persistent Volume. If this property is not provided - a local Persistent Volume backup will be chosen.",
-							Default:     map[string]interface{}{},
-							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec"),
+							Description: "SQL query run against the restored database. The backup is considered broken if the query fails or returns nothing or 0. Defaults to counting the realms.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"instanceSelector": {
+				},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakClient(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakClient is the Schema for the keycloakclients API.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Selector for looking up Keycloak Custom Resources.",
-							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"storageClassName": {
+					"apiVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Name of the StorageClass for Postgresql Backup Persistent Volume Claim",
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakAWSSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
 	}
 }
 
-func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakClientSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KeycloakBackupStatus defines the observed state of KeycloakBackup.",
+				Description: "KeycloakClientSpec defines the desired state of KeycloakClient.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"realmSelector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Selector for looking up KeycloakRealm Custom Resources.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+						},
+					},
+					"client": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Keycloak Client REST object.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakAPIClient"),
+						},
+					},
+					"roles": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Client Roles",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.RoleRepresentation"),
+									},
+								},
+							},
+						},
+					},
+					"scopeMappings": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Scope Mappings",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.MappingsRepresentation"),
+						},
+					},
+					"serviceAccountRealmRoles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Service account realm roles for this client.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"serviceAccountClientRoles": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Service account client roles for this client.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type: []string{"array"},
+										Items: &spec.SchemaOrArray{
+											Schema: &spec.Schema{
+												SchemaProps: spec.SchemaProps{
+													Default: "",
+													Type:    []string{"string"},
+													Format:  "",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"realmSelector", "client"},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakAPIClient", "./pkg/apis/keycloak/v1alpha1.MappingsRepresentation", "./pkg/apis/keycloak/v1alpha1.RoleRepresentation", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakClientStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakClientStatus defines the observed state of KeycloakClient",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"phase": {
@@ -263,205 +975,246 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakBackupStatus(ref common.Reference
 	}
 }
 
-func schema_pkg_apis_keycloak_v1alpha1_KeycloakClient(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakCrossSiteStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KeycloakClient is the Schema for the keycloakclients API.",
+				Description: "KeycloakCrossSiteStatus defines the observed state of a cross-site deployment.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"kind": {
+					"activeSite": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Description: "Site that is currently active.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"apiVersion": {
+					"generation": {
 						SchemaProps: spec.SchemaProps{
-							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Description: "Number of failovers since the sites were set up, the failover with the highest number wins.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"lastFailoverRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value of the keycloak.org/cross-site-failover annotation the last failover was requested with.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"metadata": {
+					"sites": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+							Description: "Health of every site as last reported by its operator.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakSiteStatus"),
+									},
+								},
+							},
 						},
 					},
-					"spec": {
+				},
+			},
+		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakSiteStatus"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakDatabaseCredentialsStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakDatabaseCredentialsStatus defines the observed state of the database credentials.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"lastRotationTime": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec"),
+							Description: "Time the credentials were last rotated.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"status": {
+					"lastFailureTime": {
 						SchemaProps: spec.SchemaProps{
-							Default: map[string]interface{}{},
-							Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus"),
+							Description: "Time the last rotation of the password of the embedded database failed, the previous password is kept.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"lastRotationRequest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Value of the keycloak.org/rotate-database-credentials annotation the last rotation was requested with.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"username": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Username of the credentials issued by Vault.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"leaseID": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lease of the credentials issued by Vault.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"leaseExpirationTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the lease of the credentials issued by Vault expires.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"previousLeaseID": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Lease of the credentials issued by Vault before the current ones. It's revoked once the Keycloak pods were rolled onto the current credentials.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakClientSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakClientStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_keycloak_v1alpha1_KeycloakClientSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakMigrationStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KeycloakClientSpec defines the desired state of KeycloakClient.",
+				Description: "KeycloakMigrationStatus defines the observed state of an image migration.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"realmSelector": {
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Current phase of the migration.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fromImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Image the migration started from.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"toImage": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Selector for looking up KeycloakRealm Custom Resources.",
-							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"),
+							Description: "Image the migration upgrades to.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"client": {
+					"backup": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Keycloak Client REST object.",
-							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakAPIClient"),
+							Description: "Name of the KeycloakBackup taken before the upgrade.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"roles": {
-						VendorExtensible: spec.VendorExtensible{
-							Extensions: spec.Extensions{
-								"x-kubernetes-list-map-keys": []interface{}{
-									"name",
-								},
-								"x-kubernetes-list-type": "map",
-							},
+					"upgradeTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the upgraded image was rolled out.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
+					},
+					"database": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Client Roles",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Default: map[string]interface{}{},
-										Ref:     ref("./pkg/apis/keycloak/v1alpha1.RoleRepresentation"),
-									},
-								},
-							},
+							Description: "Database the upgraded pods of a bluegreen migration run against.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"scopeMappings": {
+					"switchTime": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Scope Mappings",
-							Ref:         ref("./pkg/apis/keycloak/v1alpha1.MappingsRepresentation"),
+							Description: "Time the Keycloak Service was switched to the upgraded pods of a bluegreen migration.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"serviceAccountRealmRoles": {
+					"fromDatabaseVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Service account realm roles for this client.",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Default: "",
-										Type:    []string{"string"},
-										Format:  "",
-									},
-								},
-							},
+							Description: "Major version of the embedded PostgreSQL database the upgrade started from. Only set in databaseUpgrade, the images are the ones of the database there.",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"serviceAccountClientRoles": {
+					"toDatabaseVersion": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Service account client roles for this client.",
-							Type:        []string{"object"},
-							AdditionalProperties: &spec.SchemaOrBool{
-								Allows: true,
+							Description: "Major version of the embedded PostgreSQL database the upgrade upgrades to.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Steps the migration went through, oldest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Type: []string{"array"},
-										Items: &spec.SchemaOrArray{
-											Schema: &spec.Schema{
-												SchemaProps: spec.SchemaProps{
-													Default: "",
-													Type:    []string{"string"},
-													Format:  "",
-												},
-											},
-										},
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStep"),
 									},
 								},
 							},
 						},
 					},
 				},
-				Required: []string{"realmSelector", "client"},
+				Required: []string{"phase", "fromImage", "toImage"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakAPIClient", "./pkg/apis/keycloak/v1alpha1.MappingsRepresentation", "./pkg/apis/keycloak/v1alpha1.RoleRepresentation", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStep", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
-func schema_pkg_apis_keycloak_v1alpha1_KeycloakClientStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakMigrationStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "KeycloakClientStatus defines the observed state of KeycloakClient",
+				Description: "KeycloakMigrationStep defines a step of an image migration.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
 					"phase": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Current phase of the operator.",
+							Description: "Phase the migration entered.",
 							Default:     "",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"message": {
+					"time": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Human-readable message indicating details about current operator phase or error.",
-							Default:     "",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Time the phase was entered.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
-					"ready": {
+					"message": {
 						SchemaProps: spec.SchemaProps{
-							Description: "True if all resources are in a ready state and all work is done.",
-							Default:     false,
-							Type:        []string{"boolean"},
+							Description: "Human-readable details about the step.",
+							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
-					"secondaryResources": {
-						SchemaProps: spec.SchemaProps{
-							Description: "A map of all the secondary resources types and names created for this CR. e.g \"Deployment\": [ \"DeploymentName1\", \"DeploymentName2\" ]",
-							Type:        []string{"object"},
-							AdditionalProperties: &spec.SchemaOrBool{
-								Allows: true,
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Type: []string{"array"},
-										Items: &spec.SchemaOrArray{
-											Schema: &spec.Schema{
-												SchemaProps: spec.SchemaProps{
-													Default: "",
-													Type:    []string{"string"},
-													Format:  "",
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
 				},
-				Required: []string{"phase", "message", "ready"},
+				Required: []string{"phase", "time"},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -556,12 +1309,19 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmSpec(ref common.ReferenceCal
 							},
 						},
 					},
+					"eventBridge": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Polls the login and admin events of the realm. Logins are counted by client in the keycloak_operator_realm_logins_total metric, changes to the realm made outside of the operator are mirrored as Kubernetes Events on this KeycloakRealm or the KeycloakClient or KeycloakUser owning the changed resource. Requires eventsEnabled and adminEventsEnabled on the realm.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakRealmEventBridge"),
+						},
+					},
 				},
 				Required: []string{"realm"},
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakAPIRealm", "./pkg/apis/keycloak/v1alpha1.RedirectorIdentityProviderOverride", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakAPIRealm", "./pkg/apis/keycloak/v1alpha1.KeycloakRealmEventBridge", "./pkg/apis/keycloak/v1alpha1.RedirectorIdentityProviderOverride", "k8s.io/apimachinery/pkg/apis/meta/v1.LabelSelector"},
 	}
 }
 
@@ -626,10 +1386,80 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakRealmStatus(ref common.ReferenceC
 							Format:  "",
 						},
 					},
+					"eventBridge": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Progress of the event bridge by Keycloak instance, keyed by namespace/name of the Keycloak CR.",
+							Type:        []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakRealmEventBridgeStatus"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"phase", "message", "ready", "loginURL"},
 			},
 		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakRealmEventBridgeStatus"},
+	}
+}
+
+func schema_pkg_apis_keycloak_v1alpha1_KeycloakSiteStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "KeycloakSiteStatus defines the observed state of one site of a cross-site deployment.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Name of the site.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"healthy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "True if the Keycloak pods of the site are ready, or if the site is passive and doesn't run any, and the operator of the site reported recently.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"readyReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of ready Keycloak pods of the site.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"lastHeartbeatTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Time the operator of the site last reported.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Why the site isn't healthy.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "healthy", "readyReplicas"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -654,6 +1484,12 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakExternal"),
 						},
 					},
+					"operatorAuthentication": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Authentication of the operator against the admin API with a confidential client instead of the password of the master realm admin user in the credential-<name> secret.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakOperatorAuthentication"),
+						},
+					},
 					"extensions": {
 						VendorExtensible: spec.VendorExtensible{
 							Extensions: spec.Extensions{
@@ -661,7 +1497,7 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							},
 						},
 						SchemaProps: spec.SchemaProps{
-							Description: "A list of extensions, where each one is a URL to a JAR files that will be deployed in Keycloak.",
+							Description: "A list of extensions, where each one is a URL to a JAR files that will be deployed in Keycloak. Deprecated: use extensionSources, which supports checksums, authenticated downloads and OCI images.",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
@@ -674,6 +1510,34 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"extensionSources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A list of extensions that will be deployed in Keycloak. Each one is either downloaded from a URL, optionally verified with a sha256 checksum and using credentials from a Secret, or copied from an OCI image.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakExtension"),
+									},
+								},
+							},
+						},
+					},
+					"themes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A list of custom themes deployed to the Keycloak themes directory, where they can be selected in realm settings such as loginTheme. Keycloak pods are restarted when a theme changes.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakTheme"),
+									},
+								},
+							},
+						},
+					},
 					"instances": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Number of Keycloak instances in HA mode. Default is 1.",
@@ -688,6 +1552,13 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakExternalAccess"),
 						},
 					},
+					"certManager": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls the cert-manager integration, issuing the certificate Keycloak serves HTTPS with.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakCertManager"),
+						},
+					},
 					"externalDatabase": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Controls external database settings. Using an external database requires providing a secret containing credentials as well as connection details. Here's an example of such secret:\n\n    apiVersion: v1\n    kind: Secret\n    metadata:\n        name: keycloak-db-secret\n        namespace: keycloak\n    stringData:\n        POSTGRES_DATABASE: <Database Name>\n        POSTGRES_EXTERNAL_ADDRESS: <External Database IP or URL (resolvable by K8s)>\n        POSTGRES_EXTERNAL_PORT: <External Database Port>\n        # Strongly recommended to use <'Keycloak CR Name'-postgresql>\n        POSTGRES_HOST: <Database Service Name>\n        POSTGRES_PASSWORD: <Database Password>\n        # Required for AWS Backup functionality\n        POSTGRES_SUPERUSER: true\n        POSTGRES_USERNAME: <Database Username>\n     type: Opaque\n\nBoth POSTGRES_EXTERNAL_ADDRESS and POSTGRES_EXTERNAL_PORT are specifically required for creating connection to the external database. The secret name is created using the following convention:\n      <Custom Resource Name>-db-secret\n\nFor more information, please refer to the Operator documentation.",
@@ -695,6 +1566,27 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase"),
 						},
 					},
+					"databaseCredentials": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls the rotation of the database credentials. Keycloak pods are restarted whenever the credentials in the keycloak-db-secret change.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakDatabaseCredentials"),
+						},
+					},
+					"clustering": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Controls the clustering of the Keycloak pods: how they discover each other, how many of them hold a copy of the entries of the distributed caches, the encryption of the cluster traffic and remote caches in an external Infinispan or Data Grid. Keycloak pods are restarted when it changes.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakClustering"),
+						},
+					},
+					"crossSite": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Runs Keycloak in one of several sites, e.g. clusters in different regions, sharing the external database and replicating the caches between the Infinispan or Data Grid servers of the sites. Needs externalDatabase and clustering.externalInfinispan to be set. A failover to another site is requested by setting the keycloak.org/cross-site-failover annotation to the name of the site.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakCrossSite"),
+						},
+					},
 					"profile": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Profile used for controlling Operator behavior. Default is empty.",
@@ -751,6 +1643,13 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 							Format:      "",
 						},
 					},
+					"monitoring": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tunes the alerts of the PrometheusRule created by the operator: thresholds, for durations, severities and labels per alert, disabled alerts and additional rules. Groups and labels others added to the PrometheusRule are kept.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakMonitoring"),
+						},
+					},
 					"disableReplicasSyncing": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Specify whether disabling the syncing of instances from the Keycloak CR to the statefulset replicas should be enabled or disabled. This option could be used when enabling HPA(horizontal pod autoscaler). Defaults to false.",
@@ -762,7 +1661,7 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakSpec(ref common.ReferenceCallback
 			},
 		},
 		Dependencies: []string{
-			"./pkg/apis/keycloak/v1alpha1.KeycloakDeploymentSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakExternal", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalAccess", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase", "./pkg/apis/keycloak/v1alpha1.MigrateConfig", "./pkg/apis/keycloak/v1alpha1.MultiAvailablityZonesConfig", "./pkg/apis/keycloak/v1alpha1.PodDisruptionBudgetConfig", "./pkg/apis/keycloak/v1alpha1.PostgresqlDeploymentSpec"},
+			"./pkg/apis/keycloak/v1alpha1.KeycloakCertManager", "./pkg/apis/keycloak/v1alpha1.KeycloakClustering", "./pkg/apis/keycloak/v1alpha1.KeycloakCrossSite", "./pkg/apis/keycloak/v1alpha1.KeycloakDatabaseCredentials", "./pkg/apis/keycloak/v1alpha1.KeycloakDeploymentSpec", "./pkg/apis/keycloak/v1alpha1.KeycloakExtension", "./pkg/apis/keycloak/v1alpha1.KeycloakExternal", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalAccess", "./pkg/apis/keycloak/v1alpha1.KeycloakExternalDatabase", "./pkg/apis/keycloak/v1alpha1.KeycloakMonitoring", "./pkg/apis/keycloak/v1alpha1.KeycloakOperatorAuthentication", "./pkg/apis/keycloak/v1alpha1.KeycloakTheme", "./pkg/apis/keycloak/v1alpha1.MigrateConfig", "./pkg/apis/keycloak/v1alpha1.MultiAvailablityZonesConfig", "./pkg/apis/keycloak/v1alpha1.PodDisruptionBudgetConfig", "./pkg/apis/keycloak/v1alpha1.PostgresqlDeploymentSpec"},
 	}
 }
 
@@ -851,10 +1750,65 @@ func schema_pkg_apis_keycloak_v1alpha1_KeycloakStatus(ref common.ReferenceCallba
 							Format:      "",
 						},
 					},
+					"extensions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Extensions from extensionSources loaded by the running Keycloak pods.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("./pkg/apis/keycloak/v1alpha1.KeycloakExtensionStatus"),
+									},
+								},
+							},
+						},
+					},
+					"migration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Progress of the last image migration.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStatus"),
+						},
+					},
+					"databaseUpgrade": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Progress of the last major version upgrade of the embedded PostgreSQL database.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStatus"),
+						},
+					},
+					"clonedDatabases": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Databases cloned by bluegreen migrations that haven't been dropped yet. The operator only ever drops these databases.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"databaseCredentials": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Last rotation of the database credentials.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakDatabaseCredentialsStatus"),
+						},
+					},
+					"crossSite": {
+						SchemaProps: spec.SchemaProps{
+							Description: "State of the sites of a cross-site deployment.",
+							Ref:         ref("./pkg/apis/keycloak/v1alpha1.KeycloakCrossSiteStatus"),
+						},
+					},
 				},
 				Required: []string{"phase", "message", "ready", "version", "internalURL", "credentialSecret"},
 			},
 		},
+		Dependencies: []string{
+			"./pkg/apis/keycloak/v1alpha1.KeycloakCrossSiteStatus", "./pkg/apis/keycloak/v1alpha1.KeycloakDatabaseCredentialsStatus", "./pkg/apis/keycloak/v1alpha1.KeycloakExtensionStatus", "./pkg/apis/keycloak/v1alpha1.KeycloakMigrationStatus"},
 	}
 }
 
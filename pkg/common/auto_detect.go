@@ -6,6 +6,7 @@ import (
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	grafanav1alpha1 "github.com/integr8ly/grafana-operator/v3/pkg/apis/integreatly/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/k8sutil"
+	"github.com/keycloak/keycloak-operator/pkg/model"
 	routev1 "github.com/openshift/api/route/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/client-go/discovery"
@@ -51,6 +52,9 @@ func (b *Background) autoDetectCapabilities() {
 	b.detectMonitoringResources()
 	b.detectRoute()
 	b.detectPodDisruptionBudget()
+	b.detectCertManager()
+	b.detectPostgresOperators()
+	b.detectInfinispanOperator()
 }
 
 func (b *Background) detectRoute() {
@@ -95,3 +99,25 @@ func (b *Background) detectPodDisruptionBudget() {
 	stateManager := GetStateManager()
 	stateManager.SetState(PodDisruptionBudgetKind, resourceExists)
 }
+
+func (b *Background) detectCertManager() {
+	certificateGroupVersion := model.CertificateGroupVersionKind.GroupVersion().String()
+	resourceExists, _ := k8sutil.ResourceExists(b.dc, certificateGroupVersion, CertificateKind)
+	stateManager := GetStateManager()
+	stateManager.SetState(CertificateKind, resourceExists)
+}
+
+func (b *Background) detectPostgresOperators() {
+	stateManager := GetStateManager()
+	resourceExists, _ := k8sutil.ResourceExists(b.dc, model.CloudNativePGClusterGroupVersionKind.GroupVersion().String(), model.CloudNativePGClusterGroupVersionKind.Kind)
+	stateManager.SetState(CloudNativePGClusterKind, resourceExists)
+
+	resourceExists, _ = k8sutil.ResourceExists(b.dc, model.ZalandoPostgresqlGroupVersionKind.GroupVersion().String(), model.ZalandoPostgresqlGroupVersionKind.Kind)
+	stateManager.SetState(ZalandoPostgresqlKind, resourceExists)
+}
+
+func (b *Background) detectInfinispanOperator() {
+	resourceExists, _ := k8sutil.ResourceExists(b.dc, model.InfinispanCacheGroupVersionKind.GroupVersion().String(), model.InfinispanCacheGroupVersionKind.Kind)
+	stateManager := GetStateManager()
+	stateManager.SetState(InfinispanCacheKind, resourceExists)
+}
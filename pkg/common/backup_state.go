@@ -17,6 +17,11 @@ type BackupState struct {
 	LocalPersistentVolumeClaim *v1.PersistentVolumeClaim
 	AwsJob                     *v12.Job
 	AwsPeriodicJob             *v1beta1.CronJob
+	DestinationJob             *v12.Job
+	DestinationPeriodicJob     *v1beta1.CronJob
+	BackupJobs                 *v12.JobList
+	BackupPods                 *v1.PodList
+	RestoreJob                 *v12.Job
 	Keycloak                   *kc.Keycloak
 }
 
@@ -51,16 +56,41 @@ func (i *BackupState) Read(context context.Context, cr *kc.KeycloakBackup, contr
 		return err
 	}
 
+	err = i.readDestinationBackupJob(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readDestinationPeriodicBackupJob(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readBackupJobs(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readBackupPods(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readRestoreJob(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
 	return err
 }
 
 func (i *BackupState) readLocalBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
 	// decide Job type first
-	if cr.Spec.AWS.CredentialsSecretName != "" {
+	if cr.Spec.AWS.CredentialsSecretName != "" || model.PostgresqlBackupDestination(cr) != nil {
 		return nil
 	}
 
-	localBackupJob := model.PostgresqlBackup(cr)
+	localBackupJob := model.PostgresqlBackup(cr, i.Keycloak)
 	localBackupJobSelector := model.PostgresqlBackupSelector(cr)
 
 	err := controllerClient.Get(context, localBackupJobSelector, localBackupJob)
@@ -92,7 +122,7 @@ func (i *BackupState) readLocalBackupPersistentVolumeClaim(context context.Conte
 }
 
 func (i *BackupState) readAwsBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
-	if cr.Spec.AWS.CredentialsSecretName == "" {
+	if cr.Spec.AWS.CredentialsSecretName == "" || cr.Spec.Destination != nil {
 		return nil
 	}
 
@@ -127,8 +157,129 @@ func (i *BackupState) readAwsPeriodicBackupJob(context context.Context, cr *kc.K
 	return nil
 }
 
+func (i *BackupState) readDestinationBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if model.PostgresqlBackupDestination(cr) == nil {
+		return nil
+	}
+
+	destinationBackupJob := &v12.Job{}
+	destinationBackupJobSelector := model.PostgresqlDestinationBackupSelector(cr)
+
+	err := controllerClient.Get(context, destinationBackupJobSelector, destinationBackupJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.DestinationJob = destinationBackupJob
+		cr.UpdateStatusSecondaryResources(i.DestinationJob.Kind, i.DestinationJob.Name)
+	}
+	return nil
+}
+
+func (i *BackupState) readDestinationPeriodicBackupJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if model.PostgresqlBackupDestination(cr) == nil {
+		return nil
+	}
+
+	destinationPeriodicBackupJob := &v1beta1.CronJob{}
+	destinationPeriodicBackupJobSelector := model.PostgresqlDestinationPeriodicBackupSelector(cr)
+
+	err := controllerClient.Get(context, destinationPeriodicBackupJobSelector, destinationPeriodicBackupJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.DestinationPeriodicJob = destinationPeriodicBackupJob
+		cr.UpdateStatusSecondaryResources(i.DestinationPeriodicJob.Kind, i.DestinationPeriodicJob.Name)
+	}
+	return nil
+}
+
+// Jobs created by the CronJobs are found by their label, they're needed for the history of backup runs
+func (i *BackupState) readBackupJobs(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	backupJobs := &v12.JobList{}
+	err := controllerClient.List(context, backupJobs, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		model.PostgresqlBackupLabel: cr.Name,
+	})
+	if err != nil {
+		return err
+	}
+	i.BackupJobs = backupJobs
+	return nil
+}
+
+// BackupJobsForHistory returns all known backup Jobs, including the one-time Jobs of the legacy backups
+func (i *BackupState) BackupJobsForHistory() []v12.Job {
+	var jobs []v12.Job
+	for _, job := range []*v12.Job{i.LocalPersistentVolumeJob, i.AwsJob, i.DestinationJob} {
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
+	}
+	if i.BackupJobs != nil {
+		jobs = append(jobs, i.BackupJobs.Items...)
+	}
+	return jobs
+}
+
+// BackupCronJob returns the CronJob of periodic backups, if any
+func (i *BackupState) BackupCronJob() *v1beta1.CronJob {
+	if i.DestinationPeriodicJob != nil {
+		return i.DestinationPeriodicJob
+	}
+	return i.AwsPeriodicJob
+}
+
+// The pods of the backup Jobs report the retained backups
+func (i *BackupState) readBackupPods(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if model.PostgresqlBackupDestination(cr) == nil {
+		return nil
+	}
+
+	backupPods := &v1.PodList{}
+	err := controllerClient.List(context, backupPods, client.InNamespace(cr.Namespace), client.MatchingLabels{
+		model.PostgresqlBackupLabel: cr.Name,
+	})
+	if err != nil {
+		return err
+	}
+	i.BackupPods = backupPods
+	return nil
+}
+
+func (i *BackupState) readRestoreJob(context context.Context, cr *kc.KeycloakBackup, controllerClient client.Client) error {
+	if cr.Spec.Mode != kc.BackupModeRealms || !cr.Spec.Restore {
+		return nil
+	}
+
+	restoreJob := &v12.Job{}
+	restoreJobSelector := model.KeycloakRealmRestoreSelector(cr)
+
+	err := controllerClient.Get(context, restoreJobSelector, restoreJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.RestoreJob = restoreJob
+		cr.UpdateStatusSecondaryResources(i.RestoreJob.Kind, i.RestoreJob.Name)
+	}
+	return nil
+}
+
+// IsRestored returns true once the realm backup has been imported
+func (i *BackupState) IsRestored() (bool, error) {
+	return IsJobReady(i.RestoreJob)
+}
+
 func (i *BackupState) IsResourcesReady() (bool, error) {
 	switch {
+	case i.DestinationJob != nil:
+		return IsJobReady(i.DestinationJob)
+	case i.DestinationPeriodicJob != nil:
+		return true, nil
 	case i.AwsJob != nil:
 		return IsJobReady(i.AwsJob)
 	case i.LocalPersistentVolumeJob != nil:
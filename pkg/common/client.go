@@ -1,7 +1,6 @@
 package common
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -17,6 +16,7 @@ import (
 	"github.com/keycloak/keycloak-operator/pkg/model"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -24,7 +24,7 @@ import (
 )
 
 const (
-	authURL = "realms/master/protocol/openid-connect/token"
+	authURL = "realms/%s/protocol/openid-connect/token"
 )
 
 type Requester interface {
@@ -36,46 +36,35 @@ type Client struct {
 	URL         string
 	contextRoot string
 	token       string
+	credentials *adminCredentials
+	retry       *retryPolicy
+	// called when the token is rejected, e.g. to drop the client from the cache
+	unauthorized func()
 }
 
 // T is a generic type for keycloak spec resources
 type T interface{}
 
 // Generic create function for creating new Keycloak resources
-func (c *Client) create(obj T, resourcePath, resourceName string) (string, error) {
+func (c *Client) create(ctx context.Context, obj T, resourcePath, resourceName string) (string, error) {
 	jsonValue, err := json.Marshal(obj)
 	if err != nil {
 		logrus.Errorf("error %+v marshalling object", err)
-		return "", nil
+		return "", errors.Wrapf(err, "error marshalling %s", resourceName)
 	}
 
-	req, err := http.NewRequest(
-		"POST",
-		fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath),
-		bytes.NewBuffer(jsonValue),
-	)
-	if err != nil {
-		logrus.Errorf("error creating POST %s request %+v", resourceName, err)
-		return "", errors.Wrapf(err, "error creating POST %s request", resourceName)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
-
+	res, body, err := c.do(ctx, "POST", fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath), jsonValue)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return "", errors.Wrapf(err, "error performing POST %s request", resourceName)
 	}
-	defer res.Body.Close()
 
 	if res.StatusCode != 201 && res.StatusCode != 204 {
-		return "", errors.Errorf("failed to create %s: (%d) %s", resourceName, res.StatusCode, res.Status)
+		return "", &APIError{Operation: "create", Resource: resourceName, StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	if resourceName == "client" {
-		d, _ := ioutil.ReadAll(res.Body)
-		fmt.Println("user response ", string(d))
+		fmt.Println("user response ", string(body))
 	}
 
 	location := strings.Split(res.Header.Get("Location"), "/")
@@ -87,47 +76,47 @@ func (c *Client) Endpoint() string {
 	return c.URL
 }
 
-func (c *Client) CreateRealm(realm *v1alpha1.KeycloakRealm) (string, error) {
-	return c.create(realm.Spec.Realm, "realms", "realm")
+func (c *Client) CreateRealm(ctx context.Context, realm *v1alpha1.KeycloakRealm) (string, error) {
+	return c.create(ctx, realm.Spec.Realm, "realms", "realm")
 }
 
-func (c *Client) CreateClient(client *v1alpha1.KeycloakAPIClient, realmName string) (string, error) {
-	return c.create(client, fmt.Sprintf("realms/%s/clients", realmName), "client")
+func (c *Client) CreateClient(ctx context.Context, client *v1alpha1.KeycloakAPIClient, realmName string) (string, error) {
+	return c.create(ctx, client, fmt.Sprintf("realms/%s/clients", realmName), "client")
 }
 
-func (c *Client) CreateClientRole(clientID string, role *v1alpha1.RoleRepresentation, realmName string) (string, error) {
-	return c.create(role, fmt.Sprintf("realms/%s/clients/%s/roles", realmName, clientID), "client role")
+func (c *Client) CreateClientRole(ctx context.Context, clientID string, role *v1alpha1.RoleRepresentation, realmName string) (string, error) {
+	return c.create(ctx, role, fmt.Sprintf("realms/%s/clients/%s/roles", realmName, clientID), "client role")
 }
 
-func (c *Client) AddRealmRoleComposites(realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error {
-	_, err := c.create(roles, fmt.Sprintf("realms/%s/roles-by-id/%s/composites", realmName, roleID), "realm role composites")
+func (c *Client) AddRealmRoleComposites(ctx context.Context, realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error {
+	_, err := c.create(ctx, roles, fmt.Sprintf("realms/%s/roles-by-id/%s/composites", realmName, roleID), "realm role composites")
 	return err
 }
 
-func (c *Client) CreateClientRealmScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error {
-	_, err := c.create(mappings, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/realm", realmName, specClient.ID), "client realm scope mappings")
+func (c *Client) CreateClientRealmScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error {
+	_, err := c.create(ctx, mappings, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/realm", realmName, specClient.ID), "client realm scope mappings")
 	return err
 }
 
-func (c *Client) CreateClientClientScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error {
-	_, err := c.create(mappings.Mappings, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/clients/%s", realmName, specClient.ID, mappings.ID), "client client scope mappings")
+func (c *Client) CreateClientClientScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error {
+	_, err := c.create(ctx, mappings.Mappings, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/clients/%s", realmName, specClient.ID, mappings.ID), "client client scope mappings")
 	return err
 }
 
-func (c *Client) CreateUser(user *v1alpha1.KeycloakAPIUser, realmName string) (string, error) {
-	return c.create(user, fmt.Sprintf("realms/%s/users", realmName), "user")
+func (c *Client) CreateUser(ctx context.Context, user *v1alpha1.KeycloakAPIUser, realmName string) (string, error) {
+	return c.create(ctx, user, fmt.Sprintf("realms/%s/users", realmName), "user")
 }
 
-func (c *Client) CreateFederatedIdentity(fid v1alpha1.FederatedIdentity, userID string, realmName string) (string, error) {
-	return c.create(fid, fmt.Sprintf("realms/%s/users/%s/federated-identity/%s", realmName, userID, fid.IdentityProvider), "federated-identity")
+func (c *Client) CreateFederatedIdentity(ctx context.Context, fid v1alpha1.FederatedIdentity, userID string, realmName string) (string, error) {
+	return c.create(ctx, fid, fmt.Sprintf("realms/%s/users/%s/federated-identity/%s", realmName, userID, fid.IdentityProvider), "federated-identity")
 }
 
-func (c *Client) RemoveFederatedIdentity(fid v1alpha1.FederatedIdentity, userID string, realmName string) error {
-	return c.delete(fmt.Sprintf("realms/%s/users/%s/federated-identity/%s", realmName, userID, fid.IdentityProvider), "federated-identity", fid)
+func (c *Client) RemoveFederatedIdentity(ctx context.Context, fid v1alpha1.FederatedIdentity, userID string, realmName string) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/users/%s/federated-identity/%s", realmName, userID, fid.IdentityProvider), "federated-identity", fid)
 }
 
-func (c *Client) GetUserFederatedIdentities(userID string, realmName string) ([]v1alpha1.FederatedIdentity, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/users/%s/federated-identity", realmName, userID), "federated-identity", func(body []byte) (T, error) {
+func (c *Client) GetUserFederatedIdentities(ctx context.Context, userID string, realmName string) ([]v1alpha1.FederatedIdentity, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/users/%s/federated-identity", realmName, userID), "federated-identity", func(body []byte) (T, error) {
 		var fids []v1alpha1.FederatedIdentity
 		err := json.Unmarshal(body, &fids)
 		return fids, err
@@ -138,27 +127,27 @@ func (c *Client) GetUserFederatedIdentities(userID string, realmName string) ([]
 	return result.([]v1alpha1.FederatedIdentity), err
 }
 
-func (c *Client) CreateUserClientRole(role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) (string, error) {
-	return c.create(
+func (c *Client) CreateUserClientRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) (string, error) {
+	return c.create(ctx,
 		[]*v1alpha1.KeycloakUserRole{role},
 		fmt.Sprintf("realms/%s/users/%s/role-mappings/clients/%s", realmName, userID, clientID),
 		"user-client-role",
 	)
 }
-func (c *Client) CreateUserRealmRole(role *v1alpha1.KeycloakUserRole, realmName, userID string) (string, error) {
-	return c.create(
+func (c *Client) CreateUserRealmRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, userID string) (string, error) {
+	return c.create(ctx,
 		[]*v1alpha1.KeycloakUserRole{role},
 		fmt.Sprintf("realms/%s/users/%s/role-mappings/realm", realmName, userID),
 		"user-realm-role",
 	)
 }
 
-func (c *Client) CreateAuthenticatorConfig(authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName, executionID string) (string, error) {
-	return c.create(authenticatorConfig, fmt.Sprintf("realms/%s/authentication/executions/%s/config", realmName, executionID), "AuthenticatorConfig")
+func (c *Client) CreateAuthenticatorConfig(ctx context.Context, authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName, executionID string) (string, error) {
+	return c.create(ctx, authenticatorConfig, fmt.Sprintf("realms/%s/authentication/executions/%s/config", realmName, executionID), "AuthenticatorConfig")
 }
 
-func (c *Client) DeleteUserClientRole(role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) error {
-	err := c.delete(
+func (c *Client) DeleteUserClientRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) error {
+	err := c.delete(ctx,
 		fmt.Sprintf("realms/%s/users/%s/role-mappings/clients/%s", realmName, userID, clientID),
 		"user-client-role",
 		[]*v1alpha1.KeycloakUserRole{role},
@@ -166,8 +155,8 @@ func (c *Client) DeleteUserClientRole(role *v1alpha1.KeycloakUserRole, realmName
 	return err
 }
 
-func (c *Client) DeleteUserRealmRole(role *v1alpha1.KeycloakUserRole, realmName, userID string) error {
-	err := c.delete(
+func (c *Client) DeleteUserRealmRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, userID string) error {
+	err := c.delete(ctx,
 		fmt.Sprintf("realms/%s/users/%s/role-mappings/realm", realmName, userID),
 		"user-realm-role",
 		[]*v1alpha1.KeycloakUserRole{role},
@@ -175,99 +164,54 @@ func (c *Client) DeleteUserRealmRole(role *v1alpha1.KeycloakUserRole, realmName,
 	return err
 }
 
-func (c *Client) UpdatePassword(user *v1alpha1.KeycloakAPIUser, realmName, newPass string) error {
+func (c *Client) UpdatePassword(ctx context.Context, user *v1alpha1.KeycloakAPIUser, realmName, newPass string) error {
 	passReset := &v1alpha1.KeycloakAPIPasswordReset{}
 	passReset.Type = "password"
 	passReset.Temporary = false
 	passReset.Value = newPass
 	u := fmt.Sprintf("realms/%s/users/%s/reset-password", realmName, user.ID)
-	if err := c.update(passReset, u, "paswordreset"); err != nil {
+	if err := c.update(ctx, passReset, u, "paswordreset"); err != nil {
 		return errors.Wrap(err, "error calling keycloak api ")
 	}
 	return nil
 }
 
-func (c *Client) FindUserByEmail(email, realm string) (*v1alpha1.KeycloakAPIUser, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/users?first=0&max=1&search=%s", realm, email), "user", func(body []byte) (T, error) {
-		var users []*v1alpha1.KeycloakAPIUser
-		if err := json.Unmarshal(body, &users); err != nil {
-			return nil, err
-		}
-		if len(users) == 0 {
-			return nil, nil
-		}
-		return users[0], nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	if result == nil {
-		return nil, err
-	}
-	return result.(*v1alpha1.KeycloakAPIUser), nil
+func (c *Client) FindUserByEmail(ctx context.Context, email, realm string) (*v1alpha1.KeycloakAPIUser, error) {
+	return c.findUser(ctx, UserQuery{Email: email}, realm)
 }
 
-func (c *Client) FindUserByUsername(name, realm string) (*v1alpha1.KeycloakAPIUser, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/users?username=%s&max=-1", realm, name), "user", func(body []byte) (T, error) {
-		var users []*v1alpha1.KeycloakAPIUser
-		if err := json.Unmarshal(body, &users); err != nil {
-			return nil, err
-		}
+func (c *Client) FindUserByUsername(ctx context.Context, name, realm string) (*v1alpha1.KeycloakAPIUser, error) {
+	return c.findUser(ctx, UserQuery{Username: name}, realm)
+}
 
-		for _, user := range users {
-			if user.UserName == name {
-				return user, nil
-			}
-		}
-		return nil, errors.Errorf("not found")
-	})
-	if err != nil {
-		return nil, err
+func (c *Client) findUser(ctx context.Context, query UserQuery, realm string) (*v1alpha1.KeycloakAPIUser, error) {
+	users := NewUserIterator(ctx, c, realm, query)
+	if users.Next() {
+		return users.User(), nil
 	}
-	if result == nil {
-		return nil, nil
-	}
-	return result.(*v1alpha1.KeycloakAPIUser), nil
+	return nil, users.Err()
 }
 
-func (c *Client) CreateIdentityProvider(identityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) (string, error) {
-	return c.create(identityProvider, fmt.Sprintf("realms/%s/identity-provider/instances", realmName), "identity provider")
+func (c *Client) CreateIdentityProvider(ctx context.Context, identityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) (string, error) {
+	return c.create(ctx, identityProvider, fmt.Sprintf("realms/%s/identity-provider/instances", realmName), "identity provider")
 }
 
 // Generic get function for returning a Keycloak resource
-func (c *Client) get(resourcePath, resourceName string, unMarshalFunc func(body []byte) (T, error)) (T, error) {
+func (c *Client) get(ctx context.Context, resourcePath, resourceName string, unMarshalFunc func(body []byte) (T, error)) (T, error) {
 	u := fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath)
-	req, err := http.NewRequest(
-		"GET",
-		u,
-		nil,
-	)
-	if err != nil {
-		logrus.Errorf("error creating GET %s request %+v", resourceName, err)
-		return nil, errors.Wrapf(err, "error creating GET %s request", resourceName)
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, body, err := c.do(ctx, "GET", u, nil)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return nil, errors.Wrapf(err, "error performing GET %s request", resourceName)
 	}
 
-	defer res.Body.Close()
 	if res.StatusCode == 404 {
 		logrus.Errorf("Resource %v/%v doesn't exist", resourcePath, resourceName)
 		return nil, nil
 	}
 
 	if res.StatusCode != 200 {
-		return nil, errors.Errorf("failed to GET %s: (%d) %s", resourceName, res.StatusCode, res.Status)
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		logrus.Errorf("error reading response %+v", err)
-		return nil, errors.Wrapf(err, "error reading %s GET response", resourceName)
+		return nil, &APIError{Operation: "GET", Resource: resourceName, StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	obj, err := unMarshalFunc(body)
@@ -278,8 +222,8 @@ func (c *Client) get(resourcePath, resourceName string, unMarshalFunc func(body
 	return obj, nil
 }
 
-func (c *Client) GetRealm(realmName string) (*v1alpha1.KeycloakRealm, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s", realmName), "realm", func(body []byte) (T, error) {
+func (c *Client) GetRealm(ctx context.Context, realmName string) (*v1alpha1.KeycloakRealm, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s", realmName), "realm", func(body []byte) (T, error) {
 		realm := &v1alpha1.KeycloakAPIRealm{}
 		err := json.Unmarshal(body, realm)
 		return realm, err
@@ -295,8 +239,8 @@ func (c *Client) GetRealm(realmName string) (*v1alpha1.KeycloakRealm, error) {
 	return ret, err
 }
 
-func (c *Client) GetClient(clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/clients/%s", realmName, clientID), "client", func(body []byte) (T, error) {
+func (c *Client) GetClient(ctx context.Context, clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/clients/%s", realmName, clientID), "client", func(body []byte) (T, error) {
 		client := &v1alpha1.KeycloakAPIClient{}
 		err := json.Unmarshal(body, client)
 		return client, err
@@ -311,9 +255,9 @@ func (c *Client) GetClient(clientID, realmName string) (*v1alpha1.KeycloakAPICli
 	return ret, err
 }
 
-func (c *Client) GetClientSecret(clientID, realmName string) (string, error) {
+func (c *Client) GetClientSecret(ctx context.Context, clientID, realmName string) (string, error) {
 	//"https://{{ rhsso_route }}/auth/admin/realms/{{ rhsso_realm }}/clients/{{ rhsso_client_id }}/client-secret"
-	result, err := c.get(fmt.Sprintf("realms/%s/clients/%s/client-secret", realmName, clientID), "client-secret", func(body []byte) (T, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/clients/%s/client-secret", realmName, clientID), "client-secret", func(body []byte) (T, error) {
 		res := map[string]string{}
 		if err := json.Unmarshal(body, &res); err != nil {
 			return nil, err
@@ -329,9 +273,9 @@ func (c *Client) GetClientSecret(clientID, realmName string) (string, error) {
 	return result.(string), nil
 }
 
-func (c *Client) GetClientInstall(clientID, realmName string) ([]byte, error) {
+func (c *Client) GetClientInstall(ctx context.Context, clientID, realmName string) ([]byte, error) {
 	var response []byte
-	if _, err := c.get(fmt.Sprintf("realms/%s/clients/%s/installation/providers/keycloak-oidc-keycloak-json", realmName, clientID), "client-installation", func(body []byte) (T, error) {
+	if _, err := c.get(ctx, fmt.Sprintf("realms/%s/clients/%s/installation/providers/keycloak-oidc-keycloak-json", realmName, clientID), "client-installation", func(body []byte) (T, error) {
 		response = body
 		return body, nil
 	}); err != nil {
@@ -340,8 +284,8 @@ func (c *Client) GetClientInstall(clientID, realmName string) ([]byte, error) {
 	return response, nil
 }
 
-func (c *Client) GetUser(userID, realmName string) (*v1alpha1.KeycloakAPIUser, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/users/%s", realmName, userID), "user", func(body []byte) (T, error) {
+func (c *Client) GetUser(ctx context.Context, userID, realmName string) (*v1alpha1.KeycloakAPIUser, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/users/%s", realmName, userID), "user", func(body []byte) (T, error) {
 		user := &v1alpha1.KeycloakAPIUser{}
 		err := json.Unmarshal(body, user)
 		return user, err
@@ -356,8 +300,8 @@ func (c *Client) GetUser(userID, realmName string) (*v1alpha1.KeycloakAPIUser, e
 	return ret, err
 }
 
-func (c *Client) GetIdentityProvider(alias string, realmName string) (*v1alpha1.KeycloakIdentityProvider, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, alias), "identity provider", func(body []byte) (T, error) {
+func (c *Client) GetIdentityProvider(ctx context.Context, alias string, realmName string) (*v1alpha1.KeycloakIdentityProvider, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, alias), "identity provider", func(body []byte) (T, error) {
 		provider := &v1alpha1.KeycloakIdentityProvider{}
 		err := json.Unmarshal(body, provider)
 		return provider, err
@@ -371,8 +315,8 @@ func (c *Client) GetIdentityProvider(alias string, realmName string) (*v1alpha1.
 	return result.(*v1alpha1.KeycloakIdentityProvider), err
 }
 
-func (c *Client) GetAuthenticatorConfig(configID, realmName string) (*v1alpha1.AuthenticatorConfig, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/authentication/config/%s", realmName, configID), "AuthenticatorConfig", func(body []byte) (T, error) {
+func (c *Client) GetAuthenticatorConfig(ctx context.Context, configID, realmName string) (*v1alpha1.AuthenticatorConfig, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/authentication/config/%s", realmName, configID), "AuthenticatorConfig", func(body []byte) (T, error) {
 		authenticatorConfig := &v1alpha1.AuthenticatorConfig{}
 		err := json.Unmarshal(body, authenticatorConfig)
 		return authenticatorConfig, err
@@ -387,194 +331,143 @@ func (c *Client) GetAuthenticatorConfig(configID, realmName string) (*v1alpha1.A
 }
 
 // Generic put function for updating Keycloak resources
-func (c *Client) update(obj T, resourcePath, resourceName string) error {
+func (c *Client) update(ctx context.Context, obj T, resourcePath, resourceName string) error {
 	jsonValue, err := json.Marshal(obj)
 	if err != nil {
-		return nil
+		return errors.Wrapf(err, "error marshalling %s", resourceName)
 	}
 
-	req, err := http.NewRequest(
-		"PUT",
-		fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath),
-		bytes.NewBuffer(jsonValue),
-	)
-	if err != nil {
-		logrus.Errorf("error creating UPDATE %s request %+v", resourceName, err)
-		return errors.Wrapf(err, "error creating UPDATE %s request", resourceName)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+c.token)
-	res, err := c.requester.Do(req)
+	res, _, err := c.do(ctx, "PUT", fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath), jsonValue)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing UPDATE %s request", resourceName)
 	}
-	defer res.Body.Close()
 	if res.StatusCode < 200 || res.StatusCode > 299 {
 		logrus.Errorf("failed to UPDATE %s %v", resourceName, res.Status)
-		return errors.Errorf("failed to UPDATE %s: (%d) %s", resourceName, res.StatusCode, res.Status)
+		return &APIError{Operation: "UPDATE", Resource: resourceName, StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	return nil
 }
 
-func (c *Client) UpdateRealm(realm *v1alpha1.KeycloakRealm) error {
-	return c.update(realm, fmt.Sprintf("realms/%s", realm.Spec.Realm.ID), "realm")
+func (c *Client) UpdateRealm(ctx context.Context, realm *v1alpha1.KeycloakRealm) error {
+	return c.update(ctx, realm, fmt.Sprintf("realms/%s", realm.Spec.Realm.ID), "realm")
 }
 
-func (c *Client) UpdateClient(specClient *v1alpha1.KeycloakAPIClient, realmName string) error {
-	return c.update(specClient, fmt.Sprintf("realms/%s/clients/%s", realmName, specClient.ID), "client")
+func (c *Client) UpdateClient(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, realmName string) error {
+	return c.update(ctx, specClient, fmt.Sprintf("realms/%s/clients/%s", realmName, specClient.ID), "client")
 }
 
-func (c *Client) UpdateClientRole(clientID string, role, oldRole *v1alpha1.RoleRepresentation, realmName string) error {
-	return c.update(role, fmt.Sprintf("realms/%s/clients/%s/roles/%s", realmName, clientID, oldRole.Name), "client role")
+func (c *Client) UpdateClientRole(ctx context.Context, clientID string, role, oldRole *v1alpha1.RoleRepresentation, realmName string) error {
+	return c.update(ctx, role, fmt.Sprintf("realms/%s/clients/%s/roles/%s", realmName, clientID, oldRole.Name), "client role")
 }
 
-func (c *Client) UpdateUser(specUser *v1alpha1.KeycloakAPIUser, realmName string) error {
-	return c.update(specUser, fmt.Sprintf("realms/%s/users/%s", realmName, specUser.ID), "user")
+func (c *Client) UpdateUser(ctx context.Context, specUser *v1alpha1.KeycloakAPIUser, realmName string) error {
+	return c.update(ctx, specUser, fmt.Sprintf("realms/%s/users/%s", realmName, specUser.ID), "user")
 }
 
-func (c *Client) UpdateIdentityProvider(specIdentityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) error {
-	return c.update(specIdentityProvider, fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, specIdentityProvider.Alias), "identity provider")
+func (c *Client) UpdateIdentityProvider(ctx context.Context, specIdentityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) error {
+	return c.update(ctx, specIdentityProvider, fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, specIdentityProvider.Alias), "identity provider")
 }
 
-func (c *Client) UpdateAuthenticatorConfig(authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName string) error {
-	return c.update(authenticatorConfig, fmt.Sprintf("realms/%s/authentication/config/%s", realmName, authenticatorConfig.ID), "AuthenticatorConfig")
+func (c *Client) UpdateAuthenticatorConfig(ctx context.Context, authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName string) error {
+	return c.update(ctx, authenticatorConfig, fmt.Sprintf("realms/%s/authentication/config/%s", realmName, authenticatorConfig.ID), "AuthenticatorConfig")
 }
 
-func (c *Client) UpdateClientDefaultClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
-	return c.update(clientScope, fmt.Sprintf("realms/%s/clients/%s/default-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client default client scope")
+func (c *Client) UpdateClientDefaultClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
+	return c.update(ctx, clientScope, fmt.Sprintf("realms/%s/clients/%s/default-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client default client scope")
 }
 
-func (c *Client) UpdateClientOptionalClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
-	return c.update(clientScope, fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client optional client scope")
+func (c *Client) UpdateClientOptionalClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
+	return c.update(ctx, clientScope, fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client optional client scope")
 }
 
 // Generic delete function for deleting Keycloak resources
-func (c *Client) delete(resourcePath, resourceName string, obj T) error {
-	req, err := http.NewRequest(
-		"DELETE",
-		fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath),
-		nil,
-	)
-
+func (c *Client) delete(ctx context.Context, resourcePath, resourceName string, obj T) error {
+	var jsonValue []byte
 	if obj != nil {
-		jsonValue, err := json.Marshal(obj)
-		if err != nil {
-			return nil
-		}
-		req, err = http.NewRequest(
-			"DELETE",
-			fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath),
-			bytes.NewBuffer(jsonValue),
-		)
+		var err error
+		jsonValue, err = json.Marshal(obj)
 		if err != nil {
-			return nil
+			return errors.Wrapf(err, "error marshalling %s", resourceName)
 		}
-		req.Header.Set("Content-Type", "application/json")
 	}
 
-	if err != nil {
-		logrus.Errorf("error creating DELETE %s request %+v", resourceName, err)
-		return errors.Wrapf(err, "error creating DELETE %s request", resourceName)
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+	res, _, err := c.do(ctx, "DELETE", fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath), jsonValue)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing DELETE %s request", resourceName)
 	}
-	defer res.Body.Close()
 	if res.StatusCode == 404 {
 		logrus.Errorf("Resource %v/%v already deleted", resourcePath, resourceName)
 	}
 	if res.StatusCode != 204 && res.StatusCode != 404 {
-		return errors.Errorf("failed to DELETE %s: (%d) %s", resourceName, res.StatusCode, res.Status)
+		return &APIError{Operation: "DELETE", Resource: resourceName, StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	return nil
 }
 
-func (c *Client) DeleteRealm(realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s", realmName), "realm", nil)
+func (c *Client) DeleteRealm(ctx context.Context, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s", realmName), "realm", nil)
 	return err
 }
 
-func (c *Client) DeleteClient(clientID, realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s/clients/%s", realmName, clientID), "client", nil)
+func (c *Client) DeleteClient(ctx context.Context, clientID, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s", realmName, clientID), "client", nil)
 	return err
 }
 
-func (c *Client) DeleteClientRole(clientID, role, realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s/clients/%s/roles/%s", realmName, clientID, role), "client role", nil)
+func (c *Client) DeleteClientRole(ctx context.Context, clientID, role, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s/roles/%s", realmName, clientID, role), "client role", nil)
 	return err
 }
 
-func (c *Client) DeleteRealmRoleComposites(realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error {
-	return c.delete(fmt.Sprintf("realms/%s/roles-by-id/%s/composites", realmName, roleID), "realm role composites", roles)
+func (c *Client) DeleteRealmRoleComposites(ctx context.Context, realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/roles-by-id/%s/composites", realmName, roleID), "realm role composites", roles)
 }
 
-func (c *Client) DeleteClientRealmScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error {
-	return c.delete(fmt.Sprintf("realms/%s/clients/%s/scope-mappings/realm", realmName, specClient.ID), "client realm scope mappings", mappings)
+func (c *Client) DeleteClientRealmScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/realm", realmName, specClient.ID), "client realm scope mappings", mappings)
 }
 
-func (c *Client) DeleteClientClientScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error {
-	return c.delete(fmt.Sprintf("realms/%s/clients/%s/scope-mappings/clients/%s", realmName, specClient.ID, mappings.ID), "client client scope mappings", mappings.Mappings)
+func (c *Client) DeleteClientClientScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s/scope-mappings/clients/%s", realmName, specClient.ID, mappings.ID), "client client scope mappings", mappings.Mappings)
 }
 
-func (c *Client) DeleteClientDefaultClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
-	return c.delete(fmt.Sprintf("realms/%s/clients/%s/default-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client default client scope", clientScope)
+func (c *Client) DeleteClientDefaultClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s/default-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client default client scope", clientScope)
 }
 
-func (c *Client) DeleteClientOptionalClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
-	return c.delete(fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client optional client scope", clientScope)
+func (c *Client) DeleteClientOptionalClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error {
+	return c.delete(ctx, fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes/%s", realmName, specClient.ID, clientScope.ID), "client optional client scope", clientScope)
 }
 
-func (c *Client) DeleteUser(userID, realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s/users/%s", realmName, userID), "user", nil)
+func (c *Client) DeleteUser(ctx context.Context, userID, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s/users/%s", realmName, userID), "user", nil)
 	return err
 }
 
-func (c *Client) DeleteIdentityProvider(alias string, realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, alias), "identity provider", nil)
+func (c *Client) DeleteIdentityProvider(ctx context.Context, alias string, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s/identity-provider/instances/%s", realmName, alias), "identity provider", nil)
 	return err
 }
 
-func (c *Client) DeleteAuthenticatorConfig(configID, realmName string) error {
-	err := c.delete(fmt.Sprintf("realms/%s/authentication/config/%s", realmName, configID), "AuthenticatorConfig", nil)
+func (c *Client) DeleteAuthenticatorConfig(ctx context.Context, configID, realmName string) error {
+	err := c.delete(ctx, fmt.Sprintf("realms/%s/authentication/config/%s", realmName, configID), "AuthenticatorConfig", nil)
 	return err
 }
 
 // Generic list function for listing Keycloak resources
-func (c *Client) list(resourcePath, resourceName string, unMarshalListFunc func(body []byte) (T, error)) (T, error) {
-	req, err := http.NewRequest(
-		"GET",
-		fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath),
-		nil,
-	)
-	if err != nil {
-		logrus.Errorf("error creating LIST %s request %+v", resourceName, err)
-		return nil, errors.Wrapf(err, "error creating LIST %s request", resourceName)
-	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	res, err := c.requester.Do(req)
+func (c *Client) list(ctx context.Context, resourcePath, resourceName string, unMarshalListFunc func(body []byte) (T, error)) (T, error) {
+	res, body, err := c.do(ctx, "GET", fmt.Sprintf("%sadmin/%s", c.GetFullKeycloakPath(), resourcePath), nil)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return nil, errors.Wrapf(err, "error performing LIST %s request", resourceName)
 	}
-	defer res.Body.Close()
 
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return nil, errors.Errorf("failed to LIST %s: (%d) %s", resourceName, res.StatusCode, res.Status)
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		logrus.Errorf("error reading response %+v", err)
-		return nil, errors.Wrapf(err, "error reading %s LIST response", resourceName)
+		return nil, &APIError{Operation: "LIST", Resource: resourceName, StatusCode: res.StatusCode, Status: res.Status}
 	}
 
 	objs, err := unMarshalListFunc(body)
@@ -585,8 +478,8 @@ func (c *Client) list(resourcePath, resourceName string, unMarshalListFunc func(
 	return objs, nil
 }
 
-func (c *Client) ListRealms() ([]*v1alpha1.KeycloakRealm, error) {
-	result, err := c.list("realms", "realm", func(body []byte) (T, error) {
+func (c *Client) ListRealms(ctx context.Context) ([]*v1alpha1.KeycloakRealm, error) {
+	result, err := c.list(ctx, "realms", "realm", func(body []byte) (T, error) {
 		var realms []*v1alpha1.KeycloakRealm
 		err := json.Unmarshal(body, &realms)
 		return realms, err
@@ -598,8 +491,8 @@ func (c *Client) ListRealms() ([]*v1alpha1.KeycloakRealm, error) {
 	return resultAsRealm, err
 }
 
-func (c *Client) ListRealmRoleClientRoleComposites(realmName, roleID, clientID string) ([]v1alpha1.RoleRepresentation, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/roles-by-id/%s/composites/clients/%s", realmName, roleID, clientID), "realm role client role composites", func(body []byte) (T, error) {
+func (c *Client) ListRealmRoleClientRoleComposites(ctx context.Context, realmName, roleID, clientID string) ([]v1alpha1.RoleRepresentation, error) {
+	result, err := c.list(ctx, fmt.Sprintf("realms/%s/roles-by-id/%s/composites/clients/%s", realmName, roleID, clientID), "realm role client role composites", func(body []byte) (T, error) {
 		var roles []v1alpha1.RoleRepresentation
 		err := json.Unmarshal(body, &roles)
 		return roles, err
@@ -618,28 +511,23 @@ func (c *Client) ListRealmRoleClientRoleComposites(realmName, roleID, clientID s
 	return res, nil
 }
 
-func (c *Client) ListClients(realmName string) ([]*v1alpha1.KeycloakAPIClient, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/clients", realmName), "clients", func(body []byte) (T, error) {
-		var clients []*v1alpha1.KeycloakAPIClient
-		err := json.Unmarshal(body, &clients)
-		return clients, err
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	res, ok := result.([]*v1alpha1.KeycloakAPIClient)
-
-	if !ok {
-		return nil, errors.Errorf("error decoding list clients response")
+// ListClients returns all clients of the realm, it fails on realms with more than MaxListSize clients.
+//
+// Deprecated: walk the clients with a ClientIterator or look them up with FindClientByClientID.
+func (c *Client) ListClients(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakAPIClient, error) {
+	var res []*v1alpha1.KeycloakAPIClient
+	clients := NewClientIterator(ctx, c, realmName, ClientQuery{})
+	for clients.Next() {
+		if len(res) == MaxListSize {
+			return nil, errors.Errorf("realm %v has more than %v clients, walk them with a ClientIterator", realmName, MaxListSize)
+		}
+		res = append(res, clients.Client())
 	}
-
-	return res, nil
+	return res, clients.Err()
 }
 
-func (c *Client) ListClientRoles(clientID, realmName string) ([]v1alpha1.RoleRepresentation, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/clients/%s/roles", realmName, clientID), "client roles", func(body []byte) (T, error) {
+func (c *Client) ListClientRoles(ctx context.Context, clientID, realmName string) ([]v1alpha1.RoleRepresentation, error) {
+	result, err := c.list(ctx, fmt.Sprintf("realms/%s/clients/%s/roles", realmName, clientID), "client roles", func(body []byte) (T, error) {
 		var roles []v1alpha1.RoleRepresentation
 		err := json.Unmarshal(body, &roles)
 		return roles, err
@@ -658,8 +546,8 @@ func (c *Client) ListClientRoles(clientID, realmName string) ([]v1alpha1.RoleRep
 	return res, nil
 }
 
-func (c *Client) ListScopeMappings(clientID, realmName string) (*v1alpha1.MappingsRepresentation, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/clients/%s/scope-mappings", realmName, clientID), "client scope mappings", func(body []byte) (T, error) {
+func (c *Client) ListScopeMappings(ctx context.Context, clientID, realmName string) (*v1alpha1.MappingsRepresentation, error) {
+	result, err := c.list(ctx, fmt.Sprintf("realms/%s/clients/%s/scope-mappings", realmName, clientID), "client scope mappings", func(body []byte) (T, error) {
 		var mappings v1alpha1.MappingsRepresentation
 		err := json.Unmarshal(body, &mappings)
 		return mappings, err
@@ -678,8 +566,8 @@ func (c *Client) ListScopeMappings(clientID, realmName string) (*v1alpha1.Mappin
 	return &res, nil
 }
 
-func (c *Client) listClientScopes(path string, msg string) ([]v1alpha1.KeycloakClientScope, error) {
-	result, err := c.list(path, msg, func(body []byte) (T, error) {
+func (c *Client) listClientScopes(ctx context.Context, path string, msg string) ([]v1alpha1.KeycloakClientScope, error) {
+	result, err := c.list(ctx, path, msg, func(body []byte) (T, error) {
 		var assignedClientScopes []v1alpha1.KeycloakClientScope
 		err := json.Unmarshal(body, &assignedClientScopes)
 		return assignedClientScopes, err
@@ -698,32 +586,35 @@ func (c *Client) listClientScopes(path string, msg string) ([]v1alpha1.KeycloakC
 	return res, nil
 }
 
-func (c *Client) ListAvailableClientScopes(realmName string) ([]v1alpha1.KeycloakClientScope, error) {
-	return c.listClientScopes(fmt.Sprintf("realms/%s/client-scopes", realmName), "available client scopes")
+func (c *Client) ListAvailableClientScopes(ctx context.Context, realmName string) ([]v1alpha1.KeycloakClientScope, error) {
+	return c.listClientScopes(ctx, fmt.Sprintf("realms/%s/client-scopes", realmName), "available client scopes")
 }
 
-func (c *Client) ListDefaultClientScopes(clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error) {
-	return c.listClientScopes(fmt.Sprintf("realms/%s/clients/%s/default-client-scopes", realmName, clientID), "default client scopes")
+func (c *Client) ListDefaultClientScopes(ctx context.Context, clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error) {
+	return c.listClientScopes(ctx, fmt.Sprintf("realms/%s/clients/%s/default-client-scopes", realmName, clientID), "default client scopes")
 }
 
-func (c *Client) ListOptionalClientScopes(clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error) {
-	return c.listClientScopes(fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes", realmName, clientID), "optional client scopes")
+func (c *Client) ListOptionalClientScopes(ctx context.Context, clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error) {
+	return c.listClientScopes(ctx, fmt.Sprintf("realms/%s/clients/%s/optional-client-scopes", realmName, clientID), "optional client scopes")
 }
 
-func (c *Client) ListUsers(realmName string) ([]*v1alpha1.KeycloakAPIUser, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/users", realmName), "users", func(body []byte) (T, error) {
-		var users []*v1alpha1.KeycloakAPIUser
-		err := json.Unmarshal(body, &users)
-		return users, err
-	})
-	if err != nil {
-		return nil, err
+// ListUsers returns all users of the realm, it fails on realms with more than MaxListSize users.
+//
+// Deprecated: walk the users with a UserIterator or look them up with FindUserByUsername.
+func (c *Client) ListUsers(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakAPIUser, error) {
+	var res []*v1alpha1.KeycloakAPIUser
+	users := NewUserIterator(ctx, c, realmName, UserQuery{})
+	for users.Next() {
+		if len(res) == MaxListSize {
+			return nil, errors.Errorf("realm %v has more than %v users, walk them with a UserIterator", realmName, MaxListSize)
+		}
+		res = append(res, users.User())
 	}
-	return result.([]*v1alpha1.KeycloakAPIUser), err
+	return res, users.Err()
 }
 
-func (c *Client) ListIdentityProviders(realmName string) ([]*v1alpha1.KeycloakIdentityProvider, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/identity-provider/instances", realmName), "identity providers", func(body []byte) (T, error) {
+func (c *Client) ListIdentityProviders(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakIdentityProvider, error) {
+	result, err := c.list(ctx, fmt.Sprintf("realms/%s/identity-provider/instances", realmName), "identity providers", func(body []byte) (T, error) {
 		var providers []*v1alpha1.KeycloakIdentityProvider
 		err := json.Unmarshal(body, &providers)
 		return providers, err
@@ -734,8 +625,8 @@ func (c *Client) ListIdentityProviders(realmName string) ([]*v1alpha1.KeycloakId
 	return result.([]*v1alpha1.KeycloakIdentityProvider), err
 }
 
-func (c *Client) ListUserClientRoles(realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
-	objects, err := c.list("realms/"+realmName+"/users/"+userID+"/role-mappings/clients/"+clientID, "userClientRoles", func(body []byte) (t T, e error) {
+func (c *Client) ListUserClientRoles(ctx context.Context, realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
+	objects, err := c.list(ctx, "realms/"+realmName+"/users/"+userID+"/role-mappings/clients/"+clientID, "userClientRoles", func(body []byte) (t T, e error) {
 		var userClientRoles []*v1alpha1.KeycloakUserRole
 		err := json.Unmarshal(body, &userClientRoles)
 		return userClientRoles, err
@@ -749,8 +640,8 @@ func (c *Client) ListUserClientRoles(realmName, clientID, userID string) ([]*v1a
 	return objects.([]*v1alpha1.KeycloakUserRole), err
 }
 
-func (c *Client) ListAvailableUserClientRoles(realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
-	objects, err := c.list("realms/"+realmName+"/users/"+userID+"/role-mappings/clients/"+clientID+"/available", "userClientRoles", func(body []byte) (t T, e error) {
+func (c *Client) ListAvailableUserClientRoles(ctx context.Context, realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
+	objects, err := c.list(ctx, "realms/"+realmName+"/users/"+userID+"/role-mappings/clients/"+clientID+"/available", "userClientRoles", func(body []byte) (t T, e error) {
 		var userClientRoles []*v1alpha1.KeycloakUserRole
 		err := json.Unmarshal(body, &userClientRoles)
 		return userClientRoles, err
@@ -764,8 +655,8 @@ func (c *Client) ListAvailableUserClientRoles(realmName, clientID, userID string
 	return objects.([]*v1alpha1.KeycloakUserRole), err
 }
 
-func (c *Client) ListUserRealmRoles(realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
-	objects, err := c.list("realms/"+realmName+"/users/"+userID+"/role-mappings/realm", "userRealmRoles", func(body []byte) (t T, e error) {
+func (c *Client) ListUserRealmRoles(ctx context.Context, realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
+	objects, err := c.list(ctx, "realms/"+realmName+"/users/"+userID+"/role-mappings/realm", "userRealmRoles", func(body []byte) (t T, e error) {
 		var userRealmRoles []*v1alpha1.KeycloakUserRole
 		err := json.Unmarshal(body, &userRealmRoles)
 		return userRealmRoles, err
@@ -779,8 +670,8 @@ func (c *Client) ListUserRealmRoles(realmName, userID string) ([]*v1alpha1.Keycl
 	return objects.([]*v1alpha1.KeycloakUserRole), err
 }
 
-func (c *Client) ListAvailableUserRealmRoles(realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
-	objects, err := c.list("realms/"+realmName+"/users/"+userID+"/role-mappings/realm/available", "userClientRoles", func(body []byte) (t T, e error) {
+func (c *Client) ListAvailableUserRealmRoles(ctx context.Context, realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error) {
+	objects, err := c.list(ctx, "realms/"+realmName+"/users/"+userID+"/role-mappings/realm/available", "userClientRoles", func(body []byte) (t T, e error) {
 		var userRealmRoles []*v1alpha1.KeycloakUserRole
 		err := json.Unmarshal(body, &userRealmRoles)
 		return userRealmRoles, err
@@ -794,8 +685,8 @@ func (c *Client) ListAvailableUserRealmRoles(realmName, userID string) ([]*v1alp
 	return objects.([]*v1alpha1.KeycloakUserRole), err
 }
 
-func (c *Client) ListAuthenticationExecutionsForFlow(flowAlias, realmName string) ([]*v1alpha1.AuthenticationExecutionInfo, error) {
-	result, err := c.list(fmt.Sprintf("realms/%s/authentication/flows/%s/executions", realmName, flowAlias), "AuthenticationExecution", func(body []byte) (T, error) {
+func (c *Client) ListAuthenticationExecutionsForFlow(ctx context.Context, flowAlias, realmName string) ([]*v1alpha1.AuthenticationExecutionInfo, error) {
+	result, err := c.list(ctx, fmt.Sprintf("realms/%s/authentication/flows/%s/executions", realmName, flowAlias), "AuthenticationExecution", func(body []byte) (T, error) {
 		var authenticationExecutions []*v1alpha1.AuthenticationExecutionInfo
 		err := json.Unmarshal(body, &authenticationExecutions)
 		return authenticationExecutions, err
@@ -806,15 +697,8 @@ func (c *Client) ListAuthenticationExecutionsForFlow(flowAlias, realmName string
 	return result.([]*v1alpha1.AuthenticationExecutionInfo), err
 }
 
-func (c *Client) Ping() error {
-	u := c.GetFullKeycloakPath()
-	req, err := http.NewRequest("GET", u, nil)
-	if err != nil {
-		logrus.Errorf("error creating ping request %+v", err)
-		return errors.Wrap(err, "error creating ping request")
-	}
-
-	res, err := c.requester.Do(req)
+func (c *Client) Ping(ctx context.Context) error {
+	res, _, err := c.do(ctx, "GET", c.GetFullKeycloakPath(), nil)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
 		return errors.Wrapf(err, "error performing ping request")
@@ -824,13 +708,12 @@ func (c *Client) Ping() error {
 	if res.StatusCode != 200 {
 		return errors.Errorf("failed to ping, response status code: %v", res.StatusCode)
 	}
-	defer res.Body.Close()
 
 	return nil
 }
 
-func (c *Client) GetServiceAccountUser(realmName, clientID string) (*v1alpha1.KeycloakAPIUser, error) {
-	result, err := c.get(fmt.Sprintf("realms/%s/clients/%s/service-account-user", realmName, clientID), "service-account-user", func(body []byte) (T, error) {
+func (c *Client) GetServiceAccountUser(ctx context.Context, realmName, clientID string) (*v1alpha1.KeycloakAPIUser, error) {
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/clients/%s/service-account-user", realmName, clientID), "service-account-user", func(body []byte) (T, error) {
 		user := &v1alpha1.KeycloakAPIUser{}
 		err := json.Unmarshal(body, user)
 		return user, err
@@ -847,56 +730,97 @@ func (c *Client) GetServiceAccountUser(realmName, clientID string) (*v1alpha1.Ke
 
 // login requests a new auth token from Keycloak
 func (c *Client) login(user, pass string) error {
-	form := url.Values{}
-	form.Add("username", user)
-	form.Add("password", pass)
-	form.Add("client_id", "admin-cli")
-	form.Add("grant_type", "password")
+	c.credentials = passwordCredentials(user, pass)
+	_, err := c.authenticate()
+	return err
+}
+
+// authenticate requests a token with the credentials of the operator
+func (c *Client) authenticate() (*v1alpha1.TokenResponse, error) {
+	form, err := c.credentials.grant(c.tokenURL())
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating login request")
+	}
+	return c.requestToken(form)
+}
 
+// refresh requests a new token with the refresh token of a previous one
+func (c *Client) refresh(refreshToken string) (*v1alpha1.TokenResponse, error) {
+	form, err := c.credentials.refreshGrant(c.tokenURL(), refreshToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating refresh request")
+	}
+	return c.requestToken(form)
+}
+
+// tokenURL returns the token endpoint of the realm the operator authenticates in
+func (c *Client) tokenURL() string {
+	return c.GetFullKeycloakPath() + fmt.Sprintf(authURL, c.credentials.realm)
+}
+
+func (c *Client) requestToken(form url.Values) (*v1alpha1.TokenResponse, error) {
 	req, err := http.NewRequest(
 		"POST",
-		c.GetFullKeycloakPath()+authURL,
+		c.tokenURL(),
 		strings.NewReader(form.Encode()),
 	)
 	if err != nil {
-		return errors.Wrap(err, "error creating login request")
+		return nil, errors.Wrap(err, "error creating login request")
 	}
 
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req, span := startRequestSpan(req)
+	start := time.Now()
 	res, err := c.requester.Do(req)
+	observeAdminAPIRequest(req, res, err, start)
+	endRequestSpan(span, res, err)
 	if err != nil {
 		logrus.Errorf("error on request %+v", err)
-		return errors.Wrap(err, "error performing token request")
+		return nil, errors.Wrap(err, "error performing token request")
 	}
 	defer res.Body.Close()
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		logrus.Errorf("error reading response %+v", err)
-		return errors.Wrap(err, "error reading token response")
+		return nil, errors.Wrap(err, "error reading token response")
 	}
 
 	tokenRes := &v1alpha1.TokenResponse{}
 	err = json.Unmarshal(body, tokenRes)
 	if err != nil {
-		return errors.Wrap(err, "error parsing token response")
+		return nil, errors.Wrap(err, "error parsing token response")
 	}
 
 	if tokenRes.Error != "" {
 		logrus.Errorf("error with request: " + tokenRes.Error)
-		return errors.Errorf(tokenRes.Error)
+		return nil, errors.Errorf(tokenRes.Error)
 	}
 
 	c.token = tokenRes.AccessToken
 
-	return nil
+	return tokenRes, nil
 }
 
 // defaultRequester returns a default client for requesting http endpoints
 func defaultRequester(serverCert []byte) (Requester, error) {
+	return clientCertificateRequester(serverCert, nil)
+}
+
+// clientCertificateRequester returns a client presenting the certificates in the TLS handshake, for clients
+// authenticating with mTLS
+func clientCertificateRequester(serverCert []byte, certificates []tls.Certificate) (Requester, error) {
+	return serverNameRequester(serverCert, certificates, "")
+}
+
+// serverNameRequester returns a clientCertificateRequester verifying the server certificate against serverName
+// rather than the host of the requested URL
+func serverNameRequester(serverCert []byte, certificates []tls.Certificate, serverName string) (Requester, error) {
 	tlsConfig, err := createTLSConfig(serverCert)
 	if err != nil {
 		return nil, err
 	}
+	tlsConfig.Certificates = certificates
+	tlsConfig.ServerName = serverName
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.TLSClientConfig = tlsConfig
 
@@ -925,80 +849,91 @@ func createTLSConfig(serverCert []byte) (*tls.Config, error) {
 //go:generate moq -out keycloakClient_moq.go . KeycloakInterface
 
 type KeycloakInterface interface {
-	Ping() error
+	Ping(ctx context.Context) error
 
 	Endpoint() string
 
-	CreateRealm(realm *v1alpha1.KeycloakRealm) (string, error)
-	GetRealm(realmName string) (*v1alpha1.KeycloakRealm, error)
-	UpdateRealm(specRealm *v1alpha1.KeycloakRealm) error
-	DeleteRealm(realmName string) error
-	ListRealms() ([]*v1alpha1.KeycloakRealm, error)
-
-	ListRealmRoleClientRoleComposites(realmName, roleID, clientID string) ([]v1alpha1.RoleRepresentation, error)
-	AddRealmRoleComposites(realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error
-	DeleteRealmRoleComposites(realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error
-
-	CreateClient(client *v1alpha1.KeycloakAPIClient, realmName string) (string, error)
-	GetClient(clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error)
-	GetClientSecret(clientID, realmName string) (string, error)
-	GetClientInstall(clientID, realmName string) ([]byte, error)
-	UpdateClient(specClient *v1alpha1.KeycloakAPIClient, realmName string) error
-	DeleteClient(clientID, realmName string) error
-	ListClients(realmName string) ([]*v1alpha1.KeycloakAPIClient, error)
-	ListClientRoles(clientID, realmName string) ([]v1alpha1.RoleRepresentation, error)
-	ListScopeMappings(clientID, realmName string) (*v1alpha1.MappingsRepresentation, error)
-	ListAvailableClientScopes(realmName string) ([]v1alpha1.KeycloakClientScope, error)
-	ListDefaultClientScopes(clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error)
-	ListOptionalClientScopes(clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error)
-	CreateClientRole(clientID string, role *v1alpha1.RoleRepresentation, realmName string) (string, error)
-	UpdateClientRole(clientID string, role, oldRole *v1alpha1.RoleRepresentation, realmName string) error
-	DeleteClientRole(clientID, role, realmName string) error
-	CreateClientRealmScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error
-	DeleteClientRealmScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error
-	CreateClientClientScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error
-	DeleteClientClientScopeMappings(specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error
-	UpdateClientDefaultClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
-	DeleteClientDefaultClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
-	UpdateClientOptionalClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
-	DeleteClientOptionalClientScope(specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
-
-	CreateUser(user *v1alpha1.KeycloakAPIUser, realmName string) (string, error)
-	CreateFederatedIdentity(fid v1alpha1.FederatedIdentity, userID string, realmName string) (string, error)
-	RemoveFederatedIdentity(fid v1alpha1.FederatedIdentity, userID string, realmName string) error
-	GetUserFederatedIdentities(userName string, realmName string) ([]v1alpha1.FederatedIdentity, error)
-	UpdatePassword(user *v1alpha1.KeycloakAPIUser, realmName, newPass string) error
-	FindUserByEmail(email, realm string) (*v1alpha1.KeycloakAPIUser, error)
-	FindUserByUsername(name, realm string) (*v1alpha1.KeycloakAPIUser, error)
-	GetUser(userID, realmName string) (*v1alpha1.KeycloakAPIUser, error)
-	UpdateUser(specUser *v1alpha1.KeycloakAPIUser, realmName string) error
-	DeleteUser(userID, realmName string) error
-	ListUsers(realmName string) ([]*v1alpha1.KeycloakAPIUser, error)
-
-	CreateIdentityProvider(identityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) (string, error)
-	GetIdentityProvider(alias, realmName string) (*v1alpha1.KeycloakIdentityProvider, error)
-	UpdateIdentityProvider(specIdentityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) error
-	DeleteIdentityProvider(alias, realmName string) error
-	ListIdentityProviders(realmName string) ([]*v1alpha1.KeycloakIdentityProvider, error)
-
-	CreateUserClientRole(role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) (string, error)
-	ListUserClientRoles(realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error)
-	ListAvailableUserClientRoles(realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error)
-	DeleteUserClientRole(role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) error
-
-	CreateUserRealmRole(role *v1alpha1.KeycloakUserRole, realmName, userID string) (string, error)
-	ListUserRealmRoles(realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error)
-	ListAvailableUserRealmRoles(realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error)
-	DeleteUserRealmRole(role *v1alpha1.KeycloakUserRole, realmName, userID string) error
-
-	ListAuthenticationExecutionsForFlow(flowAlias, realmName string) ([]*v1alpha1.AuthenticationExecutionInfo, error)
-
-	CreateAuthenticatorConfig(authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName, executionID string) (string, error)
-	GetAuthenticatorConfig(configID, realmName string) (*v1alpha1.AuthenticatorConfig, error)
-	UpdateAuthenticatorConfig(authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName string) error
-	DeleteAuthenticatorConfig(configID, realmName string) error
-
-	GetServiceAccountUser(realmName, clientID string) (*v1alpha1.KeycloakAPIUser, error)
+	CreateRealm(ctx context.Context, realm *v1alpha1.KeycloakRealm) (string, error)
+	GetRealm(ctx context.Context, realmName string) (*v1alpha1.KeycloakRealm, error)
+	UpdateRealm(ctx context.Context, specRealm *v1alpha1.KeycloakRealm) error
+	DeleteRealm(ctx context.Context, realmName string) error
+	ListRealms(ctx context.Context) ([]*v1alpha1.KeycloakRealm, error)
+
+	ListRealmRoleClientRoleComposites(ctx context.Context, realmName, roleID, clientID string) ([]v1alpha1.RoleRepresentation, error)
+	AddRealmRoleComposites(ctx context.Context, realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error
+	DeleteRealmRoleComposites(ctx context.Context, realmName, roleID string, roles *[]v1alpha1.RoleRepresentation) error
+
+	CreateClient(ctx context.Context, client *v1alpha1.KeycloakAPIClient, realmName string) (string, error)
+	GetClient(ctx context.Context, clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error)
+	GetClientSecret(ctx context.Context, clientID, realmName string) (string, error)
+	GetClientInstall(ctx context.Context, clientID, realmName string) ([]byte, error)
+	UpdateClient(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, realmName string) error
+	DeleteClient(ctx context.Context, clientID, realmName string) error
+	// Deprecated: use NewClientIterator or FindClientByClientID
+	ListClients(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakAPIClient, error)
+	ListClientsPage(ctx context.Context, realmName string, query ClientQuery, page Page) ([]*v1alpha1.KeycloakAPIClient, error)
+	CountClients(ctx context.Context, realmName string, query ClientQuery) (int, error)
+	FindClientByClientID(ctx context.Context, clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error)
+	ListClientRoles(ctx context.Context, clientID, realmName string) ([]v1alpha1.RoleRepresentation, error)
+	ListScopeMappings(ctx context.Context, clientID, realmName string) (*v1alpha1.MappingsRepresentation, error)
+	ListAvailableClientScopes(ctx context.Context, realmName string) ([]v1alpha1.KeycloakClientScope, error)
+	ListDefaultClientScopes(ctx context.Context, clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error)
+	ListOptionalClientScopes(ctx context.Context, clientID, realmName string) ([]v1alpha1.KeycloakClientScope, error)
+	CreateClientRole(ctx context.Context, clientID string, role *v1alpha1.RoleRepresentation, realmName string) (string, error)
+	UpdateClientRole(ctx context.Context, clientID string, role, oldRole *v1alpha1.RoleRepresentation, realmName string) error
+	DeleteClientRole(ctx context.Context, clientID, role, realmName string) error
+	CreateClientRealmScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error
+	DeleteClientRealmScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *[]v1alpha1.RoleRepresentation, realmName string) error
+	CreateClientClientScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error
+	DeleteClientClientScopeMappings(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, mappings *v1alpha1.ClientMappingsRepresentation, realmName string) error
+	UpdateClientDefaultClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
+	DeleteClientDefaultClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
+	UpdateClientOptionalClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
+	DeleteClientOptionalClientScope(ctx context.Context, specClient *v1alpha1.KeycloakAPIClient, clientScope *v1alpha1.KeycloakClientScope, realmName string) error
+
+	CreateUser(ctx context.Context, user *v1alpha1.KeycloakAPIUser, realmName string) (string, error)
+	CreateFederatedIdentity(ctx context.Context, fid v1alpha1.FederatedIdentity, userID string, realmName string) (string, error)
+	RemoveFederatedIdentity(ctx context.Context, fid v1alpha1.FederatedIdentity, userID string, realmName string) error
+	GetUserFederatedIdentities(ctx context.Context, userName string, realmName string) ([]v1alpha1.FederatedIdentity, error)
+	UpdatePassword(ctx context.Context, user *v1alpha1.KeycloakAPIUser, realmName, newPass string) error
+	FindUserByEmail(ctx context.Context, email, realm string) (*v1alpha1.KeycloakAPIUser, error)
+	FindUserByUsername(ctx context.Context, name, realm string) (*v1alpha1.KeycloakAPIUser, error)
+	GetUser(ctx context.Context, userID, realmName string) (*v1alpha1.KeycloakAPIUser, error)
+	UpdateUser(ctx context.Context, specUser *v1alpha1.KeycloakAPIUser, realmName string) error
+	DeleteUser(ctx context.Context, userID, realmName string) error
+	// Deprecated: use NewUserIterator or FindUserByUsername
+	ListUsers(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakAPIUser, error)
+	ListUsersPage(ctx context.Context, realmName string, query UserQuery, page Page) ([]*v1alpha1.KeycloakAPIUser, error)
+	CountUsers(ctx context.Context, realmName string, query UserQuery) (int, error)
+
+	CreateIdentityProvider(ctx context.Context, identityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) (string, error)
+	GetIdentityProvider(ctx context.Context, alias, realmName string) (*v1alpha1.KeycloakIdentityProvider, error)
+	UpdateIdentityProvider(ctx context.Context, specIdentityProvider *v1alpha1.KeycloakIdentityProvider, realmName string) error
+	DeleteIdentityProvider(ctx context.Context, alias, realmName string) error
+	ListIdentityProviders(ctx context.Context, realmName string) ([]*v1alpha1.KeycloakIdentityProvider, error)
+
+	CreateUserClientRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) (string, error)
+	ListUserClientRoles(ctx context.Context, realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error)
+	ListAvailableUserClientRoles(ctx context.Context, realmName, clientID, userID string) ([]*v1alpha1.KeycloakUserRole, error)
+	DeleteUserClientRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, clientID, userID string) error
+
+	CreateUserRealmRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, userID string) (string, error)
+	ListUserRealmRoles(ctx context.Context, realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error)
+	ListAvailableUserRealmRoles(ctx context.Context, realmName, userID string) ([]*v1alpha1.KeycloakUserRole, error)
+	DeleteUserRealmRole(ctx context.Context, role *v1alpha1.KeycloakUserRole, realmName, userID string) error
+
+	ListAuthenticationExecutionsForFlow(ctx context.Context, flowAlias, realmName string) ([]*v1alpha1.AuthenticationExecutionInfo, error)
+
+	CreateAuthenticatorConfig(ctx context.Context, authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName, executionID string) (string, error)
+	GetAuthenticatorConfig(ctx context.Context, configID, realmName string) (*v1alpha1.AuthenticatorConfig, error)
+	UpdateAuthenticatorConfig(ctx context.Context, authenticatorConfig *v1alpha1.AuthenticatorConfig, realmName string) error
+	DeleteAuthenticatorConfig(ctx context.Context, configID, realmName string) error
+
+	GetServiceAccountUser(ctx context.Context, realmName, clientID string) (*v1alpha1.KeycloakAPIUser, error)
+
+	ListEventsPage(ctx context.Context, realmName string, query EventQuery, page Page) ([]*v1alpha1.KeycloakAPIEvent, error)
+	ListAdminEventsPage(ctx context.Context, realmName string, query EventQuery, page Page) ([]*v1alpha1.KeycloakAPIAdminEvent, error)
+	TokenSubject() string
 }
 
 // check if Client implements KeycloakInterface
@@ -1011,6 +946,11 @@ type KeycloakClientFactory interface {
 	AuthenticatedClient(kc v1alpha1.Keycloak) (KeycloakInterface, error)
 }
 
+// BlueGreenClientFactory logs into the upgraded pods of a bluegreen migration
+type BlueGreenClientFactory interface {
+	BlueGreenClient(kc v1alpha1.Keycloak) (KeycloakInterface, error)
+}
+
 type LocalConfigKeycloakFactory struct {
 }
 
@@ -1026,29 +966,26 @@ func (i *LocalConfigKeycloakFactory) AuthenticatedClient(kc v1alpha1.Keycloak, i
 		return nil, err
 	}
 
-	var credentialSecret string
-	if kc.Spec.External.Enabled {
-		credentialSecret = "credential-" + kc.Name
-	} else {
-		credentialSecret = kc.Status.CredentialSecret
-	}
-
-	adminCreds, err := secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), credentialSecret, v12.GetOptions{})
+	credentials, err := readAdminCredentials(kc, func(name string) (*v1.Secret, error) {
+		return secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), name, v12.GetOptions{})
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get the admin credentials")
+		return nil, err
 	}
-	user := string(adminCreds.Data[model.AdminUsernameProperty])
-	pass := string(adminCreds.Data[model.AdminPasswordProperty])
 
 	var serverCert []byte = nil
 	if !insecureSsl {
-		serverCert, err = getKCServerCert(secretClient, kc)
+		if kc.Spec.CertManager.Enabled {
+			serverCert, err = getCertManagerCA(secretClient, kc)
+		} else {
+			serverCert, err = getKCServerCert(secretClient, kc)
+		}
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	requester, err := defaultRequester(serverCert)
+	requester, err := clientCertificateRequester(serverCert, credentials.clientCertificates())
 	if err != nil {
 		return nil, err
 	}
@@ -1058,22 +995,26 @@ func (i *LocalConfigKeycloakFactory) AuthenticatedClient(kc v1alpha1.Keycloak, i
 		return nil, err
 	}
 
-	var contextRoot string
-	if kc.Spec.External.Enabled && kc.Spec.Unmanaged {
-		contextRoot += kc.Spec.External.ContextRoot
-	}
-
 	client := &Client{
 		URL:         kcURL,
 		requester:   requester,
-		contextRoot: contextRoot,
+		contextRoot: model.KeycloakContextRoot(kc),
+		credentials: credentials,
 	}
-	if err := client.login(user, pass); err != nil {
+	if _, err := client.authenticate(); err != nil {
 		return nil, err
 	}
 	return client, nil
 }
 
+// keycloakCredentialSecretName returns the secret holding the admin credentials of the instance
+func keycloakCredentialSecretName(kc v1alpha1.Keycloak) string {
+	if kc.Spec.External.Enabled {
+		return "credential-" + kc.Name
+	}
+	return kc.Status.CredentialSecret
+}
+
 func getKCServerCert(secretClient *kubernetes.Clientset, kc v1alpha1.Keycloak) ([]byte, error) {
 	sslCertsSecret, err := secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), model.ServingCertSecretName, v12.GetOptions{})
 	switch {
@@ -1086,6 +1027,22 @@ func getKCServerCert(secretClient *kubernetes.Clientset, kc v1alpha1.Keycloak) (
 	}
 }
 
+// getCertManagerCA returns the CA of the certificate issued by cert-manager. Issuers that don't provide it,
+// like ACME, fall back to trusting the certificate itself.
+func getCertManagerCA(secretClient *kubernetes.Clientset, kc v1alpha1.Keycloak) ([]byte, error) {
+	sslCertsSecret, err := secretClient.CoreV1().Secrets(kc.Namespace).Get(context.TODO(), model.ServingCertSecretName, v12.GetOptions{})
+	switch {
+	case err == nil && len(sslCertsSecret.Data["ca.crt"]) > 0:
+		return sslCertsSecret.Data["ca.crt"], nil
+	case err == nil:
+		return sslCertsSecret.Data["tls.crt"], nil
+	case k8sErrors.IsNotFound(err):
+		return nil, errors.Errorf("certificate secret %v not issued by cert-manager yet", model.ServingCertSecretName)
+	default:
+		return nil, err
+	}
+}
+
 // At normal conditions, Keycloak should be accessible via the internalURL. However, there are some corner cases (like
 // operator running locally during development or services being inaccessible due to network policies) which requires
 // use of externalURL.
@@ -1136,11 +1093,5 @@ func validateKeycloakURL(url string, requester Requester) (string, error) {
 }
 
 func (c *Client) GetFullKeycloakPath() string {
-	URL := c.URL
-	if c.contextRoot != "" {
-		URL += c.contextRoot
-	} else {
-		URL += "/auth/"
-	}
-	return URL
+	return model.KeycloakPath(c.URL, c.contextRoot)
 }
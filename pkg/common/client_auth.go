@@ -0,0 +1,235 @@
+package common
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	adminCLIClientID        = "admin-cli"
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifespan = time.Minute
+)
+
+// adminCredentials are the credentials the operator requests its tokens with, either the password of the master
+// realm admin user or the credentials of a confidential client
+type adminCredentials struct {
+	realm        string
+	clientID     string
+	method       v1alpha1.KeycloakOperatorAuthenticationMethod
+	username     string
+	password     string
+	clientSecret string
+	privateKey   *rsa.PrivateKey
+	certificate  *tls.Certificate
+	// checksum of the secret the credentials were read from
+	checksum string
+}
+
+// passwordCredentials authenticates as the admin user of the master realm
+func passwordCredentials(user, pass string) *adminCredentials {
+	return &adminCredentials{
+		realm:    model.OperatorAuthenticationDefaultRealm,
+		clientID: adminCLIClientID,
+		username: user,
+		password: pass,
+	}
+}
+
+// readAdminCredentials reads the credentials of the operator from the secret configured in the Keycloak CR, or from
+// the credential secret of the admin user if no operator authentication is configured
+func readAdminCredentials(kc v1alpha1.Keycloak, getSecret func(name string) (*v1.Secret, error)) (*adminCredentials, error) {
+	authentication := kc.Spec.OperatorAuthentication
+	if authentication == nil {
+		adminCreds, err := getSecret(keycloakCredentialSecretName(kc))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get the admin credentials")
+		}
+		credentials := passwordCredentials(string(adminCreds.Data[model.AdminUsernameProperty]), string(adminCreds.Data[model.AdminPasswordProperty]))
+		credentials.checksum = secretChecksum(adminCreds)
+		return credentials, nil
+	}
+
+	if authentication.ClientID == "" || authentication.SecretName == "" {
+		return nil, errors.Errorf("operatorAuthentication requires clientId and secretName to be set")
+	}
+	secret, err := getSecret(authentication.SecretName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the client credentials of the operator")
+	}
+
+	credentials := &adminCredentials{
+		realm:    authentication.Realm,
+		clientID: authentication.ClientID,
+		method:   authentication.Method,
+		checksum: secretChecksum(secret),
+	}
+	if credentials.realm == "" {
+		credentials.realm = model.OperatorAuthenticationDefaultRealm
+	}
+	if credentials.method == "" {
+		credentials.method = v1alpha1.OperatorAuthenticationClientSecret
+	}
+
+	switch credentials.method {
+	case v1alpha1.OperatorAuthenticationClientSecret:
+		credentials.clientSecret = string(secret.Data[model.OperatorClientSecretProperty])
+		if credentials.clientSecret == "" {
+			return nil, errors.Errorf("secret %v has no %v", secret.Name, model.OperatorClientSecretProperty)
+		}
+	case v1alpha1.OperatorAuthenticationPrivateKeyJWT:
+		credentials.privateKey, err = parseRSAPrivateKey(secret.Data[model.OperatorClientKeyProperty])
+		if err != nil {
+			return nil, errors.Wrapf(err, "secret %v has no valid %v", secret.Name, model.OperatorClientKeyProperty)
+		}
+	case v1alpha1.OperatorAuthenticationTLS:
+		certificate, err := tls.X509KeyPair(secret.Data[model.OperatorClientCertificateProperty], secret.Data[model.OperatorClientKeyProperty])
+		if err != nil {
+			return nil, errors.Wrapf(err, "secret %v has no valid client certificate", secret.Name)
+		}
+		credentials.certificate = &certificate
+	default:
+		return nil, errors.Errorf("unknown operatorAuthentication method %v", credentials.method)
+	}
+	return credentials, nil
+}
+
+// grant returns the form requesting a new token
+func (a *adminCredentials) grant(tokenURL string) (url.Values, error) {
+	form, err := a.clientAuthentication(tokenURL)
+	if err != nil {
+		return nil, err
+	}
+	if a.method == "" {
+		form.Add("username", a.username)
+		form.Add("password", a.password)
+		form.Add("grant_type", "password")
+	} else {
+		form.Add("grant_type", "client_credentials")
+	}
+	return form, nil
+}
+
+// refreshGrant returns the form requesting a new token with the refresh token of a previous one. Confidential
+// clients have to authenticate for refreshes as well.
+func (a *adminCredentials) refreshGrant(tokenURL, refreshToken string) (url.Values, error) {
+	form, err := a.clientAuthentication(tokenURL)
+	if err != nil {
+		return nil, err
+	}
+	form.Add("refresh_token", refreshToken)
+	form.Add("grant_type", "refresh_token")
+	return form, nil
+}
+
+// clientAuthentication returns the parameters identifying the client. The certificate of mTLS is presented by the
+// requester.
+func (a *adminCredentials) clientAuthentication(tokenURL string) (url.Values, error) {
+	form := url.Values{}
+	form.Add("client_id", a.clientID)
+	switch a.method {
+	case v1alpha1.OperatorAuthenticationClientSecret:
+		form.Add("client_secret", a.clientSecret)
+	case v1alpha1.OperatorAuthenticationPrivateKeyJWT:
+		assertion, err := clientAssertion(a.clientID, tokenURL, a.privateKey, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		form.Add("client_assertion_type", clientAssertionType)
+		form.Add("client_assertion", assertion)
+	}
+	return form, nil
+}
+
+// clientCertificates returns the certificates presented in the TLS handshake
+func (a *adminCredentials) clientCertificates() []tls.Certificate {
+	if a.certificate == nil {
+		return nil
+	}
+	return []tls.Certificate{*a.certificate}
+}
+
+// clientAssertion returns a JWT signed with RS256 that authenticates the client at the token endpoint, as
+// described by RFC 7523
+func clientAssertion(clientID, audience string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": clientID,
+		"sub": clientID,
+		"aud": audience,
+		"jti": fmt.Sprintf("%x", jti),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifespan).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM encoded private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("only RSA private keys are supported")
+	}
+	return rsaKey, nil
+}
+
+func secretChecksum(secret *v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
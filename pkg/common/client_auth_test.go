@@ -0,0 +1,190 @@
+package common
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClient_AuthenticateWithClientSecret(t *testing.T) {
+	// given
+	var form url.Values
+	server := httptest.NewServer(formHandler(t, "/auth/realms/operators/protocol/openid-connect/token", &form))
+	defer server.Close()
+
+	kc := operatorAuthenticationKeycloak(v1alpha1.OperatorAuthenticationClientSecret)
+	credentials, err := readAdminCredentials(kc, secretGetter(map[string][]byte{
+		model.OperatorClientSecretProperty: []byte("client-secret"),
+	}))
+	assert.NoError(t, err)
+
+	client := &Client{
+		URL:         server.URL,
+		requester:   server.Client(),
+		credentials: credentials,
+	}
+
+	// when
+	_, err = client.authenticate()
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "token", client.token)
+	assert.Equal(t, "client_credentials", form.Get("grant_type"))
+	assert.Equal(t, "keycloak-operator", form.Get("client_id"))
+	assert.Equal(t, "client-secret", form.Get("client_secret"))
+	assert.Equal(t, "", form.Get("password"))
+}
+
+func TestClient_AuthenticateWithPrivateKeyJWT(t *testing.T) {
+	// given
+	var form url.Values
+	server := httptest.NewServer(formHandler(t, "/auth/realms/operators/protocol/openid-connect/token", &form))
+	defer server.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	kc := operatorAuthenticationKeycloak(v1alpha1.OperatorAuthenticationPrivateKeyJWT)
+	credentials, err := readAdminCredentials(kc, secretGetter(map[string][]byte{
+		model.OperatorClientKeyProperty: keyPEM,
+	}))
+	assert.NoError(t, err)
+
+	client := &Client{
+		URL:         server.URL,
+		requester:   server.Client(),
+		credentials: credentials,
+	}
+
+	// when
+	_, err = client.authenticate()
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "client_credentials", form.Get("grant_type"))
+	assert.Equal(t, clientAssertionType, form.Get("client_assertion_type"))
+
+	parts := strings.Split(form.Get("client_assertion"), ".")
+	assert.Len(t, parts, 3)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.NoError(t, err)
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+	claims := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, "keycloak-operator", claims["iss"])
+	assert.Equal(t, "keycloak-operator", claims["sub"])
+	assert.Equal(t, server.URL+"/auth/realms/operators/protocol/openid-connect/token", claims["aud"])
+}
+
+func TestReadAdminCredentials_DefaultsToAdminUser(t *testing.T) {
+	// given
+	kc := v1alpha1.Keycloak{}
+	kc.Name = "example-keycloak"
+	kc.Status.CredentialSecret = "credential-example-keycloak"
+
+	// when
+	credentials, err := readAdminCredentials(kc, secretGetter(map[string][]byte{
+		model.AdminUsernameProperty: []byte("admin"),
+		model.AdminPasswordProperty: []byte("password"),
+	}))
+	form, formErr := credentials.grant("https://keycloak/auth/realms/master/protocol/openid-connect/token")
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, formErr)
+	assert.Equal(t, "master", credentials.realm)
+	assert.Equal(t, "password", form.Get("grant_type"))
+	assert.Equal(t, "admin-cli", form.Get("client_id"))
+	assert.Equal(t, "admin", form.Get("username"))
+}
+
+func TestReadAdminCredentials_InvalidClientCertificate(t *testing.T) {
+	// given
+	kc := operatorAuthenticationKeycloak(v1alpha1.OperatorAuthenticationTLS)
+
+	// when
+	_, err := readAdminCredentials(kc, secretGetter(map[string][]byte{
+		model.OperatorClientCertificateProperty: []byte("not a certificate"),
+	}))
+	_, missingErr := readAdminCredentials(kc, func(name string) (*v1.Secret, error) {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+	})
+
+	// then
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no valid client certificate")
+	assert.Error(t, missingErr)
+}
+
+func TestClientAssertion_Lifespan(t *testing.T) {
+	// given
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// when
+	assertion, err := clientAssertion("keycloak-operator", "https://keycloak/token", key, now)
+
+	// then
+	assert.NoError(t, err)
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(assertion, ".")[1])
+	assert.NoError(t, err)
+	claims := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, float64(now.Unix()), claims["iat"])
+	assert.Equal(t, float64(now.Add(time.Minute).Unix()), claims["exp"])
+}
+
+func operatorAuthenticationKeycloak(method v1alpha1.KeycloakOperatorAuthenticationMethod) v1alpha1.Keycloak {
+	kc := v1alpha1.Keycloak{}
+	kc.Name = "example-keycloak"
+	kc.Spec.OperatorAuthentication = &v1alpha1.KeycloakOperatorAuthentication{
+		Realm:      "operators",
+		ClientID:   "keycloak-operator",
+		Method:     method,
+		SecretName: "keycloak-operator-client",
+	}
+	return kc
+}
+
+func secretGetter(data map[string][]byte) func(name string) (*v1.Secret, error) {
+	return func(name string) (*v1.Secret, error) {
+		secret := &v1.Secret{Data: data}
+		secret.Name = name
+		return secret, nil
+	}
+}
+
+// formHandler records the form of a token request and issues a token
+func formHandler(t *testing.T, path string, form *url.Values) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, path, req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		*form = req.PostForm
+		_, _ = w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	}
+}
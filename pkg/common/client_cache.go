@@ -0,0 +1,265 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A refresh token is no longer used shortly before it expires, the refresh could reach Keycloak too late
+const refreshMargin = 10 * time.Second
+
+// DefaultKeycloakClientCache is shared by the controllers, all of them talk to the same Keycloak instances
+var DefaultKeycloakClientCache = NewKeycloakClientCache()
+
+// KeycloakClientCache keeps an authenticated admin client per Keycloak CR. The token of a client is refreshed
+// with its refresh token once half of its lifespan passed, a new login is only performed when the refresh token
+// expired as well. A client is replaced when the admin credentials, the server certificate or the URLs of the
+// instance change.
+type KeycloakClientCache struct {
+	mutex   sync.Mutex
+	clients map[types.NamespacedName]*cachedKeycloakClient
+	now     func() time.Time
+}
+
+type cachedKeycloakClient struct {
+	mutex         sync.Mutex
+	client        *Client
+	fingerprint   string
+	refreshToken  string
+	expiry        time.Time
+	refreshExpiry time.Time
+}
+
+func NewKeycloakClientCache() *KeycloakClientCache {
+	return &KeycloakClientCache{
+		clients: map[types.NamespacedName]*cachedKeycloakClient{},
+		now:     time.Now,
+	}
+}
+
+// Invalidate drops the client of the Keycloak CR, e.g. once the CR is deleted
+func (c *KeycloakClientCache) Invalidate(key types.NamespacedName) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.clients, key)
+}
+
+func (c *KeycloakClientCache) entry(key types.NamespacedName) *cachedKeycloakClient {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.clients[key]
+	if !ok {
+		entry = &cachedKeycloakClient{}
+		c.clients[key] = entry
+	}
+	return entry
+}
+
+// authenticatedClient returns the cached client if its fingerprint matches, refreshing its token if needed.
+// Otherwise a client is created with newClient and logged in. Clients handed out are never modified, a refresh
+// replaces the cached client with a copy holding the new token.
+func (c *KeycloakClientCache) authenticatedClient(key types.NamespacedName, fingerprint string, newClient func() (*Client, error)) (*Client, error) {
+	entry := c.entry(key)
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	now := c.now()
+	if entry.client != nil && entry.fingerprint == fingerprint {
+		if now.Before(entry.expiry) {
+			return entry.client, nil
+		}
+
+		refreshed := *entry.client
+		if entry.refreshable(now) {
+			token, err := refreshed.refresh(entry.refreshToken)
+			observeTokenRefresh(err)
+			if err == nil {
+				entry.set(&refreshed, fingerprint, token, now)
+				return entry.client, nil
+			}
+			log.Info(fmt.Sprintf("refreshing the token for keycloak %v failed, logging in again: %v", key, err))
+		}
+
+		token, err := refreshed.authenticate()
+		if err != nil {
+			entry.client = nil
+			return nil, err
+		}
+		entry.set(&refreshed, fingerprint, token, now)
+		return entry.client, nil
+	}
+
+	authenticated, err := newClient()
+	if err != nil {
+		entry.client = nil
+		return nil, err
+	}
+	token, err := authenticated.authenticate()
+	if err != nil {
+		entry.client = nil
+		return nil, err
+	}
+	entry.set(authenticated, fingerprint, token, now)
+	return entry.client, nil
+}
+
+func (e *cachedKeycloakClient) set(authenticated *Client, fingerprint string, token *v1alpha1.TokenResponse, now time.Time) {
+	e.client = authenticated
+	e.fingerprint = fingerprint
+	e.refreshToken = token.RefreshToken
+	e.expiry = now.Add(time.Duration(token.ExpiresIn) * time.Second / 2)
+	// Offline tokens don't expire, their refresh_expires_in is 0
+	e.refreshExpiry = time.Time{}
+	if token.RefreshExpiresIn > 0 {
+		e.refreshExpiry = now.Add(time.Duration(token.RefreshExpiresIn)*time.Second - refreshMargin)
+	}
+}
+
+// refreshable returns true if the refresh token is still valid
+func (e *cachedKeycloakClient) refreshable(now time.Time) bool {
+	return e.refreshToken != "" && (e.refreshExpiry.IsZero() || now.Before(e.refreshExpiry))
+}
+
+// CachedKeycloakFactory hands out the admin clients of the shared cache. The admin credentials and the server
+// certificate are read through the client of the manager, which serves them from its informer cache.
+type CachedKeycloakFactory struct {
+	context context.Context
+	client  client.Client
+	cache   *KeycloakClientCache
+}
+
+func NewCachedKeycloakFactory(context context.Context, client client.Client) *CachedKeycloakFactory {
+	return &CachedKeycloakFactory{
+		context: context,
+		client:  client,
+		cache:   DefaultKeycloakClientCache,
+	}
+}
+
+// check if CachedKeycloakFactory implements KeycloakClientFactory
+var _ KeycloakClientFactory = &CachedKeycloakFactory{}
+
+// check if CachedKeycloakFactory implements BlueGreenClientFactory
+var _ BlueGreenClientFactory = &CachedKeycloakFactory{}
+
+// AuthenticatedClient returns the cached client of the Keycloak CR, or logs a new one in
+func (i *CachedKeycloakFactory) AuthenticatedClient(kc v1alpha1.Keycloak) (KeycloakInterface, error) {
+	credentials, err := i.adminCredentials(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCert, err := i.serverCert(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := keycloakClientFingerprint(kc, credentials, serverCert)
+	key := types.NamespacedName{Namespace: kc.Namespace, Name: kc.Name}
+	return i.cache.authenticatedClient(key, fingerprint, func() (*Client, error) {
+		requester, err := clientCertificateRequester(serverCert, credentials.clientCertificates())
+		if err != nil {
+			return nil, err
+		}
+
+		kcURL, err := getKeycloakURL(kc, requester)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Client{
+			URL:         kcURL,
+			requester:   requester,
+			contextRoot: model.KeycloakContextRoot(kc),
+			credentials: credentials,
+			// A revoked token is replaced by a new login in the next reconciliation
+			unauthorized: func() {
+				i.cache.Invalidate(key)
+			},
+		}, nil
+	})
+}
+
+// BlueGreenClient logs into the upgraded pods of a bluegreen migration through their Service. They serve the
+// certificate of the Keycloak Service, it's verified against the host of the internal URL like for the cached
+// clients. The client isn't cached, every smoke check logs in again.
+func (i *CachedKeycloakFactory) BlueGreenClient(kc v1alpha1.Keycloak) (KeycloakInterface, error) {
+	credentials, err := i.adminCredentials(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCert, err := i.serverCert(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	internalURL, err := url.Parse(kc.Status.InternalURL)
+	if err != nil || internalURL.Hostname() == "" {
+		return nil, errors.Errorf("keycloak %v has no internal url to verify the certificate of the upgraded pods against", kc.Name)
+	}
+	requester, err := serverNameRequester(serverCert, credentials.clientCertificates(), internalURL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := &Client{
+		URL:         model.KeycloakBlueGreenURL(&kc),
+		requester:   requester,
+		contextRoot: model.KeycloakContextRoot(kc),
+		credentials: credentials,
+	}
+	if _, err := authenticated.authenticate(); err != nil {
+		return nil, err
+	}
+	return authenticated, nil
+}
+
+// adminCredentials reads the credentials of the operator for the instance
+func (i *CachedKeycloakFactory) adminCredentials(kc v1alpha1.Keycloak) (*adminCredentials, error) {
+	return readAdminCredentials(kc, func(name string) (*v1.Secret, error) {
+		secret := &v1.Secret{}
+		err := i.client.Get(i.context, types.NamespacedName{Namespace: kc.Namespace, Name: name}, secret)
+		return secret, err
+	})
+}
+
+// serverCert returns the certificate the instance is trusted with, like getKCServerCert and getCertManagerCA
+func (i *CachedKeycloakFactory) serverCert(kc v1alpha1.Keycloak) ([]byte, error) {
+	sslCertsSecret := &v1.Secret{}
+	err := i.client.Get(i.context, types.NamespacedName{Namespace: kc.Namespace, Name: model.ServingCertSecretName}, sslCertsSecret)
+	switch {
+	case err == nil && kc.Spec.CertManager.Enabled && len(sslCertsSecret.Data["ca.crt"]) > 0:
+		return sslCertsSecret.Data["ca.crt"], nil
+	case err == nil:
+		return sslCertsSecret.Data["tls.crt"], nil
+	case k8sErrors.IsNotFound(err) && kc.Spec.CertManager.Enabled:
+		return nil, errors.Errorf("certificate secret %v not issued by cert-manager yet", model.ServingCertSecretName)
+	case k8sErrors.IsNotFound(err):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// keycloakClientFingerprint changes whenever a cached client of the instance can't be used anymore
+func keycloakClientFingerprint(kc v1alpha1.Keycloak, credentials *adminCredentials, serverCert []byte) string {
+	hash := sha256.New()
+	for _, value := range []string{credentials.realm, credentials.clientID, string(credentials.method), credentials.checksum, string(serverCert), kc.Status.InternalURL, kc.Status.ExternalURL, model.KeycloakContextRoot(kc)} {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
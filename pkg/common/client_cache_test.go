@@ -0,0 +1,126 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestKeycloakClientCache_ReusesClient(t *testing.T) {
+	// given
+	grants := []string{}
+	server := httptest.NewServer(tokenHandler(t, &grants))
+	defer server.Close()
+
+	cache, now := testClientCache()
+	key := types.NamespacedName{Namespace: "keycloak", Name: "example-keycloak"}
+	newClient := testClientFunc(server)
+
+	// when
+	first, err := cache.authenticatedClient(key, "fingerprint", newClient)
+	assert.NoError(t, err)
+	*now = now.Add(time.Minute)
+	second, secondErr := cache.authenticatedClient(key, "fingerprint", newClient)
+
+	// then
+	assert.NoError(t, secondErr)
+	assert.Same(t, first, second)
+	assert.Equal(t, []string{"password"}, grants)
+}
+
+func TestKeycloakClientCache_RefreshesExpiredToken(t *testing.T) {
+	// given
+	grants := []string{}
+	server := httptest.NewServer(tokenHandler(t, &grants))
+	defer server.Close()
+
+	cache, now := testClientCache()
+	key := types.NamespacedName{Namespace: "keycloak", Name: "example-keycloak"}
+	newClient := testClientFunc(server)
+
+	// when
+	first, err := cache.authenticatedClient(key, "fingerprint", newClient)
+	assert.NoError(t, err)
+	*now = now.Add(3 * time.Minute)
+	refreshed, refreshErr := cache.authenticatedClient(key, "fingerprint", newClient)
+	assert.NoError(t, refreshErr)
+	*now = now.Add(time.Hour)
+	loggedIn, loginErr := cache.authenticatedClient(key, "fingerprint", newClient)
+
+	// then
+	assert.NoError(t, loginErr)
+	assert.Equal(t, []string{"password", "refresh_token", "password"}, grants)
+	assert.Equal(t, "token-1", first.token)
+	assert.Equal(t, "token-2", refreshed.token)
+	assert.Equal(t, "token-3", loggedIn.token)
+	assert.Equal(t, first.requester, refreshed.requester)
+}
+
+func TestKeycloakClientCache_RecreatesClientOnFingerprintChange(t *testing.T) {
+	// given
+	grants := []string{}
+	server := httptest.NewServer(tokenHandler(t, &grants))
+	defer server.Close()
+
+	cache, _ := testClientCache()
+	key := types.NamespacedName{Namespace: "keycloak", Name: "example-keycloak"}
+	created := 0
+	newClient := func() (*Client, error) {
+		created++
+		return testClientFunc(server)()
+	}
+
+	// when
+	first, err := cache.authenticatedClient(key, "fingerprint", newClient)
+	assert.NoError(t, err)
+	second, secondErr := cache.authenticatedClient(key, "rotated-fingerprint", newClient)
+	assert.NoError(t, secondErr)
+	cache.Invalidate(key)
+	third, thirdErr := cache.authenticatedClient(key, "rotated-fingerprint", newClient)
+
+	// then
+	assert.NoError(t, thirdErr)
+	assert.Equal(t, 3, created)
+	assert.NotSame(t, first, second)
+	assert.NotSame(t, second, third)
+	assert.Equal(t, []string{"password", "password", "password"}, grants)
+}
+
+func testClientCache() (*KeycloakClientCache, *time.Time) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewKeycloakClientCache()
+	cache.now = func() time.Time {
+		return now
+	}
+	return cache, &now
+}
+
+func testClientFunc(server *httptest.Server) func() (*Client, error) {
+	return func() (*Client, error) {
+		return &Client{
+			URL:         server.URL,
+			requester:   server.Client(),
+			credentials: passwordCredentials("admin", "password"),
+		}, nil
+	}
+}
+
+// tokenHandler issues access tokens valid for 5 minutes and refresh tokens valid for 30 minutes
+func tokenHandler(t *testing.T, grants *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, TokenPath, req.URL.Path)
+		assert.NoError(t, req.ParseForm())
+		grant := req.Form.Get("grant_type")
+		if grant == "refresh_token" {
+			assert.Equal(t, "refresh-1", req.Form.Get("refresh_token"))
+		}
+		*grants = append(*grants, grant)
+		n := len(*grants)
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 300, "refresh_expires_in": 1800, "refresh_token": "refresh-%d"}`, n, n)
+	}
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// APIError is returned when the admin API answers a request with an unexpected status. Use IsNotFound,
+// IsConflict and IsUnauthorized to tell the failures apart.
+type APIError struct {
+	// The operation, e.g. GET or create
+	Operation string
+	// The kind of the resource, e.g. realm or client
+	Resource   string
+	StatusCode int
+	Status     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("failed to %s %s: (%d) %s", e.Operation, e.Resource, e.StatusCode, e.Status)
+}
+
+// IsNotFound returns true if the resource doesn't exist in Keycloak
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict returns true if the resource already exists in Keycloak
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsUnauthorized returns true if the token of the operator was rejected or lacks the roles for the request
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized, http.StatusForbidden)
+}
+
+func hasStatusCode(err error, statusCodes ...int) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	for _, statusCode := range statusCodes {
+		if apiError.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableStatusCode returns true for responses of an overloaded or temporarily unavailable Keycloak
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
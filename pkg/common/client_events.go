@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// EventQuery narrows an event listing down to the events of the types set, recorded on or after the day of
+// DateFrom. Keycloak returns the newest events first.
+type EventQuery struct {
+	Types    []string
+	DateFrom time.Time
+}
+
+func (q EventQuery) values() url.Values {
+	values := url.Values{}
+	for _, eventType := range q.Types {
+		values.Add("type", eventType)
+	}
+	if !q.DateFrom.IsZero() {
+		values.Set("dateFrom", q.DateFrom.UTC().Format("2006-01-02"))
+	}
+	return values
+}
+
+func (c *Client) ListEventsPage(ctx context.Context, realmName string, query EventQuery, page Page) ([]*v1alpha1.KeycloakAPIEvent, error) {
+	path := fmt.Sprintf("realms/%s/events?%s", realmName, page.values(query.values()).Encode())
+	result, err := c.list(ctx, path, "events", func(body []byte) (T, error) {
+		var events []*v1alpha1.KeycloakAPIEvent
+		err := json.Unmarshal(body, &events)
+		return events, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.([]*v1alpha1.KeycloakAPIEvent)
+	if !ok {
+		return nil, errors.Errorf("error decoding list events response")
+	}
+	return res, nil
+}
+
+// ListAdminEventsPage lists the admin events, the types of the query are ignored
+func (c *Client) ListAdminEventsPage(ctx context.Context, realmName string, query EventQuery, page Page) ([]*v1alpha1.KeycloakAPIAdminEvent, error) {
+	query.Types = nil
+	path := fmt.Sprintf("realms/%s/admin-events?%s", realmName, page.values(query.values()).Encode())
+	result, err := c.list(ctx, path, "admin events", func(body []byte) (T, error) {
+		var events []*v1alpha1.KeycloakAPIAdminEvent
+		err := json.Unmarshal(body, &events)
+		return events, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.([]*v1alpha1.KeycloakAPIAdminEvent)
+	if !ok {
+		return nil, errors.Errorf("error decoding list admin events response")
+	}
+	return res, nil
+}
+
+// TokenSubject returns the ID of the user the client is authenticated as, taken from the access token without
+// verifying it. Admin events record the same ID as the user that made a change.
+func (c *Client) TokenSubject() string {
+	parts := strings.Split(c.token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		Subject string `json:"sub"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Subject
+}
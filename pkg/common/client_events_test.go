@@ -0,0 +1,83 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ListEventsPage(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/dummy/events", req.URL.Path)
+		assert.Equal(t, []string{"LOGIN", "LOGIN_ERROR"}, req.URL.Query()["type"])
+		assert.Equal(t, "2024-01-31", req.URL.Query().Get("dateFrom"))
+		assert.Equal(t, "100", req.URL.Query().Get("first"))
+		assert.Equal(t, "50", req.URL.Query().Get("max"))
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIEvent{
+			{Time: 1706745600000, Type: "LOGIN_ERROR", ClientID: "account", Error: "invalid_user_credentials"},
+		})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+	query := EventQuery{Types: []string{"LOGIN", "LOGIN_ERROR"}, DateFrom: time.Date(2024, 1, 31, 23, 0, 0, 0, time.UTC)}
+
+	// when
+	events, err := client.ListEventsPage(context.TODO(), "dummy", query, Page{First: 100, Max: 50})
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "invalid_user_credentials", events[0].Error)
+}
+
+func TestClient_ListAdminEventsPage(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/dummy/admin-events", req.URL.Path)
+		assert.Empty(t, req.URL.Query()["type"])
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIAdminEvent{{
+			Time:          1706745600000,
+			AuthDetails:   v1alpha1.KeycloakAPIAuthDetails{UserID: "alice", IPAddress: "10.0.0.1"},
+			OperationType: "UPDATE",
+			ResourceType:  "CLIENT",
+			ResourcePath:  "clients/8f3c-41",
+		}})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	events, err := client.ListAdminEventsPage(context.TODO(), "dummy", EventQuery{Types: []string{"LOGIN"}}, Page{})
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "alice", events[0].AuthDetails.UserID)
+	assert.Equal(t, "clients/8f3c-41", events[0].ResourcePath)
+}
+
+func TestClient_TokenSubject(t *testing.T) {
+	// given
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"6f2a-operator","preferred_username":"admin"}`))
+	client := &Client{token: "eyJhbGciOiJSUzI1NiJ9." + payload + ".signature"}
+	opaque := &Client{token: "dummy"}
+
+	// when
+	subject := client.TokenSubject()
+	opaqueSubject := opaque.TokenSubject()
+
+	// then
+	assert.Equal(t, "6f2a-operator", subject)
+	assert.Empty(t, opaqueSubject)
+}
@@ -0,0 +1,300 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+// DefaultPageSize is the number of users or clients the iterators fetch per request
+const DefaultPageSize = 100
+
+// MaxListSize caps the users or clients ListUsers and ListClients hold in memory
+const MaxListSize = 10000
+
+// Page selects a slice of a listing with the first and max parameters of the admin API
+type Page struct {
+	First int
+	// Max of 0 uses DefaultPageSize
+	Max int
+}
+
+func (p Page) size() int {
+	if p.Max <= 0 {
+		return DefaultPageSize
+	}
+	return p.Max
+}
+
+func (p Page) values(values url.Values) url.Values {
+	values.Set("first", strconv.Itoa(p.First))
+	values.Set("max", strconv.Itoa(p.size()))
+	return values
+}
+
+// UserQuery narrows a user listing down to the users matching all fields set exactly. An empty query matches
+// every user of the realm.
+type UserQuery struct {
+	Username string
+	Email    string
+	// Attributes the users must have, searched with the q parameter of Keycloak 15 and newer
+	Attributes map[string]string
+}
+
+func (q UserQuery) values() url.Values {
+	values := url.Values{}
+	if q.Username != "" {
+		values.Set("username", q.Username)
+	}
+	if q.Email != "" {
+		values.Set("email", q.Email)
+	}
+	if len(q.Attributes) > 0 {
+		var terms []string
+		for name, value := range q.Attributes {
+			terms = append(terms, name+":"+value)
+		}
+		sort.Strings(terms)
+		values.Set("q", strings.Join(terms, " "))
+	}
+	if len(values) > 0 {
+		values.Set("exact", "true")
+	}
+	return values
+}
+
+// matches checks a user returned by Keycloak, older versions ignore exact and return every user containing the
+// username or email. Keycloak stores usernames and emails lower case.
+func (q UserQuery) matches(user *v1alpha1.KeycloakAPIUser) bool {
+	if q.Username != "" && !strings.EqualFold(user.UserName, q.Username) {
+		return false
+	}
+	if q.Email != "" && !strings.EqualFold(user.Email, q.Email) {
+		return false
+	}
+	for name, value := range q.Attributes {
+		if !containsString(user.Attributes[name], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientQuery narrows a client listing down to the client with the clientId set. An empty query matches every
+// client of the realm.
+type ClientQuery struct {
+	ClientID string
+}
+
+func (q ClientQuery) values() url.Values {
+	values := url.Values{}
+	if q.ClientID != "" {
+		values.Set("clientId", q.ClientID)
+	}
+	return values
+}
+
+func (q ClientQuery) matches(client *v1alpha1.KeycloakAPIClient) bool {
+	return q.ClientID == "" || client.ClientID == q.ClientID
+}
+
+func (c *Client) ListUsersPage(ctx context.Context, realmName string, query UserQuery, page Page) ([]*v1alpha1.KeycloakAPIUser, error) {
+	path := fmt.Sprintf("realms/%s/users?%s", realmName, page.values(query.values()).Encode())
+	result, err := c.list(ctx, path, "users", func(body []byte) (T, error) {
+		var users []*v1alpha1.KeycloakAPIUser
+		err := json.Unmarshal(body, &users)
+		return users, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.([]*v1alpha1.KeycloakAPIUser)
+	if !ok {
+		return nil, errors.Errorf("error decoding list users response")
+	}
+	return res, nil
+}
+
+// CountUsers returns the number of users matching the query as counted by Keycloak
+func (c *Client) CountUsers(ctx context.Context, realmName string, query UserQuery) (int, error) {
+	values := query.values()
+	values.Del("exact")
+	result, err := c.get(ctx, fmt.Sprintf("realms/%s/users/count?%s", realmName, values.Encode()), "user count", func(body []byte) (T, error) {
+		var count int
+		err := json.Unmarshal(body, &count)
+		return count, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if result == nil {
+		return 0, errors.Errorf("realm %v not found", realmName)
+	}
+	return result.(int), nil
+}
+
+func (c *Client) ListClientsPage(ctx context.Context, realmName string, query ClientQuery, page Page) ([]*v1alpha1.KeycloakAPIClient, error) {
+	path := fmt.Sprintf("realms/%s/clients?%s", realmName, page.values(query.values()).Encode())
+	result, err := c.list(ctx, path, "clients", func(body []byte) (T, error) {
+		var clients []*v1alpha1.KeycloakAPIClient
+		err := json.Unmarshal(body, &clients)
+		return clients, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := result.([]*v1alpha1.KeycloakAPIClient)
+	if !ok {
+		return nil, errors.Errorf("error decoding list clients response")
+	}
+	return res, nil
+}
+
+// CountClients returns the number of clients matching the query. The admin API has no count of clients, they are
+// paged through.
+func (c *Client) CountClients(ctx context.Context, realmName string, query ClientQuery) (int, error) {
+	count := 0
+	clients := NewClientIterator(ctx, c, realmName, query)
+	for clients.Next() {
+		count++
+	}
+	return count, clients.Err()
+}
+
+func (c *Client) FindClientByClientID(ctx context.Context, clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error) {
+	clients := NewClientIterator(ctx, c, realmName, ClientQuery{ClientID: clientID})
+	if clients.Next() {
+		return clients.Client(), nil
+	}
+	return nil, clients.Err()
+}
+
+// UserIterator walks the users matching a query page by page, only one page is held in memory:
+//
+//	users := NewUserIterator(ctx, keycloakClient, realm, UserQuery{})
+//	for users.Next() {
+//		user := users.User()
+//	}
+//	if err := users.Err(); err != nil {
+//
+// Users created or deleted while iterating can shift the pages, a user may be skipped or returned twice.
+type UserIterator struct {
+	ctx      context.Context
+	client   KeycloakInterface
+	realm    string
+	query    UserQuery
+	pageSize int
+	first    int
+	page     []*v1alpha1.KeycloakAPIUser
+	user     *v1alpha1.KeycloakAPIUser
+	done     bool
+	err      error
+}
+
+func NewUserIterator(ctx context.Context, client KeycloakInterface, realmName string, query UserQuery) *UserIterator {
+	return &UserIterator{
+		ctx:      ctx,
+		client:   client,
+		realm:    realmName,
+		query:    query,
+		pageSize: DefaultPageSize,
+	}
+}
+
+// Next advances to the next user, it returns false once all users were returned or a request failed
+func (it *UserIterator) Next() bool {
+	for {
+		for len(it.page) > 0 {
+			it.user, it.page = it.page[0], it.page[1:]
+			if it.query.matches(it.user) {
+				return true
+			}
+		}
+		if it.done || it.err != nil {
+			it.user = nil
+			return false
+		}
+
+		it.page, it.err = it.client.ListUsersPage(it.ctx, it.realm, it.query, Page{First: it.first, Max: it.pageSize})
+		it.first += len(it.page)
+		it.done = len(it.page) < it.pageSize
+	}
+}
+
+func (it *UserIterator) User() *v1alpha1.KeycloakAPIUser {
+	return it.user
+}
+
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+// ClientIterator walks the clients matching a query page by page, like UserIterator
+type ClientIterator struct {
+	ctx      context.Context
+	client   KeycloakInterface
+	realm    string
+	query    ClientQuery
+	pageSize int
+	first    int
+	page     []*v1alpha1.KeycloakAPIClient
+	current  *v1alpha1.KeycloakAPIClient
+	done     bool
+	err      error
+}
+
+func NewClientIterator(ctx context.Context, client KeycloakInterface, realmName string, query ClientQuery) *ClientIterator {
+	return &ClientIterator{
+		ctx:      ctx,
+		client:   client,
+		realm:    realmName,
+		query:    query,
+		pageSize: DefaultPageSize,
+	}
+}
+
+// Next advances to the next client, it returns false once all clients were returned or a request failed
+func (it *ClientIterator) Next() bool {
+	for {
+		for len(it.page) > 0 {
+			it.current, it.page = it.page[0], it.page[1:]
+			if it.query.matches(it.current) {
+				return true
+			}
+		}
+		if it.done || it.err != nil {
+			it.current = nil
+			return false
+		}
+
+		it.page, it.err = it.client.ListClientsPage(it.ctx, it.realm, it.query, Page{First: it.first, Max: it.pageSize})
+		it.first += len(it.page)
+		it.done = len(it.page) < it.pageSize
+	}
+}
+
+func (it *ClientIterator) Client() *v1alpha1.KeycloakAPIClient {
+	return it.current
+}
+
+func (it *ClientIterator) Err() error {
+	return it.err
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserIterator_WalksAllPages(t *testing.T) {
+	// given
+	var users []*v1alpha1.KeycloakAPIUser
+	for n := 0; n < 2*DefaultPageSize+1; n++ {
+		users = append(users, &v1alpha1.KeycloakAPIUser{ID: strconv.Itoa(n), UserName: fmt.Sprintf("user-%d", n)})
+	}
+
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		assert.Equal(t, "/auth/admin/realms/dummy/users", req.URL.Path)
+		first, _ := strconv.Atoi(req.URL.Query().Get("first"))
+		max, _ := strconv.Atoi(req.URL.Query().Get("max"))
+		writeJSONPage(t, w, len(users), first, max, func(from, to int) interface{} {
+			return users[from:to]
+		})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	var names []string
+	iterator := NewUserIterator(context.TODO(), client, "dummy", UserQuery{})
+	for iterator.Next() {
+		names = append(names, iterator.User().UserName)
+	}
+
+	// then
+	assert.NoError(t, iterator.Err())
+	assert.Len(t, names, 2*DefaultPageSize+1)
+	assert.Equal(t, "user-0", names[0])
+	assert.Equal(t, fmt.Sprintf("user-%d", 2*DefaultPageSize), names[2*DefaultPageSize])
+	assert.Equal(t, 3, requests)
+	assert.Nil(t, iterator.User())
+}
+
+func TestClient_FindUserByUsernameIsExact(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "true", req.URL.Query().Get("exact"))
+		assert.Equal(t, "Alice", req.URL.Query().Get("username"))
+		// Keycloak before version 11 ignores exact
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIUser{
+			{ID: "1", UserName: "alice2"},
+			{ID: "2", UserName: "alice"},
+		})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	user, err := client.FindUserByUsername(context.TODO(), "Alice", "dummy")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "2", user.ID)
+}
+
+func TestClient_FindUserByAttribute(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "employee:42 team:keycloak", req.URL.Query().Get("q"))
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIUser{
+			{ID: "1", Attributes: map[string][]string{"employee": {"42"}, "team": {"operators"}}},
+			{ID: "2", Attributes: map[string][]string{"employee": {"42"}, "team": {"keycloak"}}},
+		})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+	query := UserQuery{Attributes: map[string]string{"team": "keycloak", "employee": "42"}}
+
+	// when
+	var ids []string
+	iterator := NewUserIterator(context.TODO(), client, "dummy", query)
+	for iterator.Next() {
+		ids = append(ids, iterator.User().ID)
+	}
+
+	// then
+	assert.NoError(t, iterator.Err())
+	assert.Equal(t, []string{"2"}, ids)
+}
+
+func TestClient_Counts(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/auth/admin/realms/dummy/users/count":
+			assert.Equal(t, "example.com", req.URL.Query().Get("email"))
+			writeJSON(t, w, 100000)
+		case "/auth/admin/realms/dummy/clients":
+			first, _ := strconv.Atoi(req.URL.Query().Get("first"))
+			max, _ := strconv.Atoi(req.URL.Query().Get("max"))
+			writeJSONPage(t, w, DefaultPageSize+5, first, max, func(from, to int) interface{} {
+				clients := []*v1alpha1.KeycloakAPIClient{}
+				for n := from; n < to; n++ {
+					clients = append(clients, &v1alpha1.KeycloakAPIClient{ID: strconv.Itoa(n)})
+				}
+				return clients
+			})
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	users, userErr := client.CountUsers(context.TODO(), "dummy", UserQuery{Email: "example.com"})
+	clients, clientErr := client.CountClients(context.TODO(), "dummy", ClientQuery{})
+
+	// then
+	assert.NoError(t, userErr)
+	assert.NoError(t, clientErr)
+	assert.Equal(t, 100000, users)
+	assert.Equal(t, DefaultPageSize+5, clients)
+}
+
+func TestClient_FindClientByClientID(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/dummy/clients", req.URL.Path)
+		if req.URL.Query().Get("clientId") == "missing" {
+			writeJSON(t, w, []*v1alpha1.KeycloakAPIClient{})
+			return
+		}
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIClient{{ID: "uuid", ClientID: req.URL.Query().Get("clientId")}})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	found, err := client.FindClientByClientID(context.TODO(), "client-secret", "dummy")
+	missing, missingErr := client.FindClientByClientID(context.TODO(), "missing", "dummy")
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, missingErr)
+	assert.Equal(t, "uuid", found.ID)
+	assert.Nil(t, missing)
+}
+
+func TestClient_ListClientsIsCapped(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		first, _ := strconv.Atoi(req.URL.Query().Get("first"))
+		max, _ := strconv.Atoi(req.URL.Query().Get("max"))
+		writeJSONPage(t, w, MaxListSize+1, first, max, func(from, to int) interface{} {
+			clients := []*v1alpha1.KeycloakAPIClient{}
+			for n := from; n < to; n++ {
+				clients = append(clients, &v1alpha1.KeycloakAPIClient{ID: strconv.Itoa(n)})
+			}
+			return clients
+		})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	clients, err := client.ListClients(context.TODO(), "dummy")
+
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, clients)
+}
+
+func TestClientState_ReadFindsExistingClientWithoutTakingItOver(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/dummy/clients", req.URL.Path)
+		assert.Equal(t, "account", req.URL.Query().Get("clientId"))
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIClient{{ID: "uuid", ClientID: "account"}})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+	cr := &v1alpha1.KeycloakClient{
+		Spec: v1alpha1.KeycloakClientSpec{
+			Client: &v1alpha1.KeycloakAPIClient{ClientID: "account"},
+		},
+	}
+	state := NewClientState(context.TODO(), testPaginationRealm(), v1alpha1.Keycloak{})
+
+	// when
+	err := state.Read(context.TODO(), cr, client, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid", state.ExistingClient.ID)
+	assert.Nil(t, state.Client)
+	assert.Empty(t, cr.Spec.Client.ID)
+}
+
+func TestUserState_ReadFindsExistingUserWithoutTakingItOver(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/auth/admin/realms/dummy/users", req.URL.Path)
+		assert.Equal(t, "admin", req.URL.Query().Get("username"))
+		writeJSON(t, w, []*v1alpha1.KeycloakAPIUser{{ID: "uuid", UserName: "admin"}})
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+	cr := &v1alpha1.KeycloakUser{
+		Spec: v1alpha1.KeycloakUserSpec{
+			User: v1alpha1.KeycloakAPIUser{UserName: "admin"},
+		},
+	}
+	state := NewUserState(v1alpha1.Keycloak{})
+
+	// when
+	err := state.Read(context.TODO(), client, nil, cr, *testPaginationRealm())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid", state.ExistingUser.ID)
+	assert.Nil(t, state.User)
+	assert.Empty(t, cr.Spec.User.ID)
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, obj interface{}) {
+	body, err := json.Marshal(obj)
+	assert.NoError(t, err)
+	_, err = w.Write(body)
+	assert.NoError(t, err)
+}
+
+// writeJSONPage writes the slice of a collection of the given size selected by first and max
+func writeJSONPage(t *testing.T, w http.ResponseWriter, size, first, max int, page func(from, to int) interface{}) {
+	from, to := first, first+max
+	if from > size {
+		from = size
+	}
+	if to > size {
+		to = size
+	}
+	writeJSON(t, w, page(from, to))
+}
+
+func testPaginationRealm() *v1alpha1.KeycloakRealm {
+	return &v1alpha1.KeycloakRealm{
+		Spec: v1alpha1.KeycloakRealmSpec{
+			Realm: &v1alpha1.KeycloakAPIRealm{Realm: "dummy"},
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// retryPolicy bounds the attempts of a request to the admin API
+type retryPolicy struct {
+	attempts     int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	// timeout of a single attempt
+	timeout time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	attempts:     4,
+	initialDelay: 250 * time.Millisecond,
+	maxDelay:     5 * time.Second,
+	timeout:      10 * time.Second,
+}
+
+func (c *Client) retryPolicy() retryPolicy {
+	if c.retry == nil {
+		return defaultRetryPolicy
+	}
+	return *c.retry
+}
+
+// do performs a request to the admin API. Connection errors and 5xx and 429 responses are retried with
+// exponential backoff, a Retry-After header is respected up to the maximum delay. POST requests aren't retried,
+// a create that timed out may have succeeded and a retry of it would fail with a conflict. Every attempt is bounded
+// by the timeout of the retry policy, all of them by the context. The body of the response is read and closed.
+func (c *Client) do(ctx context.Context, method, url string, body []byte) (*http.Response, []byte, error) {
+	policy := c.retryPolicy()
+	delay := policy.initialDelay
+	for attempt := 1; ; attempt++ {
+		res, resBody, err := c.doAttempt(ctx, policy.timeout, method, url, body)
+		var requestErr *requestError
+		if errors.As(err, &requestErr) {
+			return nil, nil, requestErr.err
+		}
+		if err == nil && res.StatusCode == http.StatusUnauthorized && c.unauthorized != nil {
+			c.unauthorized()
+		}
+		if (err == nil && !isRetryableStatusCode(res.StatusCode)) || method == http.MethodPost || attempt >= policy.attempts || ctx.Err() != nil {
+			return res, resBody, err
+		}
+
+		wait := delay
+		if retryAfter := retryAfterDelay(res); retryAfter > wait {
+			wait = retryAfter
+		}
+		if wait > policy.maxDelay {
+			wait = policy.maxDelay
+		}
+		logrus.Debugf("retrying %s %s in %v after attempt %d failed: %v", method, url, wait, attempt, attemptFailure(res, err))
+
+		select {
+		case <-ctx.Done():
+			return res, resBody, err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// requestError marks errors of building a request, they aren't retried
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+func (c *Client) doAttempt(ctx context.Context, timeout time.Duration, method, url string, body []byte) (*http.Response, []byte, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(attemptCtx, method, url, reader)
+	if err != nil {
+		return nil, nil, &requestError{err: err}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+
+	req, span := startRequestSpan(req)
+	start := time.Now()
+	res, err := c.requester.Do(req)
+	observeAdminAPIRequest(req, res, err, start)
+	endRequestSpan(span, res, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, resBody, nil
+}
+
+// retryAfterDelay returns the delay requested by the Retry-After header in seconds, HTTP dates aren't used by
+// Keycloak
+func retryAfterDelay(res *http.Response) time.Duration {
+	if res == nil {
+		return 0
+	}
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func attemptFailure(res *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return res.Status
+}
@@ -0,0 +1,168 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_RetriesUnavailableKeycloak(t *testing.T) {
+	// given
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "dummy", "realm": "dummy"}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	realm, err := client.GetRealm(context.TODO(), "dummy")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "dummy", realm.Spec.Realm.Realm)
+	assert.Equal(t, 3, requests)
+}
+
+func TestClient_GivesUpAfterRetries(t *testing.T) {
+	// given
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(429)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	err := client.UpdateUser(context.TODO(), &v1alpha1.KeycloakAPIUser{ID: "dummy"}, "dummy")
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 3, requests)
+	assert.False(t, IsNotFound(err))
+	assert.Equal(t, 429, err.(*APIError).StatusCode)
+}
+
+func TestClient_DoesNotRetryCreates(t *testing.T) {
+	// given
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(503)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+
+	// when
+	_, err := client.CreateUser(context.TODO(), &v1alpha1.KeycloakAPIUser{UserName: "dummy"}, "dummy")
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 503, err.(*APIError).StatusCode)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	// given
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.Method {
+		case http.MethodPost:
+			w.WriteHeader(409)
+		case http.MethodPut:
+			w.WriteHeader(404)
+		default:
+			w.WriteHeader(401)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	unauthorized := 0
+	client := testRetryingClient(server)
+	client.unauthorized = func() {
+		unauthorized++
+	}
+
+	// when
+	_, createErr := client.CreateRealm(context.TODO(), getDummyRealm())
+	updateErr := client.UpdateClient(context.TODO(), &v1alpha1.KeycloakAPIClient{ID: "dummy"}, "dummy")
+	_, listErr := client.ListRealms(context.TODO())
+
+	// then
+	assert.True(t, IsConflict(createErr))
+	assert.True(t, IsNotFound(updateErr))
+	assert.True(t, IsUnauthorized(listErr))
+	assert.False(t, IsConflict(listErr))
+	assert.Equal(t, 1, unauthorized)
+	assert.Equal(t, 3, requests)
+}
+
+func TestClient_StopsRetryingWhenCancelled(t *testing.T) {
+	// given
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(500)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := testRetryingClient(server)
+	client.retry.initialDelay = time.Hour
+	client.retry.maxDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// when
+	err := client.DeleteRealm(ctx, "dummy")
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_CreateFailsOnMarshallingError(t *testing.T) {
+	// given
+	client := &Client{URL: "http://localhost"}
+
+	// when
+	uid, err := client.create(context.TODO(), map[string]interface{}{"invalid": make(chan int)}, "realms", "realm")
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, "", uid)
+}
+
+func testRetryingClient(server *httptest.Server) *Client {
+	return &Client{
+		requester: server.Client(),
+		URL:       server.URL,
+		token:     "dummy",
+		retry: &retryPolicy{
+			attempts:     3,
+			initialDelay: time.Millisecond,
+			maxDelay:     10 * time.Millisecond,
+			timeout:      time.Second,
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,6 +26,9 @@ type ClientState struct {
 	DeprecatedClientSecret  *v1.Secret // keycloak-client-secret-<clientID>
 	Keycloak                kc.Keycloak
 	ServiceAccountUserState *UserState
+	// Client of the realm with the clientId of a CR without an ID. It isn't managed by the CR until spec.client.id
+	// is set to its ID.
+	ExistingClient *kc.KeycloakAPIClient
 }
 
 func NewClientState(context context.Context, realm *kc.KeycloakRealm, keycloak kc.Keycloak) *ClientState {
@@ -36,11 +40,20 @@ func NewClientState(context context.Context, realm *kc.KeycloakRealm, keycloak k
 }
 
 func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface, controllerClient client.Client) error {
+	ctx, span := StartSpan(context, "ClientState.Read",
+		attribute.String("keycloak.realm", i.Realm.Spec.Realm.Realm),
+		attribute.String("keycloak.client", cr.Spec.Client.ClientID))
+	err := i.read(ctx, cr, realmClient, controllerClient)
+	EndSpan(span, err)
+	return err
+}
+
+func (i *ClientState) read(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface, controllerClient client.Client) error {
 	if cr.Spec.Client.ID == "" {
-		return nil
+		return i.readExistingClient(context, cr, realmClient)
 	}
 
-	client, err := realmClient.GetClient(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+	client, err := realmClient.GetClient(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 
 	if err != nil {
 		return err
@@ -51,7 +64,7 @@ func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realm
 	// CR could have updated with new secret, so set saved secret to Spec only when empty
 	// Otherwise let reconcile loop to update secret with desired secret in CR
 	if cr.Spec.Client.Secret == "" {
-		clientSecret, err := realmClient.GetClientSecret(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+		clientSecret, err := realmClient.GetClientSecret(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 		if err != nil {
 			return err
 		}
@@ -76,34 +89,34 @@ func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realm
 		return nil
 	}
 
-	i.Roles, err = realmClient.ListClientRoles(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+	i.Roles, err = realmClient.ListClientRoles(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	i.ScopeMappings, err = realmClient.ListScopeMappings(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+	i.ScopeMappings, err = realmClient.ListScopeMappings(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	err = i.readClientScopes(cr, realmClient)
+	err = i.readClientScopes(context, cr, realmClient)
 	if err != nil {
 		return err
 	}
 
-	err = i.readDefaultRoles(cr, realmClient)
+	err = i.readDefaultRoles(context, cr, realmClient)
 	if err != nil {
 		return err
 	}
 
 	if i.Client.ServiceAccountsEnabled {
-		user, err := realmClient.GetServiceAccountUser(i.Realm.Spec.Realm.Realm, cr.Spec.Client.ID)
+		user, err := realmClient.GetServiceAccountUser(context, i.Realm.Spec.Realm.Realm, cr.Spec.Client.ID)
 		if err != nil {
 			return err
 		}
 
 		i.ServiceAccountUserState = NewUserState(i.Keycloak)
-		err = i.ServiceAccountUserState.ReadWithExistingAPIUser(realmClient, controllerClient, user, *i.Realm)
+		err = i.ServiceAccountUserState.ReadWithExistingAPIUser(context, realmClient, controllerClient, user, *i.Realm)
 		if err != nil {
 			return err
 		}
@@ -112,21 +125,31 @@ func (i *ClientState) Read(context context.Context, cr *kc.KeycloakClient, realm
 	return nil
 }
 
-func (i *ClientState) readClientScopes(cr *kc.KeycloakClient, realmClient KeycloakInterface) (err error) {
+// readExistingClient looks up a client the CR would conflict with on creation, e.g. a built-in client like account
+func (i *ClientState) readExistingClient(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface) (err error) {
+	if cr.DeletionTimestamp != nil || cr.Spec.Client.ClientID == "" {
+		return nil
+	}
+
+	i.ExistingClient, err = realmClient.FindClientByClientID(context, cr.Spec.Client.ClientID, i.Realm.Spec.Realm.Realm)
+	return err
+}
+
+func (i *ClientState) readClientScopes(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface) (err error) {
 	// It is not strictly a property of the client but rather of the realm.
 	// However could not figure out a better way to convey it to populate default and optional
 	// client scopes which requires client scope IDs.
-	i.AvailableClientScopes, err = realmClient.ListAvailableClientScopes(i.Realm.Spec.Realm.Realm)
+	i.AvailableClientScopes, err = realmClient.ListAvailableClientScopes(context, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	i.DefaultClientScopes, err = realmClient.ListDefaultClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+	i.DefaultClientScopes, err = realmClient.ListDefaultClientScopes(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	i.OptionalClientScopes, err = realmClient.ListOptionalClientScopes(cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
+	i.OptionalClientScopes, err = realmClient.ListOptionalClientScopes(context, cr.Spec.Client.ID, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
@@ -149,15 +172,15 @@ func (i *ClientState) readClientSecret(context context.Context, cr *kc.KeycloakC
 	return nil
 }
 
-func (i *ClientState) readDefaultRoles(cr *kc.KeycloakClient, realmClient KeycloakInterface) error {
+func (i *ClientState) readDefaultRoles(context context.Context, cr *kc.KeycloakClient, realmClient KeycloakInterface) error {
 	// we can't use state.Realm as it is the CR, not actual Realm state, and is missing defaultRole
-	realm, err := realmClient.GetRealm(i.Realm.Spec.Realm.Realm)
+	realm, err := realmClient.GetRealm(context, i.Realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
 	i.DefaultRoleID = realm.Spec.Realm.DefaultRole.ID
-	i.DefaultRoles, err = realmClient.ListRealmRoleClientRoleComposites(i.Realm.Spec.Realm.Realm, i.DefaultRoleID, cr.Spec.Client.ID)
+	i.DefaultRoles, err = realmClient.ListRealmRoleClientRoleComposites(context, i.Realm.Spec.Realm.Realm, i.DefaultRoleID, cr.Spec.Client.ID)
 	return err
 }
 
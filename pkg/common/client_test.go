@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"encoding/pem"
 	"fmt"
 	"net/http"
@@ -20,7 +21,7 @@ const (
 	UserCreatePath         = "/auth/admin/realms/%s/users"
 	UserDeletePath         = "/auth/admin/realms/%s/users/%s"
 	UserGetPath            = "/auth/admin/realms/%s/users/%s"
-	UserFindByUsernamePath = "/auth/admin/realms/%s/users?username=%s&max=-1"
+	UserFindByUsernamePath = "/auth/admin/realms/%s/users?exact=true&first=0&max=100&username=%s"
 	TokenPath              = "/auth/realms/master/protocol/openid-connect/token" // nolint
 )
 
@@ -87,7 +88,7 @@ func TestClient_CreateRealm(t *testing.T) {
 	realm := getDummyRealm()
 
 	// when
-	_, err := client.CreateRealm(realm)
+	_, err := client.CreateRealm(context.TODO(), realm)
 
 	// then
 	// no error expected
@@ -113,7 +114,7 @@ func TestClient_DeleteRealmRealm(t *testing.T) {
 	}
 
 	// when
-	err := client.DeleteRealm(realm.Spec.Realm.Realm)
+	err := client.DeleteRealm(context.TODO(), realm.Spec.Realm.Realm)
 
 	// then
 	// correct path expected on httptest server
@@ -142,7 +143,7 @@ func TestClient_CreateUser(t *testing.T) {
 	}
 
 	// when
-	uid, err := client.CreateUser(user, realm.Spec.Realm.Realm)
+	uid, err := client.CreateUser(context.TODO(), user, realm.Spec.Realm.Realm)
 
 	// then
 	// correct path expected on httptest server
@@ -169,7 +170,7 @@ func TestClient_DeleteUser(t *testing.T) {
 	}
 
 	// when
-	err := client.DeleteUser(user.ID, realm.Spec.Realm.Realm)
+	err := client.DeleteUser(context.TODO(), user.ID, realm.Spec.Realm.Realm)
 
 	// then
 	// correct path expected on httptest server
@@ -203,7 +204,7 @@ func TestClient_FindUserByUsername(t *testing.T) {
 	}
 
 	// when
-	userFound, err := client.FindUserByUsername(user.UserName, realm.Spec.Realm.Realm)
+	userFound, err := client.FindUserByUsername(context.TODO(), user.UserName, realm.Spec.Realm.Realm)
 
 	// then
 	// correct path expected on httptest server
@@ -239,7 +240,7 @@ func TestClient_GetRealm(t *testing.T) {
 	}
 
 	// when
-	newRealm, err := client.GetRealm(realm.Spec.Realm.Realm)
+	newRealm, err := client.GetRealm(context.TODO(), realm.Spec.Realm.Realm)
 
 	// then
 	// correct path expected on httptest server
@@ -277,7 +278,7 @@ func TestClient_ListRealms(t *testing.T) {
 	}
 
 	// when
-	realms, err := client.ListRealms()
+	realms, err := client.ListRealms(context.TODO())
 
 	// then
 	// correct path expected on httptest server
@@ -350,6 +351,33 @@ func TestClient_useKeycloakServerCertificate(t *testing.T) {
 	assert.Equal(t, resp.StatusCode, 200)
 }
 
+func TestClient_verifyServerCertificateAgainstServerName(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ts := httptest.NewTLSServer(handler)
+	defer ts.Close()
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	// the certificate of the test server is issued for example.com
+	requester, err := serverNameRequester(pemCert, nil, "example.com")
+	assert.NoError(t, err)
+	request, err := http.NewRequest("GET", ts.URL, nil)
+	assert.NoError(t, err)
+	resp, err := requester.Do(request)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+
+	requester, err = serverNameRequester(pemCert, nil, "keycloak.keycloak.svc")
+	assert.NoError(t, err)
+	request, err = http.NewRequest("GET", ts.URL, nil)
+	assert.NoError(t, err)
+	_, err = requester.Do(request)
+	assert.Error(t, err)
+}
+
 func TestClient_GetFullKeycloakPath(t *testing.T) {
 	serverURL := "https://foo.bar:8080"
 	customContext := "/"
@@ -3,9 +3,14 @@ package common
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/model"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -51,6 +56,8 @@ type ActionRunner interface {
 	AddDefaultRoles(obj *[]v1alpha1.RoleRepresentation, defaultRealmRoleID, realm string) error
 	DeleteDefaultRoles(obj *[]v1alpha1.RoleRepresentation, defaultRealmRoleID, realm string) error
 	ApplyOverrides(obj *v1alpha1.KeycloakRealm) error
+	IssueVaultDatabaseCredentials(obj *v1alpha1.Keycloak, databaseSecret *corev1.Secret, caSecret *corev1.Secret) error
+	RevokeVaultLease(obj *v1alpha1.Keycloak, leaseID string, caSecret *corev1.Secret) error
 	Ping() error
 }
 
@@ -87,9 +94,15 @@ func NewClusterAndKeycloakActionRunner(context context.Context, client client.Cl
 	}
 }
 
-func (i *ClusterActionRunner) RunAll(desiredState DesiredClusterState) error {
+func (i *ClusterActionRunner) RunAll(desiredState DesiredClusterState) (err error) {
+	observeDesiredState(i.cr, desiredState)
+	ctx, span := StartSpan(i.context, "ClusterActionRunner.RunAll", attribute.Int("actions", len(desiredState)))
+	defer func() {
+		EndSpan(span, err)
+	}()
+
 	for index, action := range desiredState {
-		msg, err := action.Run(i)
+		msg, err := i.run(ctx, action)
 		if err != nil {
 			log.Info(fmt.Sprintf("(%5d) %10s %s : %s", index, "FAILED", msg, err))
 			return err
@@ -100,6 +113,17 @@ func (i *ClusterActionRunner) RunAll(desiredState DesiredClusterState) error {
 	return nil
 }
 
+// run runs an action in a span of its own, the requests made by the action are its children
+func (i *ClusterActionRunner) run(ctx context.Context, action ClusterAction) (string, error) {
+	actionCtx, span := StartSpan(ctx, reflect.Indirect(reflect.ValueOf(action)).Type().Name()+".Run")
+	runner := *i
+	runner.context = actionCtx
+	msg, err := action.Run(&runner)
+	span.SetAttributes(attribute.String("action.message", msg))
+	EndSpan(span, err)
+	return msg, err
+}
+
 func (i *ClusterActionRunner) Create(obj runtime.Object) error {
 	err := controllerutil.SetControllerReference(i.cr.(v1.Object), obj.(v1.Object), i.scheme)
 	if err != nil {
@@ -123,8 +147,9 @@ func (i *ClusterActionRunner) Update(obj runtime.Object) error {
 	return i.client.Update(i.context, obj)
 }
 
+// Delete removes the object along with its dependents, the pods of Jobs aren't left behind
 func (i *ClusterActionRunner) Delete(obj runtime.Object) error {
-	return i.client.Delete(i.context, obj)
+	return i.client.Delete(i.context, obj, client.PropagationPolicy(v1.DeletePropagationBackground))
 }
 
 // Create a new realm using the keycloak api
@@ -133,7 +158,12 @@ func (i *ClusterActionRunner) CreateRealm(obj *v1alpha1.KeycloakRealm) error {
 		return errors.Errorf("cannot perform realm create when client is nil")
 	}
 
-	_, err := i.keycloakClient.CreateRealm(obj)
+	_, err := i.keycloakClient.CreateRealm(i.context, obj)
+	if IsConflict(err) {
+		// A retried request may find the realm created by the first attempt
+		log.Info(fmt.Sprintf("realm %v already exists", obj.Spec.Realm.Realm))
+		return nil
+	}
 	return err
 }
 
@@ -142,7 +172,7 @@ func (i *ClusterActionRunner) CreateClient(obj *v1alpha1.KeycloakClient, realm s
 		return errors.Errorf("cannot perform client create when client is nil")
 	}
 
-	uid, err := i.keycloakClient.CreateClient(obj.Spec.Client, realm)
+	uid, err := i.keycloakClient.CreateClient(i.context, obj.Spec.Client, realm)
 
 	if err != nil {
 		return err
@@ -157,14 +187,18 @@ func (i *ClusterActionRunner) UpdateClient(obj *v1alpha1.KeycloakClient, realm s
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client update when client is nil")
 	}
-	return i.keycloakClient.UpdateClient(obj.Spec.Client, realm)
+	return i.keycloakClient.UpdateClient(i.context, obj.Spec.Client, realm)
 }
 
 func (i *ClusterActionRunner) CreateClientRole(obj *v1alpha1.KeycloakClient, role *v1alpha1.RoleRepresentation, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client role create when client is nil")
 	}
-	_, err := i.keycloakClient.CreateClientRole(obj.Spec.Client.ID, role, realm)
+	_, err := i.keycloakClient.CreateClientRole(i.context, obj.Spec.Client.ID, role, realm)
+	if IsConflict(err) {
+		log.Info(fmt.Sprintf("role %v of client %v already exists", role.Name, obj.Spec.Client.ClientID))
+		return nil
+	}
 	return err
 }
 
@@ -172,70 +206,70 @@ func (i *ClusterActionRunner) UpdateClientRole(obj *v1alpha1.KeycloakClient, rol
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client role update when client is nil")
 	}
-	return i.keycloakClient.UpdateClientRole(obj.Spec.Client.ID, role, oldRole, realm)
+	return i.keycloakClient.UpdateClientRole(i.context, obj.Spec.Client.ID, role, oldRole, realm)
 }
 
 func (i *ClusterActionRunner) DeleteClientRole(obj *v1alpha1.KeycloakClient, role, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client role delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClientRole(obj.Spec.Client.ID, role, realm)
+	return i.keycloakClient.DeleteClientRole(i.context, obj.Spec.Client.ID, role, realm)
 }
 
 func (i *ClusterActionRunner) CreateClientRealmScopeMappings(keycloakClient *v1alpha1.KeycloakClient, mappings *[]v1alpha1.RoleRepresentation, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client realm scope create when client is nil")
 	}
-	return i.keycloakClient.CreateClientRealmScopeMappings(keycloakClient.Spec.Client, mappings, realm)
+	return i.keycloakClient.CreateClientRealmScopeMappings(i.context, keycloakClient.Spec.Client, mappings, realm)
 }
 
 func (i *ClusterActionRunner) DeleteClientRealmScopeMappings(keycloakClient *v1alpha1.KeycloakClient, mappings *[]v1alpha1.RoleRepresentation, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client realm scope delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClientRealmScopeMappings(keycloakClient.Spec.Client, mappings, realm)
+	return i.keycloakClient.DeleteClientRealmScopeMappings(i.context, keycloakClient.Spec.Client, mappings, realm)
 }
 
 func (i *ClusterActionRunner) CreateClientClientScopeMappings(keycloakClient *v1alpha1.KeycloakClient, mappings *v1alpha1.ClientMappingsRepresentation, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client client scope create when client is nil")
 	}
-	return i.keycloakClient.CreateClientClientScopeMappings(keycloakClient.Spec.Client, mappings, realm)
+	return i.keycloakClient.CreateClientClientScopeMappings(i.context, keycloakClient.Spec.Client, mappings, realm)
 }
 
 func (i *ClusterActionRunner) DeleteClientDefaultClientScope(keycloakClient *v1alpha1.KeycloakClient, clientScope *v1alpha1.KeycloakClientScope, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client default client scope delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClientDefaultClientScope(keycloakClient.Spec.Client, clientScope, realm)
+	return i.keycloakClient.DeleteClientDefaultClientScope(i.context, keycloakClient.Spec.Client, clientScope, realm)
 }
 
 func (i *ClusterActionRunner) UpdateClientDefaultClientScope(keycloakClient *v1alpha1.KeycloakClient, clientScope *v1alpha1.KeycloakClientScope, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client default client scope create when client is nil")
 	}
-	return i.keycloakClient.UpdateClientDefaultClientScope(keycloakClient.Spec.Client, clientScope, realm)
+	return i.keycloakClient.UpdateClientDefaultClientScope(i.context, keycloakClient.Spec.Client, clientScope, realm)
 }
 
 func (i *ClusterActionRunner) DeleteClientOptionalClientScope(keycloakClient *v1alpha1.KeycloakClient, clientScope *v1alpha1.KeycloakClientScope, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client optional client scope delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClientOptionalClientScope(keycloakClient.Spec.Client, clientScope, realm)
+	return i.keycloakClient.DeleteClientOptionalClientScope(i.context, keycloakClient.Spec.Client, clientScope, realm)
 }
 
 func (i *ClusterActionRunner) UpdateClientOptionalClientScope(keycloakClient *v1alpha1.KeycloakClient, clientScope *v1alpha1.KeycloakClientScope, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client optional client scope create when client is nil")
 	}
-	return i.keycloakClient.UpdateClientOptionalClientScope(keycloakClient.Spec.Client, clientScope, realm)
+	return i.keycloakClient.UpdateClientOptionalClientScope(i.context, keycloakClient.Spec.Client, clientScope, realm)
 }
 
 func (i *ClusterActionRunner) DeleteClientClientScopeMappings(keycloakClient *v1alpha1.KeycloakClient, mappings *v1alpha1.ClientMappingsRepresentation, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client client scope delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClientClientScopeMappings(keycloakClient.Spec.Client, mappings, realm)
+	return i.keycloakClient.DeleteClientClientScopeMappings(i.context, keycloakClient.Spec.Client, mappings, realm)
 }
 
 // Delete a realm using the keycloak api
@@ -243,14 +277,14 @@ func (i *ClusterActionRunner) DeleteRealm(obj *v1alpha1.KeycloakRealm) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform realm delete when client is nil")
 	}
-	return i.keycloakClient.DeleteRealm(obj.Spec.Realm.Realm)
+	return i.keycloakClient.DeleteRealm(i.context, obj.Spec.Realm.Realm)
 }
 
 func (i *ClusterActionRunner) DeleteClient(obj *v1alpha1.KeycloakClient, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform client delete when client is nil")
 	}
-	return i.keycloakClient.DeleteClient(obj.Spec.Client.ID, realm)
+	return i.keycloakClient.DeleteClient(i.context, obj.Spec.Client.ID, realm)
 }
 
 func (i *ClusterActionRunner) CreateUser(obj *v1alpha1.KeycloakUser, realm string) error {
@@ -259,7 +293,7 @@ func (i *ClusterActionRunner) CreateUser(obj *v1alpha1.KeycloakUser, realm strin
 	}
 
 	// Create the user
-	uid, err := i.keycloakClient.CreateUser(&obj.Spec.User, realm)
+	uid, err := i.keycloakClient.CreateUser(i.context, &obj.Spec.User, realm)
 	if err != nil {
 		return err
 	}
@@ -274,7 +308,7 @@ func (i *ClusterActionRunner) UpdateUser(obj *v1alpha1.KeycloakUser, realm strin
 		return errors.Errorf("cannot perform user update when client is nil")
 	}
 
-	err := i.keycloakClient.UpdateUser(&obj.Spec.User, realm)
+	err := i.keycloakClient.UpdateUser(i.context, &obj.Spec.User, realm)
 	if err != nil {
 		return err
 	}
@@ -286,7 +320,7 @@ func (i *ClusterActionRunner) DeleteUser(id, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform user delete when client is nil")
 	}
-	return i.keycloakClient.DeleteUser(id, realm)
+	return i.keycloakClient.DeleteUser(i.context, id, realm)
 }
 
 // Check if Keycloak is available
@@ -294,7 +328,7 @@ func (i *ClusterActionRunner) Ping() error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform keycloak ping when client is nil")
 	}
-	return i.keycloakClient.Ping()
+	return i.keycloakClient.Ping(i.context)
 }
 
 func (i *ClusterActionRunner) AssignRealmRole(obj *v1alpha1.KeycloakUserRole, userID, realm string) error {
@@ -302,7 +336,7 @@ func (i *ClusterActionRunner) AssignRealmRole(obj *v1alpha1.KeycloakUserRole, us
 		return errors.Errorf("cannot perform role assign when client is nil")
 	}
 
-	_, err := i.keycloakClient.CreateUserRealmRole(obj, realm, userID)
+	_, err := i.keycloakClient.CreateUserRealmRole(i.context, obj, realm, userID)
 	return err
 }
 
@@ -310,7 +344,7 @@ func (i *ClusterActionRunner) RemoveRealmRole(obj *v1alpha1.KeycloakUserRole, us
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform role remove when client is nil")
 	}
-	return i.keycloakClient.DeleteUserRealmRole(obj, realm, userID)
+	return i.keycloakClient.DeleteUserRealmRole(i.context, obj, realm, userID)
 }
 
 func (i *ClusterActionRunner) AssignClientRole(obj *v1alpha1.KeycloakUserRole, clientID, userID, realm string) error {
@@ -318,7 +352,7 @@ func (i *ClusterActionRunner) AssignClientRole(obj *v1alpha1.KeycloakUserRole, c
 		return errors.Errorf("cannot perform role assign when client is nil")
 	}
 
-	_, err := i.keycloakClient.CreateUserClientRole(obj, realm, clientID, userID)
+	_, err := i.keycloakClient.CreateUserClientRole(i.context, obj, realm, clientID, userID)
 	return err
 }
 
@@ -326,21 +360,21 @@ func (i *ClusterActionRunner) RemoveClientRole(obj *v1alpha1.KeycloakUserRole, c
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform role remove when client is nil")
 	}
-	return i.keycloakClient.DeleteUserClientRole(obj, realm, clientID, userID)
+	return i.keycloakClient.DeleteUserClientRole(i.context, obj, realm, clientID, userID)
 }
 
 func (i *ClusterActionRunner) AddDefaultRoles(obj *[]v1alpha1.RoleRepresentation, defaultRealmRoleID, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform default role add when client is nil")
 	}
-	return i.keycloakClient.AddRealmRoleComposites(realm, defaultRealmRoleID, obj)
+	return i.keycloakClient.AddRealmRoleComposites(i.context, realm, defaultRealmRoleID, obj)
 }
 
 func (i *ClusterActionRunner) DeleteDefaultRoles(obj *[]v1alpha1.RoleRepresentation, defaultRealmRoleID, realm string) error {
 	if i.keycloakClient == nil {
 		return errors.Errorf("cannot perform default role delete when client is nil")
 	}
-	return i.keycloakClient.DeleteRealmRoleComposites(realm, defaultRealmRoleID, obj)
+	return i.keycloakClient.DeleteRealmRoleComposites(i.context, realm, defaultRealmRoleID, obj)
 }
 
 // Delete a realm using the keycloak api
@@ -359,9 +393,66 @@ func (i *ClusterActionRunner) ApplyOverrides(obj *v1alpha1.KeycloakRealm) error
 	return nil
 }
 
+// IssueVaultDatabaseCredentials issues new credentials from Vault and writes them into the database secret. The lease
+// of the credentials they replace is kept in the status, to be revoked once the pods were rolled.
+func (i *ClusterActionRunner) IssueVaultDatabaseCredentials(obj *v1alpha1.Keycloak, databaseSecret *corev1.Secret, caSecret *corev1.Secret) error {
+	vault, err := LoginVault(obj, caSecret)
+	if err != nil {
+		return err
+	}
+	credentials, err := vault.ReadDatabaseCredentials(vaultMount(obj.Spec.DatabaseCredentials.Vault.Mount, model.VaultDefaultDatabaseMount), obj.Spec.DatabaseCredentials.Vault.Role)
+	if err != nil {
+		return err
+	}
+
+	databaseSecret.Data[model.DatabaseSecretUsernameProperty] = []byte(credentials.Username)
+	databaseSecret.Data[model.DatabaseSecretPasswordProperty] = []byte(credentials.Password)
+	err = i.Update(databaseSecret)
+	if err != nil {
+		// No pod ever used the credentials
+		if revokeErr := vault.RevokeLease(credentials.LeaseID); revokeErr != nil {
+			log.Error(revokeErr, "unable to revoke unused database credentials")
+		}
+		return err
+	}
+	log.Info(fmt.Sprintf("Issued database credentials %v from vault", credentials.Username))
+
+	now := time.Now()
+	if obj.Status.DatabaseCredentials == nil {
+		obj.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{}
+	}
+	status := obj.Status.DatabaseCredentials
+	status.PreviousLeaseID = status.LeaseID
+	status.LastRotationTime = &[]v1.Time{v1.NewTime(now)}[0]
+	status.LastRotationRequest = obj.Annotations[model.DatabaseCredentialsRotationAnnotation]
+	status.Username = credentials.Username
+	status.LeaseID = credentials.LeaseID
+	status.LeaseExpirationTime = nil
+	if credentials.LeaseDuration > 0 {
+		status.LeaseExpirationTime = &[]v1.Time{v1.NewTime(now.Add(credentials.LeaseDuration))}[0]
+	}
+	return nil
+}
+
+// RevokeVaultLease revokes the lease of credentials no pod uses anymore
+func (i *ClusterActionRunner) RevokeVaultLease(obj *v1alpha1.Keycloak, leaseID string, caSecret *corev1.Secret) error {
+	vault, err := LoginVault(obj, caSecret)
+	if err != nil {
+		return err
+	}
+	err = vault.RevokeLease(leaseID)
+	if err != nil {
+		return err
+	}
+	if obj.Status.DatabaseCredentials != nil && obj.Status.DatabaseCredentials.PreviousLeaseID == leaseID {
+		obj.Status.DatabaseCredentials.PreviousLeaseID = ""
+	}
+	return nil
+}
+
 func (i *ClusterActionRunner) configureBrowserRedirector(provider, flow string, obj *v1alpha1.KeycloakRealm) error {
 	realmName := obj.Spec.Realm.Realm
-	authenticationExecutionInfo, err := i.keycloakClient.ListAuthenticationExecutionsForFlow(flow, realmName)
+	authenticationExecutionInfo, err := i.keycloakClient.ListAuthenticationExecutionsForFlow(i.context, flow, realmName)
 	if err != nil {
 		return err
 	}
@@ -380,7 +471,7 @@ func (i *ClusterActionRunner) configureBrowserRedirector(provider, flow string,
 
 	var authenticatorConfig *v1alpha1.AuthenticatorConfig
 	if authenticationConfigID != "" {
-		authenticatorConfig, err = i.keycloakClient.GetAuthenticatorConfig(authenticationConfigID, realmName)
+		authenticatorConfig, err = i.keycloakClient.GetAuthenticatorConfig(i.context, authenticationConfigID, realmName)
 		if err != nil {
 			return err
 		}
@@ -392,7 +483,7 @@ func (i *ClusterActionRunner) configureBrowserRedirector(provider, flow string,
 			Config: map[string]string{"defaultProvider": provider},
 		}
 
-		if _, err := i.keycloakClient.CreateAuthenticatorConfig(config, realmName, redirectorExecutionID); err != nil {
+		if _, err := i.keycloakClient.CreateAuthenticatorConfig(i.context, config, realmName, redirectorExecutionID); err != nil {
 			return err
 		}
 		return nil
@@ -553,6 +644,20 @@ type PingAction struct {
 	Msg string
 }
 
+type IssueVaultDatabaseCredentialsAction struct {
+	Ref            *v1alpha1.Keycloak
+	DatabaseSecret *corev1.Secret
+	CASecret       *corev1.Secret
+	Msg            string
+}
+
+type RevokeVaultLeaseAction struct {
+	Ref      *v1alpha1.Keycloak
+	LeaseID  string
+	CASecret *corev1.Secret
+	Msg      string
+}
+
 type CreateUserAction struct {
 	Ref   *v1alpha1.KeycloakUser
 	Realm string
@@ -689,6 +794,14 @@ func (i PingAction) Run(runner ActionRunner) (string, error) {
 	return i.Msg, runner.Ping()
 }
 
+func (i IssueVaultDatabaseCredentialsAction) Run(runner ActionRunner) (string, error) {
+	return i.Msg, runner.IssueVaultDatabaseCredentials(i.Ref, i.DatabaseSecret, i.CASecret)
+}
+
+func (i RevokeVaultLeaseAction) Run(runner ActionRunner) (string, error) {
+	return i.Msg, runner.RevokeVaultLease(i.Ref, i.LeaseID, i.CASecret)
+}
+
 func (i ConfigureRealmAction) Run(runner ActionRunner) (string, error) {
 	return i.Msg, runner.ApplyOverrides(i.Ref)
 }
@@ -15,10 +15,13 @@ import (
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/model"
 	v12 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -68,6 +71,31 @@ type ClusterState struct {
 	PodDisruptionBudget             *v1beta12.PodDisruptionBudget
 	KeycloakProbes                  *v1.ConfigMap
 	KeycloakBackup                  *v1alpha1.KeycloakBackup
+	KeycloakThemeConfigMaps         map[string]*v1.ConfigMap
+	KeycloakCertificate             *unstructured.Unstructured
+	KeycloakServingCertSecret       *v1.Secret
+	KeycloakPods                    *v1.PodList
+	KeycloakMigrationRestoreJob     *batchv1.Job
+	KeycloakBlueGreenDeployment     *v12.StatefulSet
+	KeycloakBlueGreenService        *v1.Service
+	KeycloakBlueGreenPods           *v1.PodList
+	KeycloakDatabaseCloneJob        *batchv1.Job
+	KeycloakDatabaseDropJobs        *batchv1.JobList
+	PostgresqlCluster               *unstructured.Unstructured
+	PostgresqlClusterSecret         *v1.Secret
+	PostgresqlUpgradeVolumeClaim    *v1.PersistentVolumeClaim
+	DatabaseUpgradeBackup           *v1alpha1.KeycloakBackup
+	DatabaseUpgradeRestoreJob       *batchv1.Job
+	DatabaseCredentialsRotationJob  *batchv1.Job
+	VaultCASecret                   *v1.Secret
+	KeycloakClusteringConfigMap     *v1.ConfigMap
+	KeycloakJGroupsKeystoreSecret   *v1.Secret
+	KeycloakServiceAccount          *v1.ServiceAccount
+	KeycloakDiscoveryRole           *rbacv1.Role
+	KeycloakDiscoveryRoleBinding    *rbacv1.RoleBinding
+	KeycloakCrossSiteSecret         *v1.Secret
+	KeycloakCrossSiteKubeconfigs    map[string]*v1.Secret
+	KeycloakCrossSiteCaches         map[string]*unstructured.Unstructured
 }
 
 func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
@@ -100,6 +128,16 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	err = i.readDatabaseCredentialsRotationCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readVaultCASecretCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
 	err = i.readDatabaseSSLSecretCurrentState(context, cr, controllerClient)
 	if err != nil {
 		return err
@@ -125,6 +163,13 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	if !cr.Spec.ExternalDatabase.Enabled && cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+		err = i.readPostgresqlClusterCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = i.readPostgresqlServiceEndpointsCurrentState(context, cr, controllerClient)
 	if err != nil {
 		return err
@@ -150,6 +195,42 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	err = i.readKeycloakThemeConfigMapsCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	err = i.readKeycloakClusteringCurrentState(context, cr, controllerClient)
+	if err != nil {
+		return err
+	}
+
+	if model.IsKubePingDiscovery(cr) {
+		err = i.readKeycloakDiscoveryRBACCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.CrossSite.Enabled {
+		err = i.readKeycloakCrossSiteCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Spec.CertManager.Enabled {
+		err = i.readKeycloakCertificateCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+
+		err = i.readKeycloakServingCertSecretCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
 	if podDisruptionBudgetKeyExists && podDisruptionBudgetKindExists {
 		err = i.readPodDisruptionCurrentState(context, cr, controllerClient)
 		if err != nil {
@@ -181,6 +262,39 @@ func (i *ClusterState) Read(context context.Context, cr *kc.Keycloak, controller
 		return err
 	}
 
+	if cr.Status.Migration != nil || len(cr.Spec.ExtensionSources) > 0 {
+		err = i.readKeycloakPodsCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Status.Migration != nil {
+		err = i.readKeycloakMigrationRestoreCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+
+		err = i.readKeycloakBlueGreenCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cr.Status.DatabaseUpgrade != nil {
+		err = i.readDatabaseUpgradeCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cr.Status.ClonedDatabases) > 0 {
+		err = i.readKeycloakDatabaseDropJobsCurrentState(context, cr, controllerClient)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Read other things
 	return nil
 }
@@ -206,8 +320,9 @@ func (i *ClusterState) readKeycloakAdminSecretCurrentState(context context.Conte
 }
 
 func (i *ClusterState) readPostgresqlPersistentVolumeClaimCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
-	postgresqlPersistentVolumeClaim := model.PostgresqlPersistentVolumeClaim(cr)
-	postgresqlPersistentVolumeClaimSelector := model.PostgresqlPersistentVolumeClaimSelector(cr)
+	version := model.GetDatabaseVersion(i.DatabaseSecret)
+	postgresqlPersistentVolumeClaim := model.PostgresqlPersistentVolumeClaim(cr, version)
+	postgresqlPersistentVolumeClaimSelector := model.PostgresqlPersistentVolumeClaimSelector(cr, version)
 
 	err := controllerClient.Get(context, postgresqlPersistentVolumeClaimSelector, postgresqlPersistentVolumeClaim)
 	if err != nil {
@@ -260,7 +375,7 @@ func (i *ClusterState) readPostgresqlDeploymentCurrentState(context context.Cont
 	stateManager := GetStateManager()
 	isOpenshift, _ := stateManager.GetState(OpenShiftAPIServerKind).(bool)
 
-	postgresqlDeployment := model.PostgresqlDeployment(cr, isOpenshift)
+	postgresqlDeployment := model.PostgresqlDeployment(cr, model.GetDatabaseVersion(i.DatabaseSecret), isOpenshift)
 	postgresqlDeploymentSelector := model.PostgresqlDeploymentSelector(cr)
 
 	err := controllerClient.Get(context, postgresqlDeploymentSelector, postgresqlDeployment)
@@ -275,6 +390,43 @@ func (i *ClusterState) readPostgresqlDeploymentCurrentState(context context.Cont
 	return nil
 }
 
+func (i *ClusterState) readPostgresqlClusterCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	provider, err := PostgresqlClusterProvider(cr)
+	if err != nil {
+		return err
+	}
+
+	postgresqlCluster := model.PostgresqlCluster(cr, provider)
+	postgresqlClusterSelector := model.PostgresqlClusterSelector(cr)
+
+	err = controllerClient.Get(context, postgresqlClusterSelector, postgresqlCluster)
+	if err != nil {
+		// If the resource type doesn't exist on the cluster or does exist but is not found
+		if meta.IsNoMatchError(err) || apiErrors.IsNotFound(err) {
+			i.PostgresqlCluster = nil
+		} else {
+			return err
+		}
+	} else {
+		i.PostgresqlCluster = postgresqlCluster.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.PostgresqlCluster.GetKind(), i.PostgresqlCluster.GetName())
+	}
+
+	// The credentials are generated by the Postgres operator once the cluster has been created
+	postgresqlClusterSecret := &v1.Secret{}
+	postgresqlClusterSecretSelector := model.PostgresqlClusterSecretSelector(cr, provider)
+
+	err = controllerClient.Get(context, postgresqlClusterSecretSelector, postgresqlClusterSecret)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.PostgresqlClusterSecret = postgresqlClusterSecret.DeepCopy()
+	}
+	return nil
+}
+
 func (i *ClusterState) readKeycloakServiceCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
 	keycloakService := model.KeycloakService(cr)
 	keycloakServiceSelector := model.KeycloakServiceSelector(cr)
@@ -320,7 +472,7 @@ func (i *ClusterState) readKeycloakServiceMonitorCurrentState(context context.Co
 
 // Keycloak Prometheus Rule. Resource type provided by Prometheus operator
 func (i *ClusterState) readKeycloakPrometheusRuleCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
-	keycloakPrometheusRule := model.PrometheusRule(cr)
+	keycloakPrometheusRule := &monitoringv1.PrometheusRule{}
 	keycloakPrometheusRuleSelector := model.PrometheusRuleSelector(cr)
 
 	err := controllerClient.Get(context, keycloakPrometheusRuleSelector, keycloakPrometheusRule)
@@ -445,6 +597,180 @@ func (i *ClusterState) readKeycloakOrRHSSODeploymentCurrentState(context context
 	return nil
 }
 
+func (i *ClusterState) readKeycloakThemeConfigMapsCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	i.KeycloakThemeConfigMaps = map[string]*v1.ConfigMap{}
+
+	for _, selector := range model.KeycloakThemeConfigMapSelectors(cr) {
+		themeConfigMap := &v1.ConfigMap{}
+		err := controllerClient.Get(context, selector, themeConfigMap)
+		if err != nil {
+			// A missing ConfigMap keeps the pods from starting, which is reported by the StatefulSet
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakThemeConfigMaps[selector.Name] = themeConfigMap.DeepCopy()
+		}
+	}
+	return nil
+}
+
+func (i *ClusterState) readKeycloakClusteringCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	clusteringConfigMap := &v1.ConfigMap{}
+	err := controllerClient.Get(context, model.KeycloakClusteringConfigMapSelector(cr), clusteringConfigMap)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		i.KeycloakClusteringConfigMap = nil
+	} else {
+		i.KeycloakClusteringConfigMap = clusteringConfigMap.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakClusteringConfigMap.Kind, i.KeycloakClusteringConfigMap.Name)
+	}
+
+	keystoreSecret := &v1.Secret{}
+	err = controllerClient.Get(context, model.KeycloakJGroupsKeystoreSecretSelector(cr), keystoreSecret)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		i.KeycloakJGroupsKeystoreSecret = nil
+	} else {
+		i.KeycloakJGroupsKeystoreSecret = keystoreSecret.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakJGroupsKeystoreSecret.Kind, i.KeycloakJGroupsKeystoreSecret.Name)
+	}
+	return nil
+}
+
+func (i *ClusterState) readKeycloakCrossSiteCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	crossSiteSecret := &v1.Secret{}
+	err := controllerClient.Get(context, model.KeycloakCrossSiteSecretSelector(cr, cr.Namespace), crossSiteSecret)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		i.KeycloakCrossSiteSecret = nil
+	} else {
+		i.KeycloakCrossSiteSecret = crossSiteSecret.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakCrossSiteSecret.Kind, i.KeycloakCrossSiteSecret.Name)
+	}
+
+	// A missing kubeconfig makes the site unreachable, which is reported in the status of the site
+	i.KeycloakCrossSiteKubeconfigs = make(map[string]*v1.Secret)
+	for _, site := range cr.Spec.CrossSite.RemoteSites {
+		if site.KubeconfigSecret == "" {
+			continue
+		}
+		kubeconfigSecret := &v1.Secret{}
+		err = controllerClient.Get(context, model.KeycloakCrossSiteKubeconfigSecretSelector(cr, site), kubeconfigSecret)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakCrossSiteKubeconfigs[site.Name] = kubeconfigSecret.DeepCopy()
+		}
+	}
+
+	i.KeycloakCrossSiteCaches = make(map[string]*unstructured.Unstructured)
+	cacheKindExists, _ := GetStateManager().GetState(InfinispanCacheKind).(bool)
+	if cr.Spec.CrossSite.InfinispanCluster == "" || !cacheKindExists {
+		return nil
+	}
+	for _, cache := range model.KeycloakCrossSiteCaches(cr) {
+		err = controllerClient.Get(context, model.KeycloakCrossSiteCacheSelector(cr, cache), cache)
+		if err != nil {
+			if !meta.IsNoMatchError(err) && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.KeycloakCrossSiteCaches[cache.GetName()] = cache.DeepCopy()
+			cr.UpdateStatusSecondaryResources(cache.GetKind(), cache.GetName())
+		}
+	}
+	return nil
+}
+
+func (i *ClusterState) readKeycloakDiscoveryRBACCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	// The service account is only managed if none is configured
+	if cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName == "" {
+		serviceAccount := &v1.ServiceAccount{}
+		err := controllerClient.Get(context, model.KeycloakDiscoveryServiceAccountSelector(cr), serviceAccount)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+			i.KeycloakServiceAccount = nil
+		} else {
+			i.KeycloakServiceAccount = serviceAccount.DeepCopy()
+			cr.UpdateStatusSecondaryResources(i.KeycloakServiceAccount.Kind, i.KeycloakServiceAccount.Name)
+		}
+	}
+
+	role := &rbacv1.Role{}
+	err := controllerClient.Get(context, model.KeycloakDiscoveryRoleSelector(cr), role)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		i.KeycloakDiscoveryRole = nil
+	} else {
+		i.KeycloakDiscoveryRole = role.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakDiscoveryRole.Kind, i.KeycloakDiscoveryRole.Name)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{}
+	err = controllerClient.Get(context, model.KeycloakDiscoveryRoleBindingSelector(cr), roleBinding)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+		i.KeycloakDiscoveryRoleBinding = nil
+	} else {
+		i.KeycloakDiscoveryRoleBinding = roleBinding.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakDiscoveryRoleBinding.Kind, i.KeycloakDiscoveryRoleBinding.Name)
+	}
+	return nil
+}
+
+func (i *ClusterState) readKeycloakCertificateCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	certificate := model.KeycloakCertificate(cr)
+	certificateSelector := model.KeycloakCertificateSelector(cr)
+
+	err := controllerClient.Get(context, certificateSelector, certificate)
+
+	if err != nil {
+		// If the resource type doesn't exist on the cluster or does exist but is not found
+		if meta.IsNoMatchError(err) || apiErrors.IsNotFound(err) {
+			i.KeycloakCertificate = nil
+		} else {
+			return err
+		}
+	} else {
+		i.KeycloakCertificate = certificate.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakCertificate.GetKind(), i.KeycloakCertificate.GetName())
+	}
+	return nil
+}
+
+func (i *ClusterState) readKeycloakServingCertSecretCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	servingCertSecret := &v1.Secret{}
+	servingCertSecretSelector := model.KeycloakServingCertSecretSelector(cr)
+
+	err := controllerClient.Get(context, servingCertSecretSelector, servingCertSecret)
+
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			i.KeycloakServingCertSecret = nil
+		} else {
+			return err
+		}
+	} else {
+		i.KeycloakServingCertSecret = servingCertSecret.DeepCopy()
+	}
+	return nil
+}
+
 func (i *ClusterState) readKeycloakDiscoveryServiceCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
 	keycloakDiscoveryService := model.KeycloakDiscoveryService(cr)
 	keycloakDiscoveryServiceSelector := model.KeycloakDiscoveryServiceSelector(cr)
@@ -564,6 +890,11 @@ func (i *ClusterState) IsResourcesReady(cr *kc.Keycloak) (bool, error) {
 		postgresqlDeploymentReady = true
 	}
 
+	// The highly available cluster replaces the Deployment
+	if !cr.Spec.ExternalDatabase.Enabled && cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+		postgresqlDeploymentReady = model.IsPostgresqlClusterReady(i.PostgresqlCluster)
+	}
+
 	// If running on OpenShift, check the Route is ready
 	if cr.Spec.ExternalAccess.Enabled {
 		stateManager := GetStateManager()
@@ -584,6 +915,9 @@ func (i *ClusterState) readKeycloakBackupCurrentState(context context.Context, c
 	backupCr := &v1alpha1.KeycloakBackup{}
 	backupCr.Namespace = cr.Namespace
 	backupCr.Name = model.MigrateBackupName + "-" + BackupTime
+	if cr.Status.Migration != nil && cr.Status.Migration.Backup != "" {
+		backupCr.Name = cr.Status.Migration.Backup
+	}
 	backupCr.Spec.InstanceSelector = &labelSelect
 	backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
 
@@ -600,3 +934,188 @@ func (i *ClusterState) readKeycloakBackupCurrentState(context context.Context, c
 	}
 	return nil
 }
+
+// The pods are needed to tell whether an upgraded image is crash looping and which extensions were loaded
+func (i *ClusterState) readKeycloakPodsCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if i.KeycloakDeployment == nil || i.KeycloakDeployment.Spec.Selector == nil {
+		return nil
+	}
+
+	keycloakPods := &v1.PodList{}
+	err := controllerClient.List(context, keycloakPods, client.InNamespace(cr.Namespace), client.MatchingLabels(i.KeycloakDeployment.Spec.Selector.MatchLabels))
+	if err != nil {
+		return err
+	}
+	i.KeycloakPods = keycloakPods
+	return nil
+}
+
+func (i *ClusterState) readKeycloakMigrationRestoreCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	restoreJob, err := readMigrationRestoreJob(context, cr, cr.Status.Migration.Backup, controllerClient)
+	if err != nil {
+		return err
+	}
+	i.KeycloakMigrationRestoreJob = restoreJob
+	return nil
+}
+
+func readMigrationRestoreJob(context context.Context, cr *kc.Keycloak, backup string, controllerClient client.Client) (*batchv1.Job, error) {
+	if backup == "" {
+		return nil, nil
+	}
+
+	restoreJob := &batchv1.Job{}
+	restoreJobSelector := model.KeycloakMigrationRestoreSelector(cr, backup)
+
+	err := controllerClient.Get(context, restoreJobSelector, restoreJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	cr.UpdateStatusSecondaryResources(restoreJob.Kind, restoreJob.Name)
+	return restoreJob.DeepCopy(), nil
+}
+
+func (i *ClusterState) readKeycloakDatabaseDropJobsCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	databaseDropJobs := &batchv1.JobList{}
+	err := controllerClient.List(context, databaseDropJobs, client.InNamespace(cr.Namespace), client.MatchingLabels(model.KeycloakDatabaseDropLabels()))
+	if err != nil {
+		return err
+	}
+	i.KeycloakDatabaseDropJobs = databaseDropJobs
+	return nil
+}
+
+// The backup, its restore job and the claim of the new major version are read during a database upgrade, until
+// then the data stays in the claim of the previous version
+func (i *ClusterState) readDatabaseUpgradeCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	upgrade := cr.Status.DatabaseUpgrade
+	if upgrade.Backup != "" {
+		backup := &v1alpha1.KeycloakBackup{}
+		backup.Namespace = cr.Namespace
+		backup.Name = upgrade.Backup
+
+		err := controllerClient.Get(context, model.KeycloakMigrationOneTimeBackupSelector(backup), backup)
+		if err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return err
+			}
+		} else {
+			i.DatabaseUpgradeBackup = backup.DeepCopy()
+		}
+	}
+
+	restoreJob, err := readMigrationRestoreJob(context, cr, upgrade.Backup, controllerClient)
+	if err != nil {
+		return err
+	}
+	i.DatabaseUpgradeRestoreJob = restoreJob
+
+	if upgrade.ToDatabaseVersion == "" {
+		return nil
+	}
+
+	postgresqlUpgradeVolumeClaim := &v1.PersistentVolumeClaim{}
+	postgresqlUpgradeVolumeClaimSelector := model.PostgresqlPersistentVolumeClaimSelector(cr, upgrade.ToDatabaseVersion)
+
+	err = controllerClient.Get(context, postgresqlUpgradeVolumeClaimSelector, postgresqlUpgradeVolumeClaim)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.PostgresqlUpgradeVolumeClaim = postgresqlUpgradeVolumeClaim.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.PostgresqlUpgradeVolumeClaim.Kind, i.PostgresqlUpgradeVolumeClaim.Name)
+	}
+	return nil
+}
+
+// The upgraded stack of a bluegreen migration is read until it has been removed after the migration
+func (i *ClusterState) readKeycloakBlueGreenCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	blueGreenDeployment := &v12.StatefulSet{}
+	err := controllerClient.Get(context, model.KeycloakBlueGreenDeploymentSelector(cr), blueGreenDeployment)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakBlueGreenDeployment = blueGreenDeployment.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakBlueGreenDeployment.Kind, i.KeycloakBlueGreenDeployment.Name)
+
+		blueGreenPods := &v1.PodList{}
+		err = controllerClient.List(context, blueGreenPods, client.InNamespace(cr.Namespace), client.MatchingLabels(blueGreenDeployment.Spec.Selector.MatchLabels))
+		if err != nil {
+			return err
+		}
+		i.KeycloakBlueGreenPods = blueGreenPods
+	}
+
+	blueGreenService := &v1.Service{}
+	err = controllerClient.Get(context, model.KeycloakBlueGreenServiceSelector(cr), blueGreenService)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakBlueGreenService = blueGreenService.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakBlueGreenService.Kind, i.KeycloakBlueGreenService.Name)
+	}
+
+	if cr.Status.Migration.Database == "" {
+		return nil
+	}
+
+	cloneJob := &batchv1.Job{}
+	err = controllerClient.Get(context, model.KeycloakDatabaseCloneSelector(cr, cr.Status.Migration.Database), cloneJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.KeycloakDatabaseCloneJob = cloneJob.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.KeycloakDatabaseCloneJob.Kind, i.KeycloakDatabaseCloneJob.Name)
+	}
+	return nil
+}
+
+// The rotation Job only exists while a new password is pending in the database secret
+func (i *ClusterState) readDatabaseCredentialsRotationCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	if i.DatabaseSecret == nil || len(i.DatabaseSecret.Data[model.DatabaseSecretNextPasswordProperty]) == 0 {
+		return nil
+	}
+
+	rotationJob := &batchv1.Job{}
+	rotationJobSelector := model.DatabaseCredentialsRotationSelector(cr)
+
+	err := controllerClient.Get(context, rotationJobSelector, rotationJob)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.DatabaseCredentialsRotationJob = rotationJob.DeepCopy()
+		cr.UpdateStatusSecondaryResources(i.DatabaseCredentialsRotationJob.Kind, i.DatabaseCredentialsRotationJob.Name)
+	}
+	return nil
+}
+
+// The CA certificate of Vault is only read if databaseCredentials.vault sets a secret for it
+func (i *ClusterState) readVaultCASecretCurrentState(context context.Context, cr *kc.Keycloak, controllerClient client.Client) error {
+	vault := cr.Spec.DatabaseCredentials.Vault
+	if vault == nil || vault.CASecretName == "" {
+		return nil
+	}
+
+	caSecret := &v1.Secret{}
+	err := controllerClient.Get(context, client.ObjectKey{Namespace: cr.Namespace, Name: vault.CASecretName}, caSecret)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		i.VaultCASecret = caSecret.DeepCopy()
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -26,6 +27,15 @@ const (
 	PersistentVolumeClaimKind = "PersistentVolumeClaim"
 	PodDisruptionBudgetKind   = "PodDisruptionBudget"
 	OpenShiftAPIServerKind    = "OpenShiftAPIServer"
+	CertificateKind           = "Certificate"
+	CloudNativePGClusterKind  = "Cluster.postgresql.cnpg.io"
+	ZalandoPostgresqlKind     = "postgresql.acid.zalan.do"
+	InfinispanCacheKind       = "Cache.infinispan.org"
+	KeycloakKind              = "Keycloak"
+	KeycloakRealmKind         = "KeycloakRealm"
+	KeycloakClientKind        = "KeycloakClient"
+	KeycloakUserKind          = "KeycloakUser"
+	KeycloakBackupKind        = "KeycloakBackup"
 )
 
 func WatchSecondaryResource(c controller.Controller, controllerName string, resourceKind string, objectTypetoWatch runtime.Object, cr runtime.Object) error {
@@ -82,3 +92,32 @@ func GetMatchingRealms(ctx context.Context, c client.Client, labelSelector *v1.L
 	err := c.List(ctx, &list, opts...)
 	return list, err
 }
+
+// PostgresqlClusterProvider returns the Postgres operator creating the highly available database cluster of the
+// Keycloak instance, if none is configured the one installed on the cluster is chosen
+func PostgresqlClusterProvider(cr *v1alpha1.Keycloak) (v1alpha1.PostgresqlProvider, error) {
+	stateManager := GetStateManager()
+	cloudNativePGExists, _ := stateManager.GetState(CloudNativePGClusterKind).(bool)
+	zalandoExists, _ := stateManager.GetState(ZalandoPostgresqlKind).(bool)
+
+	switch cr.Spec.PostgresDeploymentSpec.HighAvailability.Provider {
+	case v1alpha1.PostgresqlProviderCloudNativePG:
+		if !cloudNativePGExists {
+			return "", errors.Errorf("the CloudNativePG Cluster resource is not available on the cluster")
+		}
+		return v1alpha1.PostgresqlProviderCloudNativePG, nil
+	case v1alpha1.PostgresqlProviderZalando:
+		if !zalandoExists {
+			return "", errors.Errorf("the Zalando postgresql resource is not available on the cluster")
+		}
+		return v1alpha1.PostgresqlProviderZalando, nil
+	}
+
+	if cloudNativePGExists {
+		return v1alpha1.PostgresqlProviderCloudNativePG, nil
+	}
+	if zalandoExists {
+		return v1alpha1.PostgresqlProviderZalando, nil
+	}
+	return "", errors.Errorf("postgresDeploymentSpec.highAvailability is enabled but neither the CloudNativePG nor the Zalando Postgres operator is installed on the cluster")
+}
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "keycloak_operator_reconcile_duration_seconds",
+		Help: "Duration of the reconciliations of the custom resources by kind and result.",
+	}, []string{"kind", "result"})
+	desiredStateActions = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "keycloak_operator_desired_state_actions",
+		Help:    "Number of actions of the desired cluster states run by kind of custom resource.",
+		Buckets: []float64{0, 1, 2, 5, 10, 20, 50, 100},
+	}, []string{"kind"})
+	adminAPIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "keycloak_operator_admin_api_request_duration_seconds",
+		Help: "Duration of the requests to the Keycloak admin API by method, endpoint and status code.",
+	}, []string{"method", "endpoint", "status"})
+	adminTokenRefreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "keycloak_operator_admin_token_refreshes_total",
+		Help: "Number of refreshes of the admin API tokens by result.",
+	}, []string{"result"})
+	resourceFailingDesc = prometheus.NewDesc(
+		"keycloak_operator_resource_failing_seconds",
+		"Seconds since the reconciliation of a custom resource started failing, for resources in the failing phase.",
+		[]string{"kind", "resource_namespace", "name"}, nil,
+	)
+)
+
+// adminAPIPathSegments are the fixed segments of the admin API paths, all other segments below a realm are
+// names or ids, which aren't used as labels
+var adminAPIPathSegments = map[string]bool{
+	"admin-events": true, "authentication": true, "client-scopes": true, "client-secret": true, "clients": true,
+	"composites": true, "config": true, "count": true, "default-client-scopes": true, "events": true,
+	"executions": true, "federated-identity": true, "flows": true, "identity-provider": true, "installation": true,
+	"instances": true, "keycloak-oidc-keycloak-json": true, "openid-connect": true, "optional-client-scopes": true,
+	"protocol": true, "providers": true, "realm": true, "reset-password": true, "role-mappings": true, "roles": true,
+	"roles-by-id": true, "scope-mappings": true, "service-account-user": true, "token": true, "users": true,
+}
+
+// failingResources keeps the time every failing custom resource started failing, the duration is computed at
+// scrape time
+type failingResources struct {
+	mutex sync.Mutex
+	since map[failingResourceKey]time.Time
+}
+
+type failingResourceKey struct {
+	kind string
+	types.NamespacedName
+}
+
+var failingResourcesCollector = &failingResources{
+	since: make(map[failingResourceKey]time.Time),
+}
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, desiredStateActions, adminAPIRequestDuration, adminTokenRefreshes, failingResourcesCollector)
+}
+
+func (m *failingResources) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourceFailingDesc
+}
+
+func (m *failingResources) Collect(ch chan<- prometheus.Metric) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for key, since := range m.since {
+		ch <- prometheus.MustNewConstMetric(resourceFailingDesc, prometheus.GaugeValue, now.Sub(since).Seconds(), key.kind, key.Namespace, key.Name)
+	}
+}
+
+func (m *failingResources) failing(key failingResourceKey) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	_, ok := m.since[key]
+	return ok
+}
+
+// SetResourceFailing marks a custom resource as failing, keeping the time of its first failure
+func SetResourceFailing(kind string, namespace string, name string) {
+	failingResourcesCollector.mutex.Lock()
+	defer failingResourcesCollector.mutex.Unlock()
+	resourceKey := failingResourceKey{kind: kind, NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	if _, ok := failingResourcesCollector.since[resourceKey]; !ok {
+		failingResourcesCollector.since[resourceKey] = time.Now()
+	}
+}
+
+// ClearResourceFailing marks a custom resource as reconciled successfully, or deleted
+func ClearResourceFailing(kind string, namespace string, name string) {
+	failingResourcesCollector.mutex.Lock()
+	defer failingResourcesCollector.mutex.Unlock()
+	delete(failingResourcesCollector.since, failingResourceKey{kind: kind, NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+}
+
+// ObserveReconcile records the duration of a reconciliation started at start. It's failed if the custom resource
+// is left failing.
+func ObserveReconcile(kind string, key types.NamespacedName, start time.Time) {
+	result := reconcileResultSuccess
+	if failingResourcesCollector.failing(failingResourceKey{kind: kind, NamespacedName: key}) {
+		result = reconcileResultError
+	}
+	reconcileDuration.WithLabelValues(kind, result).Observe(time.Since(start).Seconds())
+}
+
+func observeDesiredState(cr runtime.Object, desiredState DesiredClusterState) {
+	desiredStateActions.WithLabelValues(reflect.Indirect(reflect.ValueOf(cr)).Type().Name()).Observe(float64(len(desiredState)))
+}
+
+// observeAdminAPIRequest records a request to the admin API, requests without a response have the status error
+func observeAdminAPIRequest(req *http.Request, res *http.Response, err error, start time.Time) {
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+	adminAPIRequestDuration.WithLabelValues(req.Method, adminAPIEndpoint(req.URL.Path), status).Observe(time.Since(start).Seconds())
+}
+
+func observeTokenRefresh(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	adminTokenRefreshes.WithLabelValues(result).Inc()
+}
+
+// adminAPIEndpoint replaces the realm, names and ids in the path of an admin API request with placeholders, e.g.
+// /auth/admin/realms/{realm}/clients/{id}/roles
+func adminAPIEndpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "realms" || i+1 >= len(segments) {
+			continue
+		}
+		segments[i+1] = "{realm}"
+		for j := i + 2; j < len(segments); j++ {
+			if !adminAPIPathSegments[segments[j]] {
+				segments[j] = "{id}"
+			}
+		}
+		break
+	}
+	return "/" + strings.Join(segments, "/")
+}
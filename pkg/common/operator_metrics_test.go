@@ -0,0 +1,87 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestOperatorMetrics_AdminAPIEndpoint(t *testing.T) {
+	// given
+	paths := map[string]string{
+		"/auth/admin/realms/example/clients/8f3c-41/roles/admin/composites": "/auth/admin/realms/{realm}/clients/{id}/roles/{id}/composites",
+		"/auth/admin/realms/example/users/count":                            "/auth/admin/realms/{realm}/users/count",
+		"/auth/admin/realms/example/users/1234/role-mappings/clients/5678":  "/auth/admin/realms/{realm}/users/{id}/role-mappings/clients/{id}",
+		"/auth/realms/master/protocol/openid-connect/token":                 "/auth/realms/{realm}/protocol/openid-connect/token",
+		"/auth/admin/realms": "/auth/admin/realms",
+	}
+
+	for path, expected := range paths {
+		// when
+		endpoint := adminAPIEndpoint(path)
+
+		// then
+		assert.Equal(t, expected, endpoint)
+	}
+}
+
+func TestOperatorMetrics_ObserveAdminAPIRequest(t *testing.T) {
+	// given
+	req, _ := http.NewRequest(http.MethodGet, "http://keycloak/auth/admin/realms/example/client-scopes/8f3c-41", nil)
+	res := &http.Response{StatusCode: http.StatusNotFound}
+	series := testutil.CollectAndCount(adminAPIRequestDuration)
+
+	// when
+	observeAdminAPIRequest(req, res, nil, time.Now())
+	observeAdminAPIRequest(req, nil, errors.New("connection refused"), time.Now())
+
+	// then
+	assert.Equal(t, series+2, testutil.CollectAndCount(adminAPIRequestDuration))
+}
+
+func TestOperatorMetrics_FailingResources(t *testing.T) {
+	// given
+	key := types.NamespacedName{Namespace: "keycloak", Name: "failing-realm"}
+	resourceKey := failingResourceKey{kind: KeycloakRealmKind, NamespacedName: key}
+	series := testutil.CollectAndCount(reconcileDuration)
+
+	// when
+	SetResourceFailing(KeycloakRealmKind, key.Namespace, key.Name)
+	since := failingResourcesCollector.since[resourceKey]
+	SetResourceFailing(KeycloakRealmKind, key.Namespace, key.Name)
+	sinceAfterRetry := failingResourcesCollector.since[resourceKey]
+	failing := testutil.CollectAndCount(failingResourcesCollector)
+	ObserveReconcile(KeycloakRealmKind, key, time.Now())
+	ClearResourceFailing(KeycloakRealmKind, key.Namespace, key.Name)
+	ObserveReconcile(KeycloakRealmKind, key, time.Now())
+
+	// then
+	assert.Equal(t, since, sinceAfterRetry)
+	assert.Equal(t, 1, failing)
+	// The namespace label is set by Prometheus to the namespace of the operator
+	assert.Contains(t, resourceFailingDesc.String(), "resource_namespace")
+	assert.Equal(t, 0, testutil.CollectAndCount(failingResourcesCollector))
+	assert.Equal(t, series+2, testutil.CollectAndCount(reconcileDuration))
+}
+
+func TestOperatorMetrics_DesiredStateAndTokenRefreshes(t *testing.T) {
+	// given
+	runner := &ClusterActionRunner{cr: &v1alpha1.KeycloakUser{}}
+	series := testutil.CollectAndCount(desiredStateActions)
+	refreshes := testutil.ToFloat64(adminTokenRefreshes.WithLabelValues("failure"))
+
+	// when
+	err := runner.RunAll(DesiredClusterState{})
+	observeTokenRefresh(errors.New("refresh token expired"))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, series+1, testutil.CollectAndCount(desiredStateActions))
+	assert.Equal(t, refreshes+1, testutil.ToFloat64(adminTokenRefreshes.WithLabelValues("failure")))
+}
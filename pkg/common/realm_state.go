@@ -5,6 +5,7 @@ import (
 
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,7 +26,14 @@ func NewRealmState(context context.Context, keycloak kc.Keycloak) *RealmState {
 }
 
 func (i *RealmState) Read(cr *kc.KeycloakRealm, realmClient KeycloakInterface, controllerClient client.Client) error {
-	realm, err := realmClient.GetRealm(cr.Spec.Realm.Realm)
+	ctx, span := StartSpan(i.Context, "RealmState.Read", attribute.String("keycloak.realm", cr.Spec.Realm.Realm))
+	err := i.read(ctx, cr, realmClient, controllerClient)
+	EndSpan(span, err)
+	return err
+}
+
+func (i *RealmState) read(ctx context.Context, cr *kc.KeycloakRealm, realmClient KeycloakInterface, controllerClient client.Client) error {
+	realm, err := realmClient.GetRealm(ctx, cr.Spec.Realm.Realm)
 	if err != nil {
 		i.Realm = nil
 		return err
@@ -39,7 +47,7 @@ func (i *RealmState) Read(cr *kc.KeycloakRealm, realmClient KeycloakInterface, c
 	// Get the state of the realm users
 	i.RealmUserSecrets = make(map[string]*v1.Secret)
 	for _, user := range cr.Spec.Realm.Users {
-		secret, err := i.readRealmUserSecret(cr, user, controllerClient)
+		secret, err := i.readRealmUserSecret(ctx, cr, user, controllerClient)
 		if err != nil {
 			return err
 		}
@@ -51,12 +59,12 @@ func (i *RealmState) Read(cr *kc.KeycloakRealm, realmClient KeycloakInterface, c
 	return nil
 }
 
-func (i *RealmState) readRealmUserSecret(realm *kc.KeycloakRealm, user *kc.KeycloakAPIUser, controllerClient client.Client) (*v1.Secret, error) {
+func (i *RealmState) readRealmUserSecret(ctx context.Context, realm *kc.KeycloakRealm, user *kc.KeycloakAPIUser, controllerClient client.Client) (*v1.Secret, error) {
 	key := model.RealmCredentialSecretSelector(realm, user, i.Keycloak)
 	secret := &v1.Secret{}
 
 	// Try to find the user credential secret
-	err := controllerClient.Get(i.Context, key, secret)
+	err := controllerClient.Get(ctx, key, secret)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil, nil
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Requests to the clusters of remote sites don't hold up the reconcile of the local Keycloak for long
+const remoteClusterTimeout = 10 * time.Second
+
+// DefaultRemoteClusterClients is shared by all Keycloak CRs, sites mostly share the clusters of the other sites
+var DefaultRemoteClusterClients = NewRemoteClusterClients()
+
+// RemoteClusterClients keeps a client per kubeconfig for the clusters of remote cross-site sites. A changed
+// kubeconfig gets a new client.
+type RemoteClusterClients struct {
+	mutex   sync.Mutex
+	clients map[[sha256.Size]byte]client.Client
+}
+
+func NewRemoteClusterClients() *RemoteClusterClients {
+	return &RemoteClusterClients{
+		clients: map[[sha256.Size]byte]client.Client{},
+	}
+}
+
+// Client returns the client for the cluster of the kubeconfig. It only knows the built-in resource kinds.
+func (r *RemoteClusterClients) Client(kubeconfig []byte) (client.Client, error) {
+	key := sha256.Sum256(kubeconfig)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if remoteClient, ok := r.clients[key]; ok {
+		return remoteClient, nil
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the kubeconfig of the remote cluster")
+	}
+	config.Timeout = remoteClusterTimeout
+
+	remoteClient, err := client.New(config, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating a client for the remote cluster %v", config.Host)
+	}
+	r.clients[key] = remoteClient
+	return remoteClient, nil
+}
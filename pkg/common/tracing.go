@@ -0,0 +1,103 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/keycloak/keycloak-operator/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	TracingServiceName = "keycloak-operator"
+	tracerName         = "github.com/keycloak/keycloak-operator"
+)
+
+// TracingConfig configures the export of the spans of the operator over OTLP/HTTP
+type TracingConfig struct {
+	// host:port of the collector, if empty the OTEL_EXPORTER_OTLP_ENDPOINT and
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables are used
+	Endpoint string
+	Insecure bool
+}
+
+// Enabled returns true if an endpoint is set by the flag or the environment
+func (c TracingConfig) Enabled() bool {
+	return c.Endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// InitTracing registers a tracer provider exporting the spans over OTLP/HTTP and the W3C trace context
+// propagator, which passes the trace on to Keycloak in the traceparent header of the admin API requests.
+// Without tracing the spans aren't recorded. The returned function flushes the spans left on shutdown.
+func InitTracing(ctx context.Context, config TracingConfig) (func(context.Context) error, error) {
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String(TracingServiceName),
+			semconv.ServiceVersionKey.String(version.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span of the operator, it's a no-op unless tracing is initialized
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan marks the span as failed if err is set and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// startRequestSpan starts a client span for a request to Keycloak and injects the trace context into its headers
+func startRequestSpan(req *http.Request) (*http.Request, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+			attribute.String("keycloak.endpoint", adminAPIEndpoint(req.URL.Path)),
+		))
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+func endRequestSpan(span trace.Span, res *http.Response, err error) {
+	if err == nil {
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(res.StatusCode))
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(res.StatusCode))
+	}
+	EndSpan(span, err)
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing_ActionsAndRequestsAreChildSpans(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	var traceparents []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		traceparents = append(traceparents, req.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	keycloakClient := testRetryingClient(server)
+	runner := NewClusterAndKeycloakActionRunner(context.TODO(), nil, nil, &v1alpha1.Keycloak{}, keycloakClient)
+
+	// when
+	err := runner.RunAll(DesiredClusterState{PingAction{Msg: "ping"}})
+
+	// then
+	assert.NoError(t, err)
+	spans := endedSpans(recorder)
+	assert.Len(t, spans, 3)
+	request, action, runAll := spans["HTTP GET"], spans["PingAction.Run"], spans["ClusterActionRunner.RunAll"]
+	assert.Equal(t, action.SpanContext().SpanID(), request.Parent().SpanID())
+	assert.Equal(t, runAll.SpanContext().SpanID(), action.Parent().SpanID())
+	assert.Equal(t, trace.SpanKindClient, request.SpanKind())
+	assert.Len(t, traceparents, 1)
+	assert.Contains(t, traceparents[0], request.SpanContext().TraceID().String())
+	assert.Contains(t, traceparents[0], request.SpanContext().SpanID().String())
+}
+
+func TestTracing_FailedReadIsRecorded(t *testing.T) {
+	// given
+	recorder := recordSpans(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	realm := &v1alpha1.KeycloakRealm{Spec: v1alpha1.KeycloakRealmSpec{Realm: &v1alpha1.KeycloakAPIRealm{Realm: "dummy"}}}
+	cr := &v1alpha1.KeycloakClient{Spec: v1alpha1.KeycloakClientSpec{Client: &v1alpha1.KeycloakAPIClient{ID: "8f3c-41", ClientID: "dummy"}}}
+	clientState := NewClientState(context.TODO(), realm, v1alpha1.Keycloak{})
+
+	// when
+	err := clientState.Read(context.TODO(), cr, testRetryingClient(server), nil)
+
+	// then
+	assert.Error(t, err)
+	spans := endedSpans(recorder)
+	assert.Equal(t, codes.Error, spans["ClientState.Read"].Status().Code)
+	assert.Equal(t, codes.Error, spans["HTTP GET"].Status().Code)
+	assert.Equal(t, spans["ClientState.Read"].SpanContext().SpanID(), spans["HTTP GET"].Parent().SpanID())
+}
+
+// recordSpans registers a tracer provider recording the spans for the duration of the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+	return recorder
+}
+
+// endedSpans returns the ended spans by name, retried requests keep the last span
+func endedSpans(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
@@ -19,7 +19,9 @@ type UserState struct {
 	Clients              []*v1alpha1.KeycloakAPIClient
 	Secret               *v1.Secret
 	Keycloak             v1alpha1.Keycloak
-	Context              context.Context
+	// User of the realm with the username of a CR without an ID. It isn't managed by the CR until spec.user.id is
+	// set to its ID.
+	ExistingUser *v1alpha1.KeycloakAPIUser
 }
 
 func NewUserState(keycloak v1alpha1.Keycloak) *UserState {
@@ -30,18 +32,25 @@ func NewUserState(keycloak v1alpha1.Keycloak) *UserState {
 	}
 }
 
-func (i *UserState) Read(keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakUser, realm v1alpha1.KeycloakRealm) error {
-	apiUser, err := i.readUser(keycloakClient, user, realm.Spec.Realm.Realm)
+func (i *UserState) Read(context context.Context, keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakUser, realm v1alpha1.KeycloakRealm) error {
+	if user.Spec.User.ID == "" {
+		return i.readExistingUser(context, keycloakClient, user, realm.Spec.Realm.Realm)
+	}
+
+	apiUser, err := i.readUser(context, keycloakClient, user, realm.Spec.Realm.Realm)
 	if err != nil {
-		// If there was an error reading the user then don't attempt
-		// to read the roles. This user might not yet exist
-		return nil
+		// If the user doesn't exist then don't attempt to read the roles,
+		// it's created later. Other failures are reported.
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
 	}
 
-	return i.ReadWithExistingAPIUser(keycloakClient, userClient, apiUser, realm)
+	return i.ReadWithExistingAPIUser(context, keycloakClient, userClient, apiUser, realm)
 }
 
-func (i *UserState) ReadWithExistingAPIUser(keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakAPIUser, realm v1alpha1.KeycloakRealm) error {
+func (i *UserState) ReadWithExistingAPIUser(context context.Context, keycloakClient KeycloakInterface, userClient client.Client, user *v1alpha1.KeycloakAPIUser, realm v1alpha1.KeycloakRealm) error {
 	// Don't continue if the user could not be found
 	if user == nil {
 		return nil
@@ -49,22 +58,22 @@ func (i *UserState) ReadWithExistingAPIUser(keycloakClient KeycloakInterface, us
 
 	i.User = user
 
-	var err = i.readRealmRoles(keycloakClient, realm.Spec.Realm.Realm)
+	var err = i.readRealmRoles(context, keycloakClient, realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	err = i.readClientRoles(keycloakClient, realm.Spec.Realm.Realm)
+	err = i.readClientRoles(context, keycloakClient, realm.Spec.Realm.Realm)
 	if err != nil {
 		return err
 	}
 
-	return i.readSecretState(userClient, &realm)
+	return i.readSecretState(context, userClient, &realm)
 }
 
-func (i *UserState) readUser(client KeycloakInterface, user *v1alpha1.KeycloakUser, realm string) (*v1alpha1.KeycloakAPIUser, error) {
+func (i *UserState) readUser(context context.Context, client KeycloakInterface, user *v1alpha1.KeycloakUser, realm string) (*v1alpha1.KeycloakAPIUser, error) {
 	if user.Spec.User.ID != "" {
-		keycloakUser, err := client.GetUser(user.Spec.User.ID, realm)
+		keycloakUser, err := client.GetUser(context, user.Spec.User.ID, realm)
 		if err != nil {
 			return nil, err
 		}
@@ -73,16 +82,26 @@ func (i *UserState) readUser(client KeycloakInterface, user *v1alpha1.KeycloakUs
 	return nil, nil
 }
 
-func (i *UserState) readRealmRoles(client KeycloakInterface, realm string) error {
+// readExistingUser looks up a user the CR would conflict with on creation
+func (i *UserState) readExistingUser(context context.Context, client KeycloakInterface, user *v1alpha1.KeycloakUser, realm string) (err error) {
+	if user.DeletionTimestamp != nil || user.Spec.User.UserName == "" {
+		return nil
+	}
+
+	i.ExistingUser, err = client.FindUserByUsername(context, user.Spec.User.UserName, realm)
+	return err
+}
+
+func (i *UserState) readRealmRoles(context context.Context, client KeycloakInterface, realm string) error {
 	// Get all the realm roles of this user
-	roles, err := client.ListUserRealmRoles(realm, i.User.ID)
+	roles, err := client.ListUserRealmRoles(context, realm, i.User.ID)
 	if err != nil {
 		return err
 	}
 	i.RealmRoles = roles
 
 	// Get the roles that are still available to this user
-	availableRoles, err := client.ListAvailableUserRealmRoles(realm, i.User.ID)
+	availableRoles, err := client.ListAvailableUserRealmRoles(context, realm, i.User.ID)
 	if err != nil {
 		return err
 	}
@@ -91,37 +110,39 @@ func (i *UserState) readRealmRoles(client KeycloakInterface, realm string) error
 	return nil
 }
 
-func (i *UserState) readClientRoles(client KeycloakInterface, realm string) error {
-	clients, err := client.ListClients(realm)
-	if err != nil {
-		return err
-	}
-	i.Clients = clients
+func (i *UserState) readClientRoles(context context.Context, client KeycloakInterface, realm string) error {
+	// Walk the clients page by page, only their IDs are kept
+	clients := NewClientIterator(context, client, realm, ClientQuery{})
+	for clients.Next() {
+		c := &v1alpha1.KeycloakAPIClient{
+			ID:       clients.Client().ID,
+			ClientID: clients.Client().ClientID,
+		}
+		i.Clients = append(i.Clients, c)
 
-	for _, c := range clients {
 		// Get all client roles of this user
-		roles, err := client.ListUserClientRoles(realm, c.ID, i.User.ID)
+		roles, err := client.ListUserClientRoles(context, realm, c.ID, i.User.ID)
 		if err != nil {
 			return err
 		}
 		i.ClientRoles[c.ClientID] = roles
 
 		// Get the roles that are still available to this user
-		availableRoles, err := client.ListAvailableUserClientRoles(realm, c.ID, i.User.ID)
+		availableRoles, err := client.ListAvailableUserClientRoles(context, realm, c.ID, i.User.ID)
 		if err != nil {
 			return err
 		}
 		i.AvailableClientRoles[c.ClientID] = availableRoles
 	}
-	return nil
+	return clients.Err()
 }
 
-func (i *UserState) readSecretState(userClient client.Client, realm *v1alpha1.KeycloakRealm) error {
+func (i *UserState) readSecretState(context context.Context, userClient client.Client, realm *v1alpha1.KeycloakRealm) error {
 	key := model.RealmCredentialSecretSelector(realm, i.User, &i.Keycloak)
 	secret := &v1.Secret{}
 
 	// Try to find the user credential secret
-	err := userClient.Get(i.Context, key, secret)
+	err := userClient.Get(context, key, secret)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil
@@ -0,0 +1,190 @@
+package common
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	vaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" // nolint
+	vaultCACertificateKey        = "ca.crt"
+)
+
+// VaultDatabaseCredentials are the credentials issued by the database secrets engine of Vault
+type VaultDatabaseCredentials struct {
+	Username      string
+	Password      string
+	LeaseID       string
+	LeaseDuration time.Duration
+}
+
+// VaultClient talks to the HTTP API of Vault
+type VaultClient struct {
+	requester Requester
+	address   string
+	token     string
+}
+
+// NewVaultClient returns a client for the Vault at the address. The system trust store is used if caCert is nil.
+func NewVaultClient(address string, caCert []byte) (*VaultClient, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCert != nil {
+		rootCAPool := x509.NewCertPool()
+		if ok := rootCAPool.AppendCertsFromPEM(caCert); !ok {
+			return nil, errors.Errorf("unable to successfully load the vault certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAPool}
+	}
+	return &VaultClient{
+		requester: &http.Client{Transport: transport, Timeout: time.Second * 10},
+		address:   strings.TrimSuffix(address, "/"),
+	}, nil
+}
+
+// LoginKubernetes logs in through the Kubernetes auth method with the token of a service account
+func (c *VaultClient) LoginKubernetes(mount, role, jwt string) error {
+	body, err := json.Marshal(map[string]string{
+		"role": role,
+		"jwt":  jwt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshalling the vault login request")
+	}
+
+	var response struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	err = c.do("POST", fmt.Sprintf("auth/%s/login", mount), body, &response)
+	if err != nil {
+		return errors.Wrap(err, "vault login failed")
+	}
+	if response.Auth.ClientToken == "" {
+		return errors.Errorf("vault login with role %s returned no token", role)
+	}
+	c.token = response.Auth.ClientToken
+	return nil
+}
+
+// ReadDatabaseCredentials issues new credentials for the role of the database secrets engine
+func (c *VaultClient) ReadDatabaseCredentials(mount, role string) (*VaultDatabaseCredentials, error) {
+	var response struct {
+		LeaseID       string `json:"lease_id"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Data          struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"data"`
+	}
+	err := c.do("GET", fmt.Sprintf("%s/creds/%s", mount, role), nil, &response)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the database credentials of role %s", role)
+	}
+	if response.Data.Username == "" || response.Data.Password == "" {
+		return nil, errors.Errorf("vault returned no database credentials for role %s", role)
+	}
+	return &VaultDatabaseCredentials{
+		Username:      response.Data.Username,
+		Password:      response.Data.Password,
+		LeaseID:       response.LeaseID,
+		LeaseDuration: time.Duration(response.LeaseDuration) * time.Second,
+	}, nil
+}
+
+// RevokeLease revokes the lease of credentials issued before, the database secrets engine drops their user
+func (c *VaultClient) RevokeLease(leaseID string) error {
+	body, err := json.Marshal(map[string]string{
+		"lease_id": leaseID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error marshalling the vault revoke request")
+	}
+
+	err = c.do("PUT", "sys/leases/revoke", body, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke the lease %s", leaseID)
+	}
+	return nil
+}
+
+func (c *VaultClient) do(method, path string, body []byte, result interface{}) error {
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/v1/%s", c.address, path), bytes.NewBuffer(body))
+	if err != nil {
+		return errors.Wrapf(err, "error creating %s %s request", method, path)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+
+	res, err := c.requester.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "error performing %s %s request", method, path)
+	}
+	defer res.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrapf(err, "error reading the %s %s response", method, path)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.Errorf("%s %s failed: (%d) %s", method, path, res.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(responseBody, result)
+}
+
+// LoginVault logs into the Vault of the Keycloak CR with the service account of the operator. The CA certificate
+// is taken from the secret set in databaseCredentials.vault.caSecretName, read with the client of the manager.
+func LoginVault(kc *v1alpha1.Keycloak, caSecret *v1.Secret) (*VaultClient, error) {
+	vault := kc.Spec.DatabaseCredentials.Vault
+	if vault == nil {
+		return nil, errors.Errorf("databaseCredentials.vault isn't set")
+	}
+
+	var caCert []byte
+	if vault.CASecretName != "" {
+		if caSecret == nil {
+			return nil, errors.Errorf("the vault CA certificate secret %s doesn't exist", vault.CASecretName)
+		}
+		caCert = caSecret.Data[vaultCACertificateKey]
+	}
+
+	jwt, err := ioutil.ReadFile(vaultServiceAccountTokenPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the service account token")
+	}
+
+	client, err := NewVaultClient(vault.Address, caCert)
+	if err != nil {
+		return nil, err
+	}
+	err = client.LoginKubernetes(vaultMount(vault.AuthMount, model.VaultDefaultAuthMount), vault.AuthRole, strings.TrimSpace(string(jwt)))
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func vaultMount(mount, defaultMount string) string {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return defaultMount
+	}
+	return mount
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultClient_ReadDatabaseCredentials(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var login map[string]string
+			assert.NoError(t, json.NewDecoder(req.Body).Decode(&login))
+			assert.Equal(t, "keycloak-operator", login["role"])
+			assert.Equal(t, "service-account-token", login["jwt"])
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "vault-token"}}`))
+		case "/v1/database/creds/keycloak":
+			assert.Equal(t, "vault-token", req.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"lease_id": "database/creds/keycloak/abc", "lease_duration": 3600, "data": {"username": "v-keycloak-abc", "password": "secret"}}`))
+		default:
+			w.WriteHeader(404)
+		}
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &VaultClient{
+		requester: server.Client(),
+		address:   server.URL,
+	}
+
+	// when
+	err := client.LoginKubernetes(vaultMount("", "kubernetes"), "keycloak-operator", "service-account-token")
+	credentials, credentialsErr := client.ReadDatabaseCredentials(vaultMount("/database/", "database"), "keycloak")
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, credentialsErr)
+	assert.Equal(t, &VaultDatabaseCredentials{
+		Username:      "v-keycloak-abc",
+		Password:      "secret",
+		LeaseID:       "database/creds/keycloak/abc",
+		LeaseDuration: time.Hour,
+	}, credentials)
+}
+
+func TestVaultClient_LoginDenied(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(403)
+		_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &VaultClient{
+		requester: server.Client(),
+		address:   server.URL,
+	}
+
+	// when
+	err := client.LoginKubernetes("kubernetes", "keycloak-operator", "service-account-token")
+
+	// then
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+	assert.Equal(t, "", client.token)
+}
+
+func TestVaultClient_RevokeLease(t *testing.T) {
+	// given
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var revoke map[string]string
+		assert.Equal(t, "/v1/sys/leases/revoke", req.URL.Path)
+		assert.Equal(t, "PUT", req.Method)
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&revoke))
+		assert.Equal(t, "database/creds/keycloak/abc", revoke["lease_id"])
+		w.WriteHeader(204)
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := &VaultClient{
+		requester: server.Client(),
+		address:   server.URL,
+		token:     "vault-token",
+	}
+
+	// when
+	err := client.RevokeLease("database/creds/keycloak/abc")
+
+	// then
+	assert.NoError(t, err)
+}
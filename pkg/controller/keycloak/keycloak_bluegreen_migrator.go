@@ -0,0 +1,348 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	v13 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultRollbackWindowSeconds = 3600
+	blueGreenSmokeCheckTimeout   = 30 * time.Second
+)
+
+var errNoDatabaseSecret = errors.New("the database secret is required to promote the cloned database")
+
+// BlueGreenMigrator upgrades a copy of Keycloak running against a clone of the database restored from a fresh
+// backup, while the previous pods keep serving until the copy passed the smoke checks. The smoke checks only run
+// until the switch.
+type BlueGreenMigrator struct {
+	context         context.Context
+	keycloakFactory common.BlueGreenClientFactory
+}
+
+func NewBlueGreenMigrator(context context.Context, keycloakFactory common.BlueGreenClientFactory) *BlueGreenMigrator {
+	return &BlueGreenMigrator{
+		context:         context,
+		keycloakFactory: keycloakFactory,
+	}
+}
+
+func (i *BlueGreenMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	deployment, _ := findDeployment(&desiredState)
+	if needsStatefulSetRecreation(currentState, deployment) {
+		return nil, errSelectorCantBeMigrated
+	}
+	pinMigrationImage(cr, deployment)
+
+	if blueGreenState, switching, err := i.switchBlueGreen(cr, currentState, desiredState, deployment); switching {
+		return blueGreenState, err
+	}
+
+	if deployment != nil && needsImageMigration(cr, currentState) {
+		startMigration(cr, currentState)
+		deployment.Spec.Template.Spec.Containers[0].Image = cr.Status.Migration.FromImage
+
+		// The clone is restored from a backup, it's taken whether or not backups are enabled
+		backupAction, done, err := migrationBackup(cr, cr.Status.Migration, currentState.KeycloakBackup)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			return desiredState.AddAction(backupAction), nil
+		}
+
+		// The database is tracked in the status before it's cloned, the operator only drops the databases it tracks
+		migration := cr.Status.Migration
+		if migration.Database == "" {
+			migration.Database = model.ApplicationName + "_" + time.Now().UTC().Format("20060102150405")
+			cr.Status.ClonedDatabases = append(cr.Status.ClonedDatabases, migration.Database)
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseCloning, fmt.Sprintf("restoring backup %v into database %v", migration.Backup, migration.Database))
+			return desiredState, nil
+		}
+		setMigrationPhase(cr, v1alpha1.MigrationPhaseCloning, fmt.Sprintf("restoring backup %v into database %v", migration.Backup, migration.Database))
+		blueGreenState, _, err := i.switchBlueGreen(cr, currentState, desiredState, deployment)
+		return blueGreenState, err
+	}
+
+	desiredState = removeBlueGreenStack(currentState, desiredState)
+	return dropClonedDatabases(cr, currentState, desiredState), nil
+}
+
+// switchBlueGreen drives an ongoing bluegreen migration. The previous pods keep running the previous image
+// against the previous database until the rollback window passed. Returns true while the migration is handled by it.
+func (i *BlueGreenMigrator) switchBlueGreen(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) (common.DesiredClusterState, bool, error) {
+	migration := cr.Status.Migration
+	if migration == nil || deployment == nil || currentState.KeycloakDeployment == nil {
+		return desiredState, false, nil
+	}
+
+	switch migration.Phase {
+	case v1alpha1.MigrationPhaseCloning:
+		deployment.Spec.Template.Spec.Containers[0].Image = migration.FromImage
+		cloneJob := currentState.KeycloakDatabaseCloneJob
+		switch {
+		case cloneJob == nil:
+			return desiredState.AddAction(common.GenericCreateAction{
+				Ref: model.KeycloakDatabaseClone(cr, migration.Backup, migration.Database),
+				Msg: "Create Keycloak database clone job",
+			}), true, nil
+		case isJobFailed(cloneJob):
+			return abortBlueGreen(cr, currentState, desiredState, fmt.Sprintf("restoring backup %v into database %v failed", migration.Backup, migration.Database)), true, nil
+		case cloneJob.Status.Succeeded == 0:
+			return desiredState, true, nil
+		}
+		migration.UpgradeTime = &[]metav1.Time{metav1.Now()}[0]
+		setMigrationPhase(cr, v1alpha1.MigrationPhaseDeploying, fmt.Sprintf("starting %v against database %v", migration.ToImage, migration.Database))
+		fallthrough
+	case v1alpha1.MigrationPhaseDeploying:
+		deployment.Spec.Template.Spec.Containers[0].Image = migration.FromImage
+		desiredState = addBlueGreenStack(cr, currentState, desiredState, deployment)
+
+		green := currentState.KeycloakBlueGreenDeployment
+		if green != nil && isRolloutReady(green, migration.ToImage) {
+			err := i.smokeCheck(cr)
+			if err == nil {
+				migration.SwitchTime = &[]metav1.Time{metav1.Now()}[0]
+				setMigrationPhase(cr, v1alpha1.MigrationPhaseSwitched, fmt.Sprintf("switched the Keycloak Service to %v", migration.ToImage))
+				setKeycloakServiceComponent(desiredState, model.KeycloakBlueGreenComponent)
+				return desiredState, true, nil
+			}
+			log.Info(fmt.Sprintf("smoke checks of %v failed: %v", migration.ToImage, err))
+		}
+
+		deadline := time.Duration(defaultRollbackDeadlineSeconds) * time.Second
+		if cr.Spec.Migration.RollbackDeadlineSeconds != nil {
+			deadline = time.Duration(*cr.Spec.Migration.RollbackDeadlineSeconds) * time.Second
+		}
+		if migration.UpgradeTime != nil && time.Since(migration.UpgradeTime.Time) >= deadline {
+			return abortBlueGreen(cr, currentState, desiredState, fmt.Sprintf("pods of %v didn't pass the smoke checks within %v", migration.ToImage, deadline)), true, nil
+		}
+		return desiredState, true, nil
+	case v1alpha1.MigrationPhaseSwitched:
+		// Switching back loses the writes made since the switch, so the smoke checks aren't repeated and only
+		// crash looping pods or a request roll back
+		deployment.Spec.Template.Spec.Containers[0].Image = migration.FromImage
+		switch {
+		case cr.Annotations[model.KeycloakMigrationRollbackAnnotation] == "true":
+			return abortBlueGreen(cr, currentState, desiredState, "rollback requested"), true, nil
+		case isCrashLooping(currentState.KeycloakBlueGreenPods, migration.ToImage):
+			return abortBlueGreen(cr, currentState, desiredState, fmt.Sprintf("pods of %v are crash looping", migration.ToImage)), true, nil
+		}
+		window := time.Duration(defaultRollbackWindowSeconds) * time.Second
+		if cr.Spec.Migration.BlueGreen.RollbackWindowSeconds != nil {
+			window = time.Duration(*cr.Spec.Migration.BlueGreen.RollbackWindowSeconds) * time.Second
+		}
+		if migration.SwitchTime != nil && time.Since(migration.SwitchTime.Time) < window {
+			desiredState = addBlueGreenStack(cr, currentState, desiredState, deployment)
+			setKeycloakServiceComponent(desiredState, model.KeycloakBlueGreenComponent)
+			return desiredState, true, nil
+		}
+		setMigrationPhase(cr, v1alpha1.MigrationPhasePromoting, fmt.Sprintf("upgrading the previous pods onto database %v", migration.Database))
+		fallthrough
+	case v1alpha1.MigrationPhasePromoting:
+		// The upgraded pods keep serving until the previous ones have been upgraded onto the cloned database
+		if currentState.DatabaseSecret == nil {
+			return nil, true, errNoDatabaseSecret
+		}
+		deployment.Spec.Template.Spec.Containers[0].Image = migration.ToImage
+		desiredState = addBlueGreenStack(cr, currentState, desiredState, deployment)
+		setDatabaseName(deployment, migration.Database)
+		databaseSecret := currentState.DatabaseSecret.DeepCopy()
+		databaseSecret.Data[model.DatabaseSecretDatabaseProperty] = []byte(migration.Database)
+		desiredState = desiredState.AddAction(common.GenericUpdateAction{
+			Ref: databaseSecret,
+			Msg: "Update database name of the database secret",
+		})
+
+		if !isRolloutReady(currentState.KeycloakDeployment, migration.ToImage) || databaseName(currentState.KeycloakDeployment) != migration.Database {
+			setKeycloakServiceComponent(desiredState, model.KeycloakBlueGreenComponent)
+			return desiredState, true, nil
+		}
+		setMigrationPhase(cr, v1alpha1.MigrationPhaseSucceeded, fmt.Sprintf("upgraded to %v on database %v", migration.ToImage, migration.Database))
+		return removeBlueGreenStack(currentState, desiredState), true, nil
+	}
+	return desiredState, false, nil
+}
+
+// abortBlueGreen switches back to the previous pods, which are still running against the previous database. From
+// now on the previous image is pinned by migrationTargetImage.
+func abortBlueGreen(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState, reason string) common.DesiredClusterState {
+	setMigrationPhase(cr, v1alpha1.MigrationPhaseRolledBack, fmt.Sprintf("%v, rolled back to %v", reason, cr.Status.Migration.FromImage))
+	return removeBlueGreenStack(currentState, desiredState)
+}
+
+// dropClonedDatabases drops the cloned databases nothing runs against anymore, one at a time. The clone of a
+// migration rolled back after the switch holds the writes made since then, it's kept until the next migration
+// finished.
+func dropClonedDatabases(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	if currentState.DatabaseSecret == nil {
+		return desiredState
+	}
+
+	migration := cr.Status.Migration
+	for _, database := range cr.Status.ClonedDatabases {
+		if database == model.GetExternalDatabaseName(currentState.DatabaseSecret) {
+			continue
+		}
+		if migration != nil && database == migration.Database && (!isMigrationFinished(migration) || migration.SwitchTime != nil) {
+			continue
+		}
+
+		dropJob := findDatabaseDropJob(currentState, model.KeycloakDatabaseDropSelector(cr, database).Name)
+		switch {
+		case dropJob == nil:
+			return desiredState.AddAction(common.GenericCreateAction{
+				Ref: model.KeycloakDatabaseDrop(cr, database),
+				Msg: "Create Keycloak database drop job",
+			})
+		case dropJob.Status.Succeeded > 0:
+			cr.Status.ClonedDatabases = removeClonedDatabase(cr.Status.ClonedDatabases, database)
+			return desiredState
+		case isJobFailed(dropJob):
+			log.Info(fmt.Sprintf("dropping the cloned database %v failed, delete job %v to retry", database, dropJob.Name))
+		default:
+			return desiredState
+		}
+	}
+	return desiredState
+}
+
+func findDatabaseDropJob(currentState *common.ClusterState, name string) *batchv1.Job {
+	if currentState.KeycloakDatabaseDropJobs == nil {
+		return nil
+	}
+	for index := range currentState.KeycloakDatabaseDropJobs.Items {
+		if currentState.KeycloakDatabaseDropJobs.Items[index].Name == name {
+			return &currentState.KeycloakDatabaseDropJobs.Items[index]
+		}
+	}
+	return nil
+}
+
+func removeClonedDatabase(databases []string, database string) []string {
+	var remaining []string
+	for _, candidate := range databases {
+		if candidate != database {
+			remaining = append(remaining, candidate)
+		}
+	}
+	return remaining
+}
+
+func addBlueGreenStack(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) common.DesiredClusterState {
+	migration := cr.Status.Migration
+	if currentState.KeycloakBlueGreenService == nil {
+		desiredState = desiredState.AddAction(common.GenericCreateAction{
+			Ref: model.KeycloakBlueGreenService(cr),
+			Msg: "Create Keycloak bluegreen Service",
+		})
+	}
+
+	green := model.KeycloakBlueGreenDeployment(cr, deployment, migration.ToImage, migration.Database)
+	if currentState.KeycloakBlueGreenDeployment == nil {
+		return desiredState.AddAction(common.GenericCreateAction{
+			Ref: green,
+			Msg: "Create Keycloak bluegreen Deployment (StatefulSet)",
+		})
+	}
+	return desiredState.AddAction(common.GenericUpdateAction{
+		Ref: model.KeycloakBlueGreenDeploymentReconciled(green, currentState.KeycloakBlueGreenDeployment),
+		Msg: "Update Keycloak bluegreen Deployment (StatefulSet)",
+	})
+}
+
+// removeBlueGreenStack switches the Keycloak Service back to the Keycloak StatefulSet and removes what's left
+// of the upgraded stack
+func removeBlueGreenStack(currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	setKeycloakServiceComponent(desiredState, model.KeycloakDeploymentComponent)
+	if currentState.KeycloakBlueGreenDeployment != nil {
+		desiredState = desiredState.AddAction(common.GenericDeleteAction{
+			Ref: currentState.KeycloakBlueGreenDeployment,
+			Msg: "Delete Keycloak bluegreen Deployment (StatefulSet)",
+		})
+	}
+	if currentState.KeycloakBlueGreenService != nil {
+		desiredState = desiredState.AddAction(common.GenericDeleteAction{
+			Ref: currentState.KeycloakBlueGreenService,
+			Msg: "Delete Keycloak bluegreen Service",
+		})
+	}
+	return desiredState
+}
+
+// setKeycloakServiceComponent selects the pods the Keycloak Service, and with it the Ingress or Route, routes to
+func setKeycloakServiceComponent(desiredState common.DesiredClusterState, component string) {
+	if service := findService(&desiredState); service != nil {
+		service.Spec.Selector["component"] = component
+	}
+}
+
+func findService(desiredState *common.DesiredClusterState) *v1.Service {
+	for _, v := range *desiredState {
+		if (reflect.TypeOf(v) == reflect.TypeOf(common.GenericUpdateAction{})) {
+			updateAction := v.(common.GenericUpdateAction)
+			if (reflect.TypeOf(updateAction.Ref) == reflect.TypeOf(&v1.Service{})) {
+				service := updateAction.Ref.(*v1.Service)
+				if service.ObjectMeta.Name == model.ApplicationName && service.Spec.Selector != nil {
+					return service
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func setDatabaseName(deployment *v13.StatefulSet, database string) {
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	for i := range env {
+		if env[i].Name == "DB_DATABASE" {
+			env[i].Value = database
+		}
+	}
+}
+
+func databaseName(deployment *v13.StatefulSet) string {
+	for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "DB_DATABASE" {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+// smokeCheck logs into the upgraded pods and reads the realm the operator authenticates in
+func (i *BlueGreenMigrator) smokeCheck(cr *v1alpha1.Keycloak) error {
+	ctx, cancel := context.WithTimeout(i.context, blueGreenSmokeCheckTimeout)
+	defer cancel()
+
+	authenticated, err := i.keycloakFactory.BlueGreenClient(*cr)
+	if err != nil {
+		return err
+	}
+	if err := authenticated.Ping(ctx); err != nil {
+		return err
+	}
+	realmName := model.OperatorAuthenticationDefaultRealm
+	if cr.Spec.OperatorAuthentication != nil && cr.Spec.OperatorAuthentication.Realm != "" {
+		realmName = cr.Spec.OperatorAuthentication.Realm
+	}
+	realm, err := authenticated.GetRealm(ctx, realmName)
+	if err != nil {
+		return err
+	}
+	if realm == nil {
+		return fmt.Errorf("%v realm not found", realmName)
+	}
+	return nil
+}
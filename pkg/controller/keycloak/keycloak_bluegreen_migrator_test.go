@@ -0,0 +1,355 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKeycloakMigration_Test_BlueGreen_Clones_Database_From_Fresh_Backup_And_Keeps_Previous_Image(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyBlueGreen
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "old_image")
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	// when
+	_, backupNamingErr := migrator.Migrate(cr, &currentState, blueGreenDesiredState(cr))
+	backupActions, backupErr := migrator.Migrate(cr, &currentState, blueGreenDesiredState(cr))
+	backingUpPhase := cr.Status.Migration.Phase
+	backup := backupActions[2].(common.GenericCreateAction).Ref.(*v1alpha1.KeycloakBackup).DeepCopy()
+	backup.Status.Phase = v1alpha1.BackupPhaseCreated
+	currentState.KeycloakBackup = backup
+	namingActions, namingErr := migrator.Migrate(cr, &currentState, blueGreenDesiredState(cr))
+	migratedActions, err := migrator.Migrate(cr, &currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Nil(t, backupNamingErr)
+	assert.Nil(t, backupErr)
+	assert.Equal(t, v1alpha1.MigrationPhaseBackingUp, backingUpPhase)
+	assert.Equal(t, cr.Status.Migration.Backup, backup.Name)
+	assert.Nil(t, namingErr)
+	assert.Len(t, namingActions, 2)
+	assert.Equal(t, []string{cr.Status.Migration.Database}, cr.Status.ClonedDatabases)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseCloning, cr.Status.Migration.Phase)
+	assert.Equal(t, "old_image", blueGreenDeployment(migratedActions).Spec.Template.Spec.Containers[0].Image)
+	assert.Len(t, migratedActions, 3)
+	cloneJob := migratedActions[2].(common.GenericCreateAction).Ref.(*batchv1.Job)
+	assert.Equal(t, model.KeycloakDatabaseCloneSelector(cr, cr.Status.Migration.Database).Name, cloneJob.Name)
+	assert.Contains(t, cloneJob.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "TARGET_DATABASE", Value: cr.Status.Migration.Database})
+	assert.Equal(t, model.PostgresqlBackupPersistentVolumeName+"-"+backup.Name, cloneJob.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Fails_Without_Backup(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseBackingUp)
+	cr.Status.Migration.Database = ""
+	migrator := NewBlueGreenMigrator(context.TODO(), nil)
+
+	currentState := blueGreenCurrentState(cr, 3)
+	currentState.KeycloakDatabaseCloneJob = nil
+	currentState.KeycloakBackup = &v1alpha1.KeycloakBackup{}
+	currentState.KeycloakBackup.Name = cr.Status.Migration.Backup
+	currentState.KeycloakBackup.Status.Phase = v1alpha1.BackupPhaseFailing
+
+	// when
+	_, err := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Equal(t, errBackup, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseFailed, cr.Status.Migration.Phase)
+	assert.Empty(t, cr.Status.ClonedDatabases)
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Switches_Service_After_Smoke_Checks(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseDeploying)
+	keycloakFactory := &blueGreenKeycloakFactory{realm: model.OperatorAuthenticationDefaultRealm}
+	migrator := NewBlueGreenMigrator(context.TODO(), keycloakFactory)
+
+	currentState := blueGreenCurrentState(cr, 3)
+	desiredState := blueGreenDesiredState(cr)
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, keycloakFactory.logins)
+	assert.True(t, keycloakFactory.deadline)
+	assert.Equal(t, v1alpha1.MigrationPhaseSwitched, cr.Status.Migration.Phase)
+	assert.NotNil(t, cr.Status.Migration.SwitchTime)
+	assert.Equal(t, model.KeycloakBlueGreenComponent, migratedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+	assert.Equal(t, "old_image", blueGreenDeployment(migratedActions).Spec.Template.Spec.Containers[0].Image)
+
+	green := migratedActions[2].(common.GenericUpdateAction).Ref.(*v1.StatefulSet)
+	assert.Equal(t, model.KeycloakBlueGreenDeploymentName, green.Name)
+	assert.Equal(t, cr.Status.Migration.ToImage, green.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, cr.Status.Migration.Database, databaseName(green))
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Rolls_Back_Failing_Smoke_Checks_After_Deadline(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseDeploying)
+	migrator := NewBlueGreenMigrator(context.TODO(), &blueGreenKeycloakFactory{realm: "other"})
+
+	currentState := blueGreenCurrentState(cr, 3)
+
+	// when
+	cr.Spec.Migration.RollbackDeadlineSeconds = &[]int32{3600}[0]
+	_, waitingErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+	waitingPhase := cr.Status.Migration.Phase
+	cr.Spec.Migration.RollbackDeadlineSeconds = &[]int32{60}[0]
+	migratedActions, err := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Nil(t, waitingErr)
+	assert.Equal(t, v1alpha1.MigrationPhaseDeploying, waitingPhase)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseRolledBack, cr.Status.Migration.Phase)
+	assert.Equal(t, model.KeycloakDeploymentComponent, migratedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Keeps_Switched_Pods_Without_Repeating_Smoke_Checks(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseSwitched)
+	keycloakFactory := &blueGreenKeycloakFactory{err: errors.New("connection refused")}
+	migrator := NewBlueGreenMigrator(context.TODO(), keycloakFactory)
+
+	currentState := blueGreenCurrentState(cr, 3)
+	desiredState := blueGreenDesiredState(cr)
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 0, keycloakFactory.logins)
+	assert.Equal(t, v1alpha1.MigrationPhaseSwitched, cr.Status.Migration.Phase)
+	assert.Equal(t, model.KeycloakBlueGreenComponent, migratedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Rolls_Back_On_Request(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseSwitched)
+	cr.Annotations = map[string]string{model.KeycloakMigrationRollbackAnnotation: "true"}
+	migrator := NewBlueGreenMigrator(context.TODO(), &blueGreenKeycloakFactory{realm: model.OperatorAuthenticationDefaultRealm})
+
+	currentState := blueGreenCurrentState(cr, 3)
+	desiredState := blueGreenDesiredState(cr)
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseRolledBack, cr.Status.Migration.Phase)
+	assert.Equal(t, model.KeycloakDeploymentComponent, migratedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+	assert.Equal(t, "old_image", blueGreenDeployment(migratedActions).Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, model.PostgresqlDatabase, databaseName(blueGreenDeployment(migratedActions)))
+
+	var deleted []string
+	for _, action := range migratedActions {
+		if deleteAction, ok := action.(common.GenericDeleteAction); ok {
+			deleted = append(deleted, deleteAction.Ref.(metav1.Object).GetName())
+		}
+	}
+	assert.Equal(t, []string{model.KeycloakBlueGreenDeploymentName, model.KeycloakBlueGreenDeploymentName}, deleted)
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Keeps_Previous_Pods_Within_Rollback_Window(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseSwitched)
+	migrator := NewBlueGreenMigrator(context.TODO(), &blueGreenKeycloakFactory{realm: model.OperatorAuthenticationDefaultRealm})
+
+	currentState := blueGreenCurrentState(cr, 3)
+	desiredState := blueGreenDesiredState(cr)
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseSwitched, cr.Status.Migration.Phase)
+	assert.Equal(t, model.KeycloakBlueGreenComponent, migratedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+	assert.Equal(t, "old_image", blueGreenDeployment(migratedActions).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Promotes_Cloned_Database_After_Rollback_Window(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseSwitched)
+	cr.Spec.Migration.BlueGreen.RollbackWindowSeconds = &[]int32{60}[0]
+	migrator := NewBlueGreenMigrator(context.TODO(), &blueGreenKeycloakFactory{realm: model.OperatorAuthenticationDefaultRealm})
+
+	currentState := blueGreenCurrentState(cr, 3)
+
+	// when
+	promotingActions, promotingErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+	promotingPhase := cr.Status.Migration.Phase
+	currentState.KeycloakDeployment = blueGreenDeployment(promotingActions).DeepCopy()
+	SetDeployment(currentState.KeycloakDeployment, 3, "")
+	currentState.KeycloakDeployment.Status.UpdatedReplicas = 3
+	currentState.KeycloakDeployment.Status.ReadyReplicas = 3
+	promotedActions, promotedErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Nil(t, promotingErr)
+	assert.Equal(t, v1alpha1.MigrationPhasePromoting, promotingPhase)
+	assert.Equal(t, model.KeycloakBlueGreenComponent, promotingActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+	assert.Equal(t, cr.Status.Migration.ToImage, blueGreenDeployment(promotingActions).Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, cr.Status.Migration.Database, databaseName(blueGreenDeployment(promotingActions)))
+	databaseSecret := promotingActions[len(promotingActions)-1].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.Equal(t, cr.Status.Migration.Database, model.GetExternalDatabaseName(databaseSecret))
+
+	assert.Nil(t, promotedErr)
+	assert.Equal(t, v1alpha1.MigrationPhaseSucceeded, cr.Status.Migration.Phase)
+	assert.Equal(t, model.KeycloakDeploymentComponent, promotedActions[0].(common.GenericUpdateAction).Ref.(*corev1.Service).Spec.Selector["component"])
+	assert.IsType(t, common.GenericDeleteAction{}, promotedActions[len(promotedActions)-1])
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Drops_Superseded_Databases(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseSucceeded)
+	cr.Status.ClonedDatabases = []string{"keycloak_20230101000000", cr.Status.Migration.Database}
+	migrator := NewBlueGreenMigrator(context.TODO(), nil)
+
+	currentState := blueGreenCurrentState(cr, 3)
+	currentState.DatabaseSecret.Data[model.DatabaseSecretDatabaseProperty] = []byte(cr.Status.Migration.Database)
+	SetDeployment(currentState.KeycloakDeployment, 3, cr.Status.Migration.ToImage)
+
+	// when
+	droppingActions, droppingErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+	dropJob := droppingActions[len(droppingActions)-1].(common.GenericCreateAction).Ref.(*batchv1.Job).DeepCopy()
+	dropJob.Status.Succeeded = 1
+	currentState.KeycloakDatabaseDropJobs = &batchv1.JobList{Items: []batchv1.Job{*dropJob}}
+	_, droppedErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Nil(t, droppingErr)
+	assert.Equal(t, model.KeycloakDatabaseDropSelector(cr, "keycloak_20230101000000").Name, dropJob.Name)
+	assert.Nil(t, droppedErr)
+	assert.Equal(t, []string{cr.Status.Migration.Database}, cr.Status.ClonedDatabases)
+}
+
+func TestKeycloakMigration_Test_BlueGreen_Keeps_Database_Rolled_Back_After_Switch(t *testing.T) {
+	// given
+	cr := blueGreenKeycloak(v1alpha1.MigrationPhaseRolledBack)
+	cr.Status.ClonedDatabases = []string{cr.Status.Migration.Database}
+	migrator := NewBlueGreenMigrator(context.TODO(), nil)
+
+	currentState := blueGreenCurrentState(cr, 3)
+
+	// when
+	keptActions, keptErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+	cr.Status.Migration.SwitchTime = nil
+	droppingActions, droppingErr := migrator.Migrate(cr, currentState, blueGreenDesiredState(cr))
+
+	// then
+	assert.Nil(t, keptErr)
+	for _, action := range keptActions {
+		assert.NotEqual(t, reflect.TypeOf(common.GenericCreateAction{}), reflect.TypeOf(action))
+	}
+	assert.Nil(t, droppingErr)
+	assert.IsType(t, &batchv1.Job{}, droppingActions[len(droppingActions)-1].(common.GenericCreateAction).Ref)
+}
+
+func blueGreenKeycloak(phase v1alpha1.MigrationPhase) *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyBlueGreen
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		Phase:       phase,
+		FromImage:   "old_image",
+		ToImage:     model.Profiles.GetKeycloakOrRHSSOImage(cr),
+		Backup:      "migrate-backup",
+		Database:    "keycloak_20240101000000",
+		UpgradeTime: &metav1.Time{Time: time.Now().Add(-10 * time.Minute)},
+		SwitchTime:  &metav1.Time{Time: time.Now().Add(-5 * time.Minute)},
+	}
+	return cr
+}
+
+// blueGreenCurrentState returns the previous pods running against the previous database and ready upgraded pods
+func blueGreenCurrentState(cr *v1alpha1.Keycloak, replicas int32) *common.ClusterState {
+	databaseSecret := model.DatabaseSecret(cr)
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, databaseSecret, nil)
+	SetDeployment(keycloakCurrentDeployment, replicas, cr.Status.Migration.FromImage)
+
+	green := model.KeycloakBlueGreenDeployment(cr, keycloakCurrentDeployment, cr.Status.Migration.ToImage, cr.Status.Migration.Database)
+	SetDeployment(green, replicas, "")
+	green.Status.UpdatedReplicas = replicas
+	green.Status.ReadyReplicas = replicas
+
+	return &common.ClusterState{
+		DatabaseSecret:              databaseSecret,
+		KeycloakDeployment:          keycloakCurrentDeployment,
+		KeycloakService:             model.KeycloakService(cr),
+		KeycloakBlueGreenDeployment: green,
+		KeycloakBlueGreenService:    model.KeycloakBlueGreenService(cr),
+		KeycloakDatabaseCloneJob:    &batchv1.Job{Status: batchv1.JobStatus{Succeeded: 1}},
+	}
+}
+
+func blueGreenDesiredState(cr *v1alpha1.Keycloak) common.DesiredClusterState {
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+	return common.DesiredClusterState{
+		common.GenericUpdateAction{Ref: model.KeycloakService(cr)},
+		common.GenericUpdateAction{Ref: keycloakDesiredDeployment},
+	}
+}
+
+func blueGreenDeployment(desiredState common.DesiredClusterState) *v1.StatefulSet {
+	deployment, _ := findDeployment(&desiredState)
+	return deployment
+}
+
+// blueGreenKeycloakFactory logs into upgraded pods serving the realm, or fails to log in with err
+type blueGreenKeycloakFactory struct {
+	realm    string
+	err      error
+	logins   int
+	deadline bool
+}
+
+func (i *blueGreenKeycloakFactory) BlueGreenClient(kc v1alpha1.Keycloak) (common.KeycloakInterface, error) {
+	i.logins++
+	if i.err != nil {
+		return nil, i.err
+	}
+	return &blueGreenKeycloakClient{factory: i}, nil
+}
+
+type blueGreenKeycloakClient struct {
+	common.KeycloakInterface
+	factory *blueGreenKeycloakFactory
+}
+
+func (i *blueGreenKeycloakClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (i *blueGreenKeycloakClient) GetRealm(ctx context.Context, realmName string) (*v1alpha1.KeycloakRealm, error) {
+	_, i.factory.deadline = ctx.Deadline()
+	if realmName != i.factory.realm {
+		return nil, nil
+	}
+	return &v1alpha1.KeycloakRealm{Spec: v1alpha1.KeycloakRealmSpec{Realm: &v1alpha1.KeycloakAPIRealm{Realm: realmName}}}, nil
+}
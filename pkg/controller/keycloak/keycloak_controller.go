@@ -18,6 +18,7 @@ import (
 	grafanav1alpha1 "github.com/integr8ly/grafana-operator/v3/pkg/apis/integreatly/v1alpha1"
 	routev1 "github.com/openshift/api/route/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
@@ -104,6 +105,10 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	if err := common.WatchSecondaryResource(c, ControllerName, common.JobKind, &batchv1.Job{}, &kc.Keycloak{}); err != nil {
+		return err
+	}
+
 	if err := common.WatchSecondaryResource(c, ControllerName, common.PodDisruptionBudgetKind, &v1beta12.PodDisruptionBudget{}, &kc.Keycloak{}); err != nil {
 		return err
 	}
@@ -144,6 +149,7 @@ type ReconcileKeycloak struct {
 func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling Keycloak")
+	defer common.ObserveReconcile(common.KeycloakKind, request.NamespacedName, time.Now())
 
 	// Fetch the Keycloak instance
 	instance := &keycloakv1alpha1.Keycloak{}
@@ -153,6 +159,9 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 		if kubeerrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// The cached admin client of the instance isn't needed anymore.
+			common.DefaultKeycloakClientCache.Invalidate(request.NamespacedName)
+			common.ClearResourceFailing(common.KeycloakKind, request.Namespace, request.Name)
 			// Return and don't requeue
 			return reconcile.Result{}, nil
 		}
@@ -176,25 +185,107 @@ func (r *ReconcileKeycloak) Reconcile(request reconcile.Request) (reconcile.Resu
 		}
 	}
 
+	err = model.ValidateKeycloakExtensions(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakThemes(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakClustering(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakMonitoring(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakCrossSite(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+	if instance.Spec.CrossSite.Enabled && instance.Spec.CrossSite.InfinispanCluster != "" {
+		cacheKindExists, _ := common.GetStateManager().GetState(common.InfinispanCacheKind).(bool)
+		if !cacheKindExists {
+			return r.ManageError(instance, errors.Errorf("crossSite.infinispanCluster is set but the Infinispan Operator Cache resource is not available on the cluster"))
+		}
+	}
+
+	if instance.Spec.CertManager.Enabled {
+		certificateKindExists, _ := common.GetStateManager().GetState(common.CertificateKind).(bool)
+		if !certificateKindExists {
+			return r.ManageError(instance, errors.Errorf("certManager is enabled but the cert-manager Certificate resource is not available on the cluster"))
+		}
+		if instance.Spec.CertManager.IssuerRef.Name == "" {
+			return r.ManageError(instance, errors.Errorf("certManager.issuerRef.name needs to be set when certManager is enabled"))
+		}
+	}
+
+	err = model.ValidateDatabaseVendor(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateDatabaseCredentials(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	if !instance.Spec.ExternalDatabase.Enabled && instance.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+		_, err = common.PostgresqlClusterProvider(instance)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+	}
+
 	// Read current state
 	err = currentState.Read(r.context, instance, r.client)
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
 
+	// The data of the single replica database isn't moved into the cluster
+	if !instance.Spec.ExternalDatabase.Enabled && instance.Spec.PostgresDeploymentSpec.HighAvailability.Enabled && currentState.PostgresqlDeployment != nil {
+		return r.ManageError(instance, errors.Errorf("postgresDeploymentSpec.highAvailability can't be enabled while the %v Deployment exists, back the database up, delete the Deployment and restore the backup once the cluster is ready", model.PostgresqlDeploymentName))
+	}
+
 	// Get Action to reconcile current state into desired state
 	reconciler := NewKeycloakReconciler()
 	desiredState := reconciler.Reconcile(currentState, instance)
 
-	// Perform migration if needed
-	migrator, err := GetMigrator(instance)
+	// Upgrade the embedded database first, Keycloak migrations wait for it
+	desiredState, upgrading, err := upgradeDatabase(instance, currentState, desiredState)
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
-	desiredState, err = migrator.Migrate(instance, currentState, desiredState)
+
+	// Perform migration if needed
+	migrator, err := GetMigrator(r.context, instance, common.NewCachedKeycloakFactory(r.context, r.client))
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
+	if !upgrading {
+		desiredState, err = migrator.Migrate(instance, currentState, desiredState)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+	}
+
+	// Rotate the database credentials, the pods are rolled once the secret is updated
+	desiredState = NewDatabaseCredentialsRotator().Rotate(instance, currentState, desiredState)
+
+	// Share the state of this site with the other sites, the Keycloak pods of a passive site are scaled down
+	if instance.Spec.CrossSite.Enabled {
+		desiredState, err = NewCrossSiteCoordinator().Coordinate(r.context, instance, currentState, desiredState)
+		if err != nil {
+			return r.ManageError(instance, err)
+		}
+	}
 
 	// Run the actions to reach the desired state
 	actionRunner := common.NewClusterActionRunner(r.context, r.client, r.scheme, instance)
@@ -212,6 +303,7 @@ func (r *ReconcileKeycloak) ManageError(instance *v1alpha1.Keycloak, issue error
 	instance.Status.Message = issue.Error()
 	instance.Status.Ready = false
 	instance.Status.Phase = v1alpha1.PhaseFailing
+	common.SetResourceFailing(common.KeycloakKind, instance.Namespace, instance.Name)
 
 	r.setVersion(instance)
 
@@ -235,6 +327,7 @@ func (r *ReconcileKeycloak) ManageSuccess(instance *v1alpha1.Keycloak, currentSt
 
 	instance.Status.Ready = resourcesReady
 	instance.Status.Message = ""
+	common.ClearResourceFailing(common.KeycloakKind, instance.Namespace, instance.Name)
 
 	// If resources are ready and we have not errored before now, we are in a reconciling phase
 	if resourcesReady {
@@ -242,6 +335,7 @@ func (r *ReconcileKeycloak) ManageSuccess(instance *v1alpha1.Keycloak, currentSt
 	} else {
 		instance.Status.Phase = v1alpha1.PhaseInitialising
 	}
+	instance.Status.Extensions = model.KeycloakLoadedExtensions(instance, currentState.KeycloakPods)
 
 	if currentState.KeycloakService != nil && currentState.KeycloakService.Spec.ClusterIP != "" {
 		instance.Status.InternalURL = fmt.Sprintf("https://%v.%v.svc:%v",
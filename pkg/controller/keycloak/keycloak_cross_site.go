@@ -0,0 +1,190 @@
+package keycloak
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CrossSiteCoordinator shares the state of this site with the operators of the other sites through the
+// keycloak-cross-site-<name> Secret. The Secret is read from and written into the clusters of the remote sites the
+// operator has a kubeconfig for, the operators of the other sites write it into the local namespace.
+type CrossSiteCoordinator struct {
+	remoteClient func(kubeconfig []byte) (client.Client, error)
+	now          func() time.Time
+}
+
+func NewCrossSiteCoordinator() *CrossSiteCoordinator {
+	return &CrossSiteCoordinator{
+		remoteClient: common.DefaultRemoteClusterClients.Client,
+		now:          time.Now,
+	}
+}
+
+// Coordinate merges the state of all sites, performs a requested failover and reports the state of this site.
+// The Keycloak pods of a passive site are scaled down. The state is only written if it changed, the heartbeat of
+// this site is refreshed by the periodic reconciliations once it's older than the heartbeat interval.
+func (i *CrossSiteCoordinator) Coordinate(ctx context.Context, cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
+	state, err := model.ParseCrossSiteState(currentState.KeycloakCrossSiteSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	unreachable := map[string]string{}
+	remoteClients := map[string]client.Client{}
+	remoteStates := map[string]*model.CrossSiteState{}
+	for _, site := range cr.Spec.CrossSite.RemoteSites {
+		remoteClient, remoteState, err := i.readRemoteState(ctx, cr, currentState, site)
+		if err != nil {
+			log.Info(fmt.Sprintf("Site %v is unreachable: %v", site.Name, err))
+			unreachable[site.Name] = err.Error()
+			continue
+		}
+		if remoteClient != nil {
+			remoteClients[site.Name] = remoteClient
+			remoteStates[site.Name] = remoteState
+			state.Merge(remoteState)
+		}
+	}
+
+	failoverRequest := cr.Annotations[model.KeycloakCrossSiteFailoverAnnotation]
+	if isCrossSiteFailoverRequested(cr) {
+		log.Info(fmt.Sprintf("Failing over from site %v to site %v", model.CrossSiteActiveSite(cr, state), failoverRequest))
+		state.ActiveSite = failoverRequest
+		state.Generation++
+	}
+
+	now := i.now()
+	passive := model.IsCrossSitePassive(cr, state)
+	previousState, reported := state.Sites[cr.Spec.CrossSite.Site]
+	ownState := model.CrossSiteSiteState{
+		Passive:          passive,
+		DatabaseEndpoint: model.CrossSiteDatabaseEndpoint(currentState.DatabaseSecret),
+		Heartbeat:        previousState.Heartbeat,
+	}
+	if currentState.KeycloakDeployment != nil {
+		ownState.ReadyReplicas = currentState.KeycloakDeployment.Status.ReadyReplicas
+	}
+	if !reported || ownState != previousState || now.Sub(previousState.Heartbeat.Time) >= model.KeycloakCrossSiteHeartbeatInterval {
+		ownState.Heartbeat = metav1.NewTime(now)
+	}
+	state.Sites[cr.Spec.CrossSite.Site] = ownState
+
+	for _, site := range cr.Spec.CrossSite.RemoteSites {
+		remoteClient, ok := remoteClients[site.Name]
+		if !ok || remoteStates[site.Name].Equal(state) {
+			continue
+		}
+		err = writeRemoteState(ctx, remoteClient, cr, site, state)
+		if err != nil {
+			log.Info(fmt.Sprintf("Site %v is unreachable: %v", site.Name, err))
+			unreachable[site.Name] = err.Error()
+		}
+	}
+
+	localState, err := model.ParseCrossSiteState(currentState.KeycloakCrossSiteSecret)
+	if err != nil {
+		return nil, err
+	}
+	if currentState.KeycloakCrossSiteSecret == nil {
+		desiredState = desiredState.AddAction(common.GenericCreateAction{
+			Ref: model.KeycloakCrossSiteSecret(cr, cr.Namespace, state),
+			Msg: "Create Keycloak Cross-Site Secret",
+		})
+	} else if !localState.Equal(state) {
+		desiredState = desiredState.AddAction(common.GenericUpdateAction{
+			Ref: model.KeycloakCrossSiteSecretReconciled(currentState.KeycloakCrossSiteSecret, state),
+			Msg: "Update Keycloak Cross-Site Secret",
+		})
+	}
+
+	if passive {
+		scaleDownPassiveSite(desiredState)
+	}
+
+	cr.Status.CrossSite = model.KeycloakCrossSiteStatus(cr, state, unreachable, now)
+	if failoverRequest != "" {
+		cr.Status.CrossSite.LastFailoverRequest = failoverRequest
+	}
+	return desiredState, nil
+}
+
+// readRemoteState returns the state stored in the cluster of a remote site. Without a kubeconfig for the site
+// there's neither a client nor a state, its operator writes the state into the local namespace.
+func (i *CrossSiteCoordinator) readRemoteState(ctx context.Context, cr *v1alpha1.Keycloak, currentState *common.ClusterState, site v1alpha1.KeycloakRemoteSite) (client.Client, *model.CrossSiteState, error) {
+	if site.KubeconfigSecret == "" {
+		return nil, nil, nil
+	}
+	kubeconfigSecret, ok := currentState.KeycloakCrossSiteKubeconfigs[site.Name]
+	if !ok {
+		return nil, nil, errors.Errorf("the kubeconfig secret %v of the site doesn't exist", site.KubeconfigSecret)
+	}
+	kubeconfig := kubeconfigSecret.Data[model.KeycloakCrossSiteKubeconfigProperty]
+	if len(kubeconfig) == 0 {
+		return nil, nil, errors.Errorf("the kubeconfig secret %v of the site has no %v key", site.KubeconfigSecret, model.KeycloakCrossSiteKubeconfigProperty)
+	}
+
+	remoteClient, err := i.remoteClient(kubeconfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret := &v1.Secret{}
+	err = remoteClient.Get(ctx, model.KeycloakCrossSiteSecretSelector(cr, model.KeycloakRemoteSiteNamespace(cr, site)), secret)
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return nil, nil, errors.Wrap(err, "error reading the cross-site state of the site")
+		}
+		secret = nil
+	}
+	state, err := model.ParseCrossSiteState(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	return remoteClient, state, nil
+}
+
+func writeRemoteState(ctx context.Context, remoteClient client.Client, cr *v1alpha1.Keycloak, site v1alpha1.KeycloakRemoteSite, state *model.CrossSiteState) error {
+	namespace := model.KeycloakRemoteSiteNamespace(cr, site)
+	secret := &v1.Secret{}
+	err := remoteClient.Get(ctx, model.KeycloakCrossSiteSecretSelector(cr, namespace), secret)
+	if apiErrors.IsNotFound(err) {
+		err = remoteClient.Create(ctx, model.KeycloakCrossSiteSecret(cr, namespace, state))
+	} else if err == nil {
+		err = remoteClient.Update(ctx, model.KeycloakCrossSiteSecretReconciled(secret, state))
+	}
+	return errors.Wrap(err, "error writing the cross-site state of the site")
+}
+
+// isCrossSiteFailoverRequested returns true if the annotation names a site that wasn't failed over to yet
+func isCrossSiteFailoverRequested(cr *v1alpha1.Keycloak) bool {
+	request := cr.Annotations[model.KeycloakCrossSiteFailoverAnnotation]
+	if request == "" {
+		return false
+	}
+	return cr.Status.CrossSite == nil || cr.Status.CrossSite.LastFailoverRequest != request
+}
+
+func scaleDownPassiveSite(desiredState common.DesiredClusterState) {
+	for _, v := range desiredState {
+		var ref interface{}
+		switch action := v.(type) {
+		case common.GenericCreateAction:
+			ref = action.Ref
+		case common.GenericUpdateAction:
+			ref = action.Ref
+		}
+		if statefulSet, ok := ref.(*v13.StatefulSet); ok && statefulSet.Name == model.KeycloakDeploymentName {
+			statefulSet.Spec.Replicas = &[]int32{0}[0]
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package keycloak
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	v13 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var crossSiteNow = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+func TestKeycloakCrossSite_Test_Shares_State_With_Remote_Site(t *testing.T) {
+	// given
+	cr := crossSiteKeycloak()
+	currentState := crossSiteCurrentState(cr)
+	remoteClient := fake.NewFakeClientWithScheme(scheme.Scheme)
+
+	// when
+	desiredState, err := crossSiteCoordinator(remoteClient).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+
+	// then
+	assert.Nil(t, err)
+	localSecret := desiredState[1].(common.GenericCreateAction).Ref.(*corev1.Secret)
+	localState, err := model.ParseCrossSiteState(localSecret)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), localState.Sites["site-a"].ReadyReplicas)
+	assert.Equal(t, "postgres.example.com:5432", localState.Sites["site-a"].DatabaseEndpoint)
+
+	remoteState := readCrossSiteState(t, remoteClient, "keycloak-b")
+	assert.Equal(t, localState.Sites["site-a"].ReadyReplicas, remoteState.Sites["site-a"].ReadyReplicas)
+
+	statefulSet := desiredState[0].(common.GenericUpdateAction).Ref.(*v13.StatefulSet)
+	assert.Equal(t, int32(2), *statefulSet.Spec.Replicas)
+	assert.Equal(t, "site-a", cr.Status.CrossSite.ActiveSite)
+	assert.Len(t, cr.Status.CrossSite.Sites, 2)
+	assert.True(t, cr.Status.CrossSite.Sites[0].Healthy)
+	assert.False(t, cr.Status.CrossSite.Sites[1].Healthy)
+}
+
+func TestKeycloakCrossSite_Test_Failover_Scales_Down_Passive_Site(t *testing.T) {
+	// given
+	cr := crossSiteKeycloak()
+	cr.Annotations = map[string]string{model.KeycloakCrossSiteFailoverAnnotation: "site-b"}
+	currentState := crossSiteCurrentState(cr)
+	remoteClient := fake.NewFakeClientWithScheme(scheme.Scheme, crossSiteSecret("keycloak-b", &model.CrossSiteState{
+		Generation: 1,
+		ActiveSite: "site-a",
+		Sites: map[string]model.CrossSiteSiteState{
+			"site-b": {DatabaseEndpoint: "postgres.example.com:5432", Passive: true, Heartbeat: metav1.NewTime(crossSiteNow)},
+		},
+	}))
+
+	// when
+	desiredState, err := crossSiteCoordinator(remoteClient).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+	_, errAfterFailover := crossSiteCoordinator(remoteClient).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+
+	// then
+	assert.Nil(t, err)
+	statefulSet := desiredState[0].(common.GenericUpdateAction).Ref.(*v13.StatefulSet)
+	assert.Equal(t, int32(0), *statefulSet.Spec.Replicas)
+	assert.Equal(t, "site-b", cr.Status.CrossSite.ActiveSite)
+	assert.Equal(t, "site-b", cr.Status.CrossSite.LastFailoverRequest)
+
+	assert.Nil(t, errAfterFailover)
+	remoteState := readCrossSiteState(t, remoteClient, "keycloak-b")
+	assert.Equal(t, "site-b", remoteState.ActiveSite)
+	assert.Equal(t, int64(2), remoteState.Generation)
+	assert.True(t, remoteState.Sites["site-a"].Passive)
+}
+
+func TestKeycloakCrossSite_Test_Follows_Failover_Of_Remote_Site(t *testing.T) {
+	// given
+	cr := crossSiteKeycloak()
+	cr.Spec.CrossSite.RemoteSites[0].KubeconfigSecret = ""
+	currentState := crossSiteCurrentState(cr)
+	currentState.KeycloakCrossSiteSecret = crossSiteSecret("keycloak", &model.CrossSiteState{
+		Generation: 3,
+		ActiveSite: "site-b",
+		Sites: map[string]model.CrossSiteSiteState{
+			"site-b": {ReadyReplicas: 2, DatabaseEndpoint: "postgres.example.com:5432", Heartbeat: metav1.NewTime(crossSiteNow)},
+		},
+	})
+
+	// when
+	desiredState, err := crossSiteCoordinator(nil).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+
+	// then
+	assert.Nil(t, err)
+	statefulSet := desiredState[0].(common.GenericUpdateAction).Ref.(*v13.StatefulSet)
+	assert.Equal(t, int32(0), *statefulSet.Spec.Replicas)
+	localState, err := model.ParseCrossSiteState(desiredState[1].(common.GenericUpdateAction).Ref.(*corev1.Secret))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), localState.Generation)
+	assert.True(t, localState.Sites["site-a"].Passive)
+	assert.True(t, cr.Status.CrossSite.Sites[1].Healthy)
+}
+
+func TestKeycloakCrossSite_Test_Only_Writes_Changed_State(t *testing.T) {
+	// given
+	cr := crossSiteKeycloak()
+	currentState := crossSiteCurrentState(cr)
+	remoteClient := fake.NewFakeClientWithScheme(scheme.Scheme)
+	desiredState, err := crossSiteCoordinator(remoteClient).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+	assert.Nil(t, err)
+	currentState.KeycloakCrossSiteSecret = desiredState[1].(common.GenericCreateAction).Ref.(*corev1.Secret)
+
+	// when
+	unchangedState, err := crossSiteCoordinator(remoteClient).Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+	coordinator := crossSiteCoordinator(remoteClient)
+	coordinator.now = func() time.Time {
+		return crossSiteNow.Add(model.KeycloakCrossSiteHeartbeatInterval)
+	}
+	heartbeatState, heartbeatErr := coordinator.Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, unchangedState, 1)
+	assert.Nil(t, heartbeatErr)
+	localState, err := model.ParseCrossSiteState(heartbeatState[1].(common.GenericUpdateAction).Ref.(*corev1.Secret))
+	assert.Nil(t, err)
+	assert.True(t, crossSiteNow.Add(model.KeycloakCrossSiteHeartbeatInterval).Equal(localState.Sites["site-a"].Heartbeat.Time))
+	remoteState := readCrossSiteState(t, remoteClient, "keycloak-b")
+	assert.True(t, crossSiteNow.Add(model.KeycloakCrossSiteHeartbeatInterval).Equal(remoteState.Sites["site-a"].Heartbeat.Time))
+}
+
+func TestKeycloakCrossSite_Test_Unreachable_Remote_Site(t *testing.T) {
+	// given
+	cr := crossSiteKeycloak()
+	currentState := crossSiteCurrentState(cr)
+	coordinator := &CrossSiteCoordinator{
+		remoteClient: func(kubeconfig []byte) (client.Client, error) {
+			return nil, errors.New("invalid kubeconfig")
+		},
+		now: func() time.Time {
+			return crossSiteNow
+		},
+	}
+
+	// when
+	desiredState, err := coordinator.Coordinate(context.TODO(), cr, currentState, crossSiteDesiredState(cr))
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, desiredState, 2)
+	assert.False(t, cr.Status.CrossSite.Sites[1].Healthy)
+	assert.Equal(t, "invalid kubeconfig", cr.Status.CrossSite.Sites[1].Message)
+}
+
+func crossSiteCoordinator(remoteClient client.Client) *CrossSiteCoordinator {
+	return &CrossSiteCoordinator{
+		remoteClient: func(kubeconfig []byte) (client.Client, error) {
+			return remoteClient, nil
+		},
+		now: func() time.Time {
+			return crossSiteNow
+		},
+	}
+}
+
+func crossSiteKeycloak() *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Name = "example-keycloak"
+	cr.Namespace = "keycloak"
+	cr.Spec.Instances = 2
+	cr.Spec.ExternalDatabase.Enabled = true
+	cr.Spec.Clustering.ExternalInfinispan = &v1alpha1.KeycloakExternalInfinispan{Host: "infinispan.keycloak.svc"}
+	cr.Spec.CrossSite = v1alpha1.KeycloakCrossSite{
+		Enabled: true,
+		Site:    "site-a",
+		RemoteSites: []v1alpha1.KeycloakRemoteSite{
+			{Name: "site-b", KubeconfigSecret: "site-b-kubeconfig", Namespace: "keycloak-b"},
+		},
+	}
+	return cr
+}
+
+func crossSiteCurrentState(cr *v1alpha1.Keycloak) *common.ClusterState {
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.Data[model.DatabaseSecretExternalAddressProperty] = []byte("postgres.example.com")
+	databaseSecret.Data[model.DatabaseSecretExternalPortProperty] = []byte("5432")
+	deployment := model.KeycloakDeployment(cr, databaseSecret, nil)
+	deployment.Status.ReadyReplicas = 2
+	return &common.ClusterState{
+		DatabaseSecret:     databaseSecret,
+		KeycloakDeployment: deployment,
+		KeycloakCrossSiteKubeconfigs: map[string]*corev1.Secret{
+			"site-b": {Data: map[string][]byte{model.KeycloakCrossSiteKubeconfigProperty: []byte("kubeconfig")}},
+		},
+	}
+}
+
+func crossSiteDesiredState(cr *v1alpha1.Keycloak) common.DesiredClusterState {
+	currentState := crossSiteCurrentState(cr)
+	return common.DesiredClusterState{
+		common.GenericUpdateAction{Ref: model.KeycloakDeploymentReconciled(cr, currentState.KeycloakDeployment, currentState.DatabaseSecret, nil)},
+	}
+}
+
+func crossSiteSecret(namespace string, state *model.CrossSiteState) *corev1.Secret {
+	return model.KeycloakCrossSiteSecret(crossSiteKeycloak(), namespace, state)
+}
+
+func readCrossSiteState(t *testing.T, remoteClient client.Client, namespace string) *model.CrossSiteState {
+	secret := &corev1.Secret{}
+	err := remoteClient.Get(context.TODO(), model.KeycloakCrossSiteSecretSelector(crossSiteKeycloak(), namespace), secret)
+	assert.Nil(t, err)
+	state, err := model.ParseCrossSiteState(secret)
+	assert.Nil(t, err)
+	return state
+}
@@ -0,0 +1,212 @@
+package keycloak
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseCredentialsRotator rotates the password of the embedded database, or issues the credentials of an
+// external database from Vault. The new credentials are written into the database secret, the pods are rolled
+// through the checksum of the credentials on the pod template.
+type DatabaseCredentialsRotator struct {
+	now func() time.Time
+}
+
+func NewDatabaseCredentialsRotator() *DatabaseCredentialsRotator {
+	return &DatabaseCredentialsRotator{
+		now: time.Now,
+	}
+}
+
+func (i *DatabaseCredentialsRotator) Rotate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	if currentState.DatabaseSecret == nil {
+		return desiredState
+	}
+	if cr.Spec.DatabaseCredentials.Vault != nil {
+		return i.rotateVaultCredentials(cr, currentState, desiredState)
+	}
+	if cr.Spec.ExternalDatabase.Enabled || cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled || currentState.PostgresqlDeployment == nil {
+		return desiredState
+	}
+	return i.rotateDatabasePassword(cr, currentState, desiredState)
+}
+
+// rotateDatabasePassword stores a new password in the database secret next to the current one and sets it in the
+// embedded database through a Job. Only once the Job succeeded, the new password replaces the current one. If
+// the Job fails, the new password is discarded and the database keeps the current one.
+func (i *DatabaseCredentialsRotator) rotateDatabasePassword(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	nextPassword := currentState.DatabaseSecret.Data[model.DatabaseSecretNextPasswordProperty]
+	if len(nextPassword) == 0 {
+		// Migrations and database upgrades restore backups with the current password
+		if isMigrationRunning(cr) || !isDatabasePasswordRotationDue(cr, currentState.DatabaseSecret, i.now()) {
+			return desiredState
+		}
+		log.Info("Rotating the password of the embedded database")
+		databaseCredentialsStatus(cr).LastRotationRequest = cr.Annotations[model.DatabaseCredentialsRotationAnnotation]
+		rotatedState, databaseSecret := findDatabaseSecretUpdate(desiredState, currentState)
+		databaseSecret.Data[model.DatabaseSecretNextPasswordProperty] = []byte(cr.ObjectMeta.Name + "-" + model.GenerateRandomString(model.PostgresqlPasswordLength))
+		return rotatedState
+	}
+
+	rotationJob := currentState.DatabaseCredentialsRotationJob
+	switch {
+	case rotationJob == nil:
+		return desiredState.AddAction(common.GenericCreateAction{
+			Ref: model.DatabaseCredentialsRotation(cr),
+			Msg: "Create Database Credentials Rotation job",
+		})
+	case isJobFailed(rotationJob):
+		log.Info(fmt.Sprintf("Rotating the password of the embedded database failed, keeping the current password, see the logs of job %v", rotationJob.Name))
+		databaseCredentialsStatus(cr).LastFailureTime = &[]metav1.Time{metav1.NewTime(i.now())}[0]
+		rotatedState, databaseSecret := findDatabaseSecretUpdate(desiredState, currentState)
+		delete(databaseSecret.Data, model.DatabaseSecretNextPasswordProperty)
+		return rotatedState.AddAction(common.GenericDeleteAction{
+			Ref: rotationJob,
+			Msg: "Delete failed Database Credentials Rotation job",
+		})
+	case rotationJob.Status.Succeeded > 0:
+		databaseCredentialsStatus(cr).LastRotationTime = &[]metav1.Time{metav1.NewTime(i.now())}[0]
+		rotatedState, databaseSecret := findDatabaseSecretUpdate(desiredState, currentState)
+		databaseSecret.Data[model.DatabaseSecretPasswordProperty] = nextPassword
+		delete(databaseSecret.Data, model.DatabaseSecretNextPasswordProperty)
+		return rotatedState.AddAction(common.GenericDeleteAction{
+			Ref: rotationJob,
+			Msg: "Delete Database Credentials Rotation job",
+		})
+	default:
+		return desiredState
+	}
+}
+
+// rotateVaultCredentials issues new credentials after two thirds of the lease of the current ones, or when
+// requested through the annotation. The credentials are issued by an action, so that nothing is issued for a
+// reconciliation that fails before. The lease of the previous credentials is revoked once the Keycloak pods were
+// rolled onto the new ones, no new credentials are issued until then.
+func (i *DatabaseCredentialsRotator) rotateVaultCredentials(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) common.DesiredClusterState {
+	status := cr.Status.DatabaseCredentials
+	if status != nil && status.PreviousLeaseID != "" {
+		if !isKeycloakRolledOntoDatabaseCredentials(cr, currentState) {
+			return desiredState
+		}
+		return desiredState.AddAction(common.RevokeVaultLeaseAction{
+			Ref:      cr,
+			LeaseID:  status.PreviousLeaseID,
+			CASecret: currentState.VaultCASecret,
+			Msg:      "Revoke the lease of the previous Database Credentials",
+		})
+	}
+
+	if !isVaultCredentialsRenewalDue(cr, currentState.DatabaseSecret, i.now()) {
+		return desiredState
+	}
+	rotatedState, databaseSecret := findDatabaseSecretUpdate(desiredState, currentState)
+	return rotatedState.AddAction(common.IssueVaultDatabaseCredentialsAction{
+		Ref:            cr,
+		DatabaseSecret: databaseSecret,
+		CASecret:       currentState.VaultCASecret,
+		Msg:            "Issue Database Credentials from Vault",
+	})
+}
+
+// isKeycloakRolledOntoDatabaseCredentials returns true once all Keycloak pods run with the credentials of the
+// database secret
+func isKeycloakRolledOntoDatabaseCredentials(cr *v1alpha1.Keycloak, currentState *common.ClusterState) bool {
+	deployment := currentState.KeycloakDeployment
+	if deployment == nil {
+		return true
+	}
+	if deployment.Spec.Template.Annotations[model.DatabaseCredentialsChecksumAnnotation] != model.KeycloakDatabaseCredentialsChecksum(cr, currentState.DatabaseSecret) {
+		return false
+	}
+	ready, _ := common.IsStatefulSetReady(deployment)
+	return ready && deployment.Status.ObservedGeneration >= deployment.Generation
+}
+
+// isDatabasePasswordRotationDue returns true if a rotation was requested through a new value of the annotation,
+// or if the rotation period passed since the last rotation, or the creation of the secret
+func isDatabasePasswordRotationDue(cr *v1alpha1.Keycloak, databaseSecret *v1.Secret, now time.Time) bool {
+	status := cr.Status.DatabaseCredentials
+	if isDatabaseCredentialsRotationRequested(cr) {
+		return true
+	}
+
+	period := cr.Spec.DatabaseCredentials.RotationPeriod
+	if period == nil || period.Duration <= 0 {
+		return false
+	}
+	last := databaseSecret.CreationTimestamp.Time
+	if status != nil && status.LastRotationTime != nil && status.LastRotationTime.After(last) {
+		last = status.LastRotationTime.Time
+	}
+	// A failed rotation is retried after another period
+	if status != nil && status.LastFailureTime != nil && status.LastFailureTime.After(last) {
+		last = status.LastFailureTime.Time
+	}
+	return !now.Before(last.Add(period.Duration))
+}
+
+// isVaultCredentialsRenewalDue returns true if the secret doesn't hold the credentials issued last, if a rotation
+// was requested, or if two thirds of the lease passed
+func isVaultCredentialsRenewalDue(cr *v1alpha1.Keycloak, databaseSecret *v1.Secret, now time.Time) bool {
+	status := cr.Status.DatabaseCredentials
+	if status == nil || status.Username == "" || status.LastRotationTime == nil {
+		return true
+	}
+	if string(databaseSecret.Data[model.DatabaseSecretUsernameProperty]) != status.Username {
+		return true
+	}
+	if isDatabaseCredentialsRotationRequested(cr) {
+		return true
+	}
+	if status.LeaseExpirationTime == nil {
+		return false
+	}
+	lease := status.LeaseExpirationTime.Sub(status.LastRotationTime.Time)
+	return !now.Before(status.LastRotationTime.Add(lease * 2 / 3))
+}
+
+func isDatabaseCredentialsRotationRequested(cr *v1alpha1.Keycloak) bool {
+	request := cr.Annotations[model.DatabaseCredentialsRotationAnnotation]
+	if request == "" {
+		return false
+	}
+	return cr.Status.DatabaseCredentials == nil || cr.Status.DatabaseCredentials.LastRotationRequest != request
+}
+
+func isMigrationRunning(cr *v1alpha1.Keycloak) bool {
+	return (cr.Status.Migration != nil && !isMigrationFinished(cr.Status.Migration)) ||
+		(cr.Status.DatabaseUpgrade != nil && !isMigrationFinished(cr.Status.DatabaseUpgrade))
+}
+
+func databaseCredentialsStatus(cr *v1alpha1.Keycloak) *v1alpha1.KeycloakDatabaseCredentialsStatus {
+	if cr.Status.DatabaseCredentials == nil {
+		cr.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{}
+	}
+	return cr.Status.DatabaseCredentials
+}
+
+// findDatabaseSecretUpdate returns the database secret updated by the desired state, adding an update of it if
+// there's none yet
+func findDatabaseSecretUpdate(desiredState common.DesiredClusterState, currentState *common.ClusterState) (common.DesiredClusterState, *v1.Secret) {
+	for _, v := range desiredState {
+		if updateAction, ok := v.(common.GenericUpdateAction); ok {
+			if secret, ok := updateAction.Ref.(*v1.Secret); ok && secret.Name == model.DatabaseSecretName {
+				return desiredState, secret
+			}
+		}
+	}
+	databaseSecret := currentState.DatabaseSecret.DeepCopy()
+	if databaseSecret.Data == nil {
+		databaseSecret.Data = map[string][]byte{}
+	}
+	return desiredState.AddAction(common.GenericUpdateAction{
+		Ref: databaseSecret,
+		Msg: "Update Database Secret",
+	}), databaseSecret
+}
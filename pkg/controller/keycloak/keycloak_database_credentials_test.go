@@ -0,0 +1,243 @@
+package keycloak
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var databaseCredentialsNow = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+func TestKeycloakDatabaseCredentials_Test_Nothing_To_Rotate(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.DatabaseCredentials.RotationPeriod = &metav1.Duration{Duration: 30 * 24 * time.Hour}
+	currentState := databaseCredentialsCurrentState(cr)
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, cr.Status.DatabaseCredentials)
+	assert.Equal(t, desiredState, rotatedState)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Starts_Rotation_When_Requested(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Annotations = map[string]string{model.DatabaseCredentialsRotationAnnotation: "2024-01-31"}
+	currentState := databaseCredentialsCurrentState(cr)
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Equal(t, "2024-01-31", cr.Status.DatabaseCredentials.LastRotationRequest)
+	databaseSecret := rotatedState[0].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.NotEmpty(t, databaseSecret.Data[model.DatabaseSecretNextPasswordProperty])
+	assert.Equal(t, currentState.DatabaseSecret.Data[model.DatabaseSecretPasswordProperty], databaseSecret.Data[model.DatabaseSecretPasswordProperty])
+	assert.Len(t, rotatedState, len(desiredState))
+
+	assert.False(t, isDatabasePasswordRotationDue(cr, currentState.DatabaseSecret, databaseCredentialsNow))
+}
+
+func TestKeycloakDatabaseCredentials_Test_Rotation_Period(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.DatabaseCredentials.RotationPeriod = &metav1.Duration{Duration: 24 * time.Hour}
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.CreationTimestamp = metav1.NewTime(databaseCredentialsNow.Add(-48 * time.Hour))
+	rotatedCr := cr.DeepCopy()
+	rotatedCr.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{
+		LastRotationTime: &metav1.Time{Time: databaseCredentialsNow.Add(-time.Hour)},
+	}
+	failedCr := cr.DeepCopy()
+	failedCr.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{
+		LastRotationTime: &metav1.Time{Time: databaseCredentialsNow.Add(-36 * time.Hour)},
+		LastFailureTime:  &metav1.Time{Time: databaseCredentialsNow.Add(-time.Hour)},
+	}
+
+	// when
+	dueSinceCreation := isDatabasePasswordRotationDue(cr, databaseSecret, databaseCredentialsNow)
+	dueSinceRotation := isDatabasePasswordRotationDue(rotatedCr, databaseSecret, databaseCredentialsNow)
+	dueSinceFailure := isDatabasePasswordRotationDue(failedCr, databaseSecret, databaseCredentialsNow)
+
+	// then
+	assert.True(t, dueSinceCreation)
+	assert.False(t, dueSinceRotation)
+	assert.False(t, dueSinceFailure)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Runs_Rotation_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := databaseCredentialsCurrentState(cr)
+	currentState.DatabaseSecret.Data[model.DatabaseSecretNextPasswordProperty] = []byte("next")
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	rotationJob := rotatedState[len(rotatedState)-1].(common.GenericCreateAction).Ref.(*batchv1.Job)
+	assert.Equal(t, model.DatabaseCredentialsRotationJobName, rotationJob.Name)
+	assert.Equal(t, model.DatabaseSecretNextPasswordProperty, rotationJob.Spec.Template.Spec.Containers[0].Env[2].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Promotes_Password_After_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := databaseCredentialsCurrentState(cr)
+	currentState.DatabaseSecret.Data[model.DatabaseSecretNextPasswordProperty] = []byte("next")
+	currentState.DatabaseCredentialsRotationJob = &batchv1.Job{Status: batchv1.JobStatus{Succeeded: 1}}
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Equal(t, databaseCredentialsNow, cr.Status.DatabaseCredentials.LastRotationTime.Time)
+	databaseSecret := rotatedState[0].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.Equal(t, "next", string(databaseSecret.Data[model.DatabaseSecretPasswordProperty]))
+	assert.NotContains(t, databaseSecret.Data, model.DatabaseSecretNextPasswordProperty)
+	assert.Equal(t, currentState.DatabaseCredentialsRotationJob, rotatedState[len(rotatedState)-1].(common.GenericDeleteAction).Ref)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Keeps_Password_If_Job_Fails(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := databaseCredentialsCurrentState(cr)
+	currentState.DatabaseSecret.Data[model.DatabaseSecretNextPasswordProperty] = []byte("next")
+	currentState.DatabaseCredentialsRotationJob = &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+	}}}
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, cr.Status.DatabaseCredentials.LastRotationTime)
+	assert.Equal(t, databaseCredentialsNow, cr.Status.DatabaseCredentials.LastFailureTime.Time)
+	databaseSecret := rotatedState[0].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.Equal(t, currentState.DatabaseSecret.Data[model.DatabaseSecretPasswordProperty], databaseSecret.Data[model.DatabaseSecretPasswordProperty])
+	assert.NotContains(t, databaseSecret.Data, model.DatabaseSecretNextPasswordProperty)
+	assert.IsType(t, common.GenericDeleteAction{}, rotatedState[len(rotatedState)-1])
+}
+
+func TestKeycloakDatabaseCredentials_Test_Issues_Vault_Credentials(t *testing.T) {
+	// given
+	cr := databaseCredentialsVaultKeycloak()
+	currentState := databaseCredentialsCurrentState(cr)
+	currentState.VaultCASecret = &corev1.Secret{}
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rotatedState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Len(t, rotatedState, len(desiredState)+1)
+	issueAction := rotatedState[len(rotatedState)-1].(common.IssueVaultDatabaseCredentialsAction)
+	assert.Equal(t, cr, issueAction.Ref)
+	assert.Equal(t, currentState.VaultCASecret, issueAction.CASecret)
+	assert.Same(t, rotatedState[0].(common.GenericUpdateAction).Ref, issueAction.DatabaseSecret)
+	assert.Nil(t, cr.Status.DatabaseCredentials)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Renews_Vault_Credentials_After_Two_Thirds_Of_Lease(t *testing.T) {
+	// given
+	cr := databaseCredentialsVaultKeycloak()
+	cr.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{
+		LastRotationTime:    &metav1.Time{Time: databaseCredentialsNow.Add(-time.Hour)},
+		Username:            "v-keycloak-abc",
+		LeaseID:             "database/creds/keycloak/abc",
+		LeaseExpirationTime: &metav1.Time{Time: databaseCredentialsNow.Add(2 * time.Hour)},
+	}
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.Data[model.DatabaseSecretUsernameProperty] = []byte("v-keycloak-abc")
+
+	// when
+	dueAfterAnHour := isVaultCredentialsRenewalDue(cr, databaseSecret, databaseCredentialsNow)
+	dueAfterTwoHours := isVaultCredentialsRenewalDue(cr, databaseSecret, databaseCredentialsNow.Add(time.Hour))
+	databaseSecret.Data[model.DatabaseSecretUsernameProperty] = []byte("keycloak")
+	dueWithOtherUser := isVaultCredentialsRenewalDue(cr, databaseSecret, databaseCredentialsNow)
+
+	// then
+	assert.False(t, dueAfterAnHour)
+	assert.True(t, dueAfterTwoHours)
+	assert.True(t, dueWithOtherUser)
+}
+
+func TestKeycloakDatabaseCredentials_Test_Revokes_Previous_Lease_After_Rollout(t *testing.T) {
+	// given
+	cr := databaseCredentialsVaultKeycloak()
+	cr.Status.DatabaseCredentials = &v1alpha1.KeycloakDatabaseCredentialsStatus{
+		LastRotationTime: &metav1.Time{Time: databaseCredentialsNow},
+		Username:         "v-keycloak-def",
+		LeaseID:          "database/creds/keycloak/def",
+		PreviousLeaseID:  "database/creds/keycloak/abc",
+	}
+	currentState := databaseCredentialsCurrentState(cr)
+	currentState.DatabaseSecret.Data[model.DatabaseSecretUsernameProperty] = []byte("v-keycloak-def")
+	currentState.KeycloakDeployment = model.KeycloakDeployment(cr, currentState.DatabaseSecret, nil)
+	SetDeployment(currentState.KeycloakDeployment, 3, "")
+	model.SetKeycloakDatabaseCredentialsChecksum(cr, currentState.KeycloakDeployment, currentState.DatabaseSecret)
+	desiredState := databaseCredentialsDesiredState(cr, currentState)
+
+	// when
+	rollingState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+	currentState.KeycloakDeployment.Status.Replicas = 3
+	currentState.KeycloakDeployment.Status.ReadyReplicas = 3
+	rolledOutState := databaseCredentialsRotator().Rotate(cr, currentState, desiredState)
+
+	// then
+	assert.Equal(t, desiredState, rollingState)
+	assert.Len(t, rolledOutState, len(desiredState)+1)
+	revokeAction := rolledOutState[len(rolledOutState)-1].(common.RevokeVaultLeaseAction)
+	assert.Equal(t, "database/creds/keycloak/abc", revokeAction.LeaseID)
+	assert.Equal(t, cr, revokeAction.Ref)
+}
+
+func databaseCredentialsRotator() *DatabaseCredentialsRotator {
+	return &DatabaseCredentialsRotator{
+		now: func() time.Time {
+			return databaseCredentialsNow
+		},
+	}
+}
+
+func databaseCredentialsVaultKeycloak() *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.ExternalDatabase.Enabled = true
+	cr.Spec.DatabaseCredentials.Vault = &v1alpha1.KeycloakDatabaseCredentialsVault{
+		Address:  "https://vault.vault.svc:8200",
+		Role:     "keycloak",
+		AuthRole: "keycloak-operator",
+	}
+	return cr
+}
+
+func databaseCredentialsCurrentState(cr *v1alpha1.Keycloak) *common.ClusterState {
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.CreationTimestamp = metav1.NewTime(databaseCredentialsNow.Add(-time.Hour))
+	return &common.ClusterState{
+		DatabaseSecret:       databaseSecret,
+		PostgresqlDeployment: readyPostgresqlDeployment(cr, model.PostgresqlDefaultVersion),
+	}
+}
+
+func databaseCredentialsDesiredState(cr *v1alpha1.Keycloak, currentState *common.ClusterState) common.DesiredClusterState {
+	return common.DesiredClusterState{
+		common.GenericUpdateAction{Ref: model.DatabaseSecretReconciled(cr, currentState.DatabaseSecret)},
+		common.GenericUpdateAction{Ref: model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, false)},
+	}
+}
@@ -0,0 +1,186 @@
+package keycloak
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	v13 "k8s.io/api/apps/v1"
+)
+
+var errDatabaseDowngrade = errors.New("postgresVersion can't be lowered, downgrades of the database aren't supported")
+
+// upgradeDatabase upgrades the embedded database to the major version set in postgresVersion. Keycloak is scaled
+// down, the database is dumped by the migration backup and restored into a new claim running the new version.
+// The version in the database secret is only updated once the restore succeeded, a failing restore keeps the
+// previous version and its claim. Returns true while the upgrade is handled by it, image migrations of Keycloak
+// wait for it.
+func upgradeDatabase(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, bool, error) {
+	if cr.Spec.ExternalDatabase.Enabled || cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled || currentState.DatabaseSecret == nil || currentState.PostgresqlDeployment == nil {
+		return desiredState, false, nil
+	}
+
+	upgrade := cr.Status.DatabaseUpgrade
+	if upgrade == nil || upgrade.ToDatabaseVersion == "" || isMigrationFinished(upgrade) {
+		deployedVersion := model.GetDatabaseVersion(currentState.DatabaseSecret)
+		targetVersion := model.PostgresqlVersion(cr)
+		if !needsDatabaseUpgrade(upgrade, deployedVersion, targetVersion) {
+			return desiredState, false, nil
+		}
+		if compareDatabaseVersions(targetVersion, deployedVersion) < 0 {
+			return nil, true, errDatabaseDowngrade
+		}
+		startDatabaseUpgrade(cr, deployedVersion, targetVersion)
+		upgrade = cr.Status.DatabaseUpgrade
+	}
+
+	keycloakDeployment, _ := findDeployment(&desiredState)
+	postgresqlDeployment := findPostgresqlDeployment(&desiredState)
+
+	switch upgrade.Phase {
+	case "", v1alpha1.MigrationPhaseScalingDown:
+		// Nothing may write to the database once it's dumped
+		setMigrationStatusPhase(upgrade, v1alpha1.MigrationPhaseScalingDown, "scaling Keycloak down")
+		scaleDownKeycloak(keycloakDeployment, currentState)
+		if currentState.KeycloakDeployment != nil && currentState.KeycloakDeployment.Status.Replicas > 0 {
+			return desiredState, true, nil
+		}
+		fallthrough
+	case v1alpha1.MigrationPhaseBackingUp:
+		scaleDownKeycloak(keycloakDeployment, currentState)
+		backupAction, done, err := migrationBackup(cr, upgrade, currentState.DatabaseUpgradeBackup)
+		if err != nil {
+			return nil, true, err
+		}
+		if !done {
+			return desiredState.AddAction(backupAction), true, nil
+		}
+		setMigrationStatusPhase(upgrade, v1alpha1.MigrationPhaseUpgrading, fmt.Sprintf("upgrading to PostgreSQL %v", upgrade.ToDatabaseVersion))
+		fallthrough
+	case v1alpha1.MigrationPhaseUpgrading:
+		scaleDownKeycloak(keycloakDeployment, currentState)
+		if currentState.PostgresqlUpgradeVolumeClaim == nil {
+			desiredState = desiredState.AddAction(common.GenericCreateAction{
+				Ref: model.PostgresqlPersistentVolumeClaim(cr, upgrade.ToDatabaseVersion),
+				Msg: "Create Postgresql PersistentVolumeClaim for the upgraded database",
+			})
+		}
+		if postgresqlDeployment != nil {
+			model.SetPostgresqlDeploymentVersion(postgresqlDeployment, upgrade.ToDatabaseVersion)
+		}
+		if !isPostgresqlRolloutReady(currentState.PostgresqlDeployment, upgrade.ToImage) {
+			return desiredState, true, nil
+		}
+		setMigrationStatusPhase(upgrade, v1alpha1.MigrationPhaseRestoring, fmt.Sprintf("restoring backup %v into PostgreSQL %v", upgrade.Backup, upgrade.ToDatabaseVersion))
+		fallthrough
+	case v1alpha1.MigrationPhaseRestoring:
+		restoreJob := currentState.DatabaseUpgradeRestoreJob
+		switch {
+		case restoreJob == nil:
+			scaleDownKeycloak(keycloakDeployment, currentState)
+			if postgresqlDeployment != nil {
+				model.SetPostgresqlDeploymentVersion(postgresqlDeployment, upgrade.ToDatabaseVersion)
+			}
+			return desiredState.AddAction(common.GenericCreateAction{
+				Ref: model.KeycloakMigrationRestore(cr, upgrade.Backup),
+				Msg: "Create Database Upgrade Restore job",
+			}), true, nil
+		case isJobFailed(restoreJob):
+			// The previous database is untouched, its claim is still in place
+			setMigrationStatusPhase(upgrade, v1alpha1.MigrationPhaseRolledBack, fmt.Sprintf("restoring backup %v into PostgreSQL %v failed, kept PostgreSQL %v", upgrade.Backup, upgrade.ToDatabaseVersion, upgrade.FromDatabaseVersion))
+			pinKeycloakImage(keycloakDeployment, currentState)
+			return desiredState, true, nil
+		case restoreJob.Status.Succeeded > 0:
+			setMigrationStatusPhase(upgrade, v1alpha1.MigrationPhaseSucceeded, fmt.Sprintf("upgraded to PostgreSQL %v, the claim %v of PostgreSQL %v can be deleted", upgrade.ToDatabaseVersion, model.PostgresqlPersistentVolumeClaimName(upgrade.FromDatabaseVersion), upgrade.FromDatabaseVersion))
+			if postgresqlDeployment != nil {
+				model.SetPostgresqlDeploymentVersion(postgresqlDeployment, upgrade.ToDatabaseVersion)
+			}
+			desiredState = setDatabaseVersion(desiredState, currentState, upgrade.ToDatabaseVersion)
+			// A pending image migration of Keycloak starts with the next reconciliation
+			pinKeycloakImage(keycloakDeployment, currentState)
+			return desiredState, true, nil
+		default:
+			scaleDownKeycloak(keycloakDeployment, currentState)
+			if postgresqlDeployment != nil {
+				model.SetPostgresqlDeploymentVersion(postgresqlDeployment, upgrade.ToDatabaseVersion)
+			}
+			return desiredState, true, nil
+		}
+	}
+	return desiredState, false, nil
+}
+
+// needsDatabaseUpgrade returns true if the database runs another version than the one set, unless an upgrade to
+// it has been rolled back
+func needsDatabaseUpgrade(upgrade *v1alpha1.KeycloakMigrationStatus, deployedVersion string, targetVersion string) bool {
+	if deployedVersion == targetVersion {
+		return false
+	}
+	if upgrade != nil && upgrade.Phase == v1alpha1.MigrationPhaseRolledBack && upgrade.FromDatabaseVersion == deployedVersion && upgrade.ToDatabaseVersion == targetVersion {
+		return false
+	}
+	return true
+}
+
+func startDatabaseUpgrade(cr *v1alpha1.Keycloak, deployedVersion string, targetVersion string) {
+	log.Info(fmt.Sprintf("Performing database upgrade from PostgreSQL %v to %v", deployedVersion, targetVersion))
+	cr.Status.DatabaseUpgrade = &v1alpha1.KeycloakMigrationStatus{
+		FromImage:           model.PostgresqlVersionImage(deployedVersion),
+		ToImage:             model.PostgresqlVersionImage(targetVersion),
+		FromDatabaseVersion: deployedVersion,
+		ToDatabaseVersion:   targetVersion,
+	}
+}
+
+func compareDatabaseVersions(a string, b string) int {
+	versionA, _ := strconv.Atoi(a)
+	versionB, _ := strconv.Atoi(b)
+	return versionA - versionB
+}
+
+func scaleDownKeycloak(deployment *v13.StatefulSet, currentState *common.ClusterState) {
+	if deployment == nil || currentState.KeycloakDeployment == nil {
+		return
+	}
+	scaleDownAndDontUpgradeImage(deployment, currentState)
+}
+
+func pinKeycloakImage(deployment *v13.StatefulSet, currentState *common.ClusterState) {
+	if deployment == nil || currentState.KeycloakDeployment == nil {
+		return
+	}
+	deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+}
+
+func isPostgresqlRolloutReady(deployment *v13.Deployment, image string) bool {
+	if deployment == nil || deployment.Spec.Template.Spec.Containers[0].Image != image {
+		return false
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.UpdatedReplicas > 0 && deployment.Status.ReadyReplicas > 0
+}
+
+func findPostgresqlDeployment(desiredState *common.DesiredClusterState) *v13.Deployment {
+	for _, v := range *desiredState {
+		if (reflect.TypeOf(v) == reflect.TypeOf(common.GenericUpdateAction{})) {
+			updateAction := v.(common.GenericUpdateAction)
+			if (reflect.TypeOf(updateAction.Ref) == reflect.TypeOf(&v13.Deployment{})) {
+				deployment := updateAction.Ref.(*v13.Deployment)
+				if deployment.ObjectMeta.Name == model.PostgresqlDeploymentName {
+					return deployment
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setDatabaseVersion records the version in the database secret, from then on the Deployment runs it
+func setDatabaseVersion(desiredState common.DesiredClusterState, currentState *common.ClusterState, version string) common.DesiredClusterState {
+	desiredState, databaseSecret := findDatabaseSecretUpdate(desiredState, currentState)
+	databaseSecret.Data[model.DatabaseSecretVersionProperty] = []byte(version)
+	return desiredState
+}
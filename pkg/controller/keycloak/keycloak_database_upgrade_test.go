@@ -0,0 +1,205 @@
+package keycloak
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestKeycloakDatabaseUpgrade_Test_Nothing_To_Upgrade(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.False(t, upgrading)
+	assert.Nil(t, cr.Status.DatabaseUpgrade)
+	assert.Nil(t, cr.Status.Migration)
+	assert.Equal(t, desiredState, upgradedState)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Scales_Keycloak_Down(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.PostgresVersion = "15"
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, upgrading)
+	assert.Equal(t, v1alpha1.MigrationPhaseScalingDown, cr.Status.DatabaseUpgrade.Phase)
+	assert.Equal(t, model.PostgresqlDefaultVersion, cr.Status.DatabaseUpgrade.FromDatabaseVersion)
+	assert.Equal(t, "15", cr.Status.DatabaseUpgrade.ToDatabaseVersion)
+	assert.Nil(t, cr.Status.Migration)
+	assert.Equal(t, int32(0), *blueGreenDeployment(upgradedState).Spec.Replicas)
+	assert.Equal(t, "old_image", blueGreenDeployment(upgradedState).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Refuses_Downgrade(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.PostgresVersion = "12"
+	currentState := databaseUpgradeCurrentState(cr, "13")
+	desiredState := databaseUpgradeDesiredState(cr, "13")
+
+	// when
+	_, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Equal(t, errDatabaseDowngrade, err)
+	assert.True(t, upgrading)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Switches_To_New_Claim_After_Backup(t *testing.T) {
+	// given
+	cr := databaseUpgradeKeycloak(v1alpha1.MigrationPhaseBackingUp)
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	SetDeployment(currentState.KeycloakDeployment, 0, "")
+	currentState.DatabaseUpgradeBackup = &v1alpha1.KeycloakBackup{}
+	currentState.DatabaseUpgradeBackup.Name = cr.Status.DatabaseUpgrade.Backup
+	currentState.DatabaseUpgradeBackup.Status.Phase = v1alpha1.BackupPhaseCreated
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, upgrading)
+	assert.Equal(t, v1alpha1.MigrationPhaseUpgrading, cr.Status.DatabaseUpgrade.Phase)
+	claim := upgradedState[len(upgradedState)-1].(common.GenericCreateAction).Ref.(*corev1.PersistentVolumeClaim)
+	assert.Equal(t, model.PostgresqlPersistentVolumeClaimName("15"), claim.Name)
+	postgresqlDeployment := findPostgresqlDeployment(&upgradedState)
+	assert.Equal(t, model.PostgresqlVersionImage("15"), postgresqlDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, claim.Name, postgresqlDeployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, int32(0), *blueGreenDeployment(upgradedState).Spec.Replicas)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Restores_Backup_Into_New_Version(t *testing.T) {
+	// given
+	cr := databaseUpgradeKeycloak(v1alpha1.MigrationPhaseUpgrading)
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	SetDeployment(currentState.KeycloakDeployment, 0, "")
+	currentState.PostgresqlUpgradeVolumeClaim = model.PostgresqlPersistentVolumeClaim(cr, "15")
+	currentState.PostgresqlDeployment = readyPostgresqlDeployment(cr, "15")
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, upgrading)
+	assert.Equal(t, v1alpha1.MigrationPhaseRestoring, cr.Status.DatabaseUpgrade.Phase)
+	restoreJob := upgradedState[len(upgradedState)-1].(common.GenericCreateAction).Ref.(*batchv1.Job)
+	assert.Equal(t, cr.Status.DatabaseUpgrade.Backup+"-restore", restoreJob.Name)
+	assert.Equal(t, model.PostgresqlVersionImage("15"), findPostgresqlDeployment(&upgradedState).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Records_Version_After_Restore(t *testing.T) {
+	// given
+	cr := databaseUpgradeKeycloak(v1alpha1.MigrationPhaseRestoring)
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	SetDeployment(currentState.KeycloakDeployment, 0, "")
+	currentState.PostgresqlDeployment = readyPostgresqlDeployment(cr, "15")
+	currentState.DatabaseUpgradeRestoreJob = &batchv1.Job{Status: batchv1.JobStatus{Succeeded: 1}}
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, upgrading, err := upgradeDatabase(cr, currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	assert.True(t, upgrading)
+	assert.Equal(t, v1alpha1.MigrationPhaseSucceeded, cr.Status.DatabaseUpgrade.Phase)
+	databaseSecret := upgradedState[0].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.Equal(t, "15", model.GetDatabaseVersion(databaseSecret))
+	assert.Equal(t, model.PostgresqlVersionImage("15"), findPostgresqlDeployment(&upgradedState).Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "old_image", blueGreenDeployment(upgradedState).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestKeycloakDatabaseUpgrade_Test_Keeps_Previous_Version_If_Restore_Fails(t *testing.T) {
+	// given
+	cr := databaseUpgradeKeycloak(v1alpha1.MigrationPhaseRestoring)
+	currentState := databaseUpgradeCurrentState(cr, model.PostgresqlDefaultVersion)
+	SetDeployment(currentState.KeycloakDeployment, 0, "")
+	currentState.PostgresqlDeployment = readyPostgresqlDeployment(cr, "15")
+	currentState.DatabaseUpgradeRestoreJob = &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue},
+	}}}
+	desiredState := databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion)
+
+	// when
+	upgradedState, _, err := upgradeDatabase(cr, currentState, desiredState)
+	_, upgradingAfterRollback, errAfterRollback := upgradeDatabase(cr, currentState, databaseUpgradeDesiredState(cr, model.PostgresqlDefaultVersion))
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha1.MigrationPhaseRolledBack, cr.Status.DatabaseUpgrade.Phase)
+	databaseSecret := upgradedState[0].(common.GenericUpdateAction).Ref.(*corev1.Secret)
+	assert.Equal(t, model.PostgresqlDefaultVersion, model.GetDatabaseVersion(databaseSecret))
+	assert.Equal(t, model.PostgresqlVersionImage(model.PostgresqlDefaultVersion), findPostgresqlDeployment(&upgradedState).Spec.Template.Spec.Containers[0].Image)
+
+	assert.Nil(t, errAfterRollback)
+	assert.False(t, upgradingAfterRollback)
+}
+
+func databaseUpgradeKeycloak(phase v1alpha1.MigrationPhase) *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.PostgresVersion = "15"
+	cr.Status.DatabaseUpgrade = &v1alpha1.KeycloakMigrationStatus{
+		Phase:               phase,
+		FromImage:           model.PostgresqlVersionImage(model.PostgresqlDefaultVersion),
+		ToImage:             model.PostgresqlVersionImage("15"),
+		FromDatabaseVersion: model.PostgresqlDefaultVersion,
+		ToDatabaseVersion:   "15",
+		Backup:              "migrate-backup-20240101-000000",
+	}
+	return cr
+}
+
+func databaseUpgradeCurrentState(cr *v1alpha1.Keycloak, version string) *common.ClusterState {
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.Data[model.DatabaseSecretVersionProperty] = []byte(version)
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, databaseSecret, nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "old_image")
+	return &common.ClusterState{
+		DatabaseSecret:       databaseSecret,
+		KeycloakDeployment:   keycloakCurrentDeployment,
+		PostgresqlDeployment: readyPostgresqlDeployment(cr, version),
+	}
+}
+
+func databaseUpgradeDesiredState(cr *v1alpha1.Keycloak, version string) common.DesiredClusterState {
+	databaseSecret := model.DatabaseSecret(cr)
+	databaseSecret.Data[model.DatabaseSecretVersionProperty] = []byte(version)
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, databaseSecret, nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+	return common.DesiredClusterState{
+		common.GenericUpdateAction{Ref: databaseSecret},
+		common.GenericUpdateAction{Ref: model.PostgresqlDeployment(cr, version, false)},
+		common.GenericUpdateAction{Ref: keycloakDesiredDeployment},
+	}
+}
+
+func readyPostgresqlDeployment(cr *v1alpha1.Keycloak, version string) *v1.Deployment {
+	deployment := model.PostgresqlDeployment(cr, version, false)
+	deployment.Status.UpdatedReplicas = 1
+	deployment.Status.ReadyReplicas = 1
+	return deployment
+}
@@ -1,18 +1,25 @@
 package keycloak
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/common"
 	"github.com/keycloak/keycloak-operator/pkg/model"
 	v13 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const defaultRollbackDeadlineSeconds = 600
+
 var errBackup = errors.New("migrate backup fails")
+var errRestore = errors.New("restoring the migrate backup fails, the database needs to be restored manually")
 var errNoMigrator = errors.New("migrator not found")
 var errSelectorCantBeMigrated = errors.New("statefulSet Selector mismatch; please use Recreate migration strategy")
 
@@ -26,12 +33,16 @@ type RecreateMigrator struct {
 type RollingMigrator struct {
 }
 
-func GetMigrator(cr *v1alpha1.Keycloak) (Migrator, error) {
+// GetMigrator returns the migrator of the strategy of the Keycloak CR. The smoke checks of the bluegreen strategy
+// log in with the keycloak factory.
+func GetMigrator(context context.Context, cr *v1alpha1.Keycloak, keycloakFactory common.BlueGreenClientFactory) (Migrator, error) {
 	switch cr.Spec.Migration.MigrationStrategy {
 	case v1alpha1.NoStrategy, v1alpha1.StrategyRecreate:
 		return &RecreateMigrator{}, nil
 	case v1alpha1.StrategyRolling:
 		return &RollingMigrator{}, nil
+	case v1alpha1.StrategyBlueGreen:
+		return NewBlueGreenMigrator(context, keycloakFactory), nil
 	default:
 		return nil, errNoMigrator
 	}
@@ -39,6 +50,7 @@ func GetMigrator(cr *v1alpha1.Keycloak) (Migrator, error) {
 
 func (i *RecreateMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
 	deployment, deploymentIndex := findDeployment(&desiredState)
+	pinMigrationImage(cr, deployment)
 
 	// We can't modify existing selector on StatefulSet.
 	// The selector might be wrongly set by e.g. RH-SSO 7.5.2.
@@ -74,9 +86,14 @@ func (i *RecreateMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.C
 		// no need to return now, we can let the DB backup to proceed
 	}
 
+	if rolloutState, watching, err := watchMigrationRollout(cr, currentState, desiredState, deployment); watching {
+		return rolloutState, err
+	}
+
 	if needsImageMigration(cr, currentState) {
-		desiredImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+		desiredImage := migrationTargetImage(cr)
 		log.Info(fmt.Sprintf("Performing migration from '%s' to '%s'", currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image, desiredImage))
+		startMigration(cr, currentState)
 
 		// The backup should be made when Keycloak container is down.
 		// This way, we minimize the chance of skipping important updated
@@ -87,17 +104,23 @@ func (i *RecreateMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.C
 		// the desired state from current state, i.e. clones current replicas
 		// status into the desired state.
 		if deployment != nil && deployment.Status.Replicas > 0 {
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseScalingDown, "")
 			scaleDownAndDontUpgradeImage(deployment, currentState)
 			return desiredState, nil
 		}
 
+		// The upgrade waits for the backup to complete, until then only the backup is reconciled
 		if cr.Spec.Migration.Backups.Enabled {
-			var err error
-			desiredState, err = oneTimeLocalBackup(cr, currentState, desiredState)
+			backupAction, done, err := migrationBackup(cr, cr.Status.Migration, currentState.KeycloakBackup)
 			if err != nil {
-				return desiredState, err
+				return nil, err
+			}
+			if !done {
+				backupDesiredState := common.DesiredClusterState{}
+				return backupDesiredState.AddAction(backupAction), nil
 			}
 		}
+		setMigrationUpgrading(cr)
 	}
 
 	return desiredState, nil
@@ -108,6 +131,30 @@ func (i *RollingMigrator) Migrate(cr *v1alpha1.Keycloak, currentState *common.Cl
 	if needsStatefulSetRecreation(currentState, deployment) {
 		return nil, errSelectorCantBeMigrated
 	}
+	pinMigrationImage(cr, deployment)
+
+	if rolloutState, watching, err := watchMigrationRollout(cr, currentState, desiredState, deployment); watching {
+		return rolloutState, err
+	}
+
+	if needsImageMigration(cr, currentState) {
+		startMigration(cr, currentState)
+
+		// The pods keep running the previous image until the backup completed
+		if cr.Spec.Migration.Backups.Enabled {
+			backupAction, done, err := migrationBackup(cr, cr.Status.Migration, currentState.KeycloakBackup)
+			if err != nil {
+				return nil, err
+			}
+			if !done {
+				if deployment != nil {
+					deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
+				}
+				return desiredState.AddAction(backupAction), nil
+			}
+		}
+		setMigrationUpgrading(cr)
+	}
 	return desiredState, nil
 }
 
@@ -116,10 +163,26 @@ func needsImageMigration(cr *v1alpha1.Keycloak, currentState *common.ClusterStat
 		return false
 	}
 	deployedImage := currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
-	currentImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	currentImage := migrationTargetImage(cr)
 	return deployedImage != currentImage
 }
 
+// migrationTargetImage returns the image Keycloak should run. That's the image of the operator, unless a
+// migration to it has been rolled back, then the previous image stays pinned.
+func migrationTargetImage(cr *v1alpha1.Keycloak) string {
+	image := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	if migration := cr.Status.Migration; migration != nil && migration.Phase == v1alpha1.MigrationPhaseRolledBack && migration.ToImage == image {
+		return migration.FromImage
+	}
+	return image
+}
+
+func pinMigrationImage(cr *v1alpha1.Keycloak, deployment *v13.StatefulSet) {
+	if image := migrationTargetImage(cr); deployment != nil && image != model.Profiles.GetKeycloakOrRHSSOImage(cr) {
+		deployment.Spec.Template.Spec.Containers[0].Image = image
+	}
+}
+
 func needsStatefulSetRecreation(currentState *common.ClusterState, desiredDeployment *v13.StatefulSet) bool {
 	if currentState.KeycloakDeployment == nil || desiredDeployment == nil {
 		return false
@@ -149,35 +212,212 @@ func scaleDownAndDontUpgradeImage(deployment *v13.StatefulSet, currentState *com
 	deployment.Spec.Template.Spec.Containers[0].Image = currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image
 }
 
-func oneTimeLocalBackup(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState) (common.DesiredClusterState, error) {
-	keycloakBackup := currentState.KeycloakBackup
+// migrationBackup returns the action creating the backup of the migration until the backup completed
+func migrationBackup(cr *v1alpha1.Keycloak, migration *v1alpha1.KeycloakMigrationStatus, keycloakBackup *v1alpha1.KeycloakBackup) (common.ClusterAction, bool, error) {
 	switch {
-	case keycloakBackup == nil:
+	case migration.Backup == "":
+		// The name is saved in the status before the backup is created, so that a conflicting status update
+		// doesn't leave a backup behind that no migration refers to
+		migration.Backup = model.MigrateBackupName + "-" + time.Now().Format("20060102-150405")
+		setMigrationStatusPhase(migration, v1alpha1.MigrationPhaseBackingUp, fmt.Sprintf("creating backup %v", migration.Backup))
+		return nil, false, nil
+	case keycloakBackup == nil || keycloakBackup.Name != migration.Backup:
+		setMigrationStatusPhase(migration, v1alpha1.MigrationPhaseBackingUp, fmt.Sprintf("creating backup %v", migration.Backup))
+
 		backupCr := &v1alpha1.KeycloakBackup{}
 		backupCr.Namespace = cr.Namespace
-		backupCr.Name = model.MigrateBackupName + "-" + common.BackupTime
+		backupCr.Name = migration.Backup
 		labelSelect := metav1.LabelSelector{
 			MatchLabels: cr.Labels,
 		}
 		backupCr.Spec.InstanceSelector = &labelSelect
 		backupCr.Spec.StorageClassName = cr.Spec.StorageClassName
 
-		migrationBackupCR := common.GenericCreateAction{
+		return common.GenericCreateAction{
 			Ref: model.KeycloakMigrationOneTimeBackup(backupCr),
 			Msg: "Create Local Backup CR",
-		}
-
-		backupDesiredState := common.DesiredClusterState{}
-		backupDesiredState = backupDesiredState.AddAction(migrationBackupCR)
-		return backupDesiredState, nil
+		}, false, nil
 	case keycloakBackup.Status.Phase == v1alpha1.BackupPhaseCreated:
 		log.Info("migrate backup succeeds")
-		return desiredState, nil
+		return nil, true, nil
 	case keycloakBackup.Status.Phase == v1alpha1.BackupPhaseFailing:
-		return nil, errBackup
+		setMigrationStatusPhase(migration, v1alpha1.MigrationPhaseFailed, fmt.Sprintf("backup %v failed: %v", migration.Backup, keycloakBackup.Status.Message))
+		return nil, false, errBackup
 	default:
-		emptyDesiredState := common.DesiredClusterState{}
 		log.Info("wait for migrate backup's creating")
-		return emptyDesiredState, nil
+		return nil, false, nil
+	}
+}
+
+// startMigration records a new migration in the status, unless the one to the same image is still ongoing
+func startMigration(cr *v1alpha1.Keycloak, currentState *common.ClusterState) {
+	targetImage := migrationTargetImage(cr)
+	migration := cr.Status.Migration
+	if migration != nil && migration.ToImage == targetImage && !isMigrationFinished(migration) {
+		return
+	}
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		FromImage: currentState.KeycloakDeployment.Spec.Template.Spec.Containers[0].Image,
+		ToImage:   targetImage,
+	}
+}
+
+func setMigrationUpgrading(cr *v1alpha1.Keycloak) {
+	if cr.Status.Migration.Phase != v1alpha1.MigrationPhaseUpgrading {
+		cr.Status.Migration.UpgradeTime = &[]metav1.Time{metav1.Now()}[0]
+	}
+	setMigrationPhase(cr, v1alpha1.MigrationPhaseUpgrading, fmt.Sprintf("upgrading to %v", cr.Status.Migration.ToImage))
+}
+
+// setMigrationPhase records a step whenever the migration enters a new phase
+func setMigrationPhase(cr *v1alpha1.Keycloak, phase v1alpha1.MigrationPhase, message string) {
+	setMigrationStatusPhase(cr.Status.Migration, phase, message)
+}
+
+func setMigrationStatusPhase(migration *v1alpha1.KeycloakMigrationStatus, phase v1alpha1.MigrationPhase, message string) {
+	if migration.Phase == phase {
+		return
+	}
+	log.Info(fmt.Sprintf("migration from '%s' to '%s' entered phase %v", migration.FromImage, migration.ToImage, phase))
+	migration.Phase = phase
+	migration.Steps = append(migration.Steps, v1alpha1.KeycloakMigrationStep{
+		Phase:   phase,
+		Time:    metav1.Now(),
+		Message: message,
+	})
+}
+
+func isMigrationFinished(migration *v1alpha1.KeycloakMigrationStatus) bool {
+	switch migration.Phase {
+	case v1alpha1.MigrationPhaseSucceeded, v1alpha1.MigrationPhaseRolledBack, v1alpha1.MigrationPhaseFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchMigrationRollout watches the pods after an upgrade. If they're still crash looping after the rollback
+// deadline, Keycloak is scaled down, the backup taken before the upgrade is restored and the previous image is
+// pinned. Returns true while the migration is handled by it.
+func watchMigrationRollout(cr *v1alpha1.Keycloak, currentState *common.ClusterState, desiredState common.DesiredClusterState, deployment *v13.StatefulSet) (common.DesiredClusterState, bool, error) {
+	migration := cr.Status.Migration
+	if migration == nil || currentState.KeycloakDeployment == nil {
+		return desiredState, false, nil
+	}
+
+	switch migration.Phase {
+	case v1alpha1.MigrationPhaseUpgrading:
+		// A newer image starts a new migration
+		if migration.ToImage != migrationTargetImage(cr) {
+			return desiredState, false, nil
+		}
+		if isRolloutReady(currentState.KeycloakDeployment, migration.ToImage) {
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseSucceeded, fmt.Sprintf("upgraded to %v", migration.ToImage))
+			return desiredState, true, nil
+		}
+		deadline := time.Duration(defaultRollbackDeadlineSeconds) * time.Second
+		if cr.Spec.Migration.RollbackDeadlineSeconds != nil {
+			deadline = time.Duration(*cr.Spec.Migration.RollbackDeadlineSeconds) * time.Second
+		}
+		if migration.UpgradeTime == nil || time.Since(migration.UpgradeTime.Time) < deadline || !isCrashLooping(currentState.KeycloakPods, migration.ToImage) {
+			return desiredState, true, nil
+		}
+		if !cr.Spec.Migration.Backups.Enabled || migration.Backup == "" {
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseFailed, fmt.Sprintf("pods of %v are crash looping and there is no backup to roll back to", migration.ToImage))
+			return desiredState, true, nil
+		}
+		setMigrationPhase(cr, v1alpha1.MigrationPhaseRollingBack, fmt.Sprintf("pods of %v are still crash looping after %v", migration.ToImage, deadline))
+		fallthrough
+	case v1alpha1.MigrationPhaseRollingBack:
+		// Like for the upgrade, only one version may access the database at a time
+		scaleDownToImage(deployment, migration.FromImage)
+		if currentState.KeycloakDeployment.Status.Replicas > 0 {
+			return desiredState, true, nil
+		}
+		setMigrationPhase(cr, v1alpha1.MigrationPhaseRestoring, fmt.Sprintf("restoring backup %v", migration.Backup))
+		fallthrough
+	case v1alpha1.MigrationPhaseRestoring:
+		restoreJob := currentState.KeycloakMigrationRestoreJob
+		switch {
+		case restoreJob == nil:
+			scaleDownToImage(deployment, migration.FromImage)
+			return desiredState.AddAction(common.GenericCreateAction{
+				Ref: model.KeycloakMigrationRestore(cr, migration.Backup),
+				Msg: "Create Migrate Backup Restore job",
+			}), true, nil
+		case isJobFailed(restoreJob):
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseFailed, fmt.Sprintf("restoring backup %v failed", migration.Backup))
+			return nil, true, errRestore
+		case restoreJob.Status.Succeeded > 0:
+			// From now on the previous image is pinned by migrationTargetImage
+			setMigrationPhase(cr, v1alpha1.MigrationPhaseRolledBack, fmt.Sprintf("restored backup %v and pinned %v", migration.Backup, migration.FromImage))
+			if deployment != nil {
+				deployment.Spec.Template.Spec.Containers[0].Image = migration.FromImage
+			}
+			return desiredState, true, nil
+		default:
+			scaleDownToImage(deployment, migration.FromImage)
+			return desiredState, true, nil
+		}
+	case v1alpha1.MigrationPhaseFailed:
+		// A failed restore leaves Keycloak scaled down, the database needs to be fixed first
+		if currentState.KeycloakMigrationRestoreJob != nil && isJobFailed(currentState.KeycloakMigrationRestoreJob) && migration.ToImage == model.Profiles.GetKeycloakOrRHSSOImage(cr) {
+			return nil, true, errRestore
+		}
+	}
+	return desiredState, false, nil
+}
+
+func scaleDownToImage(deployment *v13.StatefulSet, image string) {
+	if deployment == nil {
+		return
+	}
+	deployment.Spec.Replicas = &[]int32{0}[0]
+	deployment.Spec.Template.Spec.Containers[0].Image = image
+}
+
+func isRolloutReady(deployment *v13.StatefulSet, image string) bool {
+	if deployment.Spec.Template.Spec.Containers[0].Image != image {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.ObservedGeneration >= deployment.Generation && deployment.Status.UpdatedReplicas >= replicas && deployment.Status.ReadyReplicas >= replicas
+}
+
+// Restarts after which a pod that still isn't ready counts as crash looping. A single restart, e.g. a liveness probe
+// killing a pod during a slow database migration, doesn't.
+const crashLoopRestartThreshold = 3
+
+// isCrashLooping returns true if a pod running the image is backing off from restarts or keeps restarting without
+// becoming ready
+func isCrashLooping(pods *v1.PodList, image string) bool {
+	if pods == nil {
+		return false
+	}
+	for _, pod := range pods.Items {
+		if len(pod.Spec.Containers) == 0 || pod.Spec.Containers[0].Image != image {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				return true
+			}
+			if status.RestartCount >= crashLoopRestartThreshold && !status.Ready {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == v1.ConditionTrue {
+			return true
+		}
 	}
+	return false
 }
@@ -1,7 +1,9 @@
 package keycloak
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/common"
@@ -9,6 +11,9 @@ import (
 	kcAssert "github.com/keycloak/keycloak-operator/test/assert"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const extraLabelName = "extra"
@@ -17,7 +22,7 @@ const extraLabelValue = "value"
 func TestKeycloakMigration_Test_No_Need_For_Migration_On_Empty_Desired_State(t *testing.T) {
 	// given
 	cr := &v1alpha1.Keycloak{}
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 	currentState := common.ClusterState{}
 	desiredState := common.DesiredClusterState{}
 
@@ -32,7 +37,7 @@ func TestKeycloakMigration_Test_No_Need_For_Migration_On_Empty_Desired_State(t *
 func TestKeycloakMigration_Test_No_Need_For_Migration_On_Missing_Deployment_In_Desired_State(t *testing.T) {
 	// given
 	cr := &v1alpha1.Keycloak{}
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakDeployment := model.KeycloakDeployment(cr, nil, nil)
 	SetDeployment(keycloakDeployment, 5, "old_image")
@@ -57,7 +62,7 @@ func TestKeycloakMigration_Test_No_Need_For_Migration_On_Missing_Deployment_In_D
 func TestKeycloakMigration_Test_Migrating_Image(t *testing.T) {
 	// given
 	cr := &v1alpha1.Keycloak{}
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
 	SetDeployment(keycloakCurrentDeployment, 5, "old_image")
@@ -92,7 +97,7 @@ func TestKeycloakMigration_Test_Migrating_RHSSO_Image(t *testing.T) {
 			Profile: model.RHSSOProfile,
 		},
 	}
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.RHSSODeployment(cr, model.DatabaseSecret(cr), nil)
 	SetDeployment(keycloakCurrentDeployment, 5, "old_image")
@@ -132,7 +137,7 @@ func TBackup(t *testing.T, backupEnabled bool) {
 	// given
 	cr := &v1alpha1.Keycloak{}
 	cr.Spec.Migration.Backups.Enabled = backupEnabled
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
 	SetDeployment(keycloakCurrentDeployment, 0, "old_image")
@@ -165,7 +170,7 @@ func TestKeycloakMigration_Test_No_Migration_Happens_With_Rolling_Migrator(t *te
 	// given
 	cr := &v1alpha1.Keycloak{}
 	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.RHSSODeployment(cr, model.DatabaseSecret(cr), nil)
 	SetDeployment(keycloakCurrentDeployment, 5, "old_image")
@@ -198,7 +203,7 @@ func TestKeycloakMigration_Test_Stateful_Set_Selector_Error_With_Rolling_Migrato
 	cr := &v1alpha1.Keycloak{}
 	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
 	cr.Spec.Instances = 3
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
 	keycloakCurrentDeployment.Spec.Selector.MatchLabels[extraLabelName] = extraLabelValue
@@ -230,7 +235,7 @@ func TestKeycloakMigration_Test_Stateful_Set_Downscaled_On_Selector_Mismatch(t *
 	// given
 	cr := &v1alpha1.Keycloak{}
 	cr.Spec.Instances = 3
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
 	keycloakCurrentDeployment.Spec.Selector.MatchLabels[extraLabelName] = extraLabelValue
@@ -267,7 +272,7 @@ func TestKeycloakMigration_Test_Stateful_Set_Recreated_After_Downscale(t *testin
 	// given
 	cr := &v1alpha1.Keycloak{}
 	cr.Spec.Instances = 3
-	migrator, _ := GetMigrator(cr)
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
 
 	keycloakCurrentDeployment := model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil)
 	keycloakCurrentDeployment.Spec.Selector.MatchLabels[extraLabelName] = extraLabelValue
@@ -300,6 +305,254 @@ func TestKeycloakMigration_Test_Stateful_Set_Recreated_After_Downscale(t *testin
 	assert.NotEqual(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
 }
 
+func TestKeycloakMigration_Test_Upgrade_Waits_For_Backup(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 0, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakDesiredDeployment, 0, "")
+
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		Phase:     v1alpha1.MigrationPhaseBackingUp,
+		FromImage: "old_image",
+		ToImage:   model.Profiles.GetKeycloakOrRHSSOImage(cr),
+		Backup:    "migrate-backup-20240101-000000",
+	}
+	backup := &v1alpha1.KeycloakBackup{}
+	backup.Name = "migrate-backup-20240101-000000"
+	backup.Status.Phase = v1alpha1.BackupPhaseReconciling
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakBackup:     backup,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	waitingActions, waitingErr := migrator.Migrate(cr, &currentState, desiredState)
+	backup.Status.Phase = v1alpha1.BackupPhaseCreated
+	upgradeActions, upgradeErr := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, waitingErr)
+	assert.Empty(t, waitingActions)
+	assert.Nil(t, upgradeErr)
+	assert.Equal(t, desiredState, upgradeActions)
+	assert.Equal(t, v1alpha1.MigrationPhaseUpgrading, cr.Status.Migration.Phase)
+	assert.NotNil(t, cr.Status.Migration.UpgradeTime)
+	assert.Len(t, cr.Status.Migration.Steps, 1)
+}
+
+func TestKeycloakMigration_Test_Rolling_Migrator_Keeps_Image_Until_Backup_Completed(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Migration.MigrationStrategy = v1alpha1.StrategyRolling
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 3, "old_image")
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	namingActions, namingErr := migrator.Migrate(cr, &currentState, desiredState)
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, namingErr)
+	assert.Len(t, namingActions, 1)
+	assert.Nil(t, err)
+	assert.Len(t, migratedActions, 2)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.IsType(t, &v1alpha1.KeycloakBackup{}, migratedActions[1].(common.GenericCreateAction).Ref)
+	assert.Equal(t, v1alpha1.MigrationPhaseBackingUp, cr.Status.Migration.Phase)
+	assert.Equal(t, cr.Status.Migration.Backup, migratedActions[1].(common.GenericCreateAction).Ref.(*v1alpha1.KeycloakBackup).Name)
+}
+
+func TestKeycloakMigration_Test_Rollback_Of_Crash_Looping_Upgrade(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.Backups.Enabled = true
+	cr.Spec.Migration.RollbackDeadlineSeconds = &[]int32{60}[0]
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	newImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 3, newImage)
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		Phase:       v1alpha1.MigrationPhaseUpgrading,
+		FromImage:   "old_image",
+		ToImage:     newImage,
+		Backup:      "migrate-backup-20240101-000000",
+		UpgradeTime: &metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+	}
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakPods: &corev1.PodList{
+			Items: []corev1.Pod{crashLoopingPod(newImage)},
+		},
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	kcAssert.ReplicasCount(t, migratedActions, 0)
+	assert.Equal(t, "old_image", keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, v1alpha1.MigrationPhaseRollingBack, cr.Status.Migration.Phase)
+}
+
+func TestKeycloakMigration_Test_No_Rollback_Of_Restarted_Upgrade_Still_Starting(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.Backups.Enabled = true
+	cr.Spec.Migration.RollbackDeadlineSeconds = &[]int32{60}[0]
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	newImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 3, newImage)
+
+	keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakDesiredDeployment, 3, "")
+
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		Phase:       v1alpha1.MigrationPhaseUpgrading,
+		FromImage:   "old_image",
+		ToImage:     newImage,
+		Backup:      "migrate-backup-20240101-000000",
+		UpgradeTime: &metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+	}
+	startingPod := crashLoopingPod(newImage)
+	startingPod.Status.ContainerStatuses[0].RestartCount = 1
+	startingPod.Status.ContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+		KeycloakPods: &corev1.PodList{
+			Items: []corev1.Pod{startingPod},
+		},
+	}
+
+	desiredState := common.DesiredClusterState{}
+	desiredState = append(desiredState, common.GenericUpdateAction{
+		Ref: keycloakDesiredDeployment,
+	})
+
+	// when
+	migratedActions, err := migrator.Migrate(cr, &currentState, desiredState)
+
+	// then
+	assert.Nil(t, err)
+	kcAssert.ReplicasCount(t, migratedActions, 3)
+	assert.Equal(t, newImage, keycloakDesiredDeployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, v1alpha1.MigrationPhaseUpgrading, cr.Status.Migration.Phase)
+}
+
+func TestKeycloakMigration_Test_Restore_And_Pin_Previous_Image(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Instances = 3
+	cr.Spec.Migration.Backups.Enabled = true
+	migrator, _ := GetMigrator(context.TODO(), cr, nil)
+
+	newImage := model.Profiles.GetKeycloakOrRHSSOImage(cr)
+	keycloakCurrentDeployment := model.KeycloakDeployment(cr, nil, nil)
+	SetDeployment(keycloakCurrentDeployment, 0, "old_image")
+
+	cr.Status.Migration = &v1alpha1.KeycloakMigrationStatus{
+		Phase:     v1alpha1.MigrationPhaseRestoring,
+		FromImage: "old_image",
+		ToImage:   newImage,
+		Backup:    "migrate-backup-20240101-000000",
+	}
+	currentState := common.ClusterState{
+		KeycloakDeployment: keycloakCurrentDeployment,
+	}
+
+	newDesiredState := func() common.DesiredClusterState {
+		keycloakDesiredDeployment := model.KeycloakDeployment(cr, nil, nil)
+		SetDeployment(keycloakDesiredDeployment, 3, "")
+		return common.DesiredClusterState{common.GenericUpdateAction{Ref: keycloakDesiredDeployment}}
+	}
+
+	// when
+	restoreActions, restoreErr := migrator.Migrate(cr, &currentState, newDesiredState())
+	currentState.KeycloakMigrationRestoreJob = &batchv1.Job{Status: batchv1.JobStatus{Succeeded: 1}}
+	restoredActions, restoredErr := migrator.Migrate(cr, &currentState, newDesiredState())
+	currentState.KeycloakMigrationRestoreJob = nil
+	pinnedActions, pinnedErr := migrator.Migrate(cr, &currentState, newDesiredState())
+
+	// then
+	assert.Nil(t, restoreErr)
+	kcAssert.ReplicasCount(t, restoreActions, 0)
+	assert.Equal(t, "migrate-backup-20240101-000000-restore", restoreActions[1].(common.GenericCreateAction).Ref.(*batchv1.Job).Name)
+	assert.Nil(t, restoredErr)
+	assert.Nil(t, pinnedErr)
+	assert.Equal(t, v1alpha1.MigrationPhaseRolledBack, cr.Status.Migration.Phase)
+	for _, actions := range []common.DesiredClusterState{restoredActions, pinnedActions} {
+		kcAssert.ReplicasCount(t, actions, 3)
+		assert.Len(t, actions, 1)
+		assert.Equal(t, "old_image", actions[0].(common.GenericUpdateAction).Ref.(*v1.StatefulSet).Spec.Template.Spec.Containers[0].Image)
+	}
+}
+
+func crashLoopingPod(image string) corev1.Pod {
+	return corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Image: image,
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					RestartCount: 4,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason: "CrashLoopBackOff",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func SetDeployment(deployment *v1.StatefulSet, replicasCount int32, image string) {
 	deployment.Spec.Replicas = &[]int32{replicasCount}[0]
 	deployment.Status.Replicas = replicasCount
@@ -8,6 +8,7 @@ import (
 	"github.com/keycloak/keycloak-operator/pkg/common"
 	"github.com/keycloak/keycloak-operator/pkg/model"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 type Reconciler interface {
@@ -34,17 +35,25 @@ func (i *KeycloakReconciler) Reconcile(clusterState *common.ClusterState, cr *kc
 
 	if !cr.Spec.ExternalDatabase.Enabled {
 		desired = desired.AddAction(i.getDatabaseSecretDesiredState(clusterState, cr))
-		desired = desired.AddAction(i.getPostgresqlPersistentVolumeClaimDesiredState(clusterState, cr))
-		desired = desired.AddAction(i.getPostgresqlDeploymentDesiredState(clusterState, cr))
-		desired = desired.AddAction(i.getPostgresqlServiceDesiredState(clusterState, cr, false))
+		if cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+			i.reconcilePostgresqlCluster(&desired, clusterState, cr)
+		} else {
+			desired = desired.AddAction(i.getPostgresqlPersistentVolumeClaimDesiredState(clusterState, cr))
+			desired = desired.AddAction(i.getPostgresqlDeploymentDesiredState(clusterState, cr))
+			desired = desired.AddAction(i.getPostgresqlServiceDesiredState(clusterState, cr, false))
+		}
 	} else {
 		i.reconcileExternalDatabase(&desired, clusterState, cr)
 	}
 
 	desired = desired.AddAction(i.getKeycloakServiceDesiredState(clusterState, cr))
+	if cr.Spec.CertManager.Enabled {
+		desired = desired.AddAction(i.getKeycloakCertificateDesiredState(clusterState, cr))
+	}
 	desired = desired.AddAction(i.getKeycloakDiscoveryServiceDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.getKeycloakMonitoringServiceDesiredState(clusterState, cr))
 	desired = desired.AddAction(i.GetKeycloakProbesDesiredState(clusterState, cr))
+	i.reconcileClustering(&desired, clusterState, cr)
 	desired = desired.AddAction(i.getKeycloakDeploymentOrRHSSODesiredState(clusterState, cr))
 	i.reconcileExternalAccess(&desired, clusterState, cr)
 	desired = desired.AddAction(i.getPodDisruptionBudgetDesiredState(clusterState, cr))
@@ -69,6 +78,56 @@ func (i *KeycloakReconciler) reconcileExternalDatabase(desired *common.DesiredCl
 	desired.AddAction(i.getPostgresqlServiceDesiredState(clusterState, cr, true))
 }
 
+func (i *KeycloakReconciler) reconcilePostgresqlCluster(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak) {
+	// The availability of the Postgres operator is validated before the reconciliation
+	provider, err := common.PostgresqlClusterProvider(cr)
+	if err != nil {
+		return
+	}
+	desired.AddAction(i.getPostgresqlClusterDesiredState(clusterState, cr, provider))
+	desired.AddAction(i.getPostgresqlClusterServiceDesiredState(clusterState, cr, provider))
+}
+
+func (i *KeycloakReconciler) reconcileClustering(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak) {
+	desired.AddAction(i.getKeycloakClusteringConfigMapDesiredState(clusterState, cr))
+	if cr.Spec.Clustering.Encryption.Enabled {
+		desired.AddAction(i.getKeycloakJGroupsKeystoreSecretDesiredState(clusterState, cr))
+	}
+	if model.IsKubePingDiscovery(cr) {
+		if cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName == "" {
+			desired.AddAction(i.getKeycloakServiceAccountDesiredState(clusterState, cr))
+		}
+		desired.AddAction(i.getKeycloakDiscoveryRoleDesiredState(clusterState, cr))
+		desired.AddAction(i.getKeycloakDiscoveryRoleBindingDesiredState(clusterState, cr))
+	}
+	i.reconcileCrossSiteCaches(desired, clusterState, cr)
+}
+
+func (i *KeycloakReconciler) reconcileCrossSiteCaches(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak) {
+	// The availability of the Infinispan Operator is validated before the reconciliation
+	cacheKindExists, _ := common.GetStateManager().GetState(common.InfinispanCacheKind).(bool)
+	if !cr.Spec.CrossSite.Enabled || cr.Spec.CrossSite.InfinispanCluster == "" || !cacheKindExists {
+		return
+	}
+	for _, cache := range model.KeycloakCrossSiteCaches(cr) {
+		desired.AddAction(i.getKeycloakCrossSiteCacheDesiredState(clusterState, cache))
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakCrossSiteCacheDesiredState(clusterState *common.ClusterState, cache *unstructured.Unstructured) common.ClusterAction {
+	currentCache, ok := clusterState.KeycloakCrossSiteCaches[cache.GetName()]
+	if !ok {
+		return common.GenericCreateAction{
+			Ref: cache,
+			Msg: "Create Keycloak Cross-Site Cache " + cache.GetName(),
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakCrossSiteCacheReconciled(cache, currentCache),
+		Msg: "Update Keycloak Cross-Site Cache " + cache.GetName(),
+	}
+}
+
 func (i *KeycloakReconciler) reconcileExternalAccess(desired *common.DesiredClusterState, clusterState *common.ClusterState, cr *kc.Keycloak) {
 	if !cr.Spec.ExternalAccess.Enabled {
 		return
@@ -114,8 +173,80 @@ func (i *KeycloakReconciler) GetKeycloakProbesDesiredState(clusterState *common.
 	return nil
 }
 
+func (i *KeycloakReconciler) getKeycloakClusteringConfigMapDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if model.KeycloakClusteringScript(cr) == "" {
+		if clusterState.KeycloakClusteringConfigMap == nil {
+			return nil
+		}
+		return common.GenericDeleteAction{
+			Ref: clusterState.KeycloakClusteringConfigMap,
+			Msg: "Delete Keycloak clustering configmap",
+		}
+	}
+
+	if clusterState.KeycloakClusteringConfigMap == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakClusteringConfigMap(cr),
+			Msg: "Create Keycloak clustering configmap",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakClusteringConfigMapReconciled(cr, clusterState.KeycloakClusteringConfigMap),
+		Msg: "Update Keycloak clustering configmap",
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakJGroupsKeystoreSecretDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakJGroupsKeystoreSecret == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakJGroupsKeystoreSecret(cr),
+			Msg: "Create JGroups keystore secret",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakJGroupsKeystoreSecretReconciled(cr, clusterState.KeycloakJGroupsKeystoreSecret),
+		Msg: "Update JGroups keystore secret",
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakServiceAccountDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakServiceAccount == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakDiscoveryServiceAccount(cr),
+			Msg: "Create Keycloak service account",
+		}
+	}
+	return nil
+}
+
+func (i *KeycloakReconciler) getKeycloakDiscoveryRoleDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakDiscoveryRole == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakDiscoveryRole(cr),
+			Msg: "Create Keycloak discovery role",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakDiscoveryRoleReconciled(cr, clusterState.KeycloakDiscoveryRole),
+		Msg: "Update Keycloak discovery role",
+	}
+}
+
+func (i *KeycloakReconciler) getKeycloakDiscoveryRoleBindingDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakDiscoveryRoleBinding == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakDiscoveryRoleBinding(cr),
+			Msg: "Create Keycloak discovery role binding",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakDiscoveryRoleBindingReconciled(cr, clusterState.KeycloakDiscoveryRoleBinding),
+		Msg: "Update Keycloak discovery role binding",
+	}
+}
+
 func (i *KeycloakReconciler) getPostgresqlPersistentVolumeClaimDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
-	postgresqlPersistentVolume := model.PostgresqlPersistentVolumeClaim(cr)
+	postgresqlPersistentVolume := model.PostgresqlPersistentVolumeClaim(cr, model.GetDatabaseVersion(clusterState.DatabaseSecret))
 	if clusterState.PostgresqlPersistentVolumeClaim == nil {
 		return common.GenericCreateAction{
 			Ref: postgresqlPersistentVolume,
@@ -137,7 +268,7 @@ func (i *KeycloakReconciler) getPostgresqlServiceDesiredState(clusterState *comm
 		}
 	}
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlServiceReconciled(clusterState.PostgresqlService, clusterState.DatabaseSecret, isExternal),
+		Ref: model.PostgresqlServiceReconciled(cr, clusterState.PostgresqlService, clusterState.DatabaseSecret, isExternal),
 		Msg: "Update Postgresql KeycloakService",
 	}
 }
@@ -147,7 +278,8 @@ func (i *KeycloakReconciler) getPostgresqlDeploymentDesiredState(clusterState *c
 	stateManager := common.GetStateManager()
 	isOpenshift, _ := stateManager.GetState(common.OpenShiftAPIServerKind).(bool)
 
-	postgresqlDeployment := model.PostgresqlDeployment(cr, isOpenshift)
+	version := model.GetDatabaseVersion(clusterState.DatabaseSecret)
+	postgresqlDeployment := model.PostgresqlDeployment(cr, version, isOpenshift)
 
 	if clusterState.PostgresqlDeployment == nil {
 		return common.GenericCreateAction{
@@ -156,11 +288,37 @@ func (i *KeycloakReconciler) getPostgresqlDeploymentDesiredState(clusterState *c
 		}
 	}
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlDeploymentReconciled(cr, clusterState.PostgresqlDeployment),
+		Ref: model.PostgresqlDeploymentReconciled(cr, version, clusterState.PostgresqlDeployment),
 		Msg: "Update Postgresql Deployment",
 	}
 }
 
+func (i *KeycloakReconciler) getPostgresqlClusterDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak, provider kc.PostgresqlProvider) common.ClusterAction {
+	if clusterState.PostgresqlCluster == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlCluster(cr, provider),
+			Msg: "Create Postgresql Cluster",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlClusterReconciled(cr, provider, clusterState.PostgresqlCluster),
+		Msg: "Update Postgresql Cluster",
+	}
+}
+
+func (i *KeycloakReconciler) getPostgresqlClusterServiceDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak, provider kc.PostgresqlProvider) common.ClusterAction {
+	if clusterState.PostgresqlService == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlClusterService(cr, provider),
+			Msg: "Create Postgresql Cluster KeycloakService",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlClusterServiceReconciled(cr, provider, clusterState.PostgresqlService),
+		Msg: "Update Postgresql Cluster KeycloakService",
+	}
+}
+
 func (i *KeycloakReconciler) getKeycloakServiceDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
 	keycloakService := model.KeycloakService(cr)
 
@@ -221,18 +379,15 @@ func (i *KeycloakReconciler) GetKeycloakPrometheusRuleDesiredState(clusterState
 		return nil
 	}
 
-	prometheusrule := model.PrometheusRule(cr)
-
 	if clusterState.KeycloakPrometheusRule == nil {
 		return common.GenericCreateAction{
-			Ref: prometheusrule,
+			Ref: model.PrometheusRule(cr),
 			Msg: "create keycloak prometheus rule",
 		}
 	}
 
-	prometheusrule.ResourceVersion = clusterState.KeycloakPrometheusRule.ResourceVersion
 	return common.GenericUpdateAction{
-		Ref: prometheusrule,
+		Ref: model.PrometheusRuleReconciled(cr, clusterState.KeycloakPrometheusRule),
 		Msg: "update keycloak prometheus rule",
 	}
 }
@@ -292,8 +447,12 @@ func (i *KeycloakReconciler) getDatabaseSecretDesiredState(clusterState *common.
 			Msg: "Create Database Secret",
 		}
 	}
+	databaseSecretReconciled := model.DatabaseSecretReconciled(cr, clusterState.DatabaseSecret)
+	if cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+		databaseSecretReconciled = model.PostgresqlClusterDatabaseSecretReconciled(databaseSecretReconciled, clusterState.PostgresqlClusterSecret)
+	}
 	return common.GenericUpdateAction{
-		Ref: model.DatabaseSecretReconciled(cr, clusterState.DatabaseSecret),
+		Ref: databaseSecretReconciled,
 		Msg: "Update Database Secret",
 	}
 }
@@ -310,6 +469,10 @@ func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterSta
 	}
 
 	if clusterState.KeycloakDeployment == nil {
+		model.SetKeycloakThemesChecksum(cr, deployment, clusterState.KeycloakThemeConfigMaps)
+		model.SetKeycloakServingCertChecksum(cr, deployment, clusterState.KeycloakServingCertSecret)
+		model.SetKeycloakDatabaseCredentialsChecksum(cr, deployment, clusterState.DatabaseSecret)
+		model.SetKeycloakClusteringChecksum(cr, deployment, clusterState.KeycloakJGroupsKeystoreSecret)
 		return common.GenericCreateAction{
 			Ref: deployment,
 			Msg: "Create " + deploymentName + " Deployment (StatefulSet)",
@@ -320,6 +483,10 @@ func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterSta
 	if isRHSSO {
 		deploymentReconciled = model.RHSSODeploymentReconciled(cr, clusterState.KeycloakDeployment, clusterState.DatabaseSecret, clusterState.DatabaseSSLCert)
 	}
+	model.SetKeycloakThemesChecksum(cr, deploymentReconciled, clusterState.KeycloakThemeConfigMaps)
+	model.SetKeycloakServingCertChecksum(cr, deploymentReconciled, clusterState.KeycloakServingCertSecret)
+	model.SetKeycloakDatabaseCredentialsChecksum(cr, deploymentReconciled, clusterState.DatabaseSecret)
+	model.SetKeycloakClusteringChecksum(cr, deploymentReconciled, clusterState.KeycloakJGroupsKeystoreSecret)
 
 	return common.GenericUpdateAction{
 		Ref: deploymentReconciled,
@@ -327,6 +494,19 @@ func (i *KeycloakReconciler) getKeycloakDeploymentOrRHSSODesiredState(clusterSta
 	}
 }
 
+func (i *KeycloakReconciler) getKeycloakCertificateDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
+	if clusterState.KeycloakCertificate == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakCertificate(cr),
+			Msg: "Create Keycloak Certificate",
+		}
+	}
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakCertificateReconciled(cr, clusterState.KeycloakCertificate),
+		Msg: "Update Keycloak Certificate",
+	}
+}
+
 func (i *KeycloakReconciler) getKeycloakRouteDesiredState(clusterState *common.ClusterState, cr *kc.Keycloak) common.ClusterAction {
 	if clusterState.KeycloakRoute == nil {
 		return common.GenericCreateAction{
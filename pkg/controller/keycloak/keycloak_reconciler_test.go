@@ -80,8 +80,8 @@ func TestKeycloakReconciler_Test_Creating_All(t *testing.T) {
 	assert.IsType(t, model.ServiceMonitor(cr), desiredState[2].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.GrafanaDashboard(cr), desiredState[3].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.DatabaseSecret(cr), desiredState[4].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.PostgresqlPersistentVolumeClaim(cr), desiredState[5].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.PostgresqlDeployment(cr, true), desiredState[6].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlPersistentVolumeClaim(cr, model.PostgresqlDefaultVersion), desiredState[5].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, true), desiredState[6].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.PostgresqlService(cr, model.DatabaseSecret(cr), false), desiredState[7].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakService(cr), desiredState[8].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakDiscoveryService(cr), desiredState[9].(common.GenericCreateAction).Ref)
@@ -144,9 +144,9 @@ func TestKeycloakReconciler_Test_Updating_RHSSO(t *testing.T) {
 		KeycloakPrometheusRule:          model.PrometheusRule(cr),
 		KeycloakGrafanaDashboard:        model.GrafanaDashboard(cr),
 		DatabaseSecret:                  model.DatabaseSecret(cr),
-		PostgresqlPersistentVolumeClaim: model.PostgresqlPersistentVolumeClaim(cr),
+		PostgresqlPersistentVolumeClaim: model.PostgresqlPersistentVolumeClaim(cr, model.PostgresqlDefaultVersion),
 		PostgresqlService:               model.PostgresqlService(cr, model.DatabaseSecret(cr), false),
-		PostgresqlDeployment:            model.PostgresqlDeployment(cr, true),
+		PostgresqlDeployment:            model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, true),
 		KeycloakService:                 model.KeycloakService(cr),
 		KeycloakDiscoveryService:        model.KeycloakDiscoveryService(cr),
 		KeycloakDeployment:              model.RHSSODeployment(cr, model.DatabaseSecret(cr), nil),
@@ -187,9 +187,9 @@ func TestKeycloakReconciler_Test_Updating_All(t *testing.T) {
 		KeycloakPrometheusRule:          model.PrometheusRule(cr),
 		KeycloakGrafanaDashboard:        model.GrafanaDashboard(cr),
 		DatabaseSecret:                  model.DatabaseSecret(cr),
-		PostgresqlPersistentVolumeClaim: model.PostgresqlPersistentVolumeClaim(cr),
+		PostgresqlPersistentVolumeClaim: model.PostgresqlPersistentVolumeClaim(cr, model.PostgresqlDefaultVersion),
 		PostgresqlService:               model.PostgresqlService(cr, model.DatabaseSecret(cr), false),
-		PostgresqlDeployment:            model.PostgresqlDeployment(cr, true),
+		PostgresqlDeployment:            model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, true),
 		KeycloakService:                 model.KeycloakService(cr),
 		KeycloakDiscoveryService:        model.KeycloakDiscoveryService(cr),
 		KeycloakMonitoringService:       model.KeycloakMonitoringService(cr),
@@ -248,8 +248,8 @@ func TestKeycloakReconciler_Test_Updating_All(t *testing.T) {
 	assert.IsType(t, model.ServiceMonitor(cr), desiredState[2].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.GrafanaDashboard(cr), desiredState[3].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.DatabaseSecret(cr), desiredState[4].(common.GenericUpdateAction).Ref)
-	assert.IsType(t, model.PostgresqlPersistentVolumeClaim(cr), desiredState[5].(common.GenericUpdateAction).Ref)
-	assert.IsType(t, model.PostgresqlDeployment(cr, true), desiredState[6].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlPersistentVolumeClaim(cr, model.PostgresqlDefaultVersion), desiredState[5].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, true), desiredState[6].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.PostgresqlService(cr, model.DatabaseSecret(cr), false), desiredState[7].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.KeycloakService(cr), desiredState[8].(common.GenericUpdateAction).Ref)
 	assert.IsType(t, model.KeycloakDiscoveryService(cr), desiredState[9].(common.GenericUpdateAction).Ref)
@@ -772,7 +772,7 @@ func TestKeycloakReconciler_Test_Setting_Resources(t *testing.T) {
 	//    6) Postgresql Deployment
 	//    12) Keycloak StatefulSets
 	assert.Equal(t, 14, len(desiredState))
-	assert.IsType(t, model.PostgresqlDeployment(cr, false), desiredState[6].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, false), desiredState[6].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil), desiredState[12].(common.GenericCreateAction).Ref)
 	keycloakContainer := desiredState[12].(common.GenericCreateAction).Ref.(*v13.StatefulSet).Spec.Template.Spec.Containers[0]
 	assert.Equal(t, &resource700Mi, keycloakContainer.Resources.Requests.Memory(), "Keycloak Deployment: Memory-Requests should be: "+resource700Mi.String()+" but is "+keycloakContainer.Resources.Requests.Memory().String())
@@ -811,7 +811,7 @@ func TestKeycloakReconciler_Test_No_Resources_Specified(t *testing.T) {
 	//    6) Postgresql Deployment
 	//    12) Keycloak StatefulSets
 	assert.Equal(t, 14, len(desiredState))
-	assert.IsType(t, model.PostgresqlDeployment(cr, true), desiredState[6].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, true), desiredState[6].(common.GenericCreateAction).Ref)
 	assert.IsType(t, model.KeycloakDeployment(cr, model.DatabaseSecret(cr), nil), desiredState[12].(common.GenericCreateAction).Ref)
 	keycloakContainer := desiredState[12].(common.GenericCreateAction).Ref.(*v13.StatefulSet).Spec.Template.Spec.Containers[0]
 	assert.Equal(t, 0, len(keycloakContainer.Resources.Requests), "Requests-List should be empty")
@@ -874,3 +874,176 @@ func TestKeycloakReconciler_Test_Should_Create_Backup(t *testing.T) {
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[9])
 	assert.IsType(t, model.KeycloakMigrationOneTimeBackup(backupCr), desiredState[9].(common.GenericUpdateAction).Ref)
 }
+
+func TestKeycloakReconciler_Test_CertManager_Certificate(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.CertManager.Enabled = true
+	cr.Spec.CertManager.IssuerRef.Name = "ca-issuer"
+
+	currentState := common.NewClusterState()
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var certificateActions []common.ClusterAction
+	for _, action := range desiredState {
+		if createAction, ok := action.(common.GenericCreateAction); ok && createAction.Msg == "Create Keycloak Certificate" {
+			certificateActions = append(certificateActions, action)
+		}
+	}
+	assert.Len(t, certificateActions, 1)
+	assert.Equal(t, model.KeycloakCertificate(cr), certificateActions[0].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakReconciler_Test_CertManager_Renewal_Rolls_Pods(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.CertManager.Enabled = true
+	cr.Spec.CertManager.IssuerRef.Name = "ca-issuer"
+
+	currentState := common.NewClusterState()
+	currentState.KeycloakDeployment = model.KeycloakDeployment(cr, nil, nil)
+	currentState.KeycloakCertificate = model.KeycloakCertificate(cr)
+	currentState.KeycloakServingCertSecret = &v1.Secret{
+		Data: map[string][]byte{
+			"tls.crt": []byte("certificate"),
+		},
+	}
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	before := getStatefulSetFromDesiredState(reconciler.Reconcile(currentState, cr))
+	currentState.KeycloakServingCertSecret.Data["tls.crt"] = []byte("renewed certificate")
+	after := getStatefulSetFromDesiredState(reconciler.Reconcile(currentState, cr))
+
+	// then
+	assert.NotEmpty(t, before.Spec.Template.Annotations[model.KeycloakServingCertChecksumAnnotation])
+	assert.NotEqual(t, before.Spec.Template.Annotations[model.KeycloakServingCertChecksumAnnotation], after.Spec.Template.Annotations[model.KeycloakServingCertChecksumAnnotation])
+}
+
+func getStatefulSetFromDesiredState(desiredState common.DesiredClusterState) *v13.StatefulSet {
+	for _, action := range desiredState {
+		if updateAction, ok := action.(common.GenericUpdateAction); ok {
+			if statefulSet, ok := updateAction.Ref.(*v13.StatefulSet); ok {
+				return statefulSet
+			}
+		}
+	}
+	return nil
+}
+
+func TestKeycloakReconciler_Test_Creating_Postgresql_Cluster(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+
+	stateManager := common.GetStateManager()
+	stateManager.SetState(common.CloudNativePGClusterKind, true)
+	defer stateManager.SetState(common.CloudNativePGClusterKind, false)
+
+	currentState := common.NewClusterState()
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.IsType(t, model.DatabaseSecret(cr), desiredState[1].(common.GenericCreateAction).Ref)
+	assert.Equal(t, model.PostgresqlCluster(cr, v1alpha1.PostgresqlProviderCloudNativePG), desiredState[2].(common.GenericCreateAction).Ref)
+	service := desiredState[3].(common.GenericCreateAction).Ref.(*v1.Service)
+	assert.Equal(t, model.PostgresqlServiceName, service.Name)
+	assert.Equal(t, "keycloak-db-rw.keycloak.svc.cluster.local", service.Spec.ExternalName)
+	for _, action := range desiredState {
+		if createAction, ok := action.(common.GenericCreateAction); ok {
+			assert.NotEqual(t, "Create Postgresql Deployment", createAction.Msg)
+			assert.NotEqual(t, "Create Postgresql PersistentVolumeClaim", createAction.Msg)
+		}
+	}
+}
+
+func TestKeycloakReconciler_Test_Postgresql_Cluster_Credentials_Are_Synced(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Provider = v1alpha1.PostgresqlProviderZalando
+
+	stateManager := common.GetStateManager()
+	stateManager.SetState(common.ZalandoPostgresqlKind, true)
+	defer stateManager.SetState(common.ZalandoPostgresqlKind, false)
+
+	currentState := common.NewClusterState()
+	currentState.DatabaseSecret = model.DatabaseSecret(cr)
+	currentState.PostgresqlService = model.PostgresqlService(cr, currentState.DatabaseSecret, false)
+	currentState.PostgresqlCluster = model.PostgresqlCluster(cr, v1alpha1.PostgresqlProviderZalando)
+	currentState.PostgresqlClusterSecret = &v1.Secret{
+		Data: map[string][]byte{
+			"username": []byte("keycloak"),
+			"password": []byte("generated"),
+		},
+	}
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	databaseSecret := desiredState[1].(common.GenericUpdateAction).Ref.(*v1.Secret)
+	assert.Equal(t, []byte("generated"), databaseSecret.Data[model.DatabaseSecretPasswordProperty])
+	assert.Equal(t, []byte(model.PostgresqlClusterVersion), databaseSecret.Data[model.DatabaseSecretVersionProperty])
+	service := desiredState[3].(common.GenericUpdateAction).Ref.(*v1.Service)
+	assert.Equal(t, v1.ServiceTypeExternalName, service.Spec.Type)
+	assert.Nil(t, service.Spec.Selector)
+	assert.Equal(t, "keycloak-db..svc.cluster.local", service.Spec.ExternalName)
+}
+
+func TestKeycloakReconciler_Test_Clustering(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.Clustering.Discovery = v1alpha1.DiscoveryProtocolKubePing
+	cr.Spec.Clustering.Encryption.Enabled = true
+
+	currentState := common.NewClusterState()
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var created []string
+	for _, action := range desiredState {
+		if createAction, ok := action.(common.GenericCreateAction); ok {
+			created = append(created, createAction.Msg)
+		}
+	}
+	assert.Contains(t, created, "Create Keycloak clustering configmap")
+	assert.Contains(t, created, "Create JGroups keystore secret")
+	assert.Contains(t, created, "Create Keycloak service account")
+	assert.Contains(t, created, "Create Keycloak discovery role")
+	assert.Contains(t, created, "Create Keycloak discovery role binding")
+}
+
+func TestKeycloakReconciler_Test_Clustering_Disabled_Deletes_Script(t *testing.T) {
+	// given
+	cr := &v1alpha1.Keycloak{}
+	currentState := common.NewClusterState()
+	currentState.KeycloakClusteringConfigMap = model.KeycloakClusteringConfigMap(cr)
+
+	// when
+	reconciler := NewKeycloakReconciler()
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	var deleted []common.ClusterAction
+	for _, action := range desiredState {
+		if deleteAction, ok := action.(common.GenericDeleteAction); ok && deleteAction.Msg == "Delete Keycloak clustering configmap" {
+			deleted = append(deleted, action)
+		}
+	}
+	assert.Len(t, deleted, 1)
+}
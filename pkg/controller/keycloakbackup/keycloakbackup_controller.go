@@ -7,11 +7,13 @@ import (
 
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/keycloak/keycloak-operator/pkg/model"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/batch/v1"
 	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -94,6 +96,7 @@ type ReconcileKeycloakBackup struct {
 func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakBackup")
+	defer common.ObserveReconcile(common.KeycloakBackupKind, request.NamespacedName, time.Now())
 
 	// Fetch the KeycloakBackup instance
 	instance := &kc.KeycloakBackup{}
@@ -103,6 +106,8 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			backupMetricsCollector.delete(request.Namespace, request.Name)
+			common.ClearResourceFailing(common.KeycloakBackupKind, request.Namespace, request.Name)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -116,6 +121,21 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 		return reconcile.Result{Requeue: false}, nil
 	}
 
+	err = model.ValidateKeycloakBackupDestination(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakBackupRetention(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
+	err = model.ValidateKeycloakRealmBackup(instance)
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
+
 	keycloaks, err := common.GetMatchingKeycloaks(r.context, r.client, instance.Spec.InstanceSelector)
 	if err != nil {
 		return r.ManageError(instance, err)
@@ -130,8 +150,13 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 
 	var currentState *common.BackupState
 	for _, keycloak := range keycloaks.Items {
-		if keycloak.Spec.Unmanaged {
-			return r.ManageError(instance, errors.Errorf("backups cannot be created for unmanaged keycloak instances"))
+		// Realms are exported through the admin API, which unmanaged instances provide as well
+		if keycloak.Spec.Unmanaged && instance.Spec.Mode != kc.BackupModeRealms {
+			return r.ManageError(instance, errors.Errorf("database backups cannot be created for unmanaged keycloak instances, use the realms mode instead"))
+		}
+		err = model.ValidateKeycloakBackupDatabase(instance, &keycloak)
+		if err != nil {
+			return r.ManageError(instance, err)
 		}
 
 		currentState = common.NewBackupState(keycloak)
@@ -151,12 +176,38 @@ func (r *ReconcileKeycloakBackup) Reconcile(request reconcile.Request) (reconcil
 	return r.ManageSuccess(instance, currentState)
 }
 
+// updateBackupHistory records the runs of the backup Jobs and when backups were last scheduled and successful
+func updateBackupHistory(instance *kc.KeycloakBackup, currentState *common.BackupState, backupPods []corev1.Pod) {
+	instance.Status.History = model.PostgresqlBackupHistory(instance, currentState.BackupJobsForHistory(), backupPods)
+
+	for _, run := range instance.Status.History {
+		if run.StartTime != nil && laterTime(run.StartTime, instance.Status.LastScheduleTime) {
+			instance.Status.LastScheduleTime = run.StartTime
+		}
+		if run.Phase == kc.BackupRunPhaseSucceeded && run.CompletionTime != nil && laterTime(run.CompletionTime, instance.Status.LastSuccessfulTime) {
+			instance.Status.LastSuccessfulTime = run.CompletionTime
+		}
+	}
+	if cronJob := currentState.BackupCronJob(); cronJob != nil && cronJob.Status.LastScheduleTime != nil && laterTime(cronJob.Status.LastScheduleTime, instance.Status.LastScheduleTime) {
+		instance.Status.LastScheduleTime = cronJob.Status.LastScheduleTime
+	}
+
+	if instance.Status.LastSuccessfulTime != nil {
+		backupMetricsCollector.setLastSuccess(instance.Namespace, instance.Name, instance.Status.LastSuccessfulTime.Time)
+	}
+}
+
+func laterTime(time *metav1.Time, than *metav1.Time) bool {
+	return than == nil || than.Before(time)
+}
+
 func (r *ReconcileKeycloakBackup) ManageError(instance *kc.KeycloakBackup, issue error) (reconcile.Result, error) {
 	r.recorder.Event(instance, "Warning", "ProcessingError", issue.Error())
 
 	instance.Status.Message = issue.Error()
 	instance.Status.Ready = false
 	instance.Status.Phase = kc.BackupPhaseFailing
+	common.SetResourceFailing(common.KeycloakBackupKind, instance.Namespace, instance.Name)
 
 	err := r.client.Status().Update(r.context, instance)
 	if err != nil {
@@ -176,10 +227,28 @@ func (r *ReconcileKeycloakBackup) ManageSuccess(instance *kc.KeycloakBackup, cur
 	}
 	instance.Status.Ready = resourcesReady
 	instance.Status.Message = ""
+	common.ClearResourceFailing(common.KeycloakBackupKind, instance.Namespace, instance.Name)
+
+	var backupPods []corev1.Pod
+	if currentState.BackupPods != nil {
+		backupPods = currentState.BackupPods.Items
+		if artifacts, ok := model.PostgresqlBackupArtifacts(instance, backupPods); ok {
+			instance.Status.Artifacts = artifacts
+		}
+	}
+	updateBackupHistory(instance, currentState, backupPods)
+
+	restored, err := currentState.IsRestored()
+	if err != nil {
+		return r.ManageError(instance, err)
+	}
 
-	if resourcesReady {
+	switch {
+	case restored:
+		instance.Status.Phase = kc.BackupPhaseRestored
+	case resourcesReady:
 		instance.Status.Phase = kc.BackupPhaseCreated
-	} else {
+	default:
 		instance.Status.Phase = kc.BackupPhaseReconciling
 	}
 
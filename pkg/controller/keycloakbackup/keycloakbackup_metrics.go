@@ -0,0 +1,69 @@
+package keycloakbackup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	backupLastSuccessDesc = prometheus.NewDesc(
+		"keycloak_backup_last_success_timestamp_seconds",
+		"Unix time of the last successful backup of a KeycloakBackup.",
+		[]string{"namespace", "backup"}, nil,
+	)
+	backupAgeDesc = prometheus.NewDesc(
+		"keycloak_backup_age_seconds",
+		"Seconds since the last successful backup of a KeycloakBackup completed.",
+		[]string{"namespace", "backup"}, nil,
+	)
+)
+
+// backupMetrics computes the age of the backups at scrape time, so that it keeps growing between reconciles
+type backupMetrics struct {
+	mutex       sync.Mutex
+	lastSuccess map[backupKey]time.Time
+}
+
+type backupKey struct {
+	namespace string
+	name      string
+}
+
+var backupMetricsCollector = &backupMetrics{
+	lastSuccess: make(map[backupKey]time.Time),
+}
+
+func init() {
+	metrics.Registry.MustRegister(backupMetricsCollector)
+}
+
+func (m *backupMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- backupLastSuccessDesc
+	ch <- backupAgeDesc
+}
+
+func (m *backupMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for key, lastSuccess := range m.lastSuccess {
+		ch <- prometheus.MustNewConstMetric(backupLastSuccessDesc, prometheus.GaugeValue, float64(lastSuccess.Unix()), key.namespace, key.name)
+		ch <- prometheus.MustNewConstMetric(backupAgeDesc, prometheus.GaugeValue, now.Sub(lastSuccess).Seconds(), key.namespace, key.name)
+	}
+}
+
+func (m *backupMetrics) setLastSuccess(namespace string, name string, lastSuccess time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.lastSuccess[backupKey{namespace: namespace, name: name}] = lastSuccess
+}
+
+func (m *backupMetrics) delete(namespace string, name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.lastSuccess, backupKey{namespace: namespace, name: name})
+}
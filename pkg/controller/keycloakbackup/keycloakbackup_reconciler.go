@@ -23,8 +23,25 @@ func NewKeycloakBackupReconciler(keycloak kc.Keycloak) *KeycloakBackupReconciler
 func (i *KeycloakBackupReconciler) Reconcile(currentState *common.BackupState, cr *kc.KeycloakBackup) common.DesiredClusterState {
 	desired := common.DesiredClusterState{}
 
-	if cr.Spec.AWS != (kc.KeycloakAWSSpec{}) {
-		if cr.Spec.AWS.Schedule == "" {
+	if destination := model.PostgresqlBackupDestination(cr); destination != nil {
+		if destination.PersistentVolumeClaim != nil && destination.PersistentVolumeClaim.ClaimName == "" {
+			desired = desired.AddAction(i.GetDestinationBackupPersistentVolumeDesiredState(currentState, cr))
+		}
+		switch {
+		case cr.Spec.Mode == kc.BackupModeRealms && model.PostgresqlBackupSchedule(cr) == "":
+			desired = desired.AddAction(i.GetRealmBackupDesiredState(currentState, cr))
+		case cr.Spec.Mode == kc.BackupModeRealms:
+			desired = desired.AddAction(i.GetRealmPeriodicBackupDesiredState(currentState, cr))
+		case model.PostgresqlBackupSchedule(cr) == "":
+			desired = desired.AddAction(i.GetDestinationBackupDesiredState(currentState, cr))
+		default:
+			desired = desired.AddAction(i.GetDestinationPeriodicBackupDesiredState(currentState, cr))
+		}
+		if cr.Spec.Mode == kc.BackupModeRealms && cr.Spec.Restore {
+			desired = desired.AddAction(i.GetRealmRestoreDesiredState(currentState, cr))
+		}
+	} else if cr.Spec.AWS != (kc.KeycloakAWSSpec{}) {
+		if model.PostgresqlBackupSchedule(cr) == "" {
 			desired = desired.AddAction(i.GetAwsBackupDesiredState(currentState, cr))
 		} else {
 			desired = desired.AddAction(i.GetAwsPeriodicBackupDesiredState(currentState, cr))
@@ -37,6 +54,88 @@ func (i *KeycloakBackupReconciler) Reconcile(currentState *common.BackupState, c
 	return desired
 }
 
+func (i *KeycloakBackupReconciler) GetDestinationBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.DestinationJob == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlDestinationBackup(cr, &i.Keycloak),
+			Msg: "Create Backup job",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlDestinationBackupReconciled(cr, &i.Keycloak, currentState.DestinationJob),
+		Msg: "Update Backup job",
+	}
+}
+
+func (i *KeycloakBackupReconciler) GetDestinationPeriodicBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.DestinationPeriodicJob == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlDestinationPeriodicBackup(cr, &i.Keycloak),
+			Msg: "Create Periodic Backup job",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlDestinationPeriodicBackupReconciled(cr, &i.Keycloak, currentState.DestinationPeriodicJob),
+		Msg: "Update Periodic Backup job",
+	}
+}
+
+func (i *KeycloakBackupReconciler) GetRealmBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.DestinationJob == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakRealmBackup(cr, &i.Keycloak),
+			Msg: "Create Realm Backup job",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakRealmBackupReconciled(cr, &i.Keycloak, currentState.DestinationJob),
+		Msg: "Update Realm Backup job",
+	}
+}
+
+func (i *KeycloakBackupReconciler) GetRealmPeriodicBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.DestinationPeriodicJob == nil {
+		return common.GenericCreateAction{
+			Ref: model.KeycloakRealmPeriodicBackup(cr, &i.Keycloak),
+			Msg: "Create Periodic Realm Backup job",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.KeycloakRealmPeriodicBackupReconciled(cr, &i.Keycloak, currentState.DestinationPeriodicJob),
+		Msg: "Update Periodic Realm Backup job",
+	}
+}
+
+// The restore Job only runs once, it's not updated after it has been created
+func (i *KeycloakBackupReconciler) GetRealmRestoreDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.RestoreJob != nil {
+		return nil
+	}
+
+	return common.GenericCreateAction{
+		Ref: model.KeycloakRealmRestore(cr, &i.Keycloak),
+		Msg: "Create Realm Restore job",
+	}
+}
+
+func (i *KeycloakBackupReconciler) GetDestinationBackupPersistentVolumeDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
+	if currentState.LocalPersistentVolumeClaim == nil {
+		return common.GenericCreateAction{
+			Ref: model.PostgresqlBackupPersistentVolumeClaim(cr),
+			Msg: "Create Backup Persistent Volume Claim",
+		}
+	}
+
+	return common.GenericUpdateAction{
+		Ref: model.PostgresqlBackupPersistentVolumeClaimReconciled(cr, currentState.LocalPersistentVolumeClaim),
+		Msg: "Update Backup Persistent Volume Claim",
+	}
+}
+
 func (i *KeycloakBackupReconciler) GetAwsPeriodicBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
 	if currentState.AwsPeriodicJob == nil {
 		return common.GenericCreateAction{
@@ -68,13 +167,13 @@ func (i *KeycloakBackupReconciler) GetAwsBackupDesiredState(currentState *common
 func (i *KeycloakBackupReconciler) GetLocalBackupDesiredState(currentState *common.BackupState, cr *kc.KeycloakBackup) common.ClusterAction {
 	if currentState.LocalPersistentVolumeJob == nil {
 		return common.GenericCreateAction{
-			Ref: model.PostgresqlBackup(cr),
+			Ref: model.PostgresqlBackup(cr, &i.Keycloak),
 			Msg: "Create Local Backup job",
 		}
 	}
 
 	return common.GenericUpdateAction{
-		Ref: model.PostgresqlBackupReconciled(cr, currentState.LocalPersistentVolumeJob),
+		Ref: model.PostgresqlBackupReconciled(cr, &i.Keycloak, currentState.LocalPersistentVolumeJob),
 		Msg: "Update Local Backup job",
 	}
 }
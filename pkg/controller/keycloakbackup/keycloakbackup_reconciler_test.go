@@ -27,7 +27,7 @@ func TestKeycloakBackupReconciler_Test_Creating_Local_Backup_Job(t *testing.T) {
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericCreateAction{}, desiredState[1])
 	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericCreateAction).Ref)
-	assert.IsType(t, model.PostgresqlBackup(cr), desiredState[1].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlBackup(cr, &keycloak), desiredState[1].(common.GenericCreateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Updating_Local_Backup_Job(t *testing.T) {
@@ -48,7 +48,7 @@ func TestKeycloakBackupReconciler_Test_Updating_Local_Backup_Job(t *testing.T) {
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[1])
 	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericUpdateAction).Ref)
-	assert.IsType(t, model.PostgresqlBackup(cr), desiredState[1].(common.GenericUpdateAction).Ref)
+	assert.IsType(t, model.PostgresqlBackup(cr, &keycloak), desiredState[1].(common.GenericUpdateAction).Ref)
 }
 
 func TestKeycloakBackupReconciler_Test_Creating_AWS_Job(t *testing.T) {
@@ -144,3 +144,224 @@ func TestKeycloakBackupReconciler_Test_Updating_AWS_Periodic_Job(t *testing.T) {
 	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
 	assert.IsType(t, model.PostgresqlAWSPeriodicBackup(cr), desiredState[0].(common.GenericUpdateAction).Ref)
 }
+
+func TestKeycloakBackupReconciler_Test_Creating_S3_Destination_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{
+					Bucket:                "keycloak",
+					Endpoint:              "https://minio.example.com:9000",
+					ForcePathStyle:        true,
+					CredentialsSecretName: "minio-secret",
+				},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 1)
+	assert.IsType(t, common.GenericCreateAction{}, desiredState[0])
+	assert.IsType(t, model.PostgresqlDestinationBackup(cr, &keycloak), desiredState[0].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Updating_Destination_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				GCS: &v1alpha1.KeycloakBackupGCSDestination{
+					Bucket:                "keycloak",
+					CredentialsSecretName: "gcs-secret",
+				},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		DestinationJob: &v1.Job{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 1)
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
+	assert.IsType(t, model.PostgresqlDestinationBackup(cr, &keycloak), desiredState[0].(common.GenericUpdateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_PVC_Destination_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlDestinationBackup(cr, &keycloak), desiredState[1].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Existing_Claim_Destination_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{
+					ClaimName: "existing-claim",
+				},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 1)
+	job := desiredState[0].(common.GenericCreateAction).Ref.(*v1.Job)
+	assert.Equal(t, "existing-claim", job.Spec.Template.Spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_Scheduled_Local_Backup(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule: "0 3 * * *",
+			Retention: &v1alpha1.KeycloakBackupRetention{
+				KeepDaily: 7,
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.IsType(t, model.PostgresqlBackupPersistentVolumeClaim(cr), desiredState[0].(common.GenericCreateAction).Ref)
+	assert.IsType(t, model.PostgresqlDestinationPeriodicBackup(cr, &keycloak), desiredState[1].(common.GenericCreateAction).Ref)
+}
+
+func TestKeycloakBackupReconciler_Test_Updating_Destination_Periodic_Job(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Schedule: "0 3 * * *",
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				Azure: &v1alpha1.KeycloakBackupAzureDestination{
+					StorageAccount:        "account",
+					Container:             "keycloak",
+					CredentialsSecretName: "azure-secret",
+				},
+			},
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		DestinationPeriodicJob: &v1beta1.CronJob{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 1)
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
+	cronJob := desiredState[0].(common.GenericUpdateAction).Ref.(*v1beta1.CronJob)
+	assert.Equal(t, "0 3 * * *", cronJob.Spec.Schedule)
+}
+
+func TestKeycloakBackupReconciler_Test_Creating_Realm_Backup_And_Restore_Jobs(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Mode:    v1alpha1.BackupModeRealms,
+			Restore: true,
+			Destination: &v1alpha1.KeycloakBackupDestination{
+				S3: &v1alpha1.KeycloakBackupS3Destination{
+					Bucket:                "keycloak",
+					CredentialsSecretName: "s3-secret",
+				},
+			},
+		},
+	}
+	cr.Name = "realms"
+	keycloak := v1alpha1.Keycloak{}
+	keycloak.Spec.Unmanaged = true
+	keycloak.Spec.External.Enabled = true
+	keycloak.Spec.External.URL = "https://keycloak.example.com"
+
+	currentState := common.NewBackupState(keycloak)
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	backup := desiredState[0].(common.GenericCreateAction).Ref.(*v1.Job)
+	assert.Equal(t, "export", backup.Spec.Template.Spec.InitContainers[0].Name)
+	restore := desiredState[1].(common.GenericCreateAction).Ref.(*v1.Job)
+	assert.Equal(t, "realms-restore", restore.Name)
+}
+
+func TestKeycloakBackupReconciler_Test_Restore_Job_Runs_Once(t *testing.T) {
+	// given
+	cr := &v1alpha1.KeycloakBackup{
+		Spec: v1alpha1.KeycloakBackupSpec{
+			Mode:     v1alpha1.BackupModeRealms,
+			Restore:  true,
+			Schedule: "0 3 * * *",
+		},
+	}
+	keycloak := v1alpha1.Keycloak{}
+
+	currentState := &common.BackupState{
+		LocalPersistentVolumeClaim: &v12.PersistentVolumeClaim{},
+		DestinationPeriodicJob:     &v1beta1.CronJob{},
+		RestoreJob:                 &v1.Job{},
+	}
+
+	// when
+	reconciler := NewKeycloakBackupReconciler(keycloak)
+	desiredState := reconciler.Reconcile(currentState, cr)
+
+	// then
+	assert.Len(t, desiredState, 2)
+	assert.IsType(t, common.GenericUpdateAction{}, desiredState[0])
+	cronJob := desiredState[1].(common.GenericUpdateAction).Ref.(*v1beta1.CronJob)
+	assert.Equal(t, "export", cronJob.Spec.JobTemplate.Spec.Template.Spec.InitContainers[0].Name)
+}
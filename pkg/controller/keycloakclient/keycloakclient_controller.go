@@ -8,6 +8,7 @@ import (
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/common"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -94,6 +95,11 @@ type ReconcileKeycloakClient struct {
 func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakClient")
+	defer common.ObserveReconcile(common.KeycloakClientKind, request.NamespacedName, time.Now())
+	ctx, span := common.StartSpan(r.context, "KeycloakClient.Reconcile",
+		attribute.String("k8s.namespace.name", request.Namespace),
+		attribute.String("keycloak.client.name", request.Name))
+	defer span.End()
 
 	// Fetch the KeycloakClient instance
 	instance := &kc.KeycloakClient{}
@@ -102,6 +108,7 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			common.ClearResourceFailing(common.KeycloakClientKind, request.Namespace, request.Name)
 			// Return and don't requeue
 			return reconcile.Result{}, nil
 		}
@@ -113,13 +120,13 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 
 	// The client may be applicable to multiple keycloak instances,
 	// process all of them
-	realms, err := common.GetMatchingRealms(r.context, r.client, instance.Spec.RealmSelector)
+	realms, err := common.GetMatchingRealms(ctx, r.client, instance.Spec.RealmSelector)
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
 	log.Info(fmt.Sprintf("found %v matching realm(s) for client %v/%v", len(realms.Items), instance.Namespace, instance.Name))
 	for _, realm := range realms.Items {
-		keycloaks, err := common.GetMatchingKeycloaks(r.context, r.client, realm.Spec.InstanceSelector)
+		keycloaks, err := common.GetMatchingKeycloaks(ctx, r.client, realm.Spec.InstanceSelector)
 		if err != nil {
 			return r.ManageError(instance, err)
 		}
@@ -127,15 +134,15 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 
 		for _, keycloak := range keycloaks.Items {
 			// Get an authenticated keycloak api client for the instance
-			keycloakFactory := common.LocalConfigKeycloakFactory{}
-			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
+			keycloakFactory := common.NewCachedKeycloakFactory(r.context, r.client)
+			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak)
 			if err != nil {
 				return r.ManageError(instance, err)
 			}
 
 			// Compute the current state of the realm
 			log.Info(fmt.Sprintf("got authenticated client for keycloak at %v", authenticated.Endpoint()))
-			clientState := common.NewClientState(r.context, realm.DeepCopy(), keycloak)
+			clientState := common.NewClientState(ctx, realm.DeepCopy(), keycloak)
 
 			log.Info(fmt.Sprintf("read client state for keycloak %v/%v, realm %v/%v, client %v/%v",
 				keycloak.Namespace,
@@ -145,16 +152,22 @@ func (r *ReconcileKeycloakClient) Reconcile(request reconcile.Request) (reconcil
 				instance.Namespace,
 				instance.Name))
 
-			err = clientState.Read(r.context, instance, authenticated, r.client)
+			err = clientState.Read(ctx, instance, authenticated, r.client)
 			if err != nil {
 				return r.ManageError(instance, err)
 			}
 
+			// Clients which weren't created by the operator are only managed once their ID is set in the CR
+			if clientState.ExistingClient != nil {
+				return r.ManageError(instance, fmt.Errorf("client %v already exists in realm %v, set spec.client.id to %v to manage it",
+					instance.Spec.Client.ClientID, realm.Spec.Realm.Realm, clientState.ExistingClient.ID))
+			}
+
 			// Figure out the actions to keep the realms up to date with
 			// the desired state
 			reconciler := NewKeycloakClientReconciler(keycloak)
 			desiredState := reconciler.Reconcile(clientState, instance)
-			actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
+			actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
 
 			// Run all actions to keep the realms updated
 			err = actionRunner.RunAll(desiredState)
@@ -183,6 +196,7 @@ func (r *ReconcileKeycloakClient) adjustCrDefaults(cr *kc.KeycloakClient) {
 func (r *ReconcileKeycloakClient) manageSuccess(client *kc.KeycloakClient, deleted bool) error {
 	client.Status.Ready = true
 	client.Status.Message = ""
+	common.ClearResourceFailing(common.KeycloakClientKind, client.Namespace, client.Name)
 	client.Status.Phase = v1alpha1.PhaseReconciling
 
 	err := r.client.Status().Update(r.context, client)
@@ -237,6 +251,7 @@ func (r *ReconcileKeycloakClient) ManageError(realm *kc.KeycloakClient, issue er
 	realm.Status.Message = issue.Error()
 	realm.Status.Ready = false
 	realm.Status.Phase = v1alpha1.PhaseFailing
+	common.SetResourceFailing(common.KeycloakClientKind, realm.Namespace, realm.Name)
 
 	err := r.client.Status().Update(r.context, realm)
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	"github.com/keycloak/keycloak-operator/pkg/common"
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -101,6 +102,11 @@ type ReconcileKeycloakRealm struct {
 func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakRealm")
+	defer common.ObserveReconcile(common.KeycloakRealmKind, request.NamespacedName, time.Now())
+	ctx, span := common.StartSpan(r.context, "KeycloakRealm.Reconcile",
+		attribute.String("k8s.namespace.name", request.Namespace),
+		attribute.String("keycloak.realm.name", request.Name))
+	defer span.End()
 
 	// Fetch the KeycloakRealm instance
 	instance := &kc.KeycloakRealm{}
@@ -109,6 +115,7 @@ func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile
 		if kubeerrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			common.ClearResourceFailing(common.KeycloakRealmKind, request.Namespace, request.Name)
 			// Return and don't requeue
 			return reconcile.Result{}, nil
 		}
@@ -127,31 +134,34 @@ func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{Requeue: false}, nil
 	}
 
-	keycloaks, err := common.GetMatchingKeycloaks(r.context, r.client, instance.Spec.InstanceSelector)
+	keycloaks, err := common.GetMatchingKeycloaks(ctx, r.client, instance.Spec.InstanceSelector)
 	if err != nil {
 		return r.ManageError(instance, err)
 	}
 
 	log.Info(fmt.Sprintf("found %v matching keycloak(s) for realm %v/%v", len(keycloaks.Items), instance.Namespace, instance.Name))
 
+	// Time until the events of the realm are polled again, if the event bridge is enabled
+	var pollAfter time.Duration
+
 	// The realm may be applicable to multiple keycloak instances,
 	// process all of them
 	for _, keycloak := range keycloaks.Items {
 		// Get an authenticated keycloak api client for the instance
-		keycloakFactory := common.LocalConfigKeycloakFactory{}
+		keycloakFactory := common.NewCachedKeycloakFactory(r.context, r.client)
 
 		if keycloak.Spec.Unmanaged {
 			return r.ManageError(instance, errors.Errorf("realms cannot be created for unmanaged keycloak instances"))
 		}
 
-		authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
+		authenticated, err := keycloakFactory.AuthenticatedClient(keycloak)
 
 		if err != nil {
 			return r.ManageError(instance, err)
 		}
 
 		// Compute the current state of the realm
-		realmState := common.NewRealmState(r.context, keycloak)
+		realmState := common.NewRealmState(ctx, keycloak)
 
 		log.Info(fmt.Sprintf("read state for keycloak %v/%v, realm %v/%v",
 			keycloak.Namespace,
@@ -168,21 +178,34 @@ func (r *ReconcileKeycloakRealm) Reconcile(request reconcile.Request) (reconcile
 		// the desired state
 		reconciler := NewKeycloakRealmReconciler(keycloak)
 		desiredState := reconciler.Reconcile(realmState, instance)
-		actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
+		actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
 
 		// Run all actions to keep the realms updated
 		err = actionRunner.RunAll(desiredState)
 		if err != nil {
 			return r.ManageError(instance, err)
 		}
+
+		if instance.Spec.EventBridge.Enabled && instance.DeletionTimestamp == nil {
+			next, err := NewEventBridge(r.client, r.recorder).Poll(ctx, keycloak, instance, authenticated)
+			if err != nil {
+				// The realm is reconciled, the events are polled again after the interval
+				log.Error(err, fmt.Sprintf("unable to poll the events of realm %v/%v", instance.Namespace, instance.Spec.Realm.Realm))
+				r.recorder.Event(instance, "Warning", "EventBridgeError", err.Error())
+			}
+			if pollAfter == 0 || next < pollAfter {
+				pollAfter = next
+			}
+		}
 	}
 
-	return reconcile.Result{Requeue: false}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
+	return reconcile.Result{Requeue: false, RequeueAfter: pollAfter}, r.manageSuccess(instance, instance.DeletionTimestamp != nil)
 }
 
 func (r *ReconcileKeycloakRealm) manageSuccess(realm *kc.KeycloakRealm, deleted bool) error {
 	realm.Status.Ready = true
 	realm.Status.Message = ""
+	common.ClearResourceFailing(common.KeycloakRealmKind, realm.Namespace, realm.Name)
 	realm.Status.Phase = v1alpha1.PhaseReconciling
 
 	err := r.client.Status().Update(r.context, realm)
@@ -237,6 +260,7 @@ func (r *ReconcileKeycloakRealm) ManageError(realm *kc.KeycloakRealm, issue erro
 	realm.Status.Message = issue.Error()
 	realm.Status.Ready = false
 	realm.Status.Phase = v1alpha1.PhaseFailing
+	common.SetResourceFailing(common.KeycloakRealmKind, realm.Namespace, realm.Name)
 
 	err := r.client.Status().Update(r.context, realm)
 	if err != nil {
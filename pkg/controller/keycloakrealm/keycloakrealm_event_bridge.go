@@ -0,0 +1,274 @@
+package keycloakrealm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kc "github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultEventBridgePollInterval = time.Minute
+	// At most this many events of each kind are exported per poll, older ones are skipped
+	maxPolledEvents = 1000
+	eventPageSize   = 100
+	// Reason of the Kubernetes Events mirroring admin events
+	AdminEventReason = "KeycloakAdminEvent"
+)
+
+var loginEventTypes = []string{"LOGIN", "LOGIN_ERROR"}
+
+// EventBridge polls the login and admin events of a realm. Logins are counted in the realm metrics, admin
+// events are mirrored as Kubernetes Events on the custom resource owning the changed resource. The time of the
+// newest event of each kind is kept in the status of the KeycloakRealm, only newer events are polled. The events
+// are only exported once both kinds were polled, a failed poll is repeated from the same watermarks.
+type EventBridge struct {
+	client   client.Client
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+func NewEventBridge(client client.Client, recorder record.EventRecorder) *EventBridge {
+	return &EventBridge{
+		client:   client,
+		recorder: recorder,
+		now:      time.Now,
+	}
+}
+
+// Poll exports the events of the realm if the poll interval passed since the last poll. It returns the time
+// until the next poll is due.
+func (i *EventBridge) Poll(ctx context.Context, keycloak kc.Keycloak, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface) (time.Duration, error) {
+	interval := eventBridgePollInterval(cr)
+	key := keycloak.Namespace + "/" + keycloak.Name
+	status, polled := cr.Status.EventBridge[key]
+	now := i.now()
+	if polled && status.LastPollTime != nil && now.Before(status.LastPollTime.Add(interval)) {
+		return status.LastPollTime.Add(interval).Sub(now), nil
+	}
+
+	if cr.Status.EventBridge == nil {
+		cr.Status.EventBridge = make(map[string]kc.KeycloakRealmEventBridgeStatus)
+	}
+	defer func() {
+		cr.Status.EventBridge[key] = status
+	}()
+
+	// Events recorded before the bridge was enabled aren't exported
+	if !polled {
+		status.EventsWatermark = now.UnixNano() / int64(time.Millisecond)
+		status.AdminEventsWatermark = status.EventsWatermark
+		status.LastPollTime = &metav1.Time{Time: now}
+		return interval, nil
+	}
+
+	loginEvents, eventsWatermark, err := i.pollLoginEvents(ctx, cr, keycloakClient, status.EventsWatermark)
+	if err != nil {
+		return interval, err
+	}
+	adminEvents, adminEventsWatermark, err := i.pollAdminEvents(ctx, cr, keycloakClient, status.AdminEventsWatermark)
+	if err != nil {
+		return interval, err
+	}
+
+	for _, event := range loginEvents {
+		realmLogins.WithLabelValues(cr.Namespace, cr.Spec.Realm.Realm, event.ClientID, loginResult(event.Type, event.Error)).Inc()
+	}
+	for _, event := range adminEvents {
+		i.mirrorAdminEvent(ctx, cr, keycloakClient, event)
+	}
+	status.EventsWatermark = eventsWatermark
+	status.AdminEventsWatermark = adminEventsWatermark
+	status.LastPollTime = &metav1.Time{Time: now}
+	return interval, nil
+}
+
+// pollLoginEvents returns the logins newer than the watermark and the time of the newest one. Keycloak returns
+// the newest events first, events recorded while paging shift the pages. Events on the later pages that aren't
+// older than the last event seen were already returned and are skipped.
+func (i *EventBridge) pollLoginEvents(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, watermark int64) ([]*kc.KeycloakAPIEvent, int64, error) {
+	realmName := cr.Spec.Realm.Realm
+	query := common.EventQuery{Types: loginEventTypes, DateFrom: time.Unix(0, watermark*int64(time.Millisecond))}
+	var polled []*kc.KeycloakAPIEvent
+	newest, oldest := watermark, int64(0)
+	for first := 0; first < maxPolledEvents; first += eventPageSize {
+		events, err := keycloakClient.ListEventsPage(ctx, realmName, query, common.Page{First: first, Max: eventPageSize})
+		if err != nil {
+			return nil, watermark, err
+		}
+		for _, event := range events {
+			if event.Time <= watermark {
+				return polled, newest, nil
+			}
+			if first > 0 && event.Time >= oldest {
+				continue
+			}
+			if event.Time > newest {
+				newest = event.Time
+			}
+			oldest = event.Time
+			polled = append(polled, event)
+		}
+		if len(events) < eventPageSize {
+			return polled, newest, nil
+		}
+	}
+	log.Info(fmt.Sprintf("more than %v new login events in realm %v/%v, skipping the older ones", maxPolledEvents, cr.Namespace, realmName))
+	return polled, newest, nil
+}
+
+// pollAdminEvents returns the admin events newer than the watermark and the time of the newest one, paging like
+// pollLoginEvents. Changes made by the operator itself are skipped.
+func (i *EventBridge) pollAdminEvents(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, watermark int64) ([]*kc.KeycloakAPIAdminEvent, int64, error) {
+	realmName := cr.Spec.Realm.Realm
+	operator := keycloakClient.TokenSubject()
+	query := common.EventQuery{DateFrom: time.Unix(0, watermark*int64(time.Millisecond))}
+	var polled []*kc.KeycloakAPIAdminEvent
+	newest, oldest := watermark, int64(0)
+	for first := 0; first < maxPolledEvents; first += eventPageSize {
+		events, err := keycloakClient.ListAdminEventsPage(ctx, realmName, query, common.Page{First: first, Max: eventPageSize})
+		if err != nil {
+			return nil, watermark, err
+		}
+		for _, event := range events {
+			if event.Time <= watermark {
+				return polled, newest, nil
+			}
+			if first > 0 && event.Time >= oldest {
+				continue
+			}
+			if event.Time > newest {
+				newest = event.Time
+			}
+			oldest = event.Time
+			if operator != "" && event.AuthDetails.UserID == operator {
+				continue
+			}
+			polled = append(polled, event)
+		}
+		if len(events) < eventPageSize {
+			return polled, newest, nil
+		}
+	}
+	log.Info(fmt.Sprintf("more than %v new admin events in realm %v/%v, skipping the older ones", maxPolledEvents, cr.Namespace, realmName))
+	return polled, newest, nil
+}
+
+func (i *EventBridge) mirrorAdminEvent(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, event *kc.KeycloakAPIAdminEvent) {
+	realmAdminEvents.WithLabelValues(cr.Namespace, cr.Spec.Realm.Realm, event.ResourceType, event.OperationType).Inc()
+
+	eventType := corev1.EventTypeNormal
+	message := fmt.Sprintf("%v %v %v by user %v from %v", event.OperationType, event.ResourceType, event.ResourcePath, event.AuthDetails.UserID, event.AuthDetails.IPAddress)
+	if event.Error != "" {
+		eventType = corev1.EventTypeWarning
+		message = fmt.Sprintf("%v failed: %v", message, event.Error)
+	}
+	i.recorder.Event(i.adminEventOwner(ctx, cr, keycloakClient, event), eventType, AdminEventReason, message)
+}
+
+// adminEventOwner returns the KeycloakClient or KeycloakUser managing the changed client or user, all other
+// changes are owned by the KeycloakRealm
+func (i *EventBridge) adminEventOwner(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, event *kc.KeycloakAPIAdminEvent) runtime.Object {
+	segments := strings.Split(event.ResourcePath, "/")
+	if len(segments) < 2 {
+		return cr
+	}
+
+	var owner runtime.Object
+	var err error
+	switch segments[0] {
+	case "clients":
+		owner, err = i.clientOwner(ctx, cr, keycloakClient, segments[1])
+	case "users":
+		owner, err = i.userOwner(ctx, cr, keycloakClient, segments[1])
+	}
+	if err != nil {
+		log.Info(fmt.Sprintf("unable to find the owner of %v in realm %v/%v: %v", event.ResourcePath, cr.Namespace, cr.Spec.Realm.Realm, err))
+	}
+	if owner == nil {
+		return cr
+	}
+	return owner
+}
+
+// clientOwner finds the KeycloakClient by the ID of the client, or by its clientId if the ID isn't known yet.
+// Only KeycloakClients in the namespace of the realm are matched, other namespaces may select a realm of the
+// same labels.
+func (i *EventBridge) clientOwner(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, id string) (runtime.Object, error) {
+	var clients kc.KeycloakClientList
+	if err := i.client.List(ctx, &clients, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var apiClient *kc.KeycloakAPIClient
+	for index := range clients.Items {
+		candidate := &clients.Items[index]
+		if candidate.Spec.Client == nil || !selectsRealm(candidate.Spec.RealmSelector, cr) {
+			continue
+		}
+		if candidate.Spec.Client.ID == id {
+			return candidate, nil
+		}
+		if apiClient == nil {
+			var err error
+			if apiClient, err = keycloakClient.GetClient(ctx, id, cr.Spec.Realm.Realm); err != nil || apiClient == nil {
+				return nil, err
+			}
+		}
+		if candidate.Spec.Client.ClientID == apiClient.ClientID {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// userOwner finds the KeycloakUser in the namespace of the realm by the ID of the user, or by its username if the
+// ID isn't known yet
+func (i *EventBridge) userOwner(ctx context.Context, cr *kc.KeycloakRealm, keycloakClient common.KeycloakInterface, id string) (runtime.Object, error) {
+	var users kc.KeycloakUserList
+	if err := i.client.List(ctx, &users, client.InNamespace(cr.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var apiUser *kc.KeycloakAPIUser
+	for index := range users.Items {
+		candidate := &users.Items[index]
+		if !selectsRealm(candidate.Spec.RealmSelector, cr) {
+			continue
+		}
+		if candidate.Spec.User.ID == id {
+			return candidate, nil
+		}
+		if apiUser == nil {
+			var err error
+			if apiUser, err = keycloakClient.GetUser(ctx, id, cr.Spec.Realm.Realm); err != nil || apiUser == nil {
+				return nil, err
+			}
+		}
+		if strings.EqualFold(candidate.Spec.User.UserName, apiUser.UserName) {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// selectsRealm matches the selector like GetMatchingRealms does
+func selectsRealm(selector *metav1.LabelSelector, cr *kc.KeycloakRealm) bool {
+	return selector != nil && labels.SelectorFromSet(selector.MatchLabels).Matches(labels.Set(cr.Labels))
+}
+
+func eventBridgePollInterval(cr *kc.KeycloakRealm) time.Duration {
+	if cr.Spec.EventBridge.PollInterval == nil || cr.Spec.EventBridge.PollInterval.Duration <= 0 {
+		return DefaultEventBridgePollInterval
+	}
+	return cr.Spec.EventBridge.PollInterval.Duration
+}
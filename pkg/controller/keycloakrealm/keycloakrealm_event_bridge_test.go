@@ -0,0 +1,293 @@
+package keycloakrealm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/keycloak/keycloak-operator/pkg/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var eventBridgeNow = time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+
+func TestEventBridge_Test_First_Poll_Sets_Watermarks(t *testing.T) {
+	// given
+	cr := eventBridgeRealm()
+	keycloakClient := &eventsKeycloakClient{}
+	bridge, recorder := eventBridge(t)
+
+	// when
+	next, err := bridge.Poll(context.TODO(), eventBridgeKeycloak(), cr, keycloakClient)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultEventBridgePollInterval, next)
+	status := cr.Status.EventBridge["keycloak/example-keycloak"]
+	assert.Equal(t, eventBridgeMillis(0), status.EventsWatermark)
+	assert.Equal(t, eventBridgeMillis(0), status.AdminEventsWatermark)
+	assert.Equal(t, 0, keycloakClient.requests)
+	assert.Empty(t, recorder.events)
+}
+
+func TestEventBridge_Test_Waits_For_Poll_Interval(t *testing.T) {
+	// given
+	cr := eventBridgeRealm()
+	cr.Spec.EventBridge.PollInterval = &metav1.Duration{Duration: 5 * time.Minute}
+	cr.Status.EventBridge = map[string]v1alpha1.KeycloakRealmEventBridgeStatus{
+		"keycloak/example-keycloak": {LastPollTime: &metav1.Time{Time: eventBridgeNow.Add(-time.Minute)}},
+	}
+	keycloakClient := &eventsKeycloakClient{}
+	bridge, _ := eventBridge(t)
+
+	// when
+	next, err := bridge.Poll(context.TODO(), eventBridgeKeycloak(), cr, keycloakClient)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Minute, next)
+	assert.Equal(t, 0, keycloakClient.requests)
+}
+
+func TestEventBridge_Test_Exports_Events_Newer_Than_Watermarks(t *testing.T) {
+	// given
+	cr := eventBridgeRealm()
+	cr.Status.EventBridge = map[string]v1alpha1.KeycloakRealmEventBridgeStatus{
+		"keycloak/example-keycloak": {
+			EventsWatermark:      eventBridgeMillis(-2 * time.Minute),
+			AdminEventsWatermark: eventBridgeMillis(-2 * time.Minute),
+			LastPollTime:         &metav1.Time{Time: eventBridgeNow.Add(-2 * time.Minute)},
+		},
+	}
+	keycloakClient := &eventsKeycloakClient{
+		subject: "operator",
+		events: []*v1alpha1.KeycloakAPIEvent{
+			{Time: eventBridgeMillis(-10 * time.Second), Type: "LOGIN", ClientID: "bridge-app"},
+			{Time: eventBridgeMillis(-20 * time.Second), Type: "LOGIN_ERROR", ClientID: "bridge-app", Error: "invalid_user_credentials"},
+			{Time: eventBridgeMillis(-30 * time.Second), Type: "LOGIN_ERROR", ClientID: "bridge-app", Error: "expired_code"},
+			{Time: eventBridgeMillis(-2 * time.Minute), Type: "LOGIN", ClientID: "bridge-app"},
+		},
+		adminEvents: []*v1alpha1.KeycloakAPIAdminEvent{
+			{Time: eventBridgeMillis(-10 * time.Second), AuthDetails: v1alpha1.KeycloakAPIAuthDetails{UserID: "operator"}, OperationType: "UPDATE", ResourceType: "CLIENT", ResourcePath: "clients/8f3c-41"},
+			{Time: eventBridgeMillis(-20 * time.Second), AuthDetails: v1alpha1.KeycloakAPIAuthDetails{UserID: "alice", IPAddress: "10.0.0.1"}, OperationType: "UPDATE", ResourceType: "CLIENT", ResourcePath: "clients/8f3c-41"},
+			{Time: eventBridgeMillis(-30 * time.Second), AuthDetails: v1alpha1.KeycloakAPIAuthDetails{UserID: "alice", IPAddress: "10.0.0.1"}, OperationType: "ACTION", ResourceType: "USER", ResourcePath: "users/2b7e-99/reset-password"},
+			{Time: eventBridgeMillis(-40 * time.Second), AuthDetails: v1alpha1.KeycloakAPIAuthDetails{UserID: "alice", IPAddress: "10.0.0.1"}, OperationType: "UPDATE", ResourceType: "REALM", ResourcePath: "events/config"},
+		},
+		clients: map[string]*v1alpha1.KeycloakAPIClient{"8f3c-41": {ID: "8f3c-41", ClientID: "bridge-app"}},
+		users:   map[string]*v1alpha1.KeycloakAPIUser{"2b7e-99": {ID: "2b7e-99", UserName: "bob"}},
+	}
+	bridgeClient := &v1alpha1.KeycloakClient{
+		ObjectMeta: metav1.ObjectMeta{Name: "bridge-app", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakClientSpec{
+			RealmSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"realm": "bridge"}},
+			Client:        &v1alpha1.KeycloakAPIClient{ClientID: "bridge-app"},
+		},
+	}
+	bridgeUser := &v1alpha1.KeycloakUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "bob", Namespace: "keycloak"},
+		Spec: v1alpha1.KeycloakUserSpec{
+			RealmSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"realm": "bridge"}},
+			User:          v1alpha1.KeycloakAPIUser{ID: "2b7e-99", UserName: "bob"},
+		},
+	}
+	otherNamespaceClient := bridgeClient.DeepCopy()
+	otherNamespaceClient.Name = "another-bridge-app"
+	otherNamespaceClient.Namespace = "another"
+	bridge, recorder := eventBridge(t, otherNamespaceClient, bridgeClient, bridgeUser)
+	logins := testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "bridge-app", loginResultSuccess))
+
+	// when
+	_, err := bridge.Poll(context.TODO(), eventBridgeKeycloak(), cr, keycloakClient)
+
+	// then
+	assert.NoError(t, err)
+	status := cr.Status.EventBridge["keycloak/example-keycloak"]
+	assert.Equal(t, eventBridgeMillis(-10*time.Second), status.EventsWatermark)
+	assert.Equal(t, eventBridgeMillis(-10*time.Second), status.AdminEventsWatermark)
+	assert.Equal(t, eventBridgeNow, status.LastPollTime.Time)
+
+	assert.Equal(t, logins+1, testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "bridge-app", loginResultSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "bridge-app", loginResultFailure)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "bridge-app", loginResultError)))
+
+	assert.Equal(t, []string{
+		"bridge-app Normal KeycloakAdminEvent: UPDATE CLIENT clients/8f3c-41 by user alice from 10.0.0.1",
+		"bob Normal KeycloakAdminEvent: ACTION USER users/2b7e-99/reset-password by user alice from 10.0.0.1",
+		"bridge-realm Normal KeycloakAdminEvent: UPDATE REALM events/config by user alice from 10.0.0.1",
+	}, recorder.events)
+}
+
+func TestEventBridge_Test_Skips_Events_Shifted_While_Paging(t *testing.T) {
+	// given
+	cr := eventBridgeRealm()
+	cr.Status.EventBridge = map[string]v1alpha1.KeycloakRealmEventBridgeStatus{
+		"keycloak/example-keycloak": {
+			EventsWatermark:      eventBridgeMillis(-time.Hour),
+			AdminEventsWatermark: eventBridgeMillis(-time.Hour),
+			LastPollTime:         &metav1.Time{Time: eventBridgeNow.Add(-time.Hour)},
+		},
+	}
+	keycloakClient := &eventsKeycloakClient{}
+	for n := 0; n < eventPageSize+10; n++ {
+		keycloakClient.events = append(keycloakClient.events, &v1alpha1.KeycloakAPIEvent{
+			Time: eventBridgeMillis(-time.Duration(n) * time.Second), Type: "LOGIN", ClientID: "shifted-app",
+		})
+	}
+	// A login recorded after the first page was read moves the last event of the first page to the second one
+	keycloakClient.afterFirstPage = func() {
+		keycloakClient.events = append([]*v1alpha1.KeycloakAPIEvent{{Time: eventBridgeMillis(time.Second), Type: "LOGIN", ClientID: "shifted-app"}}, keycloakClient.events...)
+	}
+	bridge, _ := eventBridge(t)
+
+	// when
+	_, err := bridge.Poll(context.TODO(), eventBridgeKeycloak(), cr, keycloakClient)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, float64(eventPageSize+10), testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "shifted-app", loginResultSuccess)))
+	assert.Equal(t, eventBridgeMillis(0), cr.Status.EventBridge["keycloak/example-keycloak"].EventsWatermark)
+}
+
+func TestEventBridge_Test_Keeps_Watermarks_If_Poll_Fails(t *testing.T) {
+	// given
+	cr := eventBridgeRealm()
+	cr.Status.EventBridge = map[string]v1alpha1.KeycloakRealmEventBridgeStatus{
+		"keycloak/example-keycloak": {
+			EventsWatermark:      eventBridgeMillis(-2 * time.Minute),
+			AdminEventsWatermark: eventBridgeMillis(-2 * time.Minute),
+			LastPollTime:         &metav1.Time{Time: eventBridgeNow.Add(-2 * time.Minute)},
+		},
+	}
+	keycloakClient := &eventsKeycloakClient{
+		events: []*v1alpha1.KeycloakAPIEvent{
+			{Time: eventBridgeMillis(-10 * time.Second), Type: "LOGIN", ClientID: "failing-app"},
+		},
+		adminEventsErr: errors.New("admin events are disabled"),
+	}
+	bridge, recorder := eventBridge(t)
+
+	// when
+	_, err := bridge.Poll(context.TODO(), eventBridgeKeycloak(), cr, keycloakClient)
+
+	// then
+	assert.Error(t, err)
+	status := cr.Status.EventBridge["keycloak/example-keycloak"]
+	assert.Equal(t, eventBridgeMillis(-2*time.Minute), status.EventsWatermark)
+	assert.Equal(t, eventBridgeMillis(-2*time.Minute), status.AdminEventsWatermark)
+	assert.Equal(t, eventBridgeNow.Add(-2*time.Minute), status.LastPollTime.Time)
+	assert.Equal(t, float64(0), testutil.ToFloat64(realmLogins.WithLabelValues("keycloak", "bridge", "failing-app", loginResultSuccess)))
+	assert.Empty(t, recorder.events)
+}
+
+// eventsKeycloakClient serves events newest first, only the methods used by the event bridge are implemented
+type eventsKeycloakClient struct {
+	common.KeycloakInterface
+	subject        string
+	events         []*v1alpha1.KeycloakAPIEvent
+	adminEvents    []*v1alpha1.KeycloakAPIAdminEvent
+	clients        map[string]*v1alpha1.KeycloakAPIClient
+	users          map[string]*v1alpha1.KeycloakAPIUser
+	adminEventsErr error
+	afterFirstPage func()
+	requests       int
+}
+
+func (c *eventsKeycloakClient) ListEventsPage(ctx context.Context, realmName string, query common.EventQuery, page common.Page) ([]*v1alpha1.KeycloakAPIEvent, error) {
+	c.requests++
+	from, to := eventsPage(len(c.events), page)
+	events := c.events[from:to]
+	if page.First == 0 && c.afterFirstPage != nil {
+		c.afterFirstPage()
+	}
+	return events, nil
+}
+
+func (c *eventsKeycloakClient) ListAdminEventsPage(ctx context.Context, realmName string, query common.EventQuery, page common.Page) ([]*v1alpha1.KeycloakAPIAdminEvent, error) {
+	c.requests++
+	if c.adminEventsErr != nil {
+		return nil, c.adminEventsErr
+	}
+	from, to := eventsPage(len(c.adminEvents), page)
+	return c.adminEvents[from:to], nil
+}
+
+func (c *eventsKeycloakClient) GetClient(ctx context.Context, clientID, realmName string) (*v1alpha1.KeycloakAPIClient, error) {
+	return c.clients[clientID], nil
+}
+
+func (c *eventsKeycloakClient) GetUser(ctx context.Context, userID, realmName string) (*v1alpha1.KeycloakAPIUser, error) {
+	return c.users[userID], nil
+}
+
+func (c *eventsKeycloakClient) TokenSubject() string {
+	return c.subject
+}
+
+func eventsPage(size int, page common.Page) (int, int) {
+	from, to := page.First, page.First+page.Max
+	if from > size {
+		from = size
+	}
+	if to > size {
+		to = size
+	}
+	return from, to
+}
+
+// eventsRecorder records the Kubernetes Events prefixed with the name of the object they were recorded on
+type eventsRecorder struct {
+	events []string
+}
+
+func (r *eventsRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.events = append(r.events, fmt.Sprintf("%v %v %v: %v", object.(metav1.Object).GetName(), eventtype, reason, message))
+}
+
+func (r *eventsRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *eventsRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Eventf(object, eventtype, reason, messageFmt, args...)
+}
+
+func eventBridge(t *testing.T, objs ...runtime.Object) (*EventBridge, *eventsRecorder) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.SchemeBuilder.AddToScheme(scheme))
+	recorder := &eventsRecorder{}
+	return &EventBridge{
+		client:   fake.NewFakeClientWithScheme(scheme, objs...),
+		recorder: recorder,
+		now: func() time.Time {
+			return eventBridgeNow
+		},
+	}, recorder
+}
+
+func eventBridgeRealm() *v1alpha1.KeycloakRealm {
+	cr := getDummyRealm()
+	cr.Name = "bridge-realm"
+	cr.Namespace = "keycloak"
+	cr.Labels = map[string]string{"realm": "bridge"}
+	cr.Spec.Realm.Realm = "bridge"
+	cr.Spec.EventBridge.Enabled = true
+	return cr
+}
+
+func eventBridgeKeycloak() v1alpha1.Keycloak {
+	keycloak := v1alpha1.Keycloak{}
+	keycloak.Name = "example-keycloak"
+	keycloak.Namespace = "keycloak"
+	return keycloak
+}
+
+func eventBridgeMillis(offset time.Duration) int64 {
+	return eventBridgeNow.Add(offset).UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,47 @@
+package keycloakrealm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	loginResultSuccess = "success"
+	loginResultFailure = "failure"
+	loginResultError   = "error"
+)
+
+var (
+	realmLogins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "keycloak_operator_realm_logins_total",
+		Help: "Number of logins to a realm by client and result, exported from the events of realms with the event bridge enabled.",
+	}, []string{"namespace", "realm", "client", "result"})
+	realmAdminEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "keycloak_operator_realm_admin_events_total",
+		Help: "Number of changes made to a realm outside of the operator by resource type and operation.",
+	}, []string{"namespace", "realm", "resource_type", "operation"})
+)
+
+// loginFailureErrors are the errors of LOGIN_ERROR events caused by wrong or unknown credentials, all other errors
+// are counted with the error result
+var loginFailureErrors = map[string]bool{
+	"invalid_user_credentials":  true,
+	"user_not_found":            true,
+	"user_disabled":             true,
+	"user_temporarily_disabled": true,
+}
+
+func init() {
+	metrics.Registry.MustRegister(realmLogins, realmAdminEvents)
+}
+
+func loginResult(eventType string, eventError string) string {
+	switch {
+	case eventType == "LOGIN":
+		return loginResultSuccess
+	case loginFailureErrors[eventError]:
+		return loginResultFailure
+	default:
+		return loginResultError
+	}
+}
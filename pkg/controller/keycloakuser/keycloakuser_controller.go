@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/keycloak/keycloak-operator/pkg/common"
+	"go.opentelemetry.io/otel/attribute"
 
 	"k8s.io/client-go/tools/record"
 
@@ -110,6 +111,11 @@ type ReconcileKeycloakUser struct {
 func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling KeycloakUser")
+	defer common.ObserveReconcile(common.KeycloakUserKind, request.NamespacedName, time.Now())
+	ctx, span := common.StartSpan(r.context, "KeycloakUser.Reconcile",
+		attribute.String("k8s.namespace.name", request.Namespace),
+		attribute.String("keycloak.user.name", request.Name))
+	defer span.End()
 
 	// Fetch the KeycloakUser instance
 	instance := &kc.KeycloakUser{}
@@ -118,6 +124,7 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 		if kubeerrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			common.ClearResourceFailing(common.KeycloakUserKind, request.Namespace, request.Name)
 			// Return and don't requeue
 			return reconcile.Result{}, nil
 		}
@@ -133,7 +140,7 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 	}
 
 	// Find the realms that this user should be added to based on the label selector
-	realms, err := common.GetMatchingRealms(r.context, r.client, instance.Spec.RealmSelector)
+	realms, err := common.GetMatchingRealms(ctx, r.client, instance.Spec.RealmSelector)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -145,7 +152,7 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 			return r.ManageError(instance, errors.Errorf("users cannot be created for unmanaged keycloak realms"))
 		}
 
-		keycloaks, err := common.GetMatchingKeycloaks(r.context, r.client, realm.Spec.InstanceSelector)
+		keycloaks, err := common.GetMatchingKeycloaks(ctx, r.client, realm.Spec.InstanceSelector)
 		if err != nil {
 			return r.ManageError(instance, err)
 		}
@@ -156,8 +163,8 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 			}
 
 			// Get an authenticated keycloak api client for the instance
-			keycloakFactory := common.LocalConfigKeycloakFactory{}
-			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak, false)
+			keycloakFactory := common.NewCachedKeycloakFactory(r.context, r.client)
+			authenticated, err := keycloakFactory.AuthenticatedClient(keycloak)
 			if err != nil {
 				return r.ManageError(instance, err)
 			}
@@ -171,14 +178,20 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 				instance.Namespace,
 				realm.Spec.Realm.Realm))
 
-			err = userState.Read(authenticated, r.client, instance, realm)
+			err = userState.Read(ctx, authenticated, r.client, instance, realm)
 			if err != nil {
 				return r.ManageError(instance, err)
 			}
+
+			// Users which weren't created by the operator are only managed once their ID is set in the CR
+			if userState.ExistingUser != nil {
+				return r.ManageError(instance, errors.Errorf("user %v already exists in realm %v, set spec.user.id to %v to manage it",
+					instance.Spec.User.UserName, realm.Spec.Realm.Realm, userState.ExistingUser.ID))
+			}
 			reconciler := NewKeycloakuserReconciler(keycloak, realm)
 			desiredState := reconciler.Reconcile(userState, instance)
 
-			actionRunner := common.NewClusterAndKeycloakActionRunner(r.context, r.client, r.scheme, instance, authenticated)
+			actionRunner := common.NewClusterAndKeycloakActionRunner(ctx, r.client, r.scheme, instance, authenticated)
 			err = actionRunner.RunAll(desiredState)
 			if err != nil {
 				return r.ManageError(instance, err)
@@ -192,6 +205,7 @@ func (r *ReconcileKeycloakUser) Reconcile(request reconcile.Request) (reconcile.
 func (r *ReconcileKeycloakUser) manageSuccess(user *kc.KeycloakUser, deleted bool) error {
 	user.Status.Phase = kc.UserPhaseReconciled
 	user.Status.Message = ""
+	common.ClearResourceFailing(common.KeycloakUserKind, user.Namespace, user.Name)
 
 	err := r.client.Status().Update(r.context, user)
 	if err != nil {
@@ -237,6 +251,7 @@ func (r *ReconcileKeycloakUser) ManageError(user *kc.KeycloakUser, issue error)
 	r.recorder.Event(user, "Warning", "ProcessingError", issue.Error())
 
 	user.Status.Phase = kc.UserPhaseFailing
+	common.SetResourceFailing(common.KeycloakUserKind, user.Namespace, user.Name)
 	user.Status.Message = issue.Error()
 
 	err := r.client.Status().Update(r.context, user)
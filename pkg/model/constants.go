@@ -34,10 +34,19 @@ const (
 	DatabaseSecretVersionProperty              = "POSTGRES_VERSION"          // nolint
 	DatabaseSecretExternalAddressProperty      = "POSTGRES_EXTERNAL_ADDRESS" // nolint
 	DatabaseSecretExternalPortProperty         = "POSTGRES_EXTERNAL_PORT"    // nolint
+	DatabaseSecretNextPasswordProperty         = "POSTGRES_NEXT_PASSWORD"    // nolint
 	KeycloakServicePort                        = 8443
 	PostgresDefaultPort                        = 5432
+	MySQLDefaultPort                           = 3306
+	OracleDefaultPort                          = 1521
+	MSSQLDefaultPort                           = 1433
+	MSSQLSchema                                = "dbo"
 	AdminUsernameProperty                      = "ADMIN_USERNAME"
 	AdminPasswordProperty                      = "ADMIN_PASSWORD"
+	OperatorClientSecretProperty               = "clientSecret" // nolint
+	OperatorClientCertificateProperty          = "tls.crt"
+	OperatorClientKeyProperty                  = "tls.key"
+	OperatorAuthenticationDefaultRealm         = "master"
 	ServingCertSecretName                      = "sso-x509-https-secret" // nolint
 	LivenessProbeProperty                      = "liveness_probe.sh"
 	ReadinessProbeProperty                     = "readiness_probe.sh"
@@ -56,11 +65,52 @@ const (
 	MigrateBackupName                          = "migrate-backup"
 	DatabaseSecretSslModeProperty              = "SSLMODE"
 	DatabaseSecretSslCert                      = ApplicationName + "-db-ssl-cert-secret"
+	DatabaseSslTruststoreFile                  = "truststore.p12"
 	RhssoDatabaseXAConnectionParamsProperty    = "DB_XA_CONNECTION_PROPERTY"
 	RhssoDatabaseNONXAConnectionParamsProperty = "DB_CONNECTION_PROPERTY"
 	KeycloakDatabaseConnectionParamsProperty   = "JDBC_PARAMS"
 	KeycloakCertificatePath                    = "/opt/jboss/.postgresql"
 	RhssoCertificatePath                       = "/home/jboss/.postgresql"
+	KeycloakStandalonePath                     = "/opt/jboss/keycloak/standalone"
+	RhssoStandalonePath                        = "/opt/eap/standalone"
+	KeycloakWritableVolumeName                 = ApplicationName + "-writable"
+	KeycloakThemesPath                         = "/opt/jboss/keycloak/themes"
+	RhssoThemesPath                            = "/opt/eap/themes"
+	KeycloakThemesVolumeName                   = ApplicationName + "-themes"
+	KeycloakThemesChecksumAnnotation           = "keycloak.org/themes-checksum"
+	KeycloakServingCertChecksumAnnotation      = "keycloak.org/serving-cert-checksum"
+	PostgresqlBackupLabel                      = "keycloak.org/backup"
+	KeycloakRealmRestoreLabel                  = "keycloak.org/restore"
+	KeycloakBlueGreenDeploymentName            = ApplicationName + "-green"
+	KeycloakBlueGreenComponent                 = KeycloakDeploymentComponent + "-green"
+	KeycloakDatabaseDropComponent              = "database-drop"
+	KeycloakMigrationRollbackAnnotation        = "keycloak.org/migration-rollback"
+	DatabaseCredentialsRotationAnnotation      = "keycloak.org/rotate-database-credentials"
+	DatabaseCredentialsChecksumAnnotation      = "keycloak.org/database-credentials-checksum"
+	DatabaseCredentialsRotationJobName         = ApplicationName + "-db-credentials-rotation"
+	VaultDefaultDatabaseMount                  = "database"
+	VaultDefaultAuthMount                      = "kubernetes"
+	PostgresqlSchema                           = "public"
+	PostgresqlClusterName                      = ApplicationName + "-db"
+	PostgresqlClusterInstances                 = 3
+	PostgresqlClusterVersion                   = "15"
+	PostgresqlDefaultVersion                   = "10"
+	KeycloakClusteringName                     = ApplicationName + "-clustering"
+	KeycloakClusteringChecksumAnnotation       = "keycloak.org/clustering-checksum"
+	KeycloakDiscoveryRoleName                  = ApplicationName + "-discovery"
+	KeycloakServiceAccountName                 = ApplicationName
+	KeycloakDefaultCacheOwners                 = 2
+	KeycloakJGroupsKeystoreSecretName          = ApplicationName + "-jgroups-keystore"
+	KeycloakJGroupsKeystoreProperty            = "jgroups.p12"
+	KeycloakJGroupsKeystorePasswordProperty    = "password"
+	KeycloakJGroupsKeystorePath                = "/etc/jgroups"
+	KeycloakStartupScriptsPath                 = "/opt/jboss/startup-scripts"
+	RhssoPostconfigurePath                     = "/opt/eap/extensions"
+	InfinispanDefaultPort                      = 11222
+	InfinispanDefaultProtocolVersion           = "2.9"
+	KeycloakCrossSiteSecretName                = ApplicationName + "-cross-site"
+	KeycloakCrossSiteFailoverAnnotation        = "keycloak.org/cross-site-failover"
+	KeycloakCrossSiteKubeconfigProperty        = "kubeconfig"
 )
 
 var PodLabels = map[string]string{}
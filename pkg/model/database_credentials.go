@@ -0,0 +1,116 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/apps/v1"
+	v14 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A retried Job may find the password already changed, it succeeds if the new password is accepted. The password
+// is passed as psql variable, it's quoted by psql and doesn't show up in the command line.
+const databaseCredentialsRotationScript = `set -e
+if PGPASSWORD="$NEW_PASSWORD" psql -tAc "SELECT 1" > /dev/null 2>&1; then
+  exit 0
+fi
+echo "ALTER ROLE CURRENT_USER WITH PASSWORD :'password';" | psql -q -v ON_ERROR_STOP=1 -v password="$NEW_PASSWORD"
+`
+
+// DatabaseCredentialsRotation returns a Job setting the pending password of the database secret in the embedded
+// database. Keycloak keeps running with the current password until the secret is updated.
+func DatabaseCredentialsRotation(cr *v1alpha1.Keycloak) *v14.Job {
+	return &v14.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      DatabaseCredentialsRotationJobName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":       ApplicationName,
+				"component": PostgresqlDeploymentComponent,
+			},
+		},
+		Spec: v14.JobSpec{
+			BackoffLimit: &[]int32{2}[0],
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:    "rotate-credentials",
+							Image:   PostgresqlClientImage(cr),
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{databaseCredentialsRotationScript},
+							Env: []v1.EnvVar{
+								postgresqlBackupSecretEnvVar("PGUSER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+								postgresqlBackupSecretEnvVar("PGPASSWORD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+								postgresqlBackupSecretEnvVar("NEW_PASSWORD", DatabaseSecretName, DatabaseSecretNextPasswordProperty, false),
+								{
+									Name:  "PGDATABASE",
+									Value: PostgresqlDatabase,
+								},
+								{
+									Name:  "PGHOST",
+									Value: PostgresqlServiceName,
+								},
+							},
+						},
+					},
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: PostgresqlBackupServiceAccountName,
+				},
+			},
+		},
+	}
+}
+
+func DatabaseCredentialsRotationSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      DatabaseCredentialsRotationJobName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// SetKeycloakDatabaseCredentialsChecksum annotates the pod template with a checksum of the database credentials,
+// so that the pods are rolled when they are rotated or changed in the secret of an external database. The password
+// of the embedded database only changes once rotations are used.
+func SetKeycloakDatabaseCredentialsChecksum(cr *v1alpha1.Keycloak, statefulSet *v13.StatefulSet, dbSecret *v1.Secret) {
+	setPodTemplateAnnotation(statefulSet, DatabaseCredentialsChecksumAnnotation, KeycloakDatabaseCredentialsChecksum(cr, dbSecret))
+}
+
+// KeycloakDatabaseCredentialsChecksum returns the checksum of the database credentials the pods are rolled with
+func KeycloakDatabaseCredentialsChecksum(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) string {
+	if !isDatabaseCredentialsChangeable(cr) || dbSecret == nil {
+		return ""
+	}
+	credentials := append(append([]byte{}, dbSecret.Data[DatabaseSecretUsernameProperty]...), 0)
+	credentials = append(credentials, dbSecret.Data[DatabaseSecretPasswordProperty]...)
+	return fmt.Sprintf("%x", sha256.Sum256(credentials))
+}
+
+func isDatabaseCredentialsChangeable(cr *v1alpha1.Keycloak) bool {
+	return cr.Spec.ExternalDatabase.Enabled ||
+		cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled ||
+		cr.Spec.DatabaseCredentials.RotationPeriod != nil ||
+		cr.Annotations[DatabaseCredentialsRotationAnnotation] != "" ||
+		cr.Status.DatabaseCredentials != nil
+}
+
+// ValidateDatabaseCredentials checks that the credentials of the database can be rotated as set in the Keycloak CR
+func ValidateDatabaseCredentials(cr *v1alpha1.Keycloak) error {
+	credentials := cr.Spec.DatabaseCredentials
+	switch {
+	case credentials.RotationPeriod != nil && cr.Spec.ExternalDatabase.Enabled:
+		return errors.Errorf("databaseCredentials.rotationPeriod only rotates the password of the embedded database, use databaseCredentials.vault for external databases")
+	case credentials.RotationPeriod != nil && cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled:
+		return errors.Errorf("databaseCredentials.rotationPeriod isn't supported with postgresDeploymentSpec.highAvailability, the password is managed by the cluster")
+	case credentials.Vault != nil && !cr.Spec.ExternalDatabase.Enabled:
+		return errors.Errorf("databaseCredentials.vault requires externalDatabase.enabled, Vault can't manage the users of the embedded database")
+	case credentials.Vault != nil && (credentials.Vault.Address == "" || credentials.Vault.Role == "" || credentials.Vault.AuthRole == ""):
+		return errors.Errorf("databaseCredentials.vault requires address, role and authRole to be set")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDatabaseCredentials_testChecksumFollowsCredentials(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.DatabaseCredentials.RotationPeriod = &v12.Duration{Duration: 720 * time.Hour}
+	dbSecret := DatabaseSecret(cr)
+	deployment := KeycloakDeployment(cr, dbSecret, nil)
+	rotatedDeployment := KeycloakDeployment(cr, dbSecret, nil)
+
+	//when
+	SetKeycloakDatabaseCredentialsChecksum(cr, deployment, dbSecret)
+	dbSecret.Data[DatabaseSecretPasswordProperty] = []byte("rotated")
+	SetKeycloakDatabaseCredentialsChecksum(cr, rotatedDeployment, dbSecret)
+
+	//then
+	checksum := deployment.Spec.Template.Annotations[DatabaseCredentialsChecksumAnnotation]
+	assert.NotEmpty(t, checksum)
+	assert.NotEqual(t, checksum, rotatedDeployment.Spec.Template.Annotations[DatabaseCredentialsChecksumAnnotation])
+}
+
+func TestDatabaseCredentials_testNoChecksumWithoutRotation(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+
+	//when
+	SetKeycloakDatabaseCredentialsChecksum(cr, deployment, DatabaseSecret(cr))
+
+	//then
+	assert.NotContains(t, deployment.Spec.Template.Annotations, DatabaseCredentialsChecksumAnnotation)
+}
+
+func TestDatabaseCredentials_testValidation(t *testing.T) {
+	//given
+	external := &v1alpha1.Keycloak{}
+	external.Spec.ExternalDatabase.Enabled = true
+	external.Spec.DatabaseCredentials.RotationPeriod = &v12.Duration{Duration: time.Hour}
+	embeddedVault := &v1alpha1.Keycloak{}
+	embeddedVault.Spec.DatabaseCredentials.Vault = &v1alpha1.KeycloakDatabaseCredentialsVault{Address: "https://vault:8200", Role: "keycloak", AuthRole: "keycloak"}
+	externalVault := embeddedVault.DeepCopy()
+	externalVault.Spec.ExternalDatabase.Enabled = true
+
+	//when
+	externalErr := ValidateDatabaseCredentials(external)
+	embeddedVaultErr := ValidateDatabaseCredentials(embeddedVault)
+	externalVaultErr := ValidateDatabaseCredentials(externalVault)
+
+	//then
+	assert.Error(t, externalErr)
+	assert.Error(t, embeddedVaultErr)
+	assert.Nil(t, externalVaultErr)
+}
@@ -22,7 +22,7 @@ func DatabaseSecret(cr *v1alpha1.Keycloak) *v1.Secret {
 			// The 3 entries below are not used by the Operator itself but rather by the Backup container
 			DatabaseSecretDatabaseProperty: []byte(PostgresqlDatabase),
 			DatabaseSecretHostProperty:     []byte(PostgresqlServiceName),
-			DatabaseSecretVersionProperty:  []byte("10"),
+			DatabaseSecretVersionProperty:  []byte(PostgresqlVersion(cr)),
 			DatabaseSecretSslModeProperty:  []byte(nil),
 		},
 	}
@@ -54,7 +54,7 @@ func DatabaseSecretReconciled(cr *v1alpha1.Keycloak, currentState *v1.Secret) *v
 		reconciled.Data[DatabaseSecretHostProperty] = []byte(PostgresqlServiceName)
 	}
 	if _, ok := reconciled.Data[DatabaseSecretVersionProperty]; !ok {
-		reconciled.Data[DatabaseSecretVersionProperty] = []byte("10")
+		reconciled.Data[DatabaseSecretVersionProperty] = []byte(PostgresqlDefaultVersion)
 	}
 	return reconciled
 }
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// mysqldump of the MariaDB image dumps MySQL and MariaDB databases alike
+	mysqlDumpCommand = `mysqldump --single-transaction --routines --host="$DATABASE_HOST" --port="${DATABASE_PORT:-3306}" --user="$DATABASE_USER" "$DATABASE_NAME"`
+
+	// MySQL can't drop tables in a transaction, the tables left behind by the failed schema migration are
+	// dropped before the dump is loaded
+	mysqlMigrationRestoreScript = `set -eo pipefail
+mysql_client() {
+  mysql --host="$DATABASE_HOST" --port="${DATABASE_PORT:-3306}" --user="$DATABASE_USER" "$DATABASE_NAME" "$@"
+}
+{
+  echo "SET FOREIGN_KEY_CHECKS=0;"
+  mysql_client -N -e "SELECT CONCAT('DROP TABLE IF EXISTS ', table_name, ';') FROM information_schema.tables WHERE table_schema = DATABASE()"
+  cat /backup/backup.sql
+  echo "SET FOREIGN_KEY_CHECKS=1;"
+} | mysql_client
+`
+)
+
+// GetDatabaseVendor returns the vendor of the database Keycloak runs against, the embedded database is always
+// PostgreSQL
+func GetDatabaseVendor(cr *v1alpha1.Keycloak) v1alpha1.DatabaseVendor {
+	if cr == nil || !cr.Spec.ExternalDatabase.Enabled || cr.Spec.ExternalDatabase.Vendor == "" {
+		return v1alpha1.DatabaseVendorPostgres
+	}
+	return cr.Spec.ExternalDatabase.Vendor
+}
+
+func isMySQLDatabase(cr *v1alpha1.Keycloak) bool {
+	vendor := GetDatabaseVendor(cr)
+	return vendor == v1alpha1.DatabaseVendorMySQL || vendor == v1alpha1.DatabaseVendorMariaDB
+}
+
+// GetDatabaseDefaultPort returns the port the database of the vendor listens on by default
+func GetDatabaseDefaultPort(vendor v1alpha1.DatabaseVendor) int32 {
+	switch vendor {
+	case v1alpha1.DatabaseVendorMySQL, v1alpha1.DatabaseVendorMariaDB:
+		return MySQLDefaultPort
+	case v1alpha1.DatabaseVendorOracle:
+		return OracleDefaultPort
+	case v1alpha1.DatabaseVendorMSSQL:
+		return MSSQLDefaultPort
+	default:
+		return PostgresDefaultPort
+	}
+}
+
+// getDatabaseSchema returns the schema Keycloak runs against, MySQL, MariaDB and Oracle don't have schemas
+// apart from the database or the user
+func getDatabaseSchema(cr *v1alpha1.Keycloak) string {
+	switch GetDatabaseVendor(cr) {
+	case v1alpha1.DatabaseVendorPostgres:
+		return PostgresqlSchema
+	case v1alpha1.DatabaseVendorMSSQL:
+		return MSSQLSchema
+	default:
+		return ""
+	}
+}
+
+// databaseConnectionParams translates the ssl mode of the database secret, which follows the modes of
+// PostgreSQL, into the parameters of the JDBC driver of the vendor. The CA certificate is read from the
+// certificate path, the drivers of MySQL and SQL Server only accept it as PKCS12 trust store. Oracle connects
+// through TCPS, which is part of the address and not a parameter.
+func databaseConnectionParams(vendor v1alpha1.DatabaseVendor, sslMode string, certificatePath string) []string {
+	verify := sslMode == "verify-ca" || sslMode == "verify-full"
+	switch vendor {
+	case v1alpha1.DatabaseVendorPostgres:
+		return []string{"sslmode=" + sslMode, "sslrootcert=" + certificatePath + "/root.crt"}
+	case v1alpha1.DatabaseVendorMySQL:
+		modes := map[string]string{
+			"disable":     "DISABLED",
+			"allow":       "PREFERRED",
+			"prefer":      "PREFERRED",
+			"require":     "REQUIRED",
+			"verify-ca":   "VERIFY_CA",
+			"verify-full": "VERIFY_IDENTITY",
+		}
+		params := []string{"sslMode=" + modes[sslMode]}
+		if verify {
+			params = append(params, "trustCertificateKeyStoreUrl=file:"+certificatePath+"/"+DatabaseSslTruststoreFile, "trustCertificateKeyStoreType=PKCS12")
+		}
+		return params
+	case v1alpha1.DatabaseVendorMariaDB:
+		switch {
+		case sslMode == "disable":
+			return []string{"useSsl=false"}
+		case sslMode == "verify-ca":
+			return []string{"useSsl=true", "serverSslCert=" + certificatePath + "/root.crt", "disableSslHostnameVerification=true"}
+		case verify:
+			return []string{"useSsl=true", "serverSslCert=" + certificatePath + "/root.crt"}
+		default:
+			return []string{"useSsl=true", "trustServerCertificate=true"}
+		}
+	case v1alpha1.DatabaseVendorMSSQL:
+		switch {
+		case sslMode == "disable":
+			return []string{"encrypt=false"}
+		case verify:
+			return []string{"encrypt=true", "trustServerCertificate=false", "trustStore=" + certificatePath + "/" + DatabaseSslTruststoreFile, "trustStoreType=PKCS12"}
+		default:
+			return []string{"encrypt=true", "trustServerCertificate=true"}
+		}
+	default:
+		return nil
+	}
+}
+
+// databaseConnectionParamsSeparator returns the separator of the parameters in the JDBC URL of the vendor
+func databaseConnectionParamsSeparator(vendor v1alpha1.DatabaseVendor) string {
+	if vendor == v1alpha1.DatabaseVendorMSSQL {
+		return ";"
+	}
+	return "&"
+}
+
+// rhssoDatabaseServiceMapping returns the service of DB_SERVICE_PREFIX_MAPPING. The RH-SSO image derives the
+// driver from the suffix of the service and reads its address from the service environment variables.
+func rhssoDatabaseServiceMapping(cr *v1alpha1.Keycloak) string {
+	if GetDatabaseVendor(cr) == v1alpha1.DatabaseVendorMySQL {
+		return ApplicationName + "-mysql"
+	}
+	return PostgresqlServiceName
+}
+
+func rhssoServiceEnvVar(cr *v1alpha1.Keycloak, suffix string) string {
+	serviceName := strings.ToUpper(rhssoDatabaseServiceMapping(cr))
+	serviceName = strings.ReplaceAll(serviceName, "-", "_")
+	return fmt.Sprintf("%v_%v", serviceName, suffix)
+}
+
+// DatabaseClientImage returns the image the backup and restore Jobs run in
+func DatabaseClientImage(cr *v1alpha1.Keycloak) string {
+	if isMySQLDatabase(cr) {
+		return Images.Images[BackupMySQLImage]
+	}
+	return PostgresqlClientImage(cr)
+}
+
+// mysqlClientEnv returns the connection settings of the MySQL clients. The password is read from MYSQL_PWD, it
+// doesn't show up in the command line then.
+func mysqlClientEnv() []v1.EnvVar {
+	return []v1.EnvVar{
+		postgresqlBackupSecretEnvVar("DATABASE_USER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+		postgresqlBackupSecretEnvVar("MYSQL_PWD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+		postgresqlBackupSecretEnvVar("DATABASE_NAME", DatabaseSecretName, DatabaseSecretDatabaseProperty, false),
+		postgresqlBackupSecretEnvVar("DATABASE_PORT", DatabaseSecretName, DatabaseSecretExternalPortProperty, true),
+		{
+			Name:  "DATABASE_HOST",
+			Value: PostgresqlServiceName,
+		},
+	}
+}
+
+// databaseDumpCommand returns the command writing a plain SQL dump of the Keycloak database to stdout
+func databaseDumpCommand(cr *v1alpha1.Keycloak) string {
+	if isMySQLDatabase(cr) {
+		return mysqlDumpCommand
+	}
+	return "pg_dump $POSTGRES_DB"
+}
+
+// ValidateDatabaseVendor checks that the features enabled in the Keycloak CR support the vendor of its database
+func ValidateDatabaseVendor(cr *v1alpha1.Keycloak) error {
+	vendor := GetDatabaseVendor(cr)
+	switch {
+	case Profiles.IsRHSSO(cr) && vendor != v1alpha1.DatabaseVendorPostgres && vendor != v1alpha1.DatabaseVendorMySQL:
+		return errors.Errorf("externalDatabase.vendor %v isn't supported by the RH-SSO image, use postgres or mysql", vendor)
+	case cr.Spec.Migration.MigrationStrategy == v1alpha1.StrategyBlueGreen && vendor != v1alpha1.DatabaseVendorPostgres:
+		return errors.Errorf("the bluegreen migration strategy clones PostgreSQL databases and doesn't support externalDatabase.vendor %v", vendor)
+	case cr.Spec.Migration.Backups.Enabled && !IsDatabaseBackupSupported(vendor):
+		return errors.Errorf("migration backups aren't supported for externalDatabase.vendor %v", vendor)
+	}
+	return nil
+}
+
+// IsDatabaseBackupSupported returns true if the database of the vendor can be dumped by the backup Jobs.
+// Oracle and SQL Server backups are taken on the server with the tooling of the vendor.
+func IsDatabaseBackupSupported(vendor v1alpha1.DatabaseVendor) bool {
+	switch vendor {
+	case v1alpha1.DatabaseVendorPostgres, v1alpha1.DatabaseVendorMySQL, v1alpha1.DatabaseVendorMariaDB:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateKeycloakBackupDatabase checks that the database of the Keycloak instance can be backed up as set in
+// the KeycloakBackup. Realm backups go through the admin API and work with every vendor.
+func ValidateKeycloakBackupDatabase(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) error {
+	if cr.Spec.Mode == v1alpha1.BackupModeRealms {
+		return nil
+	}
+	vendor := GetDatabaseVendor(keycloak)
+	switch {
+	case !IsDatabaseBackupSupported(vendor):
+		return errors.Errorf("backup %v can't dump the %v database of %v, back it up with the tooling of the vendor or use the realms mode", cr.Name, vendor, keycloak.Name)
+	case vendor != v1alpha1.DatabaseVendorPostgres && cr.Spec.Verification.Enabled:
+		return errors.Errorf("backup %v can only verify PostgreSQL dumps, the database of %v is %v", cr.Name, keycloak.Name, vendor)
+	case vendor != v1alpha1.DatabaseVendorPostgres && cr.Spec.AWS.CredentialsSecretName != "":
+		return errors.Errorf("backup %v can only upload PostgreSQL dumps through the aws settings, use the s3 destination for %v", cr.Name, vendor)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDatabaseVendor_testMySQLEnvs(t *testing.T) {
+	//given
+	cr := databaseVendorKeycloak(v1alpha1.DatabaseVendorMySQL)
+	dbSecret := &v1.Secret{
+		Data: map[string][]byte{
+			DatabaseSecretDatabaseProperty:        []byte("keycloak"),
+			DatabaseSecretExternalAddressProperty: []byte("mysql.example.com"),
+			DatabaseSecretSslModeProperty:         []byte("verify-full"),
+		},
+	}
+
+	//when
+	envs := KeycloakDeployment(cr, dbSecret, nil).Spec.Template.Spec.Containers[0].Env
+
+	//then
+	assert.Equal(t, "MYSQL", getEnvValueByName(envs, "DB_VENDOR"))
+	assert.Equal(t, fmt.Sprintf("%v", MySQLDefaultPort), getEnvValueByName(envs, "DB_PORT"))
+	assert.Equal(t, v1.EnvVar{}, findEnvVar(envs, "DB_SCHEMA"))
+	assert.Equal(t, "sslMode=VERIFY_IDENTITY&trustCertificateKeyStoreUrl=file:"+KeycloakCertificatePath+"/"+DatabaseSslTruststoreFile+"&trustCertificateKeyStoreType=PKCS12", getEnvValueByName(envs, KeycloakDatabaseConnectionParamsProperty))
+}
+
+func TestDatabaseVendor_testMSSQLConnectionParams(t *testing.T) {
+	//given
+	cr := databaseVendorKeycloak(v1alpha1.DatabaseVendorMSSQL)
+	dbSecret := &v1.Secret{
+		Data: map[string][]byte{
+			DatabaseSecretSslModeProperty: []byte("require"),
+		},
+	}
+
+	//when
+	envs := KeycloakDeployment(cr, dbSecret, nil).Spec.Template.Spec.Containers[0].Env
+
+	//then
+	assert.Equal(t, "MSSQL", getEnvValueByName(envs, "DB_VENDOR"))
+	assert.Equal(t, MSSQLSchema, getEnvValueByName(envs, "DB_SCHEMA"))
+	assert.Equal(t, fmt.Sprintf("%v", MSSQLDefaultPort), getEnvValueByName(envs, "DB_PORT"))
+	assert.Equal(t, "encrypt=true;trustServerCertificate=true", getEnvValueByName(envs, KeycloakDatabaseConnectionParamsProperty))
+}
+
+func TestDatabaseVendor_testPostgresConnectionParamsUnchanged(t *testing.T) {
+	//given
+	cr := databaseVendorKeycloak(v1alpha1.DatabaseVendorPostgres)
+	dbSecret := &v1.Secret{
+		Data: map[string][]byte{
+			DatabaseSecretSslModeProperty: []byte("verify-ca"),
+		},
+	}
+
+	//when
+	envs := KeycloakDeployment(cr, dbSecret, nil).Spec.Template.Spec.Containers[0].Env
+
+	//then
+	assert.Equal(t, "POSTGRES", getEnvValueByName(envs, "DB_VENDOR"))
+	assert.Equal(t, "sslmode=verify-ca&sslrootcert="+KeycloakCertificatePath+"/root.crt", getEnvValueByName(envs, KeycloakDatabaseConnectionParamsProperty))
+}
+
+func TestDatabaseVendor_testRHSSOMySQLServiceMapping(t *testing.T) {
+	//given
+	cr := databaseVendorKeycloak(v1alpha1.DatabaseVendorMySQL)
+	dbSecret := &v1.Secret{
+		Data: map[string][]byte{
+			DatabaseSecretExternalPortProperty: []byte("3307"),
+			DatabaseSecretSslModeProperty:      []byte("require"),
+		},
+	}
+
+	//when
+	envs := RHSSODeployment(cr, dbSecret, nil).Spec.Template.Spec.Containers[0].Env
+
+	//then
+	assert.Equal(t, "keycloak-mysql=DB", getEnvValueByName(envs, "DB_SERVICE_PREFIX_MAPPING"))
+	assert.Equal(t, "3307", getEnvValueByName(envs, "KEYCLOAK_MYSQL_SERVICE_PORT"))
+	assert.Equal(t, "REQUIRED", getEnvValueByName(envs, RhssoDatabaseXAConnectionParamsProperty+"_sslMode"))
+	assert.Equal(t, "REQUIRED", getEnvValueByName(envs, RhssoDatabaseNONXAConnectionParamsProperty+"_sslMode"))
+}
+
+func TestDatabaseVendor_testExternalServicePort(t *testing.T) {
+	//given
+	cr := databaseVendorKeycloak(v1alpha1.DatabaseVendorOracle)
+	dbSecret := &v1.Secret{
+		Data: map[string][]byte{
+			DatabaseSecretExternalAddressProperty: []byte("oracle.example.com"),
+		},
+	}
+
+	//when
+	service := PostgresqlService(cr, dbSecret, true)
+
+	//then
+	assert.Equal(t, int32(OracleDefaultPort), service.Spec.Ports[0].Port)
+}
+
+func TestDatabaseVendor_testMySQLBackupTooling(t *testing.T) {
+	//given
+	keycloak := databaseVendorKeycloak(v1alpha1.DatabaseVendorMariaDB)
+	backup := &v1alpha1.KeycloakBackup{}
+	backup.Name = "backup"
+	backup.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		S3: &v1alpha1.KeycloakBackupS3Destination{
+			Bucket:                "keycloak",
+			CredentialsSecretName: "s3-secret",
+		},
+	}
+
+	//when
+	localJob := PostgresqlBackup(backup, keycloak)
+	destinationJob := PostgresqlDestinationBackup(backup, keycloak)
+	restoreJob := KeycloakMigrationRestore(keycloak, "backup")
+
+	//then
+	for _, container := range []v1.Container{localJob.Spec.Template.Spec.Containers[0], destinationJob.Spec.Template.Spec.InitContainers[0], restoreJob.Spec.Template.Spec.Containers[0]} {
+		assert.Equal(t, Images.Images[BackupMySQLImage], container.Image)
+		assert.Equal(t, DatabaseSecretPasswordProperty, findEnvVar(container.Env, "MYSQL_PWD").ValueFrom.SecretKeyRef.Key)
+		assert.Equal(t, v1.EnvVar{}, findEnvVar(container.Env, "PGPASSWORD"))
+	}
+	assert.Contains(t, localJob.Spec.Template.Spec.Containers[0].Args[0], "mysqldump")
+	assert.Contains(t, destinationJob.Spec.Template.Spec.InitContainers[0].Args[0], "mysqldump")
+	assert.Contains(t, restoreJob.Spec.Template.Spec.Containers[0].Args[0], "FOREIGN_KEY_CHECKS")
+}
+
+func TestDatabaseVendor_testValidation(t *testing.T) {
+	//given
+	mssql := databaseVendorKeycloak(v1alpha1.DatabaseVendorMSSQL)
+	mysql := databaseVendorKeycloak(v1alpha1.DatabaseVendorMySQL)
+	bluegreen := databaseVendorKeycloak(v1alpha1.DatabaseVendorMySQL)
+	bluegreen.Spec.Migration.MigrationStrategy = v1alpha1.StrategyBlueGreen
+	migrationBackups := databaseVendorKeycloak(v1alpha1.DatabaseVendorOracle)
+	migrationBackups.Spec.Migration.Backups.Enabled = true
+	backup := &v1alpha1.KeycloakBackup{}
+	realmsBackup := &v1alpha1.KeycloakBackup{}
+	realmsBackup.Spec.Mode = v1alpha1.BackupModeRealms
+	verifiedBackup := &v1alpha1.KeycloakBackup{}
+	verifiedBackup.Spec.Verification.Enabled = true
+
+	//when
+	mssqlErr := ValidateDatabaseVendor(mssql)
+	bluegreenErr := ValidateDatabaseVendor(bluegreen)
+	migrationBackupsErr := ValidateDatabaseVendor(migrationBackups)
+	mssqlBackupErr := ValidateKeycloakBackupDatabase(backup, mssql)
+	mssqlRealmsBackupErr := ValidateKeycloakBackupDatabase(realmsBackup, mssql)
+	mysqlBackupErr := ValidateKeycloakBackupDatabase(backup, mysql)
+	mysqlVerifiedBackupErr := ValidateKeycloakBackupDatabase(verifiedBackup, mysql)
+
+	//then
+	assert.Nil(t, mssqlErr)
+	assert.Error(t, bluegreenErr)
+	assert.Error(t, migrationBackupsErr)
+	assert.Error(t, mssqlBackupErr)
+	assert.Nil(t, mssqlRealmsBackupErr)
+	assert.Nil(t, mysqlBackupErr)
+	assert.Error(t, mysqlVerifiedBackupErr)
+}
+
+func databaseVendorKeycloak(vendor v1alpha1.DatabaseVendor) *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.ExternalDatabase.Enabled = true
+	cr.Spec.ExternalDatabase.Vendor = vendor
+	return cr
+}
@@ -6,22 +6,41 @@ import (
 )
 
 const (
-	KeycloakImage         = "RELATED_IMAGE_KEYCLOAK"
-	RHSSOImageOpenJ9      = "RELATED_IMAGE_RHSSO_OPENJ9"
-	RHSSOImageOpenJDK     = "RELATED_IMAGE_RHSSO_OPENJDK"
-	RHSSOImage            = "RELATED_IMAGE_RHSSO"
-	KeycloakInitContainer = "RELATED_IMAGE_KEYCLOAK_INIT_CONTAINER"
-	RHSSOInitContainer    = "RELATED_IMAGE_RHSSO_INIT_CONTAINER"
-	RHMIBackupContainer   = "RELATED_IMAGE_RHMI_BACKUP_CONTAINER"
-	PostgresqlImage       = "RELATED_IMAGE_POSTGRESQL"
+	KeycloakImage          = "RELATED_IMAGE_KEYCLOAK"
+	RHSSOImageOpenJ9       = "RELATED_IMAGE_RHSSO_OPENJ9"
+	RHSSOImageOpenJDK      = "RELATED_IMAGE_RHSSO_OPENJDK"
+	RHSSOImage             = "RELATED_IMAGE_RHSSO"
+	KeycloakInitContainer  = "RELATED_IMAGE_KEYCLOAK_INIT_CONTAINER"
+	RHSSOInitContainer     = "RELATED_IMAGE_RHSSO_INIT_CONTAINER"
+	RHMIBackupContainer    = "RELATED_IMAGE_RHMI_BACKUP_CONTAINER"
+	PostgresqlImage        = "RELATED_IMAGE_POSTGRESQL"
+	PostgresqlImage12      = "RELATED_IMAGE_POSTGRESQL_12"
+	PostgresqlImage13      = "RELATED_IMAGE_POSTGRESQL_13"
+	PostgresqlImage15      = "RELATED_IMAGE_POSTGRESQL_15"
+	BackupS3Image          = "RELATED_IMAGE_BACKUP_S3"
+	BackupGCSImage         = "RELATED_IMAGE_BACKUP_GCS"
+	BackupAzureImage       = "RELATED_IMAGE_BACKUP_AZURE"
+	BackupMySQLImage       = "RELATED_IMAGE_BACKUP_MYSQL"
+	PostgresqlClusterImage = "RELATED_IMAGE_POSTGRESQL_CLUSTER"
+	KeycloakAdminAPIImage  = "RELATED_IMAGE_KEYCLOAK_ADMIN_API"
 
-	DefaultKeycloakImage         = "quay.io/keycloak/keycloak:legacy"
-	DefaultRHSSOImageOpenJ9      = "registry.redhat.io/rh-sso-7/sso75-openj9-openshift-rhel8:7.5"
-	DefaultRHSSOImageOpenJDK     = "registry.redhat.io/rh-sso-7/sso75-openshift-rhel8:7.5"
-	DefaultKeycloakInitContainer = "quay.io/keycloak/keycloak-init-container:legacy"
-	DefaultRHSSOInitContainer    = "registry.redhat.io/rh-sso-7/sso7-rhel8-init-container:7.5"
-	DefaultRHMIBackupContainer   = "quay.io/integreatly/backup-container:1.0.16"
-	DefaultPostgresqlImage       = "registry.access.redhat.com/rhscl/postgresql-10-rhel7:1"
+	DefaultKeycloakImage          = "quay.io/keycloak/keycloak:legacy"
+	DefaultRHSSOImageOpenJ9       = "registry.redhat.io/rh-sso-7/sso75-openj9-openshift-rhel8:7.5"
+	DefaultRHSSOImageOpenJDK      = "registry.redhat.io/rh-sso-7/sso75-openshift-rhel8:7.5"
+	DefaultKeycloakInitContainer  = "quay.io/keycloak/keycloak-init-container:legacy"
+	DefaultRHSSOInitContainer     = "registry.redhat.io/rh-sso-7/sso7-rhel8-init-container:7.5"
+	DefaultRHMIBackupContainer    = "quay.io/integreatly/backup-container:1.0.16"
+	DefaultPostgresqlImage        = "registry.access.redhat.com/rhscl/postgresql-10-rhel7:1"
+	DefaultPostgresqlImage12      = "registry.access.redhat.com/rhel8/postgresql-12:1"
+	DefaultPostgresqlImage13      = "registry.access.redhat.com/rhel9/postgresql-13:1"
+	DefaultPostgresqlImage15      = "registry.access.redhat.com/rhel9/postgresql-15:1"
+	DefaultBackupS3Image          = "docker.io/amazon/aws-cli:2.13.25"
+	DefaultBackupGCSImage         = "gcr.io/google.com/cloudsdktool/google-cloud-cli:449.0.0-alpine"
+	DefaultBackupAzureImage       = "mcr.microsoft.com/azure-cli:2.53.0"
+	DefaultBackupMySQLImage       = "docker.io/library/mariadb:10.11"
+	DefaultPostgresqlClusterImage = "ghcr.io/cloudnative-pg/postgresql:15"
+	// The realm exports call the admin API with curl, openssl and jq, which the Azure CLI image ships with
+	DefaultKeycloakAdminAPIImage = "mcr.microsoft.com/azure-cli:2.53.0"
 )
 
 var Images = NewImageManager()
@@ -33,14 +52,23 @@ type ImageManager struct {
 func NewImageManager() ImageManager {
 	ret := ImageManager{}
 	ret.Images = map[string]string{
-		KeycloakImage:         ret.getImage(KeycloakImage, DefaultKeycloakImage),
-		RHSSOImage:            ret.getRHSSOImage(),
-		RHSSOImageOpenJ9:      ret.getImage(RHSSOImageOpenJ9, DefaultRHSSOImageOpenJ9),
-		RHSSOImageOpenJDK:     ret.getImage(RHSSOImageOpenJDK, DefaultRHSSOImageOpenJDK),
-		KeycloakInitContainer: ret.getImage(KeycloakInitContainer, DefaultKeycloakInitContainer),
-		RHSSOInitContainer:    ret.getImage(RHSSOInitContainer, DefaultRHSSOInitContainer),
-		RHMIBackupContainer:   ret.getImage(RHMIBackupContainer, DefaultRHMIBackupContainer),
-		PostgresqlImage:       ret.getImage(PostgresqlImage, DefaultPostgresqlImage),
+		KeycloakImage:          ret.getImage(KeycloakImage, DefaultKeycloakImage),
+		RHSSOImage:             ret.getRHSSOImage(),
+		RHSSOImageOpenJ9:       ret.getImage(RHSSOImageOpenJ9, DefaultRHSSOImageOpenJ9),
+		RHSSOImageOpenJDK:      ret.getImage(RHSSOImageOpenJDK, DefaultRHSSOImageOpenJDK),
+		KeycloakInitContainer:  ret.getImage(KeycloakInitContainer, DefaultKeycloakInitContainer),
+		RHSSOInitContainer:     ret.getImage(RHSSOInitContainer, DefaultRHSSOInitContainer),
+		RHMIBackupContainer:    ret.getImage(RHMIBackupContainer, DefaultRHMIBackupContainer),
+		PostgresqlImage:        ret.getImage(PostgresqlImage, DefaultPostgresqlImage),
+		PostgresqlImage12:      ret.getImage(PostgresqlImage12, DefaultPostgresqlImage12),
+		PostgresqlImage13:      ret.getImage(PostgresqlImage13, DefaultPostgresqlImage13),
+		PostgresqlImage15:      ret.getImage(PostgresqlImage15, DefaultPostgresqlImage15),
+		BackupS3Image:          ret.getImage(BackupS3Image, DefaultBackupS3Image),
+		BackupGCSImage:         ret.getImage(BackupGCSImage, DefaultBackupGCSImage),
+		BackupAzureImage:       ret.getImage(BackupAzureImage, DefaultBackupAzureImage),
+		BackupMySQLImage:       ret.getImage(BackupMySQLImage, DefaultBackupMySQLImage),
+		PostgresqlClusterImage: ret.getImage(PostgresqlClusterImage, DefaultPostgresqlClusterImage),
+		KeycloakAdminAPIImage:  ret.getImage(KeycloakAdminAPIImage, DefaultKeycloakAdminAPIImage),
 	}
 	return ret
 }
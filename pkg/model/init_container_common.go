@@ -1,14 +1,36 @@
 package model
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 )
 
+// Downloads an extension source to the extensions volume and verifies its checksum. The values are passed
+// through the environment so that they never get interpreted by the shell.
+const keycloakExtensionDownloadScript = `set -e
+target="` + KeycloakExtensionsInitContainerPath + `/${EXTENSION_NAME}"
+if [ -n "${EXTENSION_TOKEN}" ]; then
+  curl --fail --silent --show-error --location --header "Authorization: Bearer ${EXTENSION_TOKEN}" --output "${target}" "${EXTENSION_URL}"
+elif [ -n "${EXTENSION_USERNAME}" ]; then
+  curl --fail --silent --show-error --location --user "${EXTENSION_USERNAME}:${EXTENSION_PASSWORD}" --output "${target}" "${EXTENSION_URL}"
+else
+  curl --fail --silent --show-error --location --output "${target}" "${EXTENSION_URL}"
+fi
+if [ -n "${EXTENSION_SHA256}" ]; then
+  actual=$(sha256sum "${target}" | cut -d ' ' -f 1)
+  if [ "${actual}" != "$(echo "${EXTENSION_SHA256}" | tr 'A-F' 'a-f')" ]; then
+    rm -f "${target}"
+    echo "checksum mismatch for extension ${EXTENSION_NAME}: expected sha256 ${EXTENSION_SHA256}, got ${actual}" | tee /dev/termination-log >&2
+    exit 1
+  fi
+fi`
+
 func KeycloakExtensionsInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
-	return []v1.Container{
+	initContainers := []v1.Container{
 		{
 			Name:  "extensions-init",
 			Image: Profiles.GetInitContainerImage(cr),
@@ -28,6 +50,209 @@ func KeycloakExtensionsInitContainers(cr *v1alpha1.Keycloak) []v1.Container {
 			TerminationMessagePath:   "/dev/termination-log",
 			TerminationMessagePolicy: "File",
 			ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+			SecurityContext:          KeycloakContainerSecurityContext(cr),
+		},
+	}
+
+	for i, extension := range cr.Spec.ExtensionSources {
+		if extension.Image != "" {
+			initContainers = append(initContainers, keycloakExtensionImageInitContainer(cr, i, extension))
+		} else {
+			initContainers = append(initContainers, keycloakExtensionDownloadInitContainer(cr, i, extension))
+		}
+	}
+	return initContainers
+}
+
+func keycloakExtensionDownloadInitContainer(cr *v1alpha1.Keycloak, index int, extension v1alpha1.KeycloakExtension) v1.Container {
+	env := []v1.EnvVar{
+		{
+			Name:  "EXTENSION_NAME",
+			Value: extension.Name,
+		},
+		{
+			Name:  "EXTENSION_URL",
+			Value: extension.URL,
+		},
+		{
+			Name:  "EXTENSION_SHA256",
+			Value: extension.Sha256,
+		},
+	}
+	if extension.PullSecret != "" {
+		env = append(env,
+			extensionPullSecretEnvVar("EXTENSION_TOKEN", extension.PullSecret, "token"),
+			extensionPullSecretEnvVar("EXTENSION_USERNAME", extension.PullSecret, "username"),
+			extensionPullSecretEnvVar("EXTENSION_PASSWORD", extension.PullSecret, "password"),
+		)
+	}
+
+	return v1.Container{
+		Name:    fmt.Sprintf("extension-%d", index),
+		Image:   Profiles.GetInitContainerImage(cr),
+		Command: []string{"/bin/sh", "-c", keycloakExtensionDownloadScript},
+		Env:     env,
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "keycloak-extensions",
+				MountPath: KeycloakExtensionsInitContainerPath,
+			},
+		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "FallbackToLogsOnError",
+		ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+		SecurityContext:          KeycloakInitContainerSecurityContext(cr, extension.SecurityContext),
+	}
+}
+
+func extensionPullSecretEnvVar(name string, secretName string, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key:      key,
+				Optional: &[]bool{true}[0],
+			},
+		},
+	}
+}
+
+func keycloakExtensionImageInitContainer(cr *v1alpha1.Keycloak, index int, extension v1alpha1.KeycloakExtension) v1.Container {
+	return v1.Container{
+		Name:    fmt.Sprintf("extension-%d", index),
+		Image:   extension.Image,
+		Command: []string{"cp", extension.Path, KeycloakExtensionsInitContainerPath + "/" + extension.Name},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      "keycloak-extensions",
+				MountPath: KeycloakExtensionsInitContainerPath,
+			},
 		},
+		TerminationMessagePath:   "/dev/termination-log",
+		TerminationMessagePolicy: "FallbackToLogsOnError",
+		ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+		SecurityContext:          KeycloakInitContainerSecurityContext(cr, extension.SecurityContext),
+	}
+}
+
+// KeycloakImagePullSecrets returns the pull secrets of the images extensions and themes are copied from, each
+// listed once.
+func KeycloakImagePullSecrets(cr *v1alpha1.Keycloak) []v1.LocalObjectReference {
+	var pullSecrets []v1.LocalObjectReference
+	for _, extension := range cr.Spec.ExtensionSources {
+		pullSecrets = addImagePullSecret(pullSecrets, extension.ImagePullSecret)
+	}
+	for _, theme := range cr.Spec.Themes {
+		pullSecrets = addImagePullSecret(pullSecrets, theme.ImagePullSecret)
+	}
+	return pullSecrets
+}
+
+func addImagePullSecret(pullSecrets []v1.LocalObjectReference, name string) []v1.LocalObjectReference {
+	if name == "" {
+		return pullSecrets
+	}
+	for _, pullSecret := range pullSecrets {
+		if pullSecret.Name == name {
+			return pullSecrets
+		}
+	}
+	return append(pullSecrets, v1.LocalObjectReference{Name: name})
+}
+
+// ValidateKeycloakExtensions checks that every extension source has exactly one of url and image set.
+func ValidateKeycloakExtensions(cr *v1alpha1.Keycloak) error {
+	names := make(map[string]bool)
+	for _, extension := range cr.Spec.ExtensionSources {
+		if names[extension.Name] {
+			return errors.Errorf("extension %v is listed more than once", extension.Name)
+		}
+		names[extension.Name] = true
+
+		switch {
+		case extension.URL == "" && extension.Image == "":
+			return errors.Errorf("extension %v needs either url or image to be set", extension.Name)
+		case extension.URL != "" && extension.Image != "":
+			return errors.Errorf("extension %v can't have both url and image set", extension.Name)
+		case extension.Image != "" && extension.Path == "":
+			return errors.Errorf("extension %v needs path to be set to be copied from image %v", extension.Name, extension.Image)
+		case extension.Image != "" && (extension.Sha256 != "" || extension.PullSecret != ""):
+			return errors.Errorf("sha256 and pullSecret of extension %v only apply to url downloads", extension.Name)
+		case extension.URL != "" && extension.ImagePullSecret != "":
+			return errors.Errorf("imagePullSecret of extension %v only applies to images", extension.Name)
+		}
+	}
+	return nil
+}
+
+// KeycloakLoadedExtensions describes the extension sources whose init containers completed in all Keycloak pods, as
+// reported in the status. Extensions that failed to download or to verify aren't reported.
+func KeycloakLoadedExtensions(cr *v1alpha1.Keycloak, pods *v1.PodList) []v1alpha1.KeycloakExtensionStatus {
+	if pods == nil || len(pods.Items) == 0 {
+		return nil
+	}
+
+	var loaded []v1alpha1.KeycloakExtensionStatus
+	for i, extension := range cr.Spec.ExtensionSources {
+		if !keycloakExtensionLoaded(pods, fmt.Sprintf("extension-%d", i), extension) {
+			continue
+		}
+		source := extension.URL
+		if extension.Image != "" {
+			source = extension.Image
+		}
+		loaded = append(loaded, v1alpha1.KeycloakExtensionStatus{
+			Name:   extension.Name,
+			Source: source,
+			Sha256: strings.ToLower(extension.Sha256),
+		})
+	}
+	return loaded
+}
+
+// keycloakExtensionLoaded checks that every pod ran the init container of the extension from the same source
+// and that it exited successfully
+func keycloakExtensionLoaded(pods *v1.PodList, containerName string, extension v1alpha1.KeycloakExtension) bool {
+	for _, pod := range pods.Items {
+		if !podLoadsExtension(pod, containerName, extension) {
+			return false
+		}
+
+		succeeded := false
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == containerName && status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+				succeeded = true
+			}
+		}
+		if !succeeded {
+			return false
+		}
+	}
+	return true
+}
+
+func podLoadsExtension(pod v1.Pod, containerName string, extension v1alpha1.KeycloakExtension) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name != containerName {
+			continue
+		}
+		if extension.Image != "" {
+			return container.Image == extension.Image
+		}
+		return containerEnvValue(container, "EXTENSION_URL") == extension.URL &&
+			containerEnvValue(container, "EXTENSION_SHA256") == extension.Sha256
+	}
+	return false
+}
+
+func containerEnvValue(container v1.Container, name string) string {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
 	}
+	return ""
 }
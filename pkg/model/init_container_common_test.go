@@ -0,0 +1,131 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestInitContainer_testExtensionSources(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{
+		{
+			Name:       "my-spi.jar",
+			URL:        "https://example.com/my-spi.jar",
+			Sha256:     "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08",
+			PullSecret: "my-spi-credentials",
+		},
+		{
+			Name:            "other-spi.jar",
+			Image:           "quay.io/example/other-spi:1.0",
+			Path:            "/providers/other-spi.jar",
+			ImagePullSecret: "example-registry",
+			SecurityContext: &v1.SecurityContext{RunAsNonRoot: &[]bool{false}[0]},
+		},
+		{
+			Name:            "third-spi.jar",
+			Image:           "quay.io/example/third-spi:1.0",
+			Path:            "/providers/third-spi.jar",
+			ImagePullSecret: "example-registry",
+		},
+	}
+
+	//when
+	initContainers := KeycloakExtensionsInitContainers(cr)
+	pullSecrets := KeycloakImagePullSecrets(cr)
+
+	//then
+	assert.Len(t, initContainers, 4)
+	assert.Equal(t, "extensions-init", initContainers[0].Name)
+
+	download := initContainers[1]
+	assert.Equal(t, "extension-0", download.Name)
+	assert.Equal(t, []string{"/bin/sh", "-c", keycloakExtensionDownloadScript}, download.Command)
+	assert.Contains(t, download.Env, v1.EnvVar{Name: "EXTENSION_URL", Value: "https://example.com/my-spi.jar"})
+	assert.Contains(t, download.Env, v1.EnvVar{Name: "EXTENSION_SHA256", Value: "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"})
+	assert.Contains(t, download.Env, extensionPullSecretEnvVar("EXTENSION_TOKEN", "my-spi-credentials", "token"))
+
+	image := initContainers[2]
+	assert.Equal(t, "extension-1", image.Name)
+	assert.Equal(t, "quay.io/example/other-spi:1.0", image.Image)
+	assert.Equal(t, []string{"cp", "/providers/other-spi.jar", KeycloakExtensionsInitContainerPath + "/other-spi.jar"}, image.Command)
+	assert.False(t, *image.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, download.TerminationMessagePolicy, image.TerminationMessagePolicy)
+	assert.Equal(t, KeycloakContainerSecurityContext(cr), initContainers[3].SecurityContext)
+
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "example-registry"}}, pullSecrets)
+	assert.Equal(t, pullSecrets, KeycloakDeployment(cr, nil, nil).Spec.Template.Spec.ImagePullSecrets)
+}
+
+func TestInitContainer_testLoadedExtensions(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{
+		{
+			Name:   "my-spi.jar",
+			URL:    "https://example.com/my-spi.jar",
+			Sha256: "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08",
+		},
+		{
+			Name:  "other-spi.jar",
+			Image: "quay.io/example/other-spi:1.0",
+			Path:  "/providers/other-spi.jar",
+		},
+		{
+			Name: "broken-spi.jar",
+			URL:  "https://example.com/broken-spi.jar",
+		},
+	}
+	pod := v1.Pod{}
+	pod.Spec.InitContainers = KeycloakExtensionsInitContainers(cr)
+	pod.Status.InitContainerStatuses = []v1.ContainerStatus{
+		{Name: "extension-0", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+		{Name: "extension-1", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 0}}},
+		{Name: "extension-2", State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}}},
+	}
+	outdatedPod := *pod.DeepCopy()
+	outdatedPod.Spec.InitContainers[2].Image = "quay.io/example/other-spi:0.9"
+
+	//when
+	loaded := KeycloakLoadedExtensions(cr, &v1.PodList{Items: []v1.Pod{pod}})
+	loadedDuringRollout := KeycloakLoadedExtensions(cr, &v1.PodList{Items: []v1.Pod{pod, outdatedPod}})
+	loadedWithoutPods := KeycloakLoadedExtensions(cr, &v1.PodList{})
+
+	//then
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", loaded[0].Sha256)
+	assert.Equal(t, "quay.io/example/other-spi:1.0", loaded[1].Source)
+	assert.Len(t, loadedDuringRollout, 1)
+	assert.Equal(t, "my-spi.jar", loadedDuringRollout[0].Name)
+	assert.Empty(t, loadedWithoutPods)
+}
+
+func TestInitContainer_testValidateExtensionSources(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+
+	//then
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{{Name: "a.jar", URL: "https://example.com/a.jar"}}
+	assert.NoError(t, ValidateKeycloakExtensions(cr))
+
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{{Name: "a.jar"}}
+	assert.Error(t, ValidateKeycloakExtensions(cr))
+
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{{Name: "a.jar", URL: "https://example.com/a.jar", Image: "example/a"}}
+	assert.Error(t, ValidateKeycloakExtensions(cr))
+
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{{Name: "a.jar", Image: "example/a"}}
+	assert.Error(t, ValidateKeycloakExtensions(cr))
+
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{{Name: "a.jar", URL: "https://example.com/a.jar", ImagePullSecret: "registry"}}
+	assert.Error(t, ValidateKeycloakExtensions(cr))
+
+	cr.Spec.ExtensionSources = []v1alpha1.KeycloakExtension{
+		{Name: "a.jar", URL: "https://example.com/a.jar"},
+		{Name: "a.jar", Image: "example/a", Path: "/a.jar"},
+	}
+	assert.Error(t, ValidateKeycloakExtensions(cr))
+}
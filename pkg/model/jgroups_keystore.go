@@ -0,0 +1,260 @@
+package model
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1" // nolint
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// The keystore holds the AES key JGroups encrypts the cluster traffic with. It's a PKCS#12 file with a single
+// secret key entry, as written by keytool -genseckey -storetype PKCS12. The key is protected with
+// pbeWithSHAAnd3-KeyTripleDES-CBC and the file with a SHA-1 HMAC, which every Java version reads.
+const (
+	jgroupsKeystoreAlias       = "jgroups"
+	jgroupsKeystoreKeySize     = 32
+	jgroupsKeystoreIterations  = 10000
+	pkcs12KeyDerivationID      = 1
+	pkcs12IVDerivationID       = 2
+	pkcs12MACKeyDerivationID   = 3
+	pkcs12SHA1BlockSize        = 64
+	pkcs12SHA1DigestSize       = 20
+	pkcs12TripleDESKeySize     = 24
+	pkcs12TripleDESBlockSize   = des.BlockSize
+	pkcs12SecretKeyInfoVersion = 0
+)
+
+var (
+	oidDataContentType       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS8ShroudedKeyBag   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidSecretBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 5}
+	oidPBEWithSHAAnd3KeyTDES = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidFriendlyName          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidSHA1                  = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidAES                   = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1}
+	asn1Null                 = asn1.RawValue{Tag: asn1.TagNull}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type secretBag struct {
+	SecretTypeID asn1.ObjectIdentifier
+	SecretValue  asn1.RawValue
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// secretKeyInfo is the PKCS#8 like structure Java stores secret keys in
+type secretKeyInfo struct {
+	Version   int
+	Algorithm pkix.AlgorithmIdentifier
+	Key       []byte
+}
+
+// JGroupsKeystore returns a PKCS#12 keystore holding a new random AES key under the jgroups alias, protected
+// with the password
+func JGroupsKeystore(password string) []byte {
+	return jgroupsKeystore(password, GenerateRandomBytes(jgroupsKeystoreKeySize), GenerateRandomBytes(8), GenerateRandomBytes(8))
+}
+
+func jgroupsKeystore(password string, key, keySalt, macSalt []byte) []byte {
+	bmpPassword := bmpString(password)
+
+	keyInfo := mustMarshal(secretKeyInfo{
+		Version:   pkcs12SecretKeyInfoVersion,
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidAES},
+		Key:       key,
+	})
+	shroudedKey := mustMarshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBEWithSHAAnd3KeyTDES,
+			Parameters: asn1.RawValue{FullBytes: mustMarshal(pbeParams{Salt: keySalt, Iterations: jgroupsKeystoreIterations})},
+		},
+		EncryptedData: pkcs12Encrypt(bmpPassword, keySalt, jgroupsKeystoreIterations, keyInfo),
+	})
+	secret := mustMarshal(secretBag{
+		SecretTypeID: oidPKCS8ShroudedKeyBag,
+		SecretValue:  explicitTag(mustMarshal(shroudedKey)),
+	})
+	safeContents := mustMarshal([]safeBag{
+		{
+			ID:    oidSecretBag,
+			Value: explicitTag(secret),
+			Attributes: []pkcs12Attribute{
+				{
+					ID:    oidFriendlyName,
+					Value: asn1Set(bmpStringValue(jgroupsKeystoreAlias)),
+				},
+				{
+					ID:    oidLocalKeyID,
+					Value: asn1Set(mustMarshal([]byte(jgroupsKeystoreAlias))),
+				},
+			},
+		},
+	})
+
+	// The authenticated safe holds a single unencrypted data content, the key itself is encrypted
+	authenticatedSafe := mustMarshal([]contentInfo{
+		{
+			ContentType: oidDataContentType,
+			Content:     explicitTag(mustMarshal(safeContents)),
+		},
+	})
+
+	macKey := pkcs12KeyDerivation(bmpPassword, macSalt, jgroupsKeystoreIterations, pkcs12MACKeyDerivationID, pkcs12SHA1DigestSize)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafe)
+
+	return mustMarshal(pfxPdu{
+		Version: 3,
+		AuthSafe: contentInfo{
+			ContentType: oidDataContentType,
+			Content:     explicitTag(mustMarshal(authenticatedSafe)),
+		},
+		MacData: macData{
+			Mac: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1Null},
+				Digest:    mac.Sum(nil),
+			},
+			MacSalt:    macSalt,
+			Iterations: jgroupsKeystoreIterations,
+		},
+	})
+}
+
+// pkcs12Encrypt encrypts with pbeWithSHAAnd3-KeyTripleDES-CBC
+func pkcs12Encrypt(bmpPassword, salt []byte, iterations int, plaintext []byte) []byte {
+	key := pkcs12KeyDerivation(bmpPassword, salt, iterations, pkcs12KeyDerivationID, pkcs12TripleDESKeySize)
+	iv := pkcs12KeyDerivation(bmpPassword, salt, iterations, pkcs12IVDerivationID, pkcs12TripleDESBlockSize)
+	// The derived key always has the size of a triple DES key
+	block, _ := des.NewTripleDESCipher(key)
+
+	padding := pkcs12TripleDESBlockSize - len(plaintext)%pkcs12TripleDESBlockSize
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+	return encrypted
+}
+
+// pkcs12KeyDerivation derives keys, IVs and MAC keys from a password as described in RFC 7292, appendix B.2
+func pkcs12KeyDerivation(bmpPassword, salt []byte, iterations int, id byte, size int) []byte {
+	v := pkcs12SHA1BlockSize
+	d := bytes.Repeat([]byte{id}, v)
+	i := append(pkcs12Fill(salt, v), pkcs12Fill(bmpPassword, v)...)
+
+	var derived []byte
+	for len(derived) < size {
+		hash := sha1.New()
+		hash.Write(d)
+		hash.Write(i)
+		a := hash.Sum(nil)
+		for n := 1; n < iterations; n++ {
+			sum := sha1.Sum(a)
+			a = sum[:]
+		}
+		derived = append(derived, a...)
+
+		// I_j = (I_j + B + 1) mod 2^(v*8) for every block I_j of I
+		b := pkcs12Fill(a, v)
+		for j := 0; j < len(i); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				sum := int(i[j+k]) + int(b[k]) + carry
+				i[j+k] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+	return derived[:size]
+}
+
+// pkcs12Fill repeats the bytes up to the next multiple of v
+func pkcs12Fill(b []byte, v int) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	filled := make([]byte, v*((len(b)+v-1)/v))
+	for n := range filled {
+		filled[n] = b[n%len(b)]
+	}
+	return filled
+}
+
+// bmpString encodes a password as big endian UTF-16 with a terminating null character
+func bmpString(s string) []byte {
+	var encoded []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		encoded = append(encoded, byte(c>>8), byte(c))
+	}
+	return append(encoded, 0, 0)
+}
+
+// bmpStringValue returns the DER encoded BMPString, encoding/asn1 doesn't marshal them
+func bmpStringValue(s string) []byte {
+	value := bmpString(s)
+	return mustMarshal(asn1.RawValue{Tag: asn1.TagBMPString, Bytes: value[:len(value)-2]})
+}
+
+// explicitTag wraps a DER value in the [0] EXPLICIT tag used by content infos and bags
+func explicitTag(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func asn1Set(der []byte) asn1.RawValue {
+	return asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+}
+
+// mustMarshal encodes the fixed structures of the keystore, which only fails on programming errors
+func mustMarshal(value interface{}) []byte {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		panic(errors.Wrap(err, "error encoding the jgroups keystore"))
+	}
+	return der
+}
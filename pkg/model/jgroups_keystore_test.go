@@ -0,0 +1,96 @@
+package model
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1" // nolint
+	"encoding/asn1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJGroupsKeystore_testKeyDerivation(t *testing.T) {
+	//given
+	salt := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	//when
+	key := pkcs12KeyDerivation(bmpString("secret"), salt, 2048, pkcs12KeyDerivationID, pkcs12TripleDESKeySize)
+
+	//then
+	// As derived by openssl kdf PKCS12KDF
+	assert.Equal(t, "46b7220edfa6b4bcfe35292f7f4888c97ff731e06932a6a2", hex.EncodeToString(key))
+}
+
+func TestJGroupsKeystore_testKeystore(t *testing.T) {
+	//given
+	key := GenerateRandomBytes(jgroupsKeystoreKeySize)
+
+	//when
+	keystore := jgroupsKeystore("secret", key, GenerateRandomBytes(8), GenerateRandomBytes(8))
+
+	//then
+	var pfx pfxPdu
+	_, err := asn1.Unmarshal(keystore, &pfx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pfx.Version)
+
+	var authenticatedSafe []byte
+	_, err = asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authenticatedSafe)
+	assert.NoError(t, err)
+	macKey := pkcs12KeyDerivation(bmpString("secret"), pfx.MacData.MacSalt, pfx.MacData.Iterations, pkcs12MACKeyDerivationID, pkcs12SHA1DigestSize)
+	mac := hmac.New(sha1.New, macKey)
+	mac.Write(authenticatedSafe)
+	assert.Equal(t, mac.Sum(nil), pfx.MacData.Mac.Digest)
+
+	var contents []contentInfo
+	_, err = asn1.Unmarshal(authenticatedSafe, &contents)
+	assert.NoError(t, err)
+	var safeContents []byte
+	_, err = asn1.Unmarshal(contents[0].Content.Bytes, &safeContents)
+	assert.NoError(t, err)
+	var bags []safeBag
+	_, err = asn1.Unmarshal(safeContents, &bags)
+	assert.NoError(t, err)
+	assert.Len(t, bags, 1)
+	assert.Equal(t, oidSecretBag, bags[0].ID)
+	for _, attribute := range bags[0].Attributes {
+		if attribute.ID.Equal(oidFriendlyName) {
+			assert.Equal(t, bmpStringValue(jgroupsKeystoreAlias), attribute.Value.Bytes)
+		}
+	}
+
+	var secret secretBag
+	_, err = asn1.Unmarshal(bags[0].Value.Bytes, &secret)
+	assert.NoError(t, err)
+	var shroudedKey []byte
+	_, err = asn1.Unmarshal(secret.SecretValue.Bytes, &shroudedKey)
+	assert.NoError(t, err)
+	assert.Equal(t, key, decryptJGroupsKey(t, shroudedKey, "secret"))
+}
+
+func decryptJGroupsKey(t *testing.T, shroudedKey []byte, password string) []byte {
+	var encrypted encryptedPrivateKeyInfo
+	_, err := asn1.Unmarshal(shroudedKey, &encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, oidPBEWithSHAAnd3KeyTDES, encrypted.Algorithm.Algorithm)
+	var params pbeParams
+	_, err = asn1.Unmarshal(encrypted.Algorithm.Parameters.FullBytes, &params)
+	assert.NoError(t, err)
+
+	key := pkcs12KeyDerivation(bmpString(password), params.Salt, params.Iterations, pkcs12KeyDerivationID, pkcs12TripleDESKeySize)
+	iv := pkcs12KeyDerivation(bmpString(password), params.Salt, params.Iterations, pkcs12IVDerivationID, pkcs12TripleDESBlockSize)
+	block, err := des.NewTripleDESCipher(key)
+	assert.NoError(t, err)
+	decrypted := make([]byte, len(encrypted.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, encrypted.EncryptedData)
+	decrypted = decrypted[:len(decrypted)-int(decrypted[len(decrypted)-1])]
+
+	var keyInfo secretKeyInfo
+	_, err = asn1.Unmarshal(decrypted, &keyInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, oidAES, keyInfo.Algorithm.Algorithm)
+	return keyInfo.Key
+}
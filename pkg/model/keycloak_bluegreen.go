@@ -0,0 +1,232 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// psql connects to the database Keycloak currently runs against to create the new one, a database left behind by
+// a previous attempt is dropped first. The backup is loaded into it in a single transaction.
+const keycloakDatabaseCloneScript = `set -eo pipefail
+echo 'DROP DATABASE IF EXISTS :"database"; CREATE DATABASE :"database";' | psql -q -v ON_ERROR_STOP=1 -v database="$TARGET_DATABASE"
+psql -q -v ON_ERROR_STOP=1 --single-transaction -d "$TARGET_DATABASE" -f /backup/backup.sql > /dev/null
+`
+
+// Drops a database cloned by a bluegreen migration once nothing runs against it anymore. psql connects to the
+// database Keycloak currently runs against.
+const keycloakDatabaseDropScript = `echo 'DROP DATABASE IF EXISTS :"database";' | psql -q -v ON_ERROR_STOP=1 -v database="$DATABASE"
+`
+
+// KeycloakBlueGreenDeployment returns the StatefulSet running the upgraded image during a bluegreen migration.
+// It's derived from the Keycloak StatefulSet, but its pods run against the cloned database and form a cluster of
+// their own.
+func KeycloakBlueGreenDeployment(cr *v1alpha1.Keycloak, deployment *v13.StatefulSet, image string, database string) *v13.StatefulSet {
+	green := deployment.DeepCopy()
+	green.ObjectMeta = v12.ObjectMeta{
+		Name:        KeycloakBlueGreenDeploymentName,
+		Namespace:   cr.Namespace,
+		Labels:      keycloakBlueGreenLabels(deployment.Labels),
+		Annotations: deployment.Annotations,
+	}
+	green.Status = v13.StatefulSetStatus{}
+	green.Spec.Selector = &v12.LabelSelector{
+		MatchLabels: keycloakBlueGreenLabels(deployment.Spec.Selector.MatchLabels),
+	}
+	green.Spec.Template.Name = KeycloakBlueGreenDeploymentName
+	green.Spec.Template.Labels = keycloakBlueGreenLabels(deployment.Spec.Template.Labels)
+
+	container := &green.Spec.Template.Spec.Containers[0]
+	container.Image = image
+	for i := range container.Env {
+		env := &container.Env[i]
+		switch env.Name {
+		case "DB_DATABASE":
+			env.Value = database
+		case "JGROUPS_DISCOVERY_PROPERTIES":
+			env.Value = "dns_query=" + KeycloakBlueGreenDeploymentName + "." + cr.Namespace
+		case "OPENSHIFT_DNS_PING_SERVICE_NAME":
+			env.Value = KeycloakBlueGreenDeploymentName + "." + cr.Namespace + ".svc.cluster.local"
+		case "KUBERNETES_LABELS":
+			env.Value = kubePingLabels(keycloakBlueGreenLabels(GetLabelsSelector()))
+		}
+	}
+	return green
+}
+
+func KeycloakBlueGreenDeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakBlueGreenDeploymentName,
+		Namespace: cr.Namespace,
+	}
+}
+
+func KeycloakBlueGreenDeploymentReconciled(desired *v13.StatefulSet, currentState *v13.StatefulSet) *v13.StatefulSet {
+	reconciled := currentState.DeepCopy()
+	reconciled.Labels = desired.Labels
+	reconciled.Annotations = desired.Annotations
+	reconciled.Spec.Replicas = desired.Spec.Replicas
+	reconciled.Spec.Template = desired.Spec.Template
+	return reconciled
+}
+
+func keycloakBlueGreenLabels(labels map[string]string) map[string]string {
+	greenLabels := make(map[string]string, len(labels))
+	for key, value := range labels {
+		greenLabels[key] = value
+	}
+	greenLabels["component"] = KeycloakBlueGreenComponent
+	return greenLabels
+}
+
+// KeycloakBlueGreenService returns the headless Service of the upgraded pods. It's used for their discovery and
+// to run the smoke checks against them before the Keycloak Service is switched.
+func KeycloakBlueGreenService(cr *v1alpha1.Keycloak) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakBlueGreenDeploymentName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app": ApplicationName,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Selector: map[string]string{
+				"app":       ApplicationName,
+				"component": KeycloakBlueGreenComponent,
+			},
+			Ports: []v1.ServicePort{
+				{
+					Port:       KeycloakServicePort,
+					TargetPort: intstr.FromInt(KeycloakServicePort),
+					Name:       ApplicationName,
+					Protocol:   "TCP",
+				},
+			},
+			ClusterIP:                "None",
+			PublishNotReadyAddresses: true,
+		},
+	}
+}
+
+func KeycloakBlueGreenServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakBlueGreenDeploymentName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakBlueGreenURL returns the URL of the upgraded pods
+func KeycloakBlueGreenURL(cr *v1alpha1.Keycloak) string {
+	return fmt.Sprintf("https://%v.%v.svc:%v", KeycloakBlueGreenDeploymentName, cr.Namespace, KeycloakServicePort)
+}
+
+// KeycloakDatabaseClone returns a Job restoring the local backup taken before a bluegreen migration into a new
+// database
+func KeycloakDatabaseClone(cr *v1alpha1.Keycloak, backupName string, database string) *batchv1.Job {
+	job := keycloakDatabaseJob(cr, KeycloakDatabaseCloneSelector(cr, database).Name, PostgresqlBackupComponent, "clone", keycloakDatabaseCloneScript,
+		v1.EnvVar{
+			Name:  "TARGET_DATABASE",
+			Value: database,
+		},
+	)
+	claimName := PostgresqlBackupPersistentVolumeName + "-" + backupName
+	job.Spec.Template.Spec.Volumes = []v1.Volume{
+		{
+			Name: claimName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: claimName,
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = []v1.VolumeMount{
+		{
+			Name:      claimName,
+			MountPath: "/backup",
+			ReadOnly:  true,
+		},
+	}
+	return job
+}
+
+func KeycloakDatabaseCloneSelector(cr *v1alpha1.Keycloak, database string) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakDeploymentName + "-clone-" + strings.ReplaceAll(database, "_", "-"),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakDatabaseDrop returns a Job dropping a database cloned by a bluegreen migration
+func KeycloakDatabaseDrop(cr *v1alpha1.Keycloak, database string) *batchv1.Job {
+	return keycloakDatabaseJob(cr, KeycloakDatabaseDropSelector(cr, database).Name, KeycloakDatabaseDropComponent, "drop", keycloakDatabaseDropScript,
+		v1.EnvVar{
+			Name:  "DATABASE",
+			Value: database,
+		},
+	)
+}
+
+func KeycloakDatabaseDropSelector(cr *v1alpha1.Keycloak, database string) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakDeploymentName + "-drop-" + strings.ReplaceAll(database, "_", "-"),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakDatabaseDropLabels selects the Jobs dropping cloned databases
+func KeycloakDatabaseDropLabels() map[string]string {
+	return map[string]string{
+		"app":       ApplicationName,
+		"component": KeycloakDatabaseDropComponent,
+	}
+}
+
+// keycloakDatabaseJob returns a Job running a psql script against the Keycloak database
+func keycloakDatabaseJob(cr *v1alpha1.Keycloak, name string, component string, containerName string, script string, env ...v1.EnvVar) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":       ApplicationName,
+				"component": component,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &[]int32{2}[0],
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{
+							Name:    containerName,
+							Image:   PostgresqlClientImage(cr),
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{script},
+							Env: append([]v1.EnvVar{
+								postgresqlBackupSecretEnvVar("PGUSER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+								postgresqlBackupSecretEnvVar("PGPASSWORD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+								postgresqlBackupSecretEnvVar("PGDATABASE", DatabaseSecretName, DatabaseSecretDatabaseProperty, false),
+								{
+									Name:  "PGHOST",
+									Value: PostgresqlServiceName,
+								},
+							}, env...),
+						},
+					},
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: PostgresqlBackupServiceAccountName,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKeycloakBlueGreen_testDeployment(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.Instances = 2
+	dbSecret := DatabaseSecret(cr)
+	deployment := KeycloakDeployment(cr, dbSecret, nil)
+
+	//when
+	green := KeycloakBlueGreenDeployment(cr, deployment, "new_image", "keycloak_20240101000000")
+
+	//then
+	assert.Equal(t, KeycloakBlueGreenDeploymentName, green.Name)
+	assert.Equal(t, KeycloakBlueGreenComponent, green.Spec.Selector.MatchLabels["component"])
+	assert.Equal(t, KeycloakBlueGreenComponent, green.Spec.Template.Labels["component"])
+	assert.Equal(t, KeycloakDeploymentComponent, deployment.Spec.Template.Labels["component"])
+	assert.Equal(t, deployment.Spec.Replicas, green.Spec.Replicas)
+
+	container := green.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "new_image", container.Image)
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "DB_DATABASE", Value: "keycloak_20240101000000"})
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "JGROUPS_DISCOVERY_PROPERTIES", Value: "dns_query=keycloak-green.keycloak"})
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "DB_DATABASE", Value: PostgresqlDatabase})
+}
+
+func TestKeycloakBlueGreen_testDeploymentUsesPromotedDatabase(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	dbSecret := DatabaseSecret(cr)
+	dbSecret.Data[DatabaseSecretDatabaseProperty] = []byte("keycloak_20240101000000")
+
+	//when
+	deployment := KeycloakDeployment(cr, dbSecret, nil)
+	rhssoDeployment := RHSSODeployment(cr, dbSecret, nil)
+
+	//then
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "DB_DATABASE", Value: "keycloak_20240101000000"})
+	assert.Contains(t, rhssoDeployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "DB_DATABASE", Value: "keycloak_20240101000000"})
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "DB_SCHEMA", Value: PostgresqlSchema})
+}
+
+func TestKeycloakBlueGreen_testDatabaseClone(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+
+	//when
+	job := KeycloakDatabaseClone(cr, "migrate-backup", "keycloak_20240101000000")
+
+	//then
+	assert.Equal(t, "keycloak-clone-keycloak-20240101000000", job.Name)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Env, v1.EnvVar{Name: "TARGET_DATABASE", Value: "keycloak_20240101000000"})
+	assert.Equal(t, DatabaseSecretDatabaseProperty, findEnvVar(container.Env, "PGDATABASE").ValueFrom.SecretKeyRef.Key)
+	assert.Equal(t, PostgresqlBackupPersistentVolumeName+"-migrate-backup", job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "/backup", container.VolumeMounts[0].MountPath)
+	assert.True(t, container.VolumeMounts[0].ReadOnly)
+}
+
+func TestKeycloakBlueGreen_testDatabaseDrop(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+
+	//when
+	job := KeycloakDatabaseDrop(cr, "keycloak_20240101000000")
+	restoreJob := KeycloakMigrationRestore(cr, "backup")
+
+	//then
+	assert.Equal(t, "keycloak-drop-keycloak-20240101000000", job.Name)
+	assert.Equal(t, KeycloakDatabaseDropLabels(), job.Labels)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "DATABASE", Value: "keycloak_20240101000000"})
+	assert.Equal(t, DatabaseSecretDatabaseProperty, findEnvVar(restoreJob.Spec.Template.Spec.Containers[0].Env, "PGDATABASE").ValueFrom.SecretKeyRef.Key)
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The cert-manager API types aren't a dependency of the operator, the Certificate is managed as an unstructured object
+var CertificateGroupVersionKind = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+func KeycloakCertificate(cr *v1alpha1.Keycloak) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGroupVersionKind)
+	certificate.SetName(ApplicationName)
+	certificate.SetNamespace(cr.Namespace)
+	certificate.SetLabels(map[string]string{
+		"app": ApplicationName,
+	})
+	certificate.Object["spec"] = keycloakCertificateSpec(cr)
+	return certificate
+}
+
+func KeycloakCertificateSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      ApplicationName,
+		Namespace: cr.Namespace,
+	}
+}
+
+func KeycloakCertificateReconciled(cr *v1alpha1.Keycloak, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	reconciled.Object["spec"] = keycloakCertificateSpec(cr)
+	return reconciled
+}
+
+// The certificate is stored in the secret the OpenShift service serving certificate would be stored in,
+// which is already mounted into the Keycloak pods.
+func keycloakCertificateSpec(cr *v1alpha1.Keycloak) map[string]interface{} {
+	issuerKind := cr.Spec.CertManager.IssuerRef.Kind
+	if issuerKind == "" {
+		issuerKind = "Issuer"
+	}
+	issuerGroup := cr.Spec.CertManager.IssuerRef.Group
+	if issuerGroup == "" {
+		issuerGroup = CertificateGroupVersionKind.Group
+	}
+
+	spec := map[string]interface{}{
+		"secretName": ServingCertSecretName,
+		"dnsNames":   keycloakCertificateDNSNames(cr),
+		"issuerRef": map[string]interface{}{
+			"name":  cr.Spec.CertManager.IssuerRef.Name,
+			"kind":  issuerKind,
+			"group": issuerGroup,
+		},
+	}
+	if cr.Spec.CertManager.Duration != nil {
+		spec["duration"] = cr.Spec.CertManager.Duration.Duration.String()
+	}
+	if cr.Spec.CertManager.RenewBefore != nil {
+		spec["renewBefore"] = cr.Spec.CertManager.RenewBefore.Duration.String()
+	}
+	return spec
+}
+
+func keycloakCertificateDNSNames(cr *v1alpha1.Keycloak) []interface{} {
+	dnsNames := []interface{}{
+		ApplicationName,
+		fmt.Sprintf("%v.%v", ApplicationName, cr.Namespace),
+		fmt.Sprintf("%v.%v.svc", ApplicationName, cr.Namespace),
+		fmt.Sprintf("%v.%v.svc.cluster.local", ApplicationName, cr.Namespace),
+	}
+	if cr.Spec.ExternalAccess.Host != "" {
+		dnsNames = append(dnsNames, cr.Spec.ExternalAccess.Host)
+	}
+	return dnsNames
+}
+
+func KeycloakServingCertSecretSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      ServingCertSecretName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// SetKeycloakServingCertChecksum annotates the pod template with a checksum of the serving certificate
+// issued by cert-manager, so that the pods are rolled when it gets renewed.
+func SetKeycloakServingCertChecksum(cr *v1alpha1.Keycloak, statefulSet *v13.StatefulSet, servingCertSecret *v1.Secret) {
+	checksum := ""
+	if cr.Spec.CertManager.Enabled && servingCertSecret != nil {
+		checksum = fmt.Sprintf("%x", sha256.Sum256(servingCertSecret.Data["tls.crt"]))
+	}
+	setPodTemplateAnnotation(statefulSet, KeycloakServingCertChecksumAnnotation, checksum)
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKeycloakCertificate_testSpec(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.ExternalAccess.Host = "sso.example.com"
+	cr.Spec.CertManager.Enabled = true
+	cr.Spec.CertManager.IssuerRef.Name = "letsencrypt"
+	cr.Spec.CertManager.IssuerRef.Kind = "ClusterIssuer"
+	cr.Spec.CertManager.Duration = &v12.Duration{Duration: 2160 * time.Hour}
+
+	//when
+	certificate := KeycloakCertificate(cr)
+
+	//then
+	assert.Equal(t, CertificateGroupVersionKind, certificate.GroupVersionKind())
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	assert.Equal(t, ServingCertSecretName, secretName)
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Contains(t, dnsNames, "keycloak.keycloak.svc")
+	assert.Contains(t, dnsNames, "sso.example.com")
+	issuer, _, _ := unstructured.NestedStringMap(certificate.Object, "spec", "issuerRef")
+	assert.Equal(t, map[string]string{"name": "letsencrypt", "kind": "ClusterIssuer", "group": "cert-manager.io"}, issuer)
+	duration, _, _ := unstructured.NestedString(certificate.Object, "spec", "duration")
+	assert.Equal(t, "2160h0m0s", duration)
+}
+
+func TestKeycloakCertificate_testServiceWithoutServingCertAnnotation(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.CertManager.Enabled = true
+	current := KeycloakService(&v1alpha1.Keycloak{})
+
+	//when
+	service := KeycloakService(cr)
+	reconciled := KeycloakServiceReconciled(cr, current)
+
+	//then
+	assert.NotContains(t, service.Annotations, servingCertAnnotation)
+	assert.NotContains(t, reconciled.Annotations, servingCertAnnotation)
+}
@@ -0,0 +1,585 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	keycloakClusteringScriptProperty      = "clustering.cli"
+	keycloakClusteringPostconfigureScript = "postconfigure.sh"
+	keycloakClusteringVolumeName          = "keycloak-clustering"
+	keycloakJGroupsKeystoreVolumeName     = "keycloak-jgroups-keystore"
+	keycloakAuthenticationSessionsCache   = "authenticationSessions"
+	keycloakWorkCache                     = "work"
+	keycloakRemoteCacheSocketBinding      = "remote-cache"
+	keycloakHotRodMarshaller              = "org.keycloak.cluster.infinispan.KeycloakHotRodMarshallerFactory"
+)
+
+// The distributed caches of Keycloak, their owners can be configured
+var keycloakDistributedCaches = []string{
+	"sessions",
+	"clientSessions",
+	"offlineSessions",
+	"offlineClientSessions",
+	"loginFailures",
+	keycloakAuthenticationSessionsCache,
+	"actionTokens",
+}
+
+// The caches stored in an external Infinispan by default, the authentication sessions are kept local
+var keycloakDefaultRemoteCaches = []string{
+	keycloakWorkCache,
+	"sessions",
+	"clientSessions",
+	"offlineSessions",
+	"offlineClientSessions",
+	"loginFailures",
+	"actionTokens",
+}
+
+// The postconfigure.sh script of RH-SSO is run after the server was configured from the environment
+const rhssoClusteringPostconfigure = `#!/bin/bash
+set -e
+$JBOSS_HOME/bin/jboss-cli.sh --file=` + RhssoPostconfigurePath + "/" + keycloakClusteringScriptProperty + `
+`
+
+func IsKubePingDiscovery(cr *v1alpha1.Keycloak) bool {
+	return cr.Spec.Clustering.Discovery == v1alpha1.DiscoveryProtocolKubePing
+}
+
+// KeycloakServiceAccount returns the name of the service account the Keycloak pods run as. It's the one of the
+// experimental section if set, else the keycloak service account created for KUBE_PING.
+func KeycloakServiceAccount(cr *v1alpha1.Keycloak) string {
+	if cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName != "" {
+		return cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName
+	}
+	if IsKubePingDiscovery(cr) {
+		return KeycloakServiceAccountName
+	}
+	return ""
+}
+
+func keycloakCacheOwners(cr *v1alpha1.Keycloak, cache string) int32 {
+	if owners, ok := cr.Spec.Clustering.CacheOwnersPerCache[cache]; ok {
+		return owners
+	}
+	if cr.Spec.Clustering.CacheOwners != nil {
+		return *cr.Spec.Clustering.CacheOwners
+	}
+	return KeycloakDefaultCacheOwners
+}
+
+func keycloakClusteringEnv(cr *v1alpha1.Keycloak) []v1.EnvVar {
+	var env []v1.EnvVar
+	if IsKubePingDiscovery(cr) {
+		env = append(env, v1.EnvVar{
+			Name:  "JGROUPS_DISCOVERY_PROTOCOL",
+			Value: "kubernetes.KUBE_PING",
+		})
+		env = append(env, kubePingEnv(cr)...)
+	} else {
+		env = append(env, v1.EnvVar{
+			Name:  "JGROUPS_DISCOVERY_PROTOCOL",
+			Value: "dns.DNS_PING",
+		}, v1.EnvVar{
+			Name:  "JGROUPS_DISCOVERY_PROPERTIES",
+			Value: "dns_query=" + KeycloakDiscoveryServiceName + "." + cr.Namespace,
+		})
+	}
+	env = append(env, cacheOwnersEnv(cr)...)
+	return append(env, clusteringSecretsEnv(cr)...)
+}
+
+func rhssoClusteringEnv(cr *v1alpha1.Keycloak) []v1.EnvVar {
+	var env []v1.EnvVar
+	if IsKubePingDiscovery(cr) {
+		env = append(env, v1.EnvVar{
+			Name:  "JGROUPS_PING_PROTOCOL",
+			Value: "kubernetes.KUBE_PING",
+		})
+		env = append(env, kubePingEnv(cr)...)
+	} else {
+		env = append(env, v1.EnvVar{
+			Name:  "JGROUPS_PING_PROTOCOL",
+			Value: "dns.DNS_PING",
+		}, v1.EnvVar{
+			Name:  "OPENSHIFT_DNS_PING_SERVICE_NAME",
+			Value: KeycloakDiscoveryServiceName + "." + cr.Namespace + ".svc.cluster.local",
+		})
+	}
+	env = append(env, cacheOwnersEnv(cr)...)
+	return append(env, clusteringSecretsEnv(cr)...)
+}
+
+// kubePingEnv selects the pods of the StatefulSet, the bluegreen migration replaces the component label
+func kubePingEnv(cr *v1alpha1.Keycloak) []v1.EnvVar {
+	return []v1.EnvVar{
+		{
+			Name:  "KUBERNETES_NAMESPACE",
+			Value: cr.Namespace,
+		},
+		{
+			Name:  "KUBERNETES_LABELS",
+			Value: kubePingLabels(GetLabelsSelector()),
+		},
+	}
+}
+
+func kubePingLabels(labels map[string]string) string {
+	var selector []string
+	for key, value := range labels {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	return strings.Join(selector, ",")
+}
+
+// cacheOwnersEnv sets the owners the images configure, the other distributed caches are set by the clustering
+// script
+func cacheOwnersEnv(cr *v1alpha1.Keycloak) []v1.EnvVar {
+	return []v1.EnvVar{
+		{
+			Name:  "CACHE_OWNERS_COUNT",
+			Value: fmt.Sprintf("%v", keycloakCacheOwners(cr, "")),
+		},
+		{
+			Name:  "CACHE_OWNERS_AUTH_SESSIONS_COUNT",
+			Value: fmt.Sprintf("%v", keycloakCacheOwners(cr, keycloakAuthenticationSessionsCache)),
+		},
+	}
+}
+
+func clusteringSecretsEnv(cr *v1alpha1.Keycloak) []v1.EnvVar {
+	var env []v1.EnvVar
+	if cr.Spec.Clustering.Encryption.Enabled {
+		env = append(env, secretEnvVar("JGROUPS_KEYSTORE_PASSWORD", KeycloakJGroupsKeystoreSecretName, KeycloakJGroupsKeystorePasswordProperty))
+	}
+	if infinispan := cr.Spec.Clustering.ExternalInfinispan; infinispan != nil && infinispan.CredentialsSecret != "" {
+		env = append(env,
+			secretEnvVar("INFINISPAN_USERNAME", infinispan.CredentialsSecret, "username"),
+			secretEnvVar("INFINISPAN_PASSWORD", infinispan.CredentialsSecret, "password"))
+	}
+	return env
+}
+
+func secretEnvVar(name, secretName, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+}
+
+// KeycloakClusteringScript returns the jboss-cli script configuring what the images can't be configured for from
+// the environment: the owners of the distributed caches apart from the sessions, the encryption of the JGroups
+// stacks and the remote stores. It's empty if there's nothing to configure. The script is only applied once,
+// as the configuration is kept when the container restarts with a writable volume.
+func KeycloakClusteringScript(cr *v1alpha1.Keycloak) string {
+	var commands []string
+
+	for _, cache := range keycloakDistributedCaches {
+		owners := keycloakCacheOwners(cr, cache)
+		// The owners of these caches are set with CACHE_OWNERS_COUNT and CACHE_OWNERS_AUTH_SESSIONS_COUNT
+		if cache == keycloakAuthenticationSessionsCache || owners == keycloakCacheOwners(cr, "") {
+			continue
+		}
+		commands = append(commands, fmt.Sprintf("/subsystem=infinispan/cache-container=keycloak/distributed-cache=%v:write-attribute(name=owners, value=%v)", cache, owners))
+	}
+
+	if cr.Spec.Clustering.Encryption.Enabled {
+		credential := `{clear-text="${env.JGROUPS_KEYSTORE_PASSWORD}"}`
+		commands = append(commands, fmt.Sprintf(`/subsystem=elytron/key-store=jgroups:add(path=%v/%v, type=PKCS12, credential-reference=%v, required=true)`,
+			KeycloakJGroupsKeystorePath, KeycloakJGroupsKeystoreProperty, credential))
+		for _, stack := range []string{"udp", "tcp"} {
+			// Right above the NAKACK2 protocol, the discovery and failure detection stay unencrypted
+			commands = append(commands, fmt.Sprintf(`/subsystem=jgroups/stack=%v/protocol=SYM_ENCRYPT:add(add-index=5, key-store=jgroups, key-alias=%v, key-credential-reference=%v)`,
+				stack, jgroupsKeystoreAlias, credential))
+		}
+	}
+
+	if infinispan := cr.Spec.Clustering.ExternalInfinispan; infinispan != nil {
+		commands = append(commands, fmt.Sprintf("/socket-binding-group=standard-sockets/remote-destination-outbound-socket-binding=%v:add(host=%v, port=%v)",
+			keycloakRemoteCacheSocketBinding, infinispan.Host, externalInfinispanPort(infinispan)))
+		properties := remoteStoreProperties(infinispan)
+		for _, cache := range externalInfinispanCaches(infinispan) {
+			kind := "distributed-cache"
+			if cache == keycloakWorkCache {
+				kind = "replicated-cache"
+			}
+			commands = append(commands, fmt.Sprintf(`/subsystem=infinispan/cache-container=keycloak/%v=%v/store=remote:add(cache=%v, remote-servers=["%v"], fetch-state=false, passivation=false, preload=false, purge=false, shared=true, properties=%v)`,
+				kind, cache, cache, keycloakRemoteCacheSocketBinding, properties))
+		}
+	}
+
+	commands = append(commands, crossSiteScript(cr)...)
+
+	if len(commands) == 0 {
+		return ""
+	}
+
+	serverConfig := "standalone-ha.xml"
+	if Profiles.IsRHSSO(cr) {
+		serverConfig = "standalone-openshift.xml"
+	}
+	marker := "/system-property=keycloak.operator.clustering"
+
+	script := []string{
+		fmt.Sprintf("embed-server --server-config=%v --std-out=echo", serverConfig),
+		fmt.Sprintf("if (outcome != success) of %v:read-resource", marker),
+	}
+	for _, command := range commands {
+		script = append(script, "    "+command)
+	}
+	script = append(script,
+		fmt.Sprintf("    %v:add(value=true)", marker),
+		"end-if",
+		"stop-embedded-server",
+	)
+	return strings.Join(script, "\n") + "\n"
+}
+
+func remoteStoreProperties(infinispan *v1alpha1.KeycloakExternalInfinispan) string {
+	protocolVersion := infinispan.ProtocolVersion
+	if protocolVersion == "" {
+		protocolVersion = InfinispanDefaultProtocolVersion
+	}
+	properties := []string{
+		`"rawValues" => "true"`,
+		fmt.Sprintf(`"marshaller" => "%v"`, keycloakHotRodMarshaller),
+		fmt.Sprintf(`"protocolVersion" => "%v"`, protocolVersion),
+	}
+	if infinispan.CredentialsSecret != "" {
+		properties = append(properties,
+			`"infinispan.client.hotrod.use_auth" => "true"`,
+			`"infinispan.client.hotrod.sasl_mechanism" => "DIGEST-MD5"`,
+			`"infinispan.client.hotrod.auth_username" => "${env.INFINISPAN_USERNAME}"`,
+			`"infinispan.client.hotrod.auth_password" => "${env.INFINISPAN_PASSWORD}"`)
+	}
+	return "{" + strings.Join(properties, ", ") + "}"
+}
+
+func externalInfinispanPort(infinispan *v1alpha1.KeycloakExternalInfinispan) int32 {
+	if infinispan.Port == 0 {
+		return InfinispanDefaultPort
+	}
+	return infinispan.Port
+}
+
+func externalInfinispanCaches(infinispan *v1alpha1.KeycloakExternalInfinispan) []string {
+	if len(infinispan.Caches) == 0 {
+		return keycloakDefaultRemoteCaches
+	}
+	return infinispan.Caches
+}
+
+// keycloakClusteringScripts returns the files of the clustering ConfigMap. RH-SSO runs the script from
+// postconfigure.sh, Keycloak runs every script in the startup-scripts directory.
+func keycloakClusteringScripts(cr *v1alpha1.Keycloak) []string {
+	if Profiles.IsRHSSO(cr) {
+		return []string{keycloakClusteringScriptProperty, keycloakClusteringPostconfigureScript}
+	}
+	return []string{keycloakClusteringScriptProperty}
+}
+
+func KeycloakClusteringConfigMap(cr *v1alpha1.Keycloak) *v1.ConfigMap {
+	data := map[string]string{
+		keycloakClusteringScriptProperty: KeycloakClusteringScript(cr),
+	}
+	if Profiles.IsRHSSO(cr) {
+		data[keycloakClusteringPostconfigureScript] = rhssoClusteringPostconfigure
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakClusteringName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+		Data: data,
+	}
+}
+
+func KeycloakClusteringConfigMapSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakClusteringName,
+		Namespace: cr.Namespace,
+	}
+}
+
+func KeycloakClusteringConfigMapReconciled(cr *v1alpha1.Keycloak, currentState *v1.ConfigMap) *v1.ConfigMap {
+	reconciled := currentState.DeepCopy()
+	reconciled.Data = KeycloakClusteringConfigMap(cr).Data
+	return reconciled
+}
+
+// KeycloakClusteringVolumeMounts mounts the clustering scripts into the directory the image runs them from and
+// the JGroups keystore
+func KeycloakClusteringVolumeMounts(cr *v1alpha1.Keycloak, scriptsPath string) []v1.VolumeMount {
+	var mountedVolumes []v1.VolumeMount
+	if KeycloakClusteringScript(cr) != "" {
+		for _, script := range keycloakClusteringScripts(cr) {
+			mountedVolumes = append(mountedVolumes, v1.VolumeMount{
+				Name:      keycloakClusteringVolumeName,
+				MountPath: scriptsPath + "/" + script,
+				SubPath:   script,
+				ReadOnly:  true,
+			})
+		}
+	}
+	if cr.Spec.Clustering.Encryption.Enabled {
+		mountedVolumes = append(mountedVolumes, v1.VolumeMount{
+			Name:      keycloakJGroupsKeystoreVolumeName,
+			MountPath: KeycloakJGroupsKeystorePath,
+			ReadOnly:  true,
+		})
+	}
+	return mountedVolumes
+}
+
+func addClusteringVolumes(cr *v1alpha1.Keycloak, volumes []v1.Volume) []v1.Volume {
+	if KeycloakClusteringScript(cr) != "" {
+		volumes = append(volumes, v1.Volume{
+			Name: keycloakClusteringVolumeName,
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: KeycloakClusteringName,
+					},
+					DefaultMode: &[]int32{0555}[0],
+				},
+			},
+		})
+	}
+	if cr.Spec.Clustering.Encryption.Enabled {
+		volumes = append(volumes, v1.Volume{
+			Name: keycloakJGroupsKeystoreVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: KeycloakJGroupsKeystoreSecretName,
+					Items: []v1.KeyToPath{
+						{
+							Key:  KeycloakJGroupsKeystoreProperty,
+							Path: KeycloakJGroupsKeystoreProperty,
+						},
+					},
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+// KeycloakJGroupsKeystoreSecret returns the Secret holding the key the cluster traffic is encrypted with. It's
+// not deleted when the encryption is disabled, so that the pods keep the key if it's enabled again.
+func KeycloakJGroupsKeystoreSecret(cr *v1alpha1.Keycloak) *v1.Secret {
+	password := GenerateRandomString(32)
+	return &v1.Secret{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakJGroupsKeystoreSecretName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+		Data: map[string][]byte{
+			KeycloakJGroupsKeystoreProperty:         JGroupsKeystore(password),
+			KeycloakJGroupsKeystorePasswordProperty: []byte(password),
+		},
+		Type: "Opaque",
+	}
+}
+
+func KeycloakJGroupsKeystoreSecretSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakJGroupsKeystoreSecretName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakJGroupsKeystoreSecretReconciled generates a new keystore if one of the keys was removed, the keystore
+// can't be used without its password
+func KeycloakJGroupsKeystoreSecretReconciled(cr *v1alpha1.Keycloak, currentState *v1.Secret) *v1.Secret {
+	reconciled := currentState.DeepCopy()
+	if len(reconciled.Data[KeycloakJGroupsKeystoreProperty]) == 0 || len(reconciled.Data[KeycloakJGroupsKeystorePasswordProperty]) == 0 {
+		reconciled.Data = KeycloakJGroupsKeystoreSecret(cr).Data
+	}
+	return reconciled
+}
+
+// KeycloakDiscoveryServiceAccount returns the service account created for KUBE_PING if none is configured
+func KeycloakDiscoveryServiceAccount(cr *v1alpha1.Keycloak) *v1.ServiceAccount {
+	return &v1.ServiceAccount{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakServiceAccountName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+	}
+}
+
+func KeycloakDiscoveryServiceAccountSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakServiceAccountName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakDiscoveryRole allows KUBE_PING to list the pods of the namespace
+func KeycloakDiscoveryRole(cr *v1alpha1.Keycloak) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakDiscoveryRoleName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+		Rules: keycloakDiscoveryRules(),
+	}
+}
+
+func keycloakDiscoveryRules() []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list"},
+		},
+	}
+}
+
+func KeycloakDiscoveryRoleSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakDiscoveryRoleName,
+		Namespace: cr.Namespace,
+	}
+}
+
+func KeycloakDiscoveryRoleReconciled(cr *v1alpha1.Keycloak, currentState *rbacv1.Role) *rbacv1.Role {
+	reconciled := currentState.DeepCopy()
+	reconciled.Rules = keycloakDiscoveryRules()
+	return reconciled
+}
+
+func KeycloakDiscoveryRoleBinding(cr *v1alpha1.Keycloak) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakDiscoveryRoleName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     KeycloakDiscoveryRoleName,
+		},
+		Subjects: keycloakDiscoverySubjects(cr),
+	}
+}
+
+func keycloakDiscoverySubjects(cr *v1alpha1.Keycloak) []rbacv1.Subject {
+	return []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      KeycloakServiceAccount(cr),
+			Namespace: cr.Namespace,
+		},
+	}
+}
+
+func KeycloakDiscoveryRoleBindingSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakDiscoveryRoleName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakDiscoveryRoleBindingReconciled binds the Role to the service account configured now, the role
+// reference of a binding can't be changed
+func KeycloakDiscoveryRoleBindingReconciled(cr *v1alpha1.Keycloak, currentState *rbacv1.RoleBinding) *rbacv1.RoleBinding {
+	reconciled := currentState.DeepCopy()
+	reconciled.Subjects = keycloakDiscoverySubjects(cr)
+	return reconciled
+}
+
+// SetKeycloakClusteringChecksum annotates the pod template with a checksum of the clustering script and the
+// JGroups keystore, so that the pods are rolled when they change. All pods of a cluster need the same key.
+func SetKeycloakClusteringChecksum(cr *v1alpha1.Keycloak, statefulSet *v13.StatefulSet, keystoreSecret *v1.Secret) {
+	script := KeycloakClusteringScript(cr)
+	checksum := ""
+	if script != "" {
+		hash := sha256.New()
+		hash.Write([]byte(script))
+		if cr.Spec.Clustering.Encryption.Enabled && keystoreSecret != nil {
+			hash.Write(keystoreSecret.Data[KeycloakJGroupsKeystoreProperty])
+		}
+		checksum = fmt.Sprintf("%x", hash.Sum(nil))
+	}
+	setPodTemplateAnnotation(statefulSet, KeycloakClusteringChecksumAnnotation, checksum)
+}
+
+// ValidateKeycloakClustering checks the cache names and owner counts, which Keycloak only rejects when starting.
+func ValidateKeycloakClustering(cr *v1alpha1.Keycloak) error {
+	clustering := cr.Spec.Clustering
+	if clustering.CacheOwners != nil && *clustering.CacheOwners < 1 {
+		return errors.Errorf("clustering.cacheOwners must be at least 1")
+	}
+	for cache, owners := range clustering.CacheOwnersPerCache {
+		if !containsCache(keycloakDistributedCaches, cache) {
+			return errors.Errorf("clustering.cacheOwnersPerCache has unknown cache %v, the distributed caches are %v",
+				cache, strings.Join(keycloakDistributedCaches, ", "))
+		}
+		if owners < 1 {
+			return errors.Errorf("clustering.cacheOwnersPerCache of cache %v must be at least 1", cache)
+		}
+	}
+
+	if infinispan := clustering.ExternalInfinispan; infinispan != nil {
+		if infinispan.Host == "" {
+			return errors.Errorf("clustering.externalInfinispan.host must be set")
+		}
+		for _, cache := range infinispan.Caches {
+			if !containsCache(keycloakDefaultRemoteCaches, cache) {
+				return errors.Errorf("clustering.externalInfinispan.caches has unknown cache %v, the caches are %v",
+					cache, strings.Join(keycloakDefaultRemoteCaches, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func containsCache(caches []string, cache string) bool {
+	for _, c := range caches {
+		if c == cache {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKeycloakClustering_testDefaults(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+
+	//when
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+
+	//then
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Contains(t, env, v1.EnvVar{Name: "JGROUPS_DISCOVERY_PROTOCOL", Value: "dns.DNS_PING"})
+	assert.Contains(t, env, v1.EnvVar{Name: "JGROUPS_DISCOVERY_PROPERTIES", Value: "dns_query=keycloak-discovery.keycloak"})
+	assert.Contains(t, env, v1.EnvVar{Name: "CACHE_OWNERS_COUNT", Value: "2"})
+	assert.Contains(t, env, v1.EnvVar{Name: "CACHE_OWNERS_AUTH_SESSIONS_COUNT", Value: "2"})
+	assert.Equal(t, "", KeycloakClusteringScript(cr))
+	assert.Equal(t, "", deployment.Spec.Template.Spec.ServiceAccountName)
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, keycloakClusteringVolumeName, volume.Name)
+	}
+}
+
+func TestKeycloakClustering_testKubePing(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.Clustering.Discovery = v1alpha1.DiscoveryProtocolKubePing
+
+	//when
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+	rhssoDeployment := RHSSODeployment(cr, DatabaseSecret(cr), nil)
+	green := KeycloakBlueGreenDeployment(cr, deployment, "new_image", "keycloak_20240101000000")
+	roleBinding := KeycloakDiscoveryRoleBinding(cr)
+
+	//then
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Contains(t, env, v1.EnvVar{Name: "JGROUPS_DISCOVERY_PROTOCOL", Value: "kubernetes.KUBE_PING"})
+	assert.Contains(t, env, v1.EnvVar{Name: "KUBERNETES_NAMESPACE", Value: "keycloak"})
+	assert.Contains(t, env, v1.EnvVar{Name: "KUBERNETES_LABELS", Value: "app=keycloak,component=keycloak"})
+	assert.Contains(t, rhssoDeployment.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "JGROUPS_PING_PROTOCOL", Value: "kubernetes.KUBE_PING"})
+	assert.Contains(t, green.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "KUBERNETES_LABELS", Value: "app=keycloak,component=keycloak-green"})
+	assert.Equal(t, KeycloakServiceAccountName, deployment.Spec.Template.Spec.ServiceAccountName)
+	assert.Equal(t, KeycloakServiceAccountName, roleBinding.Subjects[0].Name)
+}
+
+func TestKeycloakClustering_testKubePingWithServiceAccount(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Clustering.Discovery = v1alpha1.DiscoveryProtocolKubePing
+	cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName = "custom"
+
+	//when
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+	roleBinding := KeycloakDiscoveryRoleBinding(cr)
+
+	//then
+	assert.Equal(t, "custom", deployment.Spec.Template.Spec.ServiceAccountName)
+	assert.Equal(t, "custom", roleBinding.Subjects[0].Name)
+}
+
+func TestKeycloakClustering_testCacheOwners(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Clustering.CacheOwners = &[]int32{3}[0]
+	cr.Spec.Clustering.CacheOwnersPerCache = map[string]int32{
+		"authenticationSessions": 1,
+		"offlineSessions":        4,
+		"sessions":               3,
+	}
+
+	//when
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+	script := KeycloakClusteringScript(cr)
+
+	//then
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Contains(t, env, v1.EnvVar{Name: "CACHE_OWNERS_COUNT", Value: "3"})
+	assert.Contains(t, env, v1.EnvVar{Name: "CACHE_OWNERS_AUTH_SESSIONS_COUNT", Value: "1"})
+	assert.Contains(t, script, "embed-server --server-config=standalone-ha.xml --std-out=echo\n")
+	assert.Contains(t, script, "/subsystem=infinispan/cache-container=keycloak/distributed-cache=offlineSessions:write-attribute(name=owners, value=4)")
+	assert.NotContains(t, script, "distributed-cache=sessions")
+	assert.NotContains(t, script, "distributed-cache=authenticationSessions")
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      keycloakClusteringVolumeName,
+		MountPath: KeycloakStartupScriptsPath + "/clustering.cli",
+		SubPath:   "clustering.cli",
+		ReadOnly:  true,
+	})
+}
+
+func TestKeycloakClustering_testEncryption(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Clustering.Encryption.Enabled = true
+	keystoreSecret := KeycloakJGroupsKeystoreSecret(cr)
+	deployment := KeycloakDeployment(cr, DatabaseSecret(cr), nil)
+	rotatedDeployment := deployment.DeepCopy()
+
+	//when
+	SetKeycloakClusteringChecksum(cr, deployment, keystoreSecret)
+	SetKeycloakClusteringChecksum(cr, rotatedDeployment, KeycloakJGroupsKeystoreSecret(cr))
+
+	//then
+	script := KeycloakClusteringScript(cr)
+	assert.Contains(t, script, `/subsystem=elytron/key-store=jgroups:add(path=/etc/jgroups/jgroups.p12, type=PKCS12, credential-reference={clear-text="${env.JGROUPS_KEYSTORE_PASSWORD}"}, required=true)`)
+	assert.Contains(t, script, "/subsystem=jgroups/stack=udp/protocol=SYM_ENCRYPT:add(")
+	assert.Contains(t, script, "/subsystem=jgroups/stack=tcp/protocol=SYM_ENCRYPT:add(")
+	assert.NotEmpty(t, keystoreSecret.Data[KeycloakJGroupsKeystoreProperty])
+	assert.NotEmpty(t, keystoreSecret.Data[KeycloakJGroupsKeystorePasswordProperty])
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      keycloakJGroupsKeystoreVolumeName,
+		MountPath: KeycloakJGroupsKeystorePath,
+		ReadOnly:  true,
+	})
+	assert.NotEmpty(t, deployment.Spec.Template.Annotations[KeycloakClusteringChecksumAnnotation])
+	assert.NotEqual(t, deployment.Spec.Template.Annotations[KeycloakClusteringChecksumAnnotation], rotatedDeployment.Spec.Template.Annotations[KeycloakClusteringChecksumAnnotation])
+}
+
+func TestKeycloakClustering_testExternalInfinispan(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Profile = RHSSOProfile
+	cr.Spec.Clustering.ExternalInfinispan = &v1alpha1.KeycloakExternalInfinispan{
+		Host:              "infinispan.infinispan.svc",
+		Caches:            []string{"work", "sessions"},
+		CredentialsSecret: "infinispan-credentials",
+	}
+
+	//when
+	configMap := KeycloakClusteringConfigMap(cr)
+	deployment := RHSSODeployment(cr, DatabaseSecret(cr), nil)
+
+	//then
+	script := configMap.Data["clustering.cli"]
+	assert.Contains(t, script, "embed-server --server-config=standalone-openshift.xml --std-out=echo\n")
+	assert.Contains(t, script, "/socket-binding-group=standard-sockets/remote-destination-outbound-socket-binding=remote-cache:add(host=infinispan.infinispan.svc, port=11222)")
+	assert.Contains(t, script, `/subsystem=infinispan/cache-container=keycloak/replicated-cache=work/store=remote:add(cache=work, remote-servers=["remote-cache"]`)
+	assert.Contains(t, script, `/subsystem=infinispan/cache-container=keycloak/distributed-cache=sessions/store=remote:add(cache=sessions`)
+	assert.Contains(t, script, `"protocolVersion" => "2.9"`)
+	assert.Contains(t, script, `"infinispan.client.hotrod.auth_username" => "${env.INFINISPAN_USERNAME}"`)
+	assert.NotContains(t, script, "offlineSessions")
+	assert.Contains(t, configMap.Data["postconfigure.sh"], "--file=/opt/eap/extensions/clustering.cli")
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	assert.Contains(t, env, secretEnvVar("INFINISPAN_USERNAME", "infinispan-credentials", "username"))
+	assert.Contains(t, env, secretEnvVar("INFINISPAN_PASSWORD", "infinispan-credentials", "password"))
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      keycloakClusteringVolumeName,
+		MountPath: RhssoPostconfigurePath + "/postconfigure.sh",
+		SubPath:   "postconfigure.sh",
+		ReadOnly:  true,
+	})
+}
+
+func TestKeycloakClustering_testValidation(t *testing.T) {
+	//given
+	unknownCache := &v1alpha1.Keycloak{}
+	unknownCache.Spec.Clustering.CacheOwnersPerCache = map[string]int32{"realms": 2}
+	noOwners := &v1alpha1.Keycloak{}
+	noOwners.Spec.Clustering.CacheOwnersPerCache = map[string]int32{"sessions": 0}
+	noHost := &v1alpha1.Keycloak{}
+	noHost.Spec.Clustering.ExternalInfinispan = &v1alpha1.KeycloakExternalInfinispan{}
+	valid := &v1alpha1.Keycloak{}
+	valid.Spec.Clustering.CacheOwnersPerCache = map[string]int32{"actionTokens": 1}
+
+	//when
+	unknownCacheErr := ValidateKeycloakClustering(unknownCache)
+	noOwnersErr := ValidateKeycloakClustering(noOwners)
+	noHostErr := ValidateKeycloakClustering(noHost)
+	validErr := ValidateKeycloakClustering(valid)
+
+	//then
+	assert.Error(t, unknownCacheErr)
+	assert.Error(t, noOwnersErr)
+	assert.Error(t, noHostErr)
+	assert.NoError(t, validErr)
+}
@@ -0,0 +1,368 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	keycloakCrossSiteStateProperty = "state"
+	// A site whose operator didn't report for this long is considered unhealthy
+	KeycloakCrossSiteHeartbeatTimeout = 3 * time.Minute
+	// The heartbeat of a site is only refreshed after this long, so that writing it doesn't trigger another
+	// reconciliation right away
+	KeycloakCrossSiteHeartbeatInterval = time.Minute
+)
+
+var InfinispanCacheGroupVersionKind = schema.GroupVersionKind{
+	Group:   "infinispan.org",
+	Version: "v2alpha1",
+	Kind:    "Cache",
+}
+
+// CrossSiteState is shared by the operators of the sites in the keycloak-cross-site-<name> Secret. Every operator only
+// writes the entry of its own site, the active site is set by failovers.
+type CrossSiteState struct {
+	ActiveSite string `json:"activeSite,omitempty"`
+	// Incremented by every failover, the active site of the highest generation wins
+	Generation int64                         `json:"generation"`
+	Sites      map[string]CrossSiteSiteState `json:"sites,omitempty"`
+}
+
+type CrossSiteSiteState struct {
+	ReadyReplicas    int32    `json:"readyReplicas"`
+	Passive          bool     `json:"passive"`
+	DatabaseEndpoint string   `json:"databaseEndpoint,omitempty"`
+	Heartbeat        v12.Time `json:"heartbeat"`
+}
+
+// ParseCrossSiteState reads the state from the keycloak-cross-site-<name> Secret, a missing Secret is an empty state
+func ParseCrossSiteState(secret *v1.Secret) (*CrossSiteState, error) {
+	state := &CrossSiteState{Sites: map[string]CrossSiteSiteState{}}
+	if secret == nil || len(secret.Data[keycloakCrossSiteStateProperty]) == 0 {
+		return state, nil
+	}
+	err := json.Unmarshal(secret.Data[keycloakCrossSiteStateProperty], state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading the cross-site state of secret %v", secret.Name)
+	}
+	if state.Sites == nil {
+		state.Sites = map[string]CrossSiteSiteState{}
+	}
+	return state, nil
+}
+
+// Merge takes the active site of the later failover and the latest entry of every site from the other state. If
+// two sites failed over concurrently to different sites, the site with the lowest name wins.
+func (s *CrossSiteState) Merge(other *CrossSiteState) {
+	sameGeneration := other.Generation == s.Generation && other.ActiveSite != "" && (s.ActiveSite == "" || other.ActiveSite < s.ActiveSite)
+	if other.Generation > s.Generation || sameGeneration {
+		s.ActiveSite = other.ActiveSite
+		s.Generation = other.Generation
+	}
+	for name, site := range other.Sites {
+		if current, ok := s.Sites[name]; !ok || current.Heartbeat.Before(&site.Heartbeat) {
+			s.Sites[name] = site
+		}
+	}
+}
+
+// Equal returns true if both states would be stored the same
+func (s *CrossSiteState) Equal(other *CrossSiteState) bool {
+	return bytes.Equal(s.data(), other.data())
+}
+
+func (s *CrossSiteState) data() []byte {
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// KeycloakCrossSiteSites returns the names of all sites in alphabetical order
+func KeycloakCrossSiteSites(cr *v1alpha1.Keycloak) []string {
+	sites := []string{cr.Spec.CrossSite.Site}
+	for _, site := range cr.Spec.CrossSite.RemoteSites {
+		sites = append(sites, site.Name)
+	}
+	sort.Strings(sites)
+	return sites
+}
+
+// CrossSiteActiveSite returns the active site, which is the primary site until the first failover
+func CrossSiteActiveSite(cr *v1alpha1.Keycloak, state *CrossSiteState) string {
+	if state.ActiveSite != "" {
+		return state.ActiveSite
+	}
+	if cr.Spec.CrossSite.PrimarySite != "" {
+		return cr.Spec.CrossSite.PrimarySite
+	}
+	return KeycloakCrossSiteSites(cr)[0]
+}
+
+func crossSiteMode(cr *v1alpha1.Keycloak) v1alpha1.KeycloakCrossSiteMode {
+	if cr.Spec.CrossSite.Mode == "" {
+		return v1alpha1.CrossSiteModeActivePassive
+	}
+	return cr.Spec.CrossSite.Mode
+}
+
+// IsCrossSitePassive is true if the Keycloak pods of this site are scaled down in favour of the active site
+func IsCrossSitePassive(cr *v1alpha1.Keycloak, state *CrossSiteState) bool {
+	return cr.Spec.CrossSite.Enabled &&
+		crossSiteMode(cr) == v1alpha1.CrossSiteModeActivePassive &&
+		CrossSiteActiveSite(cr, state) != cr.Spec.CrossSite.Site
+}
+
+// CrossSiteDatabaseEndpoint returns the address of the external database all sites need to share
+func CrossSiteDatabaseEndpoint(dbSecret *v1.Secret) string {
+	if dbSecret == nil {
+		return ""
+	}
+	address := string(dbSecret.Data[DatabaseSecretExternalAddressProperty])
+	if address == "" {
+		return ""
+	}
+	return fmt.Sprintf("%v:%v", address, string(dbSecret.Data[DatabaseSecretExternalPortProperty]))
+}
+
+func KeycloakCrossSiteSecret(cr *v1alpha1.Keycloak, namespace string, state *CrossSiteState) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakCrossSiteSecretName + "-" + cr.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":           ApplicationName,
+				ApplicationName: cr.Name,
+			},
+		},
+		Data: map[string][]byte{
+			keycloakCrossSiteStateProperty: state.data(),
+		},
+		Type: "Opaque",
+	}
+}
+
+func KeycloakCrossSiteSecretSelector(cr *v1alpha1.Keycloak, namespace string) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      KeycloakCrossSiteSecretName + "-" + cr.Name,
+		Namespace: namespace,
+	}
+}
+
+func KeycloakCrossSiteSecretReconciled(currentState *v1.Secret, state *CrossSiteState) *v1.Secret {
+	reconciled := currentState.DeepCopy()
+	if reconciled.Data == nil {
+		reconciled.Data = map[string][]byte{}
+	}
+	reconciled.Data[keycloakCrossSiteStateProperty] = state.data()
+	return reconciled
+}
+
+func KeycloakCrossSiteKubeconfigSecretSelector(cr *v1alpha1.Keycloak, site v1alpha1.KeycloakRemoteSite) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      site.KubeconfigSecret,
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakRemoteSiteNamespace returns the namespace of the Keycloak of a remote site
+func KeycloakRemoteSiteNamespace(cr *v1alpha1.Keycloak, site v1alpha1.KeycloakRemoteSite) string {
+	if site.Namespace != "" {
+		return site.Namespace
+	}
+	return cr.Namespace
+}
+
+// KeycloakCrossSiteStatus reports the health of every site from the shared state. Unreachable lists the sites
+// whose state couldn't be read or written, with the reason.
+func KeycloakCrossSiteStatus(cr *v1alpha1.Keycloak, state *CrossSiteState, unreachable map[string]string, now time.Time) *v1alpha1.KeycloakCrossSiteStatus {
+	status := &v1alpha1.KeycloakCrossSiteStatus{
+		ActiveSite: CrossSiteActiveSite(cr, state),
+		Generation: state.Generation,
+	}
+	if cr.Status.CrossSite != nil {
+		status.LastFailoverRequest = cr.Status.CrossSite.LastFailoverRequest
+	}
+
+	localEndpoint := state.Sites[cr.Spec.CrossSite.Site].DatabaseEndpoint
+	for _, name := range KeycloakCrossSiteSites(cr) {
+		siteStatus := v1alpha1.KeycloakSiteStatus{Name: name}
+		site, reported := state.Sites[name]
+		switch {
+		case !reported:
+			siteStatus.Message = "the operator of the site didn't report yet"
+		case now.Sub(site.Heartbeat.Time) > KeycloakCrossSiteHeartbeatTimeout:
+			siteStatus.Message = fmt.Sprintf("the operator of the site didn't report since %v", site.Heartbeat.UTC().Format(time.RFC3339))
+		case site.DatabaseEndpoint != localEndpoint:
+			siteStatus.Message = fmt.Sprintf("the site uses database %v instead of %v", site.DatabaseEndpoint, localEndpoint)
+		case !site.Passive && site.ReadyReplicas == 0:
+			siteStatus.Message = "no Keycloak pod of the site is ready"
+		default:
+			siteStatus.Healthy = true
+		}
+		if reported {
+			siteStatus.ReadyReplicas = site.ReadyReplicas
+			siteStatus.LastHeartbeatTime = &[]v12.Time{site.Heartbeat}[0]
+		}
+		if reason, ok := unreachable[name]; ok {
+			siteStatus.Healthy = false
+			siteStatus.Message = reason
+		}
+		status.Sites = append(status.Sites, siteStatus)
+	}
+	return status
+}
+
+// crossSiteScript names the site for Keycloak, the remote stores are added for the external Infinispan
+func crossSiteScript(cr *v1alpha1.Keycloak) []string {
+	if !cr.Spec.CrossSite.Enabled {
+		return nil
+	}
+	return []string{fmt.Sprintf("/system-property=jboss.site.name:add(value=%v)", cr.Spec.CrossSite.Site)}
+}
+
+func crossSiteBackupStrategy(cr *v1alpha1.Keycloak) string {
+	if cr.Spec.CrossSite.BackupStrategy != "" {
+		return cr.Spec.CrossSite.BackupStrategy
+	}
+	if crossSiteMode(cr) == v1alpha1.CrossSiteModeActiveActive {
+		return "SYNC"
+	}
+	return "ASYNC"
+}
+
+// KeycloakCrossSiteCaches returns a Cache resource of the Infinispan Operator for every cache Keycloak stores in
+// the external Infinispan, backed up to all remote sites
+func KeycloakCrossSiteCaches(cr *v1alpha1.Keycloak) []*unstructured.Unstructured {
+	if cr.Spec.Clustering.ExternalInfinispan == nil {
+		return nil
+	}
+
+	var caches []*unstructured.Unstructured
+	for _, name := range externalInfinispanCaches(cr.Spec.Clustering.ExternalInfinispan) {
+		cache := &unstructured.Unstructured{}
+		cache.SetGroupVersionKind(InfinispanCacheGroupVersionKind)
+		cache.SetName(keycloakCrossSiteCacheName(name))
+		cache.SetNamespace(cr.Namespace)
+		cache.SetLabels(map[string]string{
+			"app":           ApplicationName,
+			ApplicationName: cr.Name,
+		})
+		cache.Object["spec"] = keycloakCrossSiteCacheSpec(cr, name)
+		caches = append(caches, cache)
+	}
+	return caches
+}
+
+// keycloakCrossSiteCacheName turns a cache name into a resource name, e.g. clientSessions into
+// keycloak-client-sessions
+func keycloakCrossSiteCacheName(cache string) string {
+	name := ApplicationName
+	for i, c := range cache {
+		if c >= 'A' && c <= 'Z' || i == 0 {
+			name += "-"
+		}
+		name += strings.ToLower(string(c))
+	}
+	return name
+}
+
+func KeycloakCrossSiteCacheSelector(cr *v1alpha1.Keycloak, cache *unstructured.Unstructured) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      cache.GetName(),
+		Namespace: cr.Namespace,
+	}
+}
+
+// KeycloakCrossSiteCacheReconciled merges the spec into the current one, keeping the fields the Infinispan
+// Operator defaults
+func KeycloakCrossSiteCacheReconciled(desired *unstructured.Unstructured, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	spec, ok := reconciled.Object["spec"].(map[string]interface{})
+	if !ok {
+		spec = map[string]interface{}{}
+	}
+	for key, value := range desired.Object["spec"].(map[string]interface{}) {
+		spec[key] = value
+	}
+	reconciled.Object["spec"] = spec
+	return reconciled
+}
+
+func keycloakCrossSiteCacheSpec(cr *v1alpha1.Keycloak, cache string) map[string]interface{} {
+	strategy := crossSiteBackupStrategy(cr)
+	// Synchronous backups fail the request if a site can't be reached, until the site was taken offline
+	failurePolicy := "WARN"
+	if strategy == "SYNC" {
+		failurePolicy = "FAIL"
+	}
+
+	var backups []string
+	for _, site := range cr.Spec.CrossSite.RemoteSites {
+		backups = append(backups, fmt.Sprintf(`    <backup site="%v" strategy="%v" failure-policy="%v" enabled="true">
+      <take-offline min-wait="60000" after-failures="3"/>
+    </backup>`, site.Name, strategy, failurePolicy))
+	}
+
+	template := fmt.Sprintf(`<replicated-cache name="%v" mode="SYNC" statistics="true">
+  <transaction mode="NON_XA" locking="PESSIMISTIC"/>
+  <backups>
+%v
+  </backups>
+</replicated-cache>`, cache, strings.Join(backups, "\n"))
+
+	return map[string]interface{}{
+		"clusterName": cr.Spec.CrossSite.InfinispanCluster,
+		"name":        cache,
+		"template":    template,
+	}
+}
+
+// ValidateKeycloakCrossSite checks that the sites are named and share the external database and Infinispan
+func ValidateKeycloakCrossSite(cr *v1alpha1.Keycloak) error {
+	crossSite := cr.Spec.CrossSite
+	if !crossSite.Enabled {
+		return nil
+	}
+	if crossSite.Site == "" {
+		return errors.Errorf("crossSite.site must be set")
+	}
+	if len(crossSite.RemoteSites) == 0 {
+		return errors.Errorf("crossSite.remoteSites must list at least one site")
+	}
+	if !cr.Spec.ExternalDatabase.Enabled {
+		return errors.Errorf("crossSite needs externalDatabase to be enabled, all sites share the database")
+	}
+	if cr.Spec.Clustering.ExternalInfinispan == nil {
+		return errors.Errorf("crossSite needs clustering.externalInfinispan to be set, the caches are replicated between the sites by Infinispan")
+	}
+
+	sites := map[string]bool{crossSite.Site: true}
+	for _, site := range crossSite.RemoteSites {
+		if site.Name == "" {
+			return errors.Errorf("crossSite.remoteSites need a name")
+		}
+		if sites[site.Name] {
+			return errors.Errorf("site %v is listed more than once in crossSite", site.Name)
+		}
+		sites[site.Name] = true
+	}
+	if crossSite.PrimarySite != "" && !sites[crossSite.PrimarySite] {
+		return errors.Errorf("crossSite.primarySite %v isn't one of the sites", crossSite.PrimarySite)
+	}
+	if failover := cr.Annotations[KeycloakCrossSiteFailoverAnnotation]; failover != "" && !sites[failover] {
+		return errors.Errorf("annotation %v names the unknown site %v", KeycloakCrossSiteFailoverAnnotation, failover)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var crossSiteNow = time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+func TestKeycloakCrossSite_testStateRoundTrip(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+	state := &CrossSiteState{
+		ActiveSite: "site-b",
+		Generation: 2,
+		Sites: map[string]CrossSiteSiteState{
+			"site-a": {ReadyReplicas: 2, DatabaseEndpoint: "postgres.example.com:5432", Heartbeat: v12.NewTime(crossSiteNow)},
+		},
+	}
+
+	//when
+	secret := KeycloakCrossSiteSecret(cr, "remote", state)
+	parsed, err := ParseCrossSiteState(secret)
+	empty, emptyErr := ParseCrossSiteState(nil)
+
+	//then
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", secret.Namespace)
+	assert.Equal(t, KeycloakCrossSiteSecretName+"-"+cr.Name, secret.Name)
+	assert.Equal(t, state.ActiveSite, parsed.ActiveSite)
+	assert.Equal(t, state.Generation, parsed.Generation)
+	assert.Equal(t, int32(2), parsed.Sites["site-a"].ReadyReplicas)
+	assert.True(t, crossSiteNow.Equal(parsed.Sites["site-a"].Heartbeat.Time))
+	assert.NoError(t, emptyErr)
+	assert.Empty(t, empty.Sites)
+}
+
+func TestKeycloakCrossSite_testMerge(t *testing.T) {
+	//given
+	state := &CrossSiteState{
+		ActiveSite: "site-a",
+		Generation: 1,
+		Sites: map[string]CrossSiteSiteState{
+			"site-a": {ReadyReplicas: 2, Heartbeat: v12.NewTime(crossSiteNow)},
+			"site-b": {ReadyReplicas: 1, Heartbeat: v12.NewTime(crossSiteNow.Add(-time.Minute))},
+		},
+	}
+	remote := &CrossSiteState{
+		ActiveSite: "site-b",
+		Generation: 2,
+		Sites: map[string]CrossSiteSiteState{
+			"site-a": {ReadyReplicas: 0, Heartbeat: v12.NewTime(crossSiteNow.Add(-time.Minute))},
+			"site-b": {ReadyReplicas: 3, Heartbeat: v12.NewTime(crossSiteNow)},
+		},
+	}
+
+	//when
+	state.Merge(remote)
+
+	//then
+	assert.Equal(t, "site-b", state.ActiveSite)
+	assert.Equal(t, int64(2), state.Generation)
+	assert.Equal(t, int32(2), state.Sites["site-a"].ReadyReplicas)
+	assert.Equal(t, int32(3), state.Sites["site-b"].ReadyReplicas)
+}
+
+func TestKeycloakCrossSite_testMergeConcurrentFailovers(t *testing.T) {
+	//given
+	siteA := &CrossSiteState{ActiveSite: "site-c", Generation: 2, Sites: map[string]CrossSiteSiteState{}}
+	siteB := &CrossSiteState{ActiveSite: "site-b", Generation: 2, Sites: map[string]CrossSiteSiteState{}}
+	remoteOfA := &CrossSiteState{ActiveSite: "site-c", Generation: 2}
+	remoteOfB := &CrossSiteState{ActiveSite: "site-b", Generation: 2}
+
+	//when
+	siteA.Merge(remoteOfB)
+	siteB.Merge(remoteOfA)
+
+	//then
+	assert.Equal(t, "site-b", siteA.ActiveSite)
+	assert.Equal(t, "site-b", siteB.ActiveSite)
+}
+
+func TestKeycloakCrossSite_testActiveSite(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+	withPrimary := crossSiteKeycloak()
+	withPrimary.Spec.CrossSite.PrimarySite = "site-b"
+	activeActive := crossSiteKeycloak()
+	activeActive.Spec.CrossSite.Mode = v1alpha1.CrossSiteModeActiveActive
+	failedOver := &CrossSiteState{ActiveSite: "site-b", Generation: 1}
+
+	//when
+	defaultActive := CrossSiteActiveSite(cr, &CrossSiteState{})
+	primaryActive := CrossSiteActiveSite(withPrimary, &CrossSiteState{})
+	failedOverActive := CrossSiteActiveSite(withPrimary, failedOver)
+
+	//then
+	assert.Equal(t, "site-a", defaultActive)
+	assert.Equal(t, "site-b", primaryActive)
+	assert.Equal(t, "site-b", failedOverActive)
+	assert.False(t, IsCrossSitePassive(cr, &CrossSiteState{}))
+	assert.True(t, IsCrossSitePassive(cr, failedOver))
+	assert.False(t, IsCrossSitePassive(activeActive, failedOver))
+}
+
+func TestKeycloakCrossSite_testStatus(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+	cr.Spec.CrossSite.RemoteSites = append(cr.Spec.CrossSite.RemoteSites,
+		v1alpha1.KeycloakRemoteSite{Name: "site-c"},
+		v1alpha1.KeycloakRemoteSite{Name: "site-d"},
+		v1alpha1.KeycloakRemoteSite{Name: "site-e"})
+	state := &CrossSiteState{
+		Sites: map[string]CrossSiteSiteState{
+			"site-a": {ReadyReplicas: 2, DatabaseEndpoint: "postgres:5432", Heartbeat: v12.NewTime(crossSiteNow)},
+			"site-b": {ReadyReplicas: 0, Passive: true, DatabaseEndpoint: "postgres:5432", Heartbeat: v12.NewTime(crossSiteNow)},
+			"site-c": {ReadyReplicas: 2, DatabaseEndpoint: "other:5432", Heartbeat: v12.NewTime(crossSiteNow)},
+			"site-d": {ReadyReplicas: 2, DatabaseEndpoint: "postgres:5432", Heartbeat: v12.NewTime(crossSiteNow.Add(-time.Hour))},
+		},
+	}
+
+	//when
+	status := KeycloakCrossSiteStatus(cr, state, map[string]string{"site-b": "connection refused"}, crossSiteNow)
+
+	//then
+	assert.Equal(t, "site-a", status.ActiveSite)
+	assert.Len(t, status.Sites, 5)
+	assert.True(t, status.Sites[0].Healthy)
+	assert.Equal(t, int32(2), status.Sites[0].ReadyReplicas)
+	assert.False(t, status.Sites[1].Healthy)
+	assert.Equal(t, "connection refused", status.Sites[1].Message)
+	assert.False(t, status.Sites[2].Healthy)
+	assert.Contains(t, status.Sites[2].Message, "other:5432")
+	assert.False(t, status.Sites[3].Healthy)
+	assert.Contains(t, status.Sites[3].Message, "didn't report since")
+	assert.False(t, status.Sites[4].Healthy)
+	assert.Nil(t, status.Sites[4].LastHeartbeatTime)
+}
+
+func TestKeycloakCrossSite_testDatabaseEndpoint(t *testing.T) {
+	//given
+	secret := &v1.Secret{Data: map[string][]byte{
+		DatabaseSecretExternalAddressProperty: []byte("postgres.example.com"),
+		DatabaseSecretExternalPortProperty:    []byte("5432"),
+	}}
+
+	//when
+	endpoint := CrossSiteDatabaseEndpoint(secret)
+
+	//then
+	assert.Equal(t, "postgres.example.com:5432", endpoint)
+	assert.Equal(t, "", CrossSiteDatabaseEndpoint(&v1.Secret{}))
+}
+
+func TestKeycloakCrossSite_testCaches(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+	cr.Spec.Clustering.ExternalInfinispan.Caches = []string{"work", "clientSessions"}
+
+	//when
+	caches := KeycloakCrossSiteCaches(cr)
+
+	//then
+	assert.Len(t, caches, 2)
+	assert.Equal(t, InfinispanCacheGroupVersionKind, caches[0].GroupVersionKind())
+	assert.Equal(t, "keycloak-work", caches[0].GetName())
+	assert.Equal(t, "keycloak-client-sessions", caches[1].GetName())
+	spec := caches[1].Object["spec"].(map[string]interface{})
+	assert.Equal(t, "infinispan", spec["clusterName"])
+	assert.Equal(t, "clientSessions", spec["name"])
+	assert.Contains(t, spec["template"], `<backup site="site-b" strategy="ASYNC" failure-policy="WARN" enabled="true">`)
+}
+
+func TestKeycloakCrossSite_testCacheReconciled(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+	cr.Spec.CrossSite.Mode = v1alpha1.CrossSiteModeActiveActive
+	desired := KeycloakCrossSiteCaches(cr)[0]
+	current := desired.DeepCopy()
+	current.Object["spec"] = map[string]interface{}{
+		"clusterName": "infinispan",
+		"name":        "work",
+		"template":    "<replicated-cache/>",
+		"updates":     map[string]interface{}{"strategy": "recreate"},
+	}
+
+	//when
+	reconciled := KeycloakCrossSiteCacheReconciled(desired, current)
+
+	//then
+	spec := reconciled.Object["spec"].(map[string]interface{})
+	assert.Contains(t, spec["template"], `strategy="SYNC" failure-policy="FAIL"`)
+	assert.Equal(t, map[string]interface{}{"strategy": "recreate"}, spec["updates"])
+}
+
+func TestKeycloakCrossSite_testSiteNameInScript(t *testing.T) {
+	//given
+	cr := crossSiteKeycloak()
+
+	//when
+	script := KeycloakClusteringScript(cr)
+
+	//then
+	assert.Contains(t, script, "/system-property=jboss.site.name:add(value=site-a)")
+}
+
+func TestKeycloakCrossSite_testValidation(t *testing.T) {
+	//given
+	noSite := crossSiteKeycloak()
+	noSite.Spec.CrossSite.Site = ""
+	noRemoteSites := crossSiteKeycloak()
+	noRemoteSites.Spec.CrossSite.RemoteSites = nil
+	noExternalDatabase := crossSiteKeycloak()
+	noExternalDatabase.Spec.ExternalDatabase.Enabled = false
+	noInfinispan := crossSiteKeycloak()
+	noInfinispan.Spec.Clustering.ExternalInfinispan = nil
+	duplicateSite := crossSiteKeycloak()
+	duplicateSite.Spec.CrossSite.RemoteSites[0].Name = "site-a"
+	unknownPrimary := crossSiteKeycloak()
+	unknownPrimary.Spec.CrossSite.PrimarySite = "site-c"
+	unknownFailover := crossSiteKeycloak()
+	unknownFailover.Annotations = map[string]string{KeycloakCrossSiteFailoverAnnotation: "site-c"}
+	valid := crossSiteKeycloak()
+	valid.Annotations = map[string]string{KeycloakCrossSiteFailoverAnnotation: "site-b"}
+
+	//when
+	noSiteErr := ValidateKeycloakCrossSite(noSite)
+	noRemoteSitesErr := ValidateKeycloakCrossSite(noRemoteSites)
+	noExternalDatabaseErr := ValidateKeycloakCrossSite(noExternalDatabase)
+	noInfinispanErr := ValidateKeycloakCrossSite(noInfinispan)
+	duplicateSiteErr := ValidateKeycloakCrossSite(duplicateSite)
+	unknownPrimaryErr := ValidateKeycloakCrossSite(unknownPrimary)
+	unknownFailoverErr := ValidateKeycloakCrossSite(unknownFailover)
+	validErr := ValidateKeycloakCrossSite(valid)
+
+	//then
+	assert.Error(t, noSiteErr)
+	assert.Error(t, noRemoteSitesErr)
+	assert.Error(t, noExternalDatabaseErr)
+	assert.Error(t, noInfinispanErr)
+	assert.Error(t, duplicateSiteErr)
+	assert.Error(t, unknownPrimaryErr)
+	assert.Error(t, unknownFailoverErr)
+	assert.NoError(t, validErr)
+}
+
+func crossSiteKeycloak() *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Name = "example-keycloak"
+	cr.Namespace = "keycloak"
+	cr.Spec.ExternalDatabase.Enabled = true
+	cr.Spec.Clustering.ExternalInfinispan = &v1alpha1.KeycloakExternalInfinispan{Host: "infinispan.keycloak.svc"}
+	cr.Spec.CrossSite = v1alpha1.KeycloakCrossSite{
+		Enabled:           true,
+		Site:              "site-a",
+		InfinispanCluster: "infinispan",
+		RemoteSites: []v1alpha1.KeycloakRemoteSite{
+			{Name: "site-b", KubeconfigSecret: "site-b-kubeconfig"},
+		},
+	}
+	return cr
+}
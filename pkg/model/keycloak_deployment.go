@@ -60,11 +60,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		// Database settings
 		{
 			Name:  "DB_VENDOR",
-			Value: "POSTGRES",
-		},
-		{
-			Name:  "DB_SCHEMA",
-			Value: "public",
+			Value: strings.ToUpper(string(GetDatabaseVendor(cr))),
 		},
 		{
 			Name:  "DB_ADDR",
@@ -72,7 +68,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		},
 		{
 			Name:  "DB_PORT",
-			Value: fmt.Sprintf("%v", GetExternalDatabasePort(dbSecret)),
+			Value: fmt.Sprintf("%v", GetExternalDatabasePort(cr, dbSecret)),
 		},
 		{
 			Name:  "DB_DATABASE",
@@ -105,23 +101,9 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			Name:  "NAMESPACE",
 			Value: cr.Namespace,
 		},
-		{
-			Name:  "JGROUPS_DISCOVERY_PROTOCOL",
-			Value: "dns.DNS_PING",
-		},
-		{
-			Name:  "JGROUPS_DISCOVERY_PROPERTIES",
-			Value: "dns_query=" + KeycloakDiscoveryServiceName + "." + cr.Namespace,
-		},
-		// Cache settings
-		{
-			Name:  "CACHE_OWNERS_COUNT",
-			Value: "2",
-		},
-		{
-			Name:  "CACHE_OWNERS_AUTH_SESSIONS_COUNT",
-			Value: "2",
-		},
+	}
+	env = append(env, keycloakClusteringEnv(cr)...)
+	env = append(env, []v1.EnvVar{
 		{
 			Name: "KEYCLOAK_USER",
 			ValueFrom: &v1.EnvVarSource{
@@ -156,6 +138,14 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			Name:  "KEYCLOAK_STATISTICS",
 			Value: "all",
 		},
+	}...)
+
+	// MySQL, MariaDB and Oracle have no schema apart from the database or the user
+	if schema := getDatabaseSchema(cr); schema != "" {
+		env = append(env, v1.EnvVar{
+			Name:  "DB_SCHEMA",
+			Value: schema,
+		})
 	}
 
 	if cr.Spec.ExternalDatabase.Enabled {
@@ -165,7 +155,7 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		})
 		env = append(env, v1.EnvVar{
 			Name:  GetServiceEnvVar("SERVICE_PORT"),
-			Value: fmt.Sprintf("%v", GetExternalDatabasePort(dbSecret)),
+			Value: fmt.Sprintf("%v", GetExternalDatabasePort(cr, dbSecret)),
 		})
 	}
 
@@ -174,28 +164,31 @@ func getKeycloakEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		env = MergeEnvs(cr.Spec.KeycloakDeploymentSpec.Experimental.Env, env)
 	}
 
-	env = KeycloakSslEnvVariables(dbSecret, env)
+	env = KeycloakSslEnvVariables(cr, dbSecret, env)
 
 	return env
 }
 
-func KeycloakSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
+func KeycloakSslEnvVariables(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 	if dbSecret != nil {
 		sslMode := string(dbSecret.Data[DatabaseSecretSslModeProperty])
+		vendor := GetDatabaseVendor(cr)
+		params := databaseConnectionParams(vendor, sslMode, KeycloakCertificatePath)
 
-		if sslMode != "" {
+		if sslMode != "" && len(params) > 0 {
+			separator := databaseConnectionParamsSeparator(vendor)
 			dbParams := ""
 			// is the deployment already having JDBC_PARAMS set ?
 			for _, element := range env {
 				if element.Name == KeycloakDatabaseConnectionParamsProperty {
-					dbParams = element.Value + "&"
+					dbParams = element.Value + separator
 					break
 				}
 			}
 			// append env variable
 			env = append(env, v1.EnvVar{
 				Name:  KeycloakDatabaseConnectionParamsProperty,
-				Value: dbParams + "sslmode=" + sslMode + "&sslrootcert=" + KeycloakCertificatePath + "/root.crt",
+				Value: dbParams + strings.Join(params, separator),
 			})
 		}
 	}
@@ -203,6 +196,10 @@ func KeycloakSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 }
 
 func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
+	volumeMounts := append(KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath), KeycloakWritableVolumeMounts(cr, KeycloakStandalonePath)...)
+	volumeMounts = append(volumeMounts, KeycloakThemeVolumeMounts(cr, KeycloakThemesPath)...)
+	volumeMounts = append(volumeMounts, KeycloakClusteringVolumeMounts(cr, KeycloakStartupScriptsPath)...)
+
 	podLabels := AddPodLabels(cr, GetLabelsSelector())
 	podAnnotations := cr.Spec.KeycloakDeploymentSpec.PodAnnotations
 	keycloakStatefulset := &v13.StatefulSet{
@@ -225,9 +222,11 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 					Annotations: podAnnotations,
 				},
 				Spec: v1.PodSpec{
-					InitContainers: KeycloakExtensionsInitContainers(cr),
-					Volumes:        KeycloakVolumes(cr, dbSSLSecret),
-					Containers: []v1.Container{
+					InitContainers:   KeycloakInitContainers(cr, Images.Images[KeycloakImage], KeycloakStandalonePath),
+					Volumes:          KeycloakVolumes(cr, dbSSLSecret),
+					SecurityContext:  KeycloakPodSecurityContext(cr),
+					ImagePullSecrets: KeycloakImagePullSecrets(cr),
+					Containers: addSidecarsFromKeycloakCR(cr, []v1.Container{
 						{
 							Name:  KeycloakDeploymentName,
 							Image: Images.Images[KeycloakImage],
@@ -247,16 +246,17 @@ func KeycloakDeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret
 							},
 
 							ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
-							VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath),
+							VolumeMounts:    volumeMounts,
 							LivenessProbe:   livenessProbe(),
 							ReadinessProbe:  readinessProbe(),
 							Env:             getKeycloakEnv(cr, dbSecret),
 							Args:            cr.Spec.KeycloakDeploymentSpec.Experimental.Args,
 							Command:         cr.Spec.KeycloakDeploymentSpec.Experimental.Command,
 							Resources:       getResources(cr),
+							SecurityContext: KeycloakContainerSecurityContext(cr),
 						},
-					},
-					ServiceAccountName: cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName,
+					}),
+					ServiceAccountName: KeycloakServiceAccount(cr),
 				},
 			},
 		},
@@ -278,6 +278,10 @@ func KeycloakDeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 }
 
 func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.StatefulSet, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
+	volumeMounts := append(KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath), KeycloakWritableVolumeMounts(cr, KeycloakStandalonePath)...)
+	volumeMounts = append(volumeMounts, KeycloakThemeVolumeMounts(cr, KeycloakThemesPath)...)
+	volumeMounts = append(volumeMounts, KeycloakClusteringVolumeMounts(cr, KeycloakStartupScriptsPath)...)
+
 	reconciled := currentState.DeepCopy()
 
 	reconciled.ObjectMeta.Labels = AddPodLabels(cr, reconciled.ObjectMeta.Labels)
@@ -285,14 +289,14 @@ func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.State
 	reconciled.Spec.Template.ObjectMeta.Labels = AddPodLabels(cr, reconciled.Spec.Template.ObjectMeta.Labels)
 	reconciled.Spec.Template.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.Spec.Template.ObjectMeta.Annotations)
 	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector()
-	reconciled.Spec.Template.Spec.ServiceAccountName = cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName
+	reconciled.Spec.Template.Spec.ServiceAccountName = KeycloakServiceAccount(cr)
 
 	reconciled.ResourceVersion = currentState.ResourceVersion
 	if !cr.Spec.DisableReplicasSyncing {
 		reconciled.Spec.Replicas = SanitizeNumberOfReplicas(cr.Spec.Instances, false)
 	}
 	reconciled.Spec.Template.Spec.Volumes = KeycloakVolumes(cr, dbSSLSecret)
-	reconciled.Spec.Template.Spec.Containers = []v1.Container{
+	reconciled.Spec.Template.Spec.Containers = addSidecarsFromKeycloakCR(cr, []v1.Container{
 		{
 			Name:    KeycloakDeploymentName,
 			Image:   Images.Images[KeycloakImage],
@@ -313,14 +317,17 @@ func KeycloakDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.State
 				},
 			},
 			ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
-			VolumeMounts:    KeycloakVolumeMounts(cr, KeycloakExtensionPath, dbSSLSecret, KeycloakCertificatePath),
+			VolumeMounts:    volumeMounts,
 			LivenessProbe:   livenessProbe(),
 			ReadinessProbe:  readinessProbe(),
 			Env:             getKeycloakEnv(cr, dbSecret),
 			Resources:       getResources(cr),
+			SecurityContext: KeycloakContainerSecurityContext(cr),
 		},
-	}
-	reconciled.Spec.Template.Spec.InitContainers = KeycloakExtensionsInitContainers(cr)
+	})
+	reconciled.Spec.Template.Spec.InitContainers = KeycloakInitContainers(cr, Images.Images[KeycloakImage], KeycloakStandalonePath)
+	reconciled.Spec.Template.Spec.SecurityContext = KeycloakPodSecurityContext(cr)
+	reconciled.Spec.Template.Spec.ImagePullSecrets = KeycloakImagePullSecrets(cr)
 	if cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity != nil {
 		reconciled.Spec.Template.Spec.Affinity = cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity
 	}
@@ -413,6 +420,9 @@ func KeycloakVolumes(cr *v1alpha1.Keycloak, dbSSLSecret *v1.Secret) []v1.Volume
 	}
 
 	volumes = addVolumesFromKeycloakCR(cr, volumes)
+	volumes = addWritableVolume(cr, volumes)
+	volumes = addThemeVolumes(cr, volumes)
+	volumes = addClusteringVolumes(cr, volumes)
 
 	return volumes
 }
@@ -461,6 +471,20 @@ func addVolumesFromKeycloakCR(cr *v1alpha1.Keycloak, volumes []v1.Volume) []v1.V
 	return volumes
 }
 
+func addSidecarsFromKeycloakCR(cr *v1alpha1.Keycloak, containers []v1.Container) []v1.Container {
+	for _, sidecar := range cr.Spec.KeycloakDeploymentSpec.Sidecars {
+		containers = append(containers, *sidecar.DeepCopy())
+	}
+	return containers
+}
+
+func addInitContainersFromKeycloakCR(cr *v1alpha1.Keycloak, initContainers []v1.Container) []v1.Container {
+	for _, initContainer := range cr.Spec.KeycloakDeploymentSpec.InitContainers {
+		initContainers = append(initContainers, *initContainer.DeepCopy())
+	}
+	return initContainers
+}
+
 func livenessProbe() *v1.Probe {
 	return &v1.Probe{
 		Handler: v1.Handler{
@@ -70,6 +70,26 @@ func TestKeycloakDeploymentReconciled_testDisableReplicasSyncingTrue(t *testing.
 	testDisableDeploymentReplicasSyncingTrue(t, KeycloakDeployment, KeycloakDeploymentReconciled)
 }
 
+func TestKeycloakDeployment_testDefaultSecurityContext(t *testing.T) {
+	testDefaultSecurityContext(t, KeycloakDeployment, KeycloakStandalonePath)
+}
+
+func TestKeycloakDeployment_testSecurityContextSet(t *testing.T) {
+	testSecurityContextSet(t, KeycloakDeployment)
+}
+
+func TestKeycloakDeploymentReconciled_testSecurityContextReconciled(t *testing.T) {
+	testSecurityContextReconciled(t, KeycloakDeployment, KeycloakDeploymentReconciled)
+}
+
+func TestKeycloakDeployment_testSidecarsAndInitContainers(t *testing.T) {
+	testSidecarsAndInitContainers(t, KeycloakDeployment)
+}
+
+func TestKeycloakDeploymentReconciled_testSidecarsAndInitContainersReconciled(t *testing.T) {
+	testSidecarsAndInitContainersReconciled(t, KeycloakDeployment, KeycloakDeploymentReconciled)
+}
+
 func testExperimentalEnvs(t *testing.T, deploymentFunction createDeploymentStatefulSet) {
 	//given
 	dbSecret := &v1.Secret{}
@@ -540,3 +560,150 @@ func testDisableDeploymentReplicasSyncingTrue(t *testing.T, deploymentFunction c
 	//then
 	assert.Equal(t, int32(4), *replicasCountAfterSyncing)
 }
+
+func testDefaultSecurityContext(t *testing.T, deploymentFunction createDeploymentStatefulSet, standalonePath string) {
+	//given
+	dbSecret := &v1.Secret{}
+	cr := &v1alpha1.Keycloak{}
+
+	//when
+	template := deploymentFunction(cr, dbSecret, nil).Spec.Template.Spec
+
+	//then
+	assert.True(t, *template.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, v1.SeccompProfileTypeRuntimeDefault, template.SecurityContext.SeccompProfile.Type)
+	for _, container := range append(template.InitContainers, template.Containers...) {
+		assert.True(t, *container.SecurityContext.RunAsNonRoot)
+		assert.False(t, *container.SecurityContext.AllowPrivilegeEscalation)
+		assert.True(t, *container.SecurityContext.ReadOnlyRootFilesystem)
+		assert.Equal(t, []v1.Capability{"ALL"}, container.SecurityContext.Capabilities.Drop)
+		assert.Equal(t, v1.SeccompProfileTypeRuntimeDefault, container.SecurityContext.SeccompProfile.Type)
+	}
+
+	// The writable directories are backed by an emptyDir seeded by the configuration init container
+	assert.Len(t, template.InitContainers, 2)
+	assert.Equal(t, "configuration-init", template.InitContainers[1].Name)
+	assert.Equal(t, KeycloakWritableVolumeName, template.Volumes[len(template.Volumes)-1].Name)
+	assert.NotNil(t, template.Volumes[len(template.Volumes)-1].EmptyDir)
+
+	mountPaths := map[string]string{}
+	for _, volumeMount := range template.Containers[0].VolumeMounts {
+		if volumeMount.Name == KeycloakWritableVolumeName {
+			mountPaths[volumeMount.MountPath] = volumeMount.SubPath
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"/tmp":                            "root-tmp",
+		standalonePath + "/configuration": "configuration",
+		standalonePath + "/data":          "data",
+		standalonePath + "/log":           "log",
+		standalonePath + "/tmp":           "tmp",
+	}, mountPaths)
+}
+
+func testSecurityContextSet(t *testing.T, deploymentFunction createDeploymentStatefulSet) {
+	//given
+	dbSecret := &v1.Secret{}
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.KeycloakDeploymentSpec.PodSecurityContext = &v1.PodSecurityContext{
+		RunAsUser: &[]int64{1000}[0],
+	}
+	cr.Spec.KeycloakDeploymentSpec.ContainerSecurityContext = &v1.SecurityContext{
+		ReadOnlyRootFilesystem: &[]bool{false}[0],
+	}
+
+	//when
+	template := deploymentFunction(cr, dbSecret, nil).Spec.Template.Spec
+
+	//then
+	assert.Equal(t, int64(1000), *template.SecurityContext.RunAsUser)
+	assert.Nil(t, template.SecurityContext.RunAsNonRoot)
+	assert.False(t, *template.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assert.False(t, *template.InitContainers[0].SecurityContext.ReadOnlyRootFilesystem)
+
+	// A writable root filesystem doesn't need the writable volume
+	assert.Len(t, template.InitContainers, 1)
+	for _, volume := range template.Volumes {
+		assert.NotEqual(t, KeycloakWritableVolumeName, volume.Name)
+	}
+	for _, volumeMount := range template.Containers[0].VolumeMounts {
+		assert.NotEqual(t, KeycloakWritableVolumeName, volumeMount.Name)
+	}
+}
+
+func testSecurityContextReconciled(t *testing.T, deploymentFunction createDeploymentStatefulSet, reconciliationFunction reconciledDeployment) {
+	//given
+	dbSecret := &v1.Secret{}
+	cr := &v1alpha1.Keycloak{}
+	statefulSet := deploymentFunction(cr, dbSecret, nil)
+
+	//when
+	cr.Spec.KeycloakDeploymentSpec.PodSecurityContext = &v1.PodSecurityContext{
+		FSGroup: &[]int64{2000}[0],
+	}
+	template := reconciliationFunction(cr, statefulSet, dbSecret, nil).Spec.Template.Spec
+
+	//then
+	assert.Equal(t, int64(2000), *template.SecurityContext.FSGroup)
+	assert.True(t, *template.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assert.Len(t, template.InitContainers, 2)
+}
+
+func testSidecarsAndInitContainers(t *testing.T, deploymentFunction createDeploymentStatefulSet) {
+	//given
+	dbSecret := &v1.Secret{}
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.KeycloakDeploymentSpec.Sidecars = []v1.Container{
+		{
+			Name:  "log-shipper",
+			Image: "fluent/fluent-bit",
+		},
+	}
+	cr.Spec.KeycloakDeploymentSpec.InitContainers = []v1.Container{
+		{
+			Name:  "theme-fetcher",
+			Image: "busybox",
+		},
+	}
+
+	//when
+	template := deploymentFunction(cr, dbSecret, nil).Spec.Template.Spec
+
+	//then
+	assert.Len(t, template.Containers, 2)
+	assert.Equal(t, KeycloakDeploymentName, template.Containers[0].Name)
+	assert.Equal(t, "log-shipper", template.Containers[1].Name)
+	assert.Len(t, template.InitContainers, 3)
+	assert.Equal(t, "extensions-init", template.InitContainers[0].Name)
+	assert.Equal(t, "theme-fetcher", template.InitContainers[2].Name)
+}
+
+func testSidecarsAndInitContainersReconciled(t *testing.T, deploymentFunction createDeploymentStatefulSet, reconciliationFunction reconciledDeployment) {
+	//given
+	dbSecret := &v1.Secret{}
+	cr := &v1alpha1.Keycloak{}
+	statefulSet := deploymentFunction(cr, dbSecret, nil)
+
+	//when
+	cr.Spec.KeycloakDeploymentSpec.Sidecars = []v1.Container{
+		{
+			Name:  "cloud-sql-proxy",
+			Image: "gcr.io/cloudsql-docker/gce-proxy",
+		},
+	}
+	cr.Spec.KeycloakDeploymentSpec.InitContainers = []v1.Container{
+		{
+			Name:  "theme-fetcher",
+			Image: "busybox",
+		},
+	}
+	reconciled := reconciliationFunction(cr, statefulSet, dbSecret, nil)
+	reconciledAgain := reconciliationFunction(cr, reconciled, dbSecret, nil).Spec.Template.Spec
+
+	//then
+	assert.Len(t, reconciledAgain.Containers, 2)
+	assert.Equal(t, KeycloakDeploymentName, reconciledAgain.Containers[0].Name)
+	assert.Equal(t, "cloud-sql-proxy", reconciledAgain.Containers[1].Name)
+	assert.Len(t, reconciledAgain.InitContainers, 3)
+	assert.Equal(t, "theme-fetcher", reconciledAgain.InitContainers[2].Name)
+}
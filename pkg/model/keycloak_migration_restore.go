@@ -0,0 +1,97 @@
+package model
+
+import (
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The dump of a local backup doesn't drop existing objects, the tables and sequences left behind by the failed
+// schema migration are dropped first. The whole restore runs in a single transaction. The database Keycloak runs
+// against after a bluegreen migration doesn't exist yet when restoring into a new major version, it's created
+// beforehand.
+const keycloakMigrationRestoreScript = `set -eo pipefail
+echo "SELECT format('CREATE DATABASE %I', :'database') WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = :'database')\\gexec" | psql -q -v ON_ERROR_STOP=1 -d postgres -v database="$PGDATABASE"
+{
+  echo "BEGIN;"
+  psql -tA -c "SELECT format('DROP TABLE IF EXISTS %I CASCADE;', tablename) FROM pg_tables WHERE schemaname = 'public'"
+  psql -tA -c "SELECT format('DROP SEQUENCE IF EXISTS %I CASCADE;', sequence_name) FROM information_schema.sequences WHERE sequence_schema = 'public'"
+  cat /backup/backup.sql
+  echo "COMMIT;"
+} | psql -q -v ON_ERROR_STOP=1 > /dev/null
+`
+
+// KeycloakMigrationRestore returns a Job restoring the local backup taken before an image migration.
+func KeycloakMigrationRestore(cr *v1alpha1.Keycloak, backupName string) *v13.Job {
+	claimName := PostgresqlBackupPersistentVolumeName + "-" + backupName
+	job := &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakMigrationRestoreSelector(cr, backupName).Name,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":       ApplicationName,
+				"component": PostgresqlBackupComponent,
+			},
+		},
+		Spec: v13.JobSpec{
+			BackoffLimit: &[]int32{2}[0],
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Volumes: []v1.Volume{
+						{
+							Name: claimName,
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: claimName,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						{
+							Name:    "restore",
+							Image:   DatabaseClientImage(cr),
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{keycloakMigrationRestoreScript},
+							Env: []v1.EnvVar{
+								postgresqlBackupSecretEnvVar("PGUSER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+								postgresqlBackupSecretEnvVar("PGPASSWORD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+								postgresqlBackupSecretEnvVar("PGDATABASE", DatabaseSecretName, DatabaseSecretDatabaseProperty, false),
+								{
+									Name:  "PGHOST",
+									Value: PostgresqlServiceName,
+								},
+							},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      claimName,
+									MountPath: "/backup",
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: PostgresqlBackupServiceAccountName,
+				},
+			},
+		},
+	}
+	if isMySQLDatabase(cr) {
+		container := &job.Spec.Template.Spec.Containers[0]
+		container.Command = []string{"/bin/bash", "-c"}
+		container.Args = []string{mysqlMigrationRestoreScript}
+		container.Env = mysqlClientEnv()
+	}
+	return job
+}
+
+func KeycloakMigrationRestoreSelector(cr *v1alpha1.Keycloak, backupName string) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      backupName + "-restore",
+		Namespace: cr.Namespace,
+	}
+}
@@ -26,7 +26,9 @@ AUTH_STRING=""
 if [ -d "/opt/eap/bin" ]; then
     pushd /opt/eap/bin > /dev/null
 	DATASOURCE_POOL_TYPE="xa-data-source"
-	DATASOURCE_POOL_NAME="keycloak_postgresql-DB"
+	# The pool is named after the service and the prefix of DB_SERVICE_PREFIX_MAPPING, e.g. keycloak_postgresql-DB
+	DATASOURCE_SERVICE="${DB_SERVICE_PREFIX_MAPPING%%=*}"
+	DATASOURCE_POOL_NAME="${DATASOURCE_SERVICE//-/_}-${DB_SERVICE_PREFIX_MAPPING#*=}"
 else
     pushd /opt/jboss/keycloak/bin > /dev/null
 	if [ -f "$PASSWORD_FILE" ]; then
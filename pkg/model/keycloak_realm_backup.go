@@ -0,0 +1,366 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	KeycloakRealmBackupFilePrefix = "keycloak-realms-"
+
+	keycloakRealmBackupCAName = "keycloak-ca"
+	keycloakRealmBackupCAPath = "/ca"
+	// The key and the certificate of the client the operator authenticates with
+	keycloakRealmBackupClientName = "keycloak-client"
+	keycloakRealmBackupClientPath = "/client"
+	keycloakRealmRestorePath      = postgresqlBackupPath + "/in"
+
+	// The admin API is called with curl and its responses are read with jq. A token is requested for every call,
+	// so that large exports don't outlive it. The serving certificate is trusted like by the operator's admin
+	// client, and the operator's client credentials are used if configured. Client assertions are signed with
+	// openssl.
+	keycloakAdminAPIFunctions = `curl_opts=--insecure
+for ca in ` + keycloakRealmBackupCAPath + `/ca.crt ` + keycloakRealmBackupCAPath + `/tls.crt; do
+  if [ -s "$ca" ]; then curl_opts="--cacert $ca"; break; fi
+done
+token_url="$KEYCLOAK_URL/realms/${AUTH_REALM:-master}/protocol/openid-connect/token"
+kc() {
+  curl -sSf $curl_opts "$@"
+}
+b64url() {
+  openssl base64 -A | tr '+/' '-_' | tr -d '='
+}
+token() {
+  case "$AUTH_METHOD" in
+    client-secret)
+      set -- -d grant_type=client_credentials --data-urlencode "client_id=$CLIENT_ID" --data-urlencode "client_secret=$CLIENT_SECRET" ;;
+    private-key-jwt)
+      now=$(date +%s)
+      claims=$(jq -cn --arg client "$CLIENT_ID" --arg aud "$token_url" --arg jti "$(openssl rand -hex 16)" --argjson now "$now" \
+        '{iss: $client, sub: $client, aud: $aud, jti: $jti, iat: $now, exp: ($now + 60)}')
+      jwt="$(printf '{"alg":"RS256","typ":"JWT"}' | b64url).$(printf '%s' "$claims" | b64url)"
+      jwt="$jwt.$(printf '%s' "$jwt" | openssl dgst -sha256 -binary -sign ` + keycloakRealmBackupClientPath + `/tls.key | b64url)"
+      set -- -d grant_type=client_credentials --data-urlencode "client_id=$CLIENT_ID" -d client_assertion_type=urn:ietf:params:oauth:client-assertion-type:jwt-bearer -d "client_assertion=$jwt" ;;
+    tls)
+      set -- --cert ` + keycloakRealmBackupClientPath + `/tls.crt --key ` + keycloakRealmBackupClientPath + `/tls.key -d grant_type=client_credentials --data-urlencode "client_id=$CLIENT_ID" ;;
+    *)
+      set -- -d grant_type=password -d client_id=admin-cli --data-urlencode "username=$ADMIN_USERNAME" --data-urlencode "password=$ADMIN_PASSWORD" ;;
+  esac
+  kc "$@" "$token_url" | jq -er .access_token
+}
+admin() {
+  path="$1"
+  shift
+  kc -H "Authorization: Bearer $(token)" "$@" "$KEYCLOAK_URL/admin/realms$path"
+}
+uri() {
+  jq -rn --arg value "$1" '$value | @uri'
+}
+`
+
+	// Every realm is exported into its own file named after the encoded realm name, users are fetched page by
+	// page and added to the export. REALMS lists one realm per line.
+	keycloakRealmExportScript = `set -eo pipefail
+` + keycloakAdminAPIFunctions + `export_dir=` + postgresqlBackupStagingPath + `/` + KeycloakRealmBackupFilePrefix + postgresqlBackupTimestamp + `
+mkdir -p "$export_dir"
+if [ -z "$REALMS" ]; then
+  admin "?briefRepresentation=true" | jq -r '.[].realm' > "$export_dir/realms"
+else
+  printf '%s\n' "$REALMS" > "$export_dir/realms"
+fi
+while IFS= read -r realm; do
+  realm_path="/$(uri "$realm")"
+  file="$export_dir/$(uri "$realm")"
+  admin "$realm_path/partial-export?exportClients=true&exportGroupsAndRoles=true" -X POST > "$file.export"
+  if [ "$INCLUDE_USERS" = "true" ]; then
+    echo '[]' > "$file.users"
+    first=0
+    while true; do
+      admin "$realm_path/users?briefRepresentation=false&first=$first&max=100" > "$file.page"
+      if [ "$(jq length "$file.page")" -eq 0 ]; then break; fi
+      jq -s '.[0] + .[1]' "$file.users" "$file.page" > "$file.tmp"
+      mv "$file.tmp" "$file.users"
+      first=$((first + 100))
+    done
+    jq --slurpfile users "$file.users" '.users = $users[0]' "$file.export" > "$file.json"
+    rm "$file.export" "$file.users" "$file.page"
+  else
+    mv "$file.export" "$file.json"
+  fi
+done < "$export_dir/realms"
+rm "$export_dir/realms"
+`
+
+	// Downloads the files of the most recent realm backup, or of the one with the requested timestamp
+	keycloakRealmFetchScript = `mkdir -p ` + keycloakRealmRestorePath + `
+stamp="$RESTORE_FROM"
+if [ -z "$stamp" ]; then
+  stamp=$(list_backups | sort -r -k1,1 | sed -n -e "s/.*${BACKUP_FILE_PREFIX}\([0-9]*T[0-9]*Z\)\/.*/\1/p" | sed -n -e 1p)
+fi
+names=$(list_backups | awk '{ print $1 }' | grep "${BACKUP_FILE_PREFIX}${stamp}/" || true)
+if [ -z "$stamp" ] || [ -z "$names" ]; then
+  echo "no realm backup ${stamp} found" > /dev/termination-log
+  exit 1
+fi
+for name in $names; do
+  download_backup "$name" "` + keycloakRealmRestorePath + `/$(basename "$name")"
+done
+`
+
+	// Missing realms are created from the export, existing ones are partially imported. The realm is read from
+	// the export, not from the file name.
+	keycloakRealmImportScript = `set -eo pipefail
+` + keycloakAdminAPIFunctions + `for file in ` + keycloakRealmRestorePath + `/*.json; do
+  realm=$(jq -er .realm "$file")
+  if admin "/$(uri "$realm")" -o /dev/null; then
+    jq --arg ifResourceExists "$IF_RESOURCE_EXISTS" '. + {ifResourceExists: $ifResourceExists}' "$file" > ` + postgresqlBackupPath + `/import.json
+    admin "/$(uri "$realm")/partialImport" -X POST -H "Content-Type: application/json" --data-binary @` + postgresqlBackupPath + `/import.json > /dev/null
+  else
+    admin "" -X POST -H "Content-Type: application/json" --data-binary @"$file" > /dev/null
+  fi
+done
+`
+)
+
+// KeycloakRealmBackup returns a one-time Job exporting the realms of the Keycloak instance into the destination
+// set in the KeycloakBackup.
+func KeycloakRealmBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v13.Job {
+	return &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    PostgresqlBackupLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: PostgresqlBackupLabels(cr),
+				},
+				Spec: keycloakRealmBackupPodSpec(cr, keycloak),
+			},
+		},
+	}
+}
+
+func KeycloakRealmBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v13.Job) *v13.Job {
+	reconciled := currentState.DeepCopy()
+	reconciled.Spec.Template.Labels = PostgresqlBackupLabels(cr)
+	reconciled.Spec.Template.Spec = keycloakRealmBackupPodSpec(cr, keycloak)
+	return reconciled
+}
+
+// KeycloakRealmPeriodicBackup returns a CronJob exporting the realms of the Keycloak instance on the schedule
+// of the KeycloakBackup.
+func KeycloakRealmPeriodicBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v1beta1.CronJob {
+	cronJob := PostgresqlDestinationPeriodicBackup(cr, keycloak)
+	cronJob.Spec.JobTemplate.Spec.Template.Spec = keycloakRealmBackupPodSpec(cr, keycloak)
+	return cronJob
+}
+
+func KeycloakRealmPeriodicBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v1beta1.CronJob) *v1beta1.CronJob {
+	reconciled := PostgresqlDestinationPeriodicBackupReconciled(cr, keycloak, currentState)
+	reconciled.Spec.JobTemplate.Spec.Template.Spec = keycloakRealmBackupPodSpec(cr, keycloak)
+	return reconciled
+}
+
+// KeycloakRealmRestore returns a Job importing a realm backup from the destination into the Keycloak instance.
+// The restore runs once, the Job isn't updated afterwards.
+func KeycloakRealmRestore(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v13.Job {
+	podSpec := v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				Name: postgresqlBackupVolumeName,
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{},
+				},
+			},
+		},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+	}
+	podSpec.Volumes = append(podSpec.Volumes, keycloakAdminAPIVolumes(keycloak)...)
+	fetch := backupDestinationContainer(cr, &podSpec, "fetch", keycloakRealmFetchScript, KeycloakRealmBackupFilePrefix)
+	fetch.Env = append(fetch.Env, v1.EnvVar{
+		Name:  "RESTORE_FROM",
+		Value: cr.Spec.RealmExport.RestoreFrom,
+	})
+	podSpec.InitContainers = []v1.Container{fetch}
+
+	ifResourceExists := cr.Spec.RealmExport.IfResourceExists
+	if ifResourceExists == "" {
+		ifResourceExists = "SKIP"
+	}
+	restore := keycloakAdminAPIContainer("import", keycloak, keycloakRealmImportScript)
+	restore.Env = append(restore.Env, v1.EnvVar{
+		Name:  "IF_RESOURCE_EXISTS",
+		Value: ifResourceExists,
+	})
+	podSpec.Containers = []v1.Container{restore}
+
+	return &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      KeycloakRealmRestoreSelector(cr).Name,
+			Namespace: cr.Namespace,
+			Labels:    keycloakRealmRestoreLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: keycloakRealmRestoreLabels(cr),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+func KeycloakRealmRestoreSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      cr.Name + "-restore",
+		Namespace: cr.Namespace,
+	}
+}
+
+// ValidateKeycloakRealmBackup checks that realm backups are stored in a destination and that the database
+// only options aren't used with them.
+func ValidateKeycloakRealmBackup(cr *v1alpha1.KeycloakBackup) error {
+	if cr.Spec.Mode != v1alpha1.BackupModeRealms {
+		return nil
+	}
+	switch {
+	case cr.Spec.AWS != (v1alpha1.KeycloakAWSSpec{}) && cr.Spec.Destination == nil:
+		return errors.Errorf("backup %v can't export realms to aws, use destination.s3 instead", cr.Name)
+	case cr.Spec.Verification.Enabled:
+		return errors.Errorf("backup %v can only verify database backups", cr.Name)
+	}
+	return nil
+}
+
+// The realms are exported by an init container and stored by the main container
+func keycloakRealmBackupPodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.PodSpec {
+	podSpec := backupDestinationPodSpec(cr, KeycloakRealmBackupFilePrefix)
+	podSpec.Volumes = append(podSpec.Volumes, keycloakAdminAPIVolumes(keycloak)...)
+
+	export := keycloakAdminAPIContainer("export", keycloak, keycloakRealmExportScript)
+	export.Env = append(export.Env,
+		v1.EnvVar{Name: "REALMS", Value: strings.Join(cr.Spec.RealmExport.Realms, "\n")},
+		v1.EnvVar{Name: "INCLUDE_USERS", Value: strconv.FormatBool(cr.Spec.RealmExport.IncludeUsers)},
+	)
+	podSpec.InitContainers = []v1.Container{export}
+	return podSpec
+}
+
+// keycloakAdminAPIContainer returns a container calling the admin API of the Keycloak instance with the admin
+// credentials, or the client credentials of the operator, for managed and external instances alike.
+func keycloakAdminAPIContainer(name string, keycloak *v1alpha1.Keycloak, script string) v1.Container {
+	container := v1.Container{
+		Name:    name,
+		Image:   Images.Images[KeycloakAdminAPIImage],
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{script},
+		Env: []v1.EnvVar{
+			{
+				Name:  "KEYCLOAK_URL",
+				Value: KeycloakAdminAPIURL(keycloak),
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      postgresqlBackupVolumeName,
+				MountPath: postgresqlBackupPath,
+			},
+			{
+				Name:      keycloakRealmBackupCAName,
+				MountPath: keycloakRealmBackupCAPath,
+				ReadOnly:  true,
+			},
+		},
+	}
+
+	authentication := keycloak.Spec.OperatorAuthentication
+	if authentication == nil {
+		container.Env = append(container.Env,
+			postgresqlBackupSecretEnvVar("ADMIN_USERNAME", KeycloakAdminSecretSelector(keycloak).Name, AdminUsernameProperty, false),
+			postgresqlBackupSecretEnvVar("ADMIN_PASSWORD", KeycloakAdminSecretSelector(keycloak).Name, AdminPasswordProperty, false),
+		)
+		return container
+	}
+
+	container.Env = append(container.Env,
+		v1.EnvVar{Name: "AUTH_METHOD", Value: string(operatorAuthenticationMethod(authentication))},
+		v1.EnvVar{Name: "AUTH_REALM", Value: authentication.Realm},
+		v1.EnvVar{Name: "CLIENT_ID", Value: authentication.ClientID},
+	)
+	if operatorAuthenticationMethod(authentication) == v1alpha1.OperatorAuthenticationClientSecret {
+		container.Env = append(container.Env, postgresqlBackupSecretEnvVar("CLIENT_SECRET", authentication.SecretName, OperatorClientSecretProperty, false))
+	} else {
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      keycloakRealmBackupClientName,
+			MountPath: keycloakRealmBackupClientPath,
+			ReadOnly:  true,
+		})
+	}
+	return container
+}
+
+// KeycloakAdminAPIURL returns the base URL of the Keycloak instance the admin API and the realms are found at,
+// preferring the internal URL like the operator's admin client.
+func KeycloakAdminAPIURL(keycloak *v1alpha1.Keycloak) string {
+	url := keycloak.Status.InternalURL
+	if url == "" {
+		url = keycloak.Status.ExternalURL
+	}
+	return strings.TrimSuffix(KeycloakPath(strings.TrimSuffix(url, "/"), KeycloakContextRoot(*keycloak)), "/")
+}
+
+// The serving certificate is optional, external instances usually have a publicly trusted one. The key and the
+// certificate of the operator's client are mounted for the client authentication methods that need them.
+func keycloakAdminAPIVolumes(keycloak *v1alpha1.Keycloak) []v1.Volume {
+	volumes := []v1.Volume{
+		{
+			Name: keycloakRealmBackupCAName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: ServingCertSecretName,
+					Optional:   &[]bool{true}[0],
+				},
+			},
+		},
+	}
+
+	authentication := keycloak.Spec.OperatorAuthentication
+	if authentication != nil && operatorAuthenticationMethod(authentication) != v1alpha1.OperatorAuthenticationClientSecret {
+		volumes = append(volumes, v1.Volume{
+			Name: keycloakRealmBackupClientName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: authentication.SecretName,
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+func operatorAuthenticationMethod(authentication *v1alpha1.KeycloakOperatorAuthentication) v1alpha1.KeycloakOperatorAuthenticationMethod {
+	if authentication.Method == "" {
+		return v1alpha1.OperatorAuthenticationClientSecret
+	}
+	return authentication.Method
+}
+
+func keycloakRealmRestoreLabels(cr *v1alpha1.KeycloakBackup) map[string]string {
+	return map[string]string{
+		"app":                     ApplicationName,
+		"component":               PostgresqlBackupComponent,
+		KeycloakRealmRestoreLabel: cr.Name,
+	}
+}
@@ -0,0 +1,135 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestKeycloakRealmBackup_testExport(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "realms"
+	cr.Spec.Mode = v1alpha1.BackupModeRealms
+	cr.Spec.RealmExport = v1alpha1.KeycloakBackupRealmExport{
+		Realms:       []string{"master", "demo"},
+		IncludeUsers: true,
+	}
+	keycloak := &v1alpha1.Keycloak{}
+	keycloak.Name = "keycloak"
+	keycloak.Status.InternalURL = "https://keycloak.keycloak.svc:8443"
+
+	//when
+	job := KeycloakRealmBackup(cr, keycloak)
+
+	//then
+	podSpec := job.Spec.Template.Spec
+	export := podSpec.InitContainers[0]
+	assert.Equal(t, Images.Images[KeycloakAdminAPIImage], export.Image)
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "KEYCLOAK_URL", Value: "https://keycloak.keycloak.svc:8443/auth"})
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "REALMS", Value: "master\ndemo"})
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "INCLUDE_USERS", Value: "true"})
+	assert.Equal(t, "credential-keycloak", findEnvVar(export.Env, "ADMIN_PASSWORD").ValueFrom.SecretKeyRef.Name)
+	assert.Contains(t, podSpec.Containers[0].Env, v1.EnvVar{Name: "BACKUP_FILE_PREFIX", Value: KeycloakRealmBackupFilePrefix})
+	assert.Equal(t, "keycloak-backup-realms", podSpec.Volumes[1].PersistentVolumeClaim.ClaimName)
+}
+
+func TestKeycloakRealmBackup_testExternalKeycloakURL(t *testing.T) {
+	//given
+	keycloak := &v1alpha1.Keycloak{}
+	keycloak.Spec.Unmanaged = true
+	keycloak.Spec.External.Enabled = true
+	keycloak.Spec.External.URL = "https://keycloak.example.com/"
+	keycloak.Spec.External.ContextRoot = "/"
+	keycloak.Status.ExternalURL = keycloak.Spec.External.URL
+
+	//when
+	url := KeycloakAdminAPIURL(keycloak)
+
+	//then
+	assert.Equal(t, "https://keycloak.example.com", url)
+}
+
+func TestKeycloakRealmBackup_testOperatorAuthentication(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "realms"
+	cr.Spec.Mode = v1alpha1.BackupModeRealms
+	keycloak := &v1alpha1.Keycloak{}
+	keycloak.Name = "keycloak"
+	keycloak.Spec.OperatorAuthentication = &v1alpha1.KeycloakOperatorAuthentication{
+		Realm:      "operators",
+		ClientID:   "keycloak-operator",
+		Method:     v1alpha1.OperatorAuthenticationTLS,
+		SecretName: "keycloak-operator-client",
+	}
+
+	//when
+	job := KeycloakRealmBackup(cr, keycloak)
+
+	//then
+	podSpec := job.Spec.Template.Spec
+	export := podSpec.InitContainers[0]
+	assert.Equal(t, v1.EnvVar{}, findEnvVar(export.Env, "ADMIN_PASSWORD"))
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "AUTH_METHOD", Value: "tls"})
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "AUTH_REALM", Value: "operators"})
+	assert.Contains(t, export.Env, v1.EnvVar{Name: "CLIENT_ID", Value: "keycloak-operator"})
+	assert.Contains(t, export.VolumeMounts, v1.VolumeMount{Name: "keycloak-client", MountPath: "/client", ReadOnly: true})
+	assert.Equal(t, "keycloak-operator-client", podSpec.Volumes[len(podSpec.Volumes)-1].Secret.SecretName)
+}
+
+func TestKeycloakRealmRestore_testPartialImport(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "realms"
+	cr.Spec.Mode = v1alpha1.BackupModeRealms
+	cr.Spec.Restore = true
+	cr.Spec.RealmExport.RestoreFrom = "20230101T000000Z"
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		GCS: &v1alpha1.KeycloakBackupGCSDestination{
+			Bucket:                "keycloak",
+			CredentialsSecretName: "gcs-secret",
+		},
+	}
+	keycloak := &v1alpha1.Keycloak{}
+
+	//when
+	job := KeycloakRealmRestore(cr, keycloak)
+
+	//then
+	assert.Equal(t, "realms-restore", job.Name)
+	assert.Equal(t, "realms", job.Labels[KeycloakRealmRestoreLabel])
+	assert.Empty(t, job.Labels[PostgresqlBackupLabel])
+	podSpec := job.Spec.Template.Spec
+	fetch := podSpec.InitContainers[0]
+	assert.Equal(t, Images.Images[BackupGCSImage], fetch.Image)
+	assert.Contains(t, fetch.Env, v1.EnvVar{Name: "RESTORE_FROM", Value: "20230101T000000Z"})
+	assert.Equal(t, "gcs-secret", podSpec.Volumes[2].Secret.SecretName)
+	assert.Contains(t, podSpec.Containers[0].Env, v1.EnvVar{Name: "IF_RESOURCE_EXISTS", Value: "SKIP"})
+	assert.Contains(t, podSpec.Containers[0].Args[0], "partialImport")
+}
+
+func TestValidateKeycloakRealmBackup(t *testing.T) {
+	//given
+	legacyAWS := &v1alpha1.KeycloakBackup{}
+	legacyAWS.Spec.Mode = v1alpha1.BackupModeRealms
+	legacyAWS.Spec.AWS.CredentialsSecretName = "aws-secret"
+	verified := &v1alpha1.KeycloakBackup{}
+	verified.Spec.Mode = v1alpha1.BackupModeRealms
+	verified.Spec.Verification.Enabled = true
+	local := &v1alpha1.KeycloakBackup{}
+	local.Spec.Mode = v1alpha1.BackupModeRealms
+
+	//when
+	legacyAWSErr := ValidateKeycloakRealmBackup(legacyAWS)
+	verifiedErr := ValidateKeycloakRealmBackup(verified)
+	localErr := ValidateKeycloakRealmBackup(local)
+
+	//then
+	assert.Error(t, legacyAWSErr)
+	assert.Error(t, verifiedErr)
+	assert.NoError(t, localErr)
+	assert.NotNil(t, PostgresqlBackupDestination(local).PersistentVolumeClaim)
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+// Directories below the standalone path that the server writes to at runtime. With a read-only root
+// filesystem they are backed by the writable emptyDir volume, using the directory name as subPath.
+var keycloakWritableDirectories = []string{"configuration", "data", "log", "tmp"}
+
+// KeycloakPodSecurityContext returns the security context set in the CR or, if there's none,
+// a default that passes the Pod Security Admission "restricted" profile.
+func KeycloakPodSecurityContext(cr *v1alpha1.Keycloak) *v1.PodSecurityContext {
+	if cr.Spec.KeycloakDeploymentSpec.PodSecurityContext != nil {
+		return cr.Spec.KeycloakDeploymentSpec.PodSecurityContext.DeepCopy()
+	}
+	return &v1.PodSecurityContext{
+		RunAsNonRoot: pointer.BoolPtr(true),
+		SeccompProfile: &v1.SeccompProfile{
+			Type: v1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// KeycloakContainerSecurityContext returns the container security context set in the CR or, if there's none,
+// a default that passes the Pod Security Admission "restricted" profile.
+func KeycloakContainerSecurityContext(cr *v1alpha1.Keycloak) *v1.SecurityContext {
+	if cr.Spec.KeycloakDeploymentSpec.ContainerSecurityContext != nil {
+		return cr.Spec.KeycloakDeploymentSpec.ContainerSecurityContext.DeepCopy()
+	}
+	return &v1.SecurityContext{
+		RunAsNonRoot:             pointer.BoolPtr(true),
+		AllowPrivilegeEscalation: pointer.BoolPtr(false),
+		ReadOnlyRootFilesystem:   pointer.BoolPtr(true),
+		Capabilities: &v1.Capabilities{
+			Drop: []v1.Capability{"ALL"},
+		},
+		SeccompProfile: &v1.SeccompProfile{
+			Type: v1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+// KeycloakInitContainerSecurityContext returns the security context set for an init container fetching an
+// extension or a theme or, if there's none, the container security context of Keycloak.
+func KeycloakInitContainerSecurityContext(cr *v1alpha1.Keycloak, securityContext *v1.SecurityContext) *v1.SecurityContext {
+	if securityContext != nil {
+		return securityContext.DeepCopy()
+	}
+	return KeycloakContainerSecurityContext(cr)
+}
+
+func IsReadOnlyRootFilesystem(cr *v1alpha1.Keycloak) bool {
+	securityContext := KeycloakContainerSecurityContext(cr)
+	return securityContext.ReadOnlyRootFilesystem != nil && *securityContext.ReadOnlyRootFilesystem
+}
+
+// KeycloakInitContainers returns the extensions and themes init containers, with a read-only root filesystem an
+// init container seeding the writable configuration directory from the server image, and the CR's init containers.
+func KeycloakInitContainers(cr *v1alpha1.Keycloak, image string, standalonePath string) []v1.Container {
+	initContainers := KeycloakExtensionsInitContainers(cr)
+	initContainers = append(initContainers, KeycloakThemesInitContainers(cr, image)...)
+	if IsReadOnlyRootFilesystem(cr) {
+		initContainers = append(initContainers, keycloakConfigurationInitContainer(cr, image, standalonePath))
+	}
+	return addInitContainersFromKeycloakCR(cr, initContainers)
+}
+
+func keycloakConfigurationInitContainer(cr *v1alpha1.Keycloak, image string, standalonePath string) v1.Container {
+	return v1.Container{
+		Name:  "configuration-init",
+		Image: image,
+		Command: []string{
+			"/bin/sh",
+			"-c",
+			"cp -R " + standalonePath + "/configuration/. /mnt/configuration/",
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      KeycloakWritableVolumeName,
+				MountPath: "/mnt/configuration",
+				SubPath:   "configuration",
+			},
+		},
+		SecurityContext: KeycloakContainerSecurityContext(cr),
+		ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+	}
+}
+
+// KeycloakWritableVolumeMounts returns the mounts backing the directories the server writes to,
+// or nothing if the root filesystem is writable.
+func KeycloakWritableVolumeMounts(cr *v1alpha1.Keycloak, standalonePath string) []v1.VolumeMount {
+	if !IsReadOnlyRootFilesystem(cr) {
+		return nil
+	}
+
+	mountedVolumes := []v1.VolumeMount{
+		{
+			Name:      KeycloakWritableVolumeName,
+			MountPath: "/tmp",
+			SubPath:   "root-tmp",
+		},
+	}
+	for _, directory := range keycloakWritableDirectories {
+		mountedVolumes = append(mountedVolumes, v1.VolumeMount{
+			Name:      KeycloakWritableVolumeName,
+			MountPath: standalonePath + "/" + directory,
+			SubPath:   directory,
+		})
+	}
+	return mountedVolumes
+}
+
+func addWritableVolume(cr *v1alpha1.Keycloak, volumes []v1.Volume) []v1.Volume {
+	if !IsReadOnlyRootFilesystem(cr) {
+		return volumes
+	}
+	return append(volumes, v1.Volume{
+		Name: KeycloakWritableVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+}
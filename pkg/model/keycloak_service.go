@@ -8,8 +8,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const servingCertAnnotation = "service.alpha.openshift.io/serving-cert-secret-name"
+
 func KeycloakService(cr *v1alpha1.Keycloak) *v1.Service {
-	return &v1.Service{
+	service := &v1.Service{
 		ObjectMeta: v12.ObjectMeta{
 			Name:      ApplicationName,
 			Namespace: cr.Namespace,
@@ -17,8 +19,8 @@ func KeycloakService(cr *v1alpha1.Keycloak) *v1.Service {
 				"app": ApplicationName,
 			},
 			Annotations: map[string]string{
-				"description": "The web server's https port.",
-				"service.alpha.openshift.io/serving-cert-secret-name": ServingCertSecretName,
+				"description":         "The web server's https port.",
+				servingCertAnnotation: ServingCertSecretName,
 			},
 		},
 		Spec: v1.ServiceSpec{
@@ -36,6 +38,12 @@ func KeycloakService(cr *v1alpha1.Keycloak) *v1.Service {
 			},
 		},
 	}
+
+	// The serving certificate secret is owned by cert-manager
+	if cr.Spec.CertManager.Enabled {
+		delete(service.Annotations, servingCertAnnotation)
+	}
+	return service
 }
 
 func KeycloakServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
@@ -47,6 +55,9 @@ func KeycloakServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 
 func KeycloakServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1.Service) *v1.Service {
 	reconciled := currentState.DeepCopy()
+	if cr.Spec.CertManager.Enabled {
+		delete(reconciled.Annotations, servingCertAnnotation)
+	}
 	reconciled.Spec.Ports = []v1.ServicePort{
 		{
 			Port:       KeycloakServicePort,
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	keycloakThemeSourcePath = "/mnt/theme-source"
+	keycloakThemeTargetPath = "/mnt/theme"
+)
+
+// KeycloakThemesInitContainers returns one init container per theme, unpacking it into the themes volume.
+// Archives from ConfigMaps are extracted with the tar binary of the server image.
+func KeycloakThemesInitContainers(cr *v1alpha1.Keycloak, image string) []v1.Container {
+	var initContainers []v1.Container
+	for i, theme := range cr.Spec.Themes {
+		initContainer := v1.Container{
+			Name: fmt.Sprintf("theme-%d", i),
+			VolumeMounts: []v1.VolumeMount{
+				{
+					Name:      KeycloakThemesVolumeName,
+					MountPath: keycloakThemeTargetPath,
+					SubPath:   theme.Name,
+				},
+			},
+			TerminationMessagePath:   "/dev/termination-log",
+			TerminationMessagePolicy: "FallbackToLogsOnError",
+			ImagePullPolicy:          cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+			SecurityContext:          KeycloakInitContainerSecurityContext(cr, theme.SecurityContext),
+		}
+
+		if theme.Image != "" {
+			initContainer.Image = theme.Image
+			initContainer.Command = []string{"cp", "-R", theme.Path + "/.", keycloakThemeTargetPath + "/"}
+		} else {
+			initContainer.Image = image
+			initContainer.Command = []string{"tar", "-xzf", keycloakThemeSourcePath + "/" + theme.ConfigMap.Key, "-C", keycloakThemeTargetPath}
+			initContainer.VolumeMounts = append(initContainer.VolumeMounts, v1.VolumeMount{
+				Name:      keycloakThemeSourceVolumeName(i),
+				MountPath: keycloakThemeSourcePath,
+				ReadOnly:  true,
+			})
+		}
+		initContainers = append(initContainers, initContainer)
+	}
+	return initContainers
+}
+
+// KeycloakThemeVolumeMounts mounts every theme next to the built-in ones in the themes directory.
+func KeycloakThemeVolumeMounts(cr *v1alpha1.Keycloak, themesPath string) []v1.VolumeMount {
+	var mountedVolumes []v1.VolumeMount
+	for _, theme := range cr.Spec.Themes {
+		mountedVolumes = append(mountedVolumes, v1.VolumeMount{
+			Name:      KeycloakThemesVolumeName,
+			MountPath: themesPath + "/" + theme.Name,
+			SubPath:   theme.Name,
+			ReadOnly:  true,
+		})
+	}
+	return mountedVolumes
+}
+
+func addThemeVolumes(cr *v1alpha1.Keycloak, volumes []v1.Volume) []v1.Volume {
+	if len(cr.Spec.Themes) == 0 {
+		return volumes
+	}
+
+	volumes = append(volumes, v1.Volume{
+		Name: KeycloakThemesVolumeName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	})
+	for i, theme := range cr.Spec.Themes {
+		if theme.ConfigMap == nil {
+			continue
+		}
+		volumes = append(volumes, v1.Volume{
+			Name: keycloakThemeSourceVolumeName(i),
+			VolumeSource: v1.VolumeSource{
+				ConfigMap: &v1.ConfigMapVolumeSource{
+					LocalObjectReference: theme.ConfigMap.LocalObjectReference,
+					Items: []v1.KeyToPath{
+						{
+							Key:  theme.ConfigMap.Key,
+							Path: theme.ConfigMap.Key,
+						},
+					},
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+func keycloakThemeSourceVolumeName(index int) string {
+	return fmt.Sprintf("theme-source-%d", index)
+}
+
+// ValidateKeycloakThemes checks that every theme has exactly one of configMap and image set.
+func ValidateKeycloakThemes(cr *v1alpha1.Keycloak) error {
+	names := make(map[string]bool)
+	for _, theme := range cr.Spec.Themes {
+		if names[theme.Name] {
+			return errors.Errorf("theme %v is listed more than once", theme.Name)
+		}
+		names[theme.Name] = true
+
+		switch {
+		case theme.ConfigMap == nil && theme.Image == "":
+			return errors.Errorf("theme %v needs either configMap or image to be set", theme.Name)
+		case theme.ConfigMap != nil && theme.Image != "":
+			return errors.Errorf("theme %v can't have both configMap and image set", theme.Name)
+		case theme.Image != "" && theme.Path == "":
+			return errors.Errorf("theme %v needs path to be set to be copied from image %v", theme.Name, theme.Image)
+		case theme.ConfigMap != nil && theme.ImagePullSecret != "":
+			return errors.Errorf("imagePullSecret of theme %v only applies to images", theme.Name)
+		}
+	}
+	return nil
+}
+
+// KeycloakThemesChecksum returns a checksum of the theme ConfigMaps referenced by the CR. Changes to the
+// images are already picked up as they're part of the pod template.
+func KeycloakThemesChecksum(cr *v1alpha1.Keycloak, configMaps map[string]*v1.ConfigMap) string {
+	hash := sha256.New()
+	for _, theme := range cr.Spec.Themes {
+		if theme.ConfigMap == nil {
+			continue
+		}
+		configMap := configMaps[theme.ConfigMap.Name]
+		if configMap == nil {
+			continue
+		}
+		fmt.Fprintf(hash, "%v/%v:", theme.Name, configMap.Name)
+		if value, ok := configMap.BinaryData[theme.ConfigMap.Key]; ok {
+			hash.Write(value)
+		} else {
+			hash.Write([]byte(configMap.Data[theme.ConfigMap.Key]))
+		}
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// SetKeycloakThemesChecksum annotates the pod template with the checksum of the theme ConfigMaps,
+// so that the pods are rolled when a theme changes.
+func SetKeycloakThemesChecksum(cr *v1alpha1.Keycloak, statefulSet *v13.StatefulSet, configMaps map[string]*v1.ConfigMap) {
+	checksum := ""
+	if len(KeycloakThemeConfigMapSelectors(cr)) > 0 {
+		checksum = KeycloakThemesChecksum(cr, configMaps)
+	}
+	setPodTemplateAnnotation(statefulSet, KeycloakThemesChecksumAnnotation, checksum)
+}
+
+// KeycloakThemeConfigMapSelectors returns the ConfigMaps holding theme archives.
+func KeycloakThemeConfigMapSelectors(cr *v1alpha1.Keycloak) []client.ObjectKey {
+	var selectors []client.ObjectKey
+	for _, theme := range cr.Spec.Themes {
+		if theme.ConfigMap != nil {
+			selectors = append(selectors, client.ObjectKey{
+				Name:      theme.ConfigMap.Name,
+				Namespace: cr.Namespace,
+			})
+		}
+	}
+	return selectors
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getThemesCR() *v1alpha1.Keycloak {
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.Themes = []v1alpha1.KeycloakTheme{
+		{
+			Name: "brand",
+			ConfigMap: &v1.ConfigMapKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: "brand-theme",
+				},
+				Key: "brand.tar.gz",
+			},
+		},
+		{
+			Name:            "partner",
+			Image:           "quay.io/example/partner-theme:1.0",
+			Path:            "/themes/partner",
+			ImagePullSecret: "example-registry",
+			SecurityContext: &v1.SecurityContext{RunAsNonRoot: &[]bool{false}[0]},
+		},
+	}
+	return cr
+}
+
+func TestKeycloakThemes_testInitContainers(t *testing.T) {
+	//given
+	cr := getThemesCR()
+
+	//when
+	initContainers := KeycloakThemesInitContainers(cr, Images.Images[KeycloakImage])
+
+	//then
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, Images.Images[KeycloakImage], initContainers[0].Image)
+	assert.Equal(t, []string{"tar", "-xzf", "/mnt/theme-source/brand.tar.gz", "-C", "/mnt/theme"}, initContainers[0].Command)
+	assert.Equal(t, "brand", initContainers[0].VolumeMounts[0].SubPath)
+	assert.Equal(t, "theme-source-0", initContainers[0].VolumeMounts[1].Name)
+	assert.Equal(t, "quay.io/example/partner-theme:1.0", initContainers[1].Image)
+	assert.Equal(t, []string{"cp", "-R", "/themes/partner/.", "/mnt/theme/"}, initContainers[1].Command)
+	assert.Equal(t, KeycloakContainerSecurityContext(cr), initContainers[0].SecurityContext)
+	assert.False(t, *initContainers[1].SecurityContext.RunAsNonRoot)
+}
+
+func TestKeycloakThemes_testDeployment(t *testing.T) {
+	//given
+	cr := getThemesCR()
+
+	//when
+	template := KeycloakDeployment(cr, &v1.Secret{}, nil).Spec.Template.Spec
+
+	//then
+	assert.Contains(t, template.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      KeycloakThemesVolumeName,
+		MountPath: KeycloakThemesPath + "/brand",
+		SubPath:   "brand",
+		ReadOnly:  true,
+	})
+	assert.Contains(t, template.Containers[0].VolumeMounts, v1.VolumeMount{
+		Name:      KeycloakThemesVolumeName,
+		MountPath: KeycloakThemesPath + "/partner",
+		SubPath:   "partner",
+		ReadOnly:  true,
+	})
+
+	var volumeNames []string
+	for _, volume := range template.Volumes {
+		volumeNames = append(volumeNames, volume.Name)
+	}
+	assert.Contains(t, volumeNames, KeycloakThemesVolumeName)
+	assert.Contains(t, volumeNames, "theme-source-0")
+	assert.NotContains(t, volumeNames, "theme-source-1")
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "example-registry"}}, template.ImagePullSecrets)
+}
+
+func TestKeycloakThemes_testChecksumChangesWithConfigMap(t *testing.T) {
+	//given
+	cr := getThemesCR()
+	configMap := &v1.ConfigMap{
+		ObjectMeta: v12.ObjectMeta{Name: "brand-theme"},
+		BinaryData: map[string][]byte{
+			"brand.tar.gz": []byte("version 1"),
+		},
+	}
+	statefulSet := KeycloakDeployment(cr, &v1.Secret{}, nil)
+
+	//when
+	SetKeycloakThemesChecksum(cr, statefulSet, map[string]*v1.ConfigMap{"brand-theme": configMap})
+	before := statefulSet.Spec.Template.Annotations[KeycloakThemesChecksumAnnotation]
+	configMap.BinaryData["brand.tar.gz"] = []byte("version 2")
+	SetKeycloakThemesChecksum(cr, statefulSet, map[string]*v1.ConfigMap{"brand-theme": configMap})
+	after := statefulSet.Spec.Template.Annotations[KeycloakThemesChecksumAnnotation]
+
+	//then
+	assert.NotEmpty(t, before)
+	assert.NotEqual(t, before, after)
+	assert.Nil(t, cr.Spec.KeycloakDeploymentSpec.PodAnnotations)
+}
+
+func TestKeycloakThemes_testValidate(t *testing.T) {
+	//given
+	cr := getThemesCR()
+
+	//then
+	assert.NoError(t, ValidateKeycloakThemes(cr))
+
+	cr.Spec.Themes[1].Path = ""
+	assert.Error(t, ValidateKeycloakThemes(cr))
+
+	cr.Spec.Themes[0].ImagePullSecret = "example-registry"
+	assert.Error(t, ValidateKeycloakThemes(cr))
+
+	cr.Spec.Themes[1] = v1alpha1.KeycloakTheme{Name: "partner"}
+	assert.Error(t, ValidateKeycloakThemes(cr))
+
+	cr.Spec.Themes[1] = cr.Spec.Themes[0]
+	assert.Error(t, ValidateKeycloakThemes(cr))
+}
@@ -1429,6 +1429,533 @@ const GrafanaDashboardJSON = `{
 				"align": false,
 				"alignLevel": null
 			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 7,
+				"x": 5,
+				"y": 35
+			  },
+			  "id": 52,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "histogram_quantile(0.95, sum(rate(keycloak_operator_reconcile_duration_seconds_bucket{namespace=\"$namespace\"}[5m])) by (le, kind))",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "{{kind}}",
+				  "refId": "A"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Reconcile Duration p95",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "format": "s",
+				  "label": "Duration",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 6,
+				"x": 12,
+				"y": 35
+			  },
+			  "id": 53,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "sum(increase(keycloak_operator_reconcile_duration_seconds_count{namespace=\"$namespace\"}[5m])) by (kind, result)",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "{{kind}} {{result}}",
+				  "refId": "A"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Reconciliations [5m]",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "decimals": 0,
+				  "format": "short",
+				  "label": "Reconciliations",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 6,
+				"x": 18,
+				"y": 35
+			  },
+			  "id": 54,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "max(keycloak_operator_resource_failing_seconds{resource_namespace=\"$namespace\"}) by (kind, name)",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "{{kind}} {{name}}",
+				  "refId": "A"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Failing Resources",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "format": "s",
+				  "label": "Failing for",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 6,
+				"x": 0,
+				"y": 40
+			  },
+			  "id": 55,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "histogram_quantile(0.95, sum(rate(keycloak_operator_desired_state_actions_bucket{namespace=\"$namespace\"}[5m])) by (le, kind))",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "{{kind}}",
+				  "refId": "A"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Actions per Reconcile p95",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "decimals": 0,
+				  "format": "short",
+				  "label": "Actions",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 10,
+				"x": 6,
+				"y": 40
+			  },
+			  "id": 56,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "histogram_quantile(0.95, sum(rate(keycloak_operator_admin_api_request_duration_seconds_bucket{namespace=\"$namespace\"}[5m])) by (le, method, endpoint))",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "{{method}} {{endpoint}}",
+				  "refId": "A"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Admin API Latency p95",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "format": "s",
+				  "label": "Duration",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
+			},
+			{
+			  "aliasColors": {},
+			  "bars": false,
+			  "dashLength": 10,
+			  "dashes": false,
+			  "fill": 1,
+			  "gridPos": {
+				"h": 5,
+				"w": 8,
+				"x": 16,
+				"y": 40
+			  },
+			  "id": 57,
+			  "legend": {
+				"avg": false,
+				"current": false,
+				"max": false,
+				"min": false,
+				"show": true,
+				"total": false,
+				"values": false
+			  },
+			  "lines": true,
+			  "linewidth": 1,
+			  "links": [],
+			  "nullPointMode": "null as zero",
+			  "options": {},
+			  "percentage": false,
+			  "pointradius": 2,
+			  "points": false,
+			  "renderer": "flot",
+			  "seriesOverrides": [],
+			  "spaceLength": 10,
+			  "stack": false,
+			  "steppedLine": false,
+			  "targets": [
+				{
+				  "expr": "sum(increase(keycloak_operator_admin_api_request_duration_seconds_count{namespace=\"$namespace\"}[5m])) by (status)",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "Status {{status}}",
+				  "refId": "A"
+				},
+				{
+				  "expr": "sum(increase(keycloak_operator_admin_token_refreshes_total{namespace=\"$namespace\"}[5m])) by (result)",
+				  "format": "time_series",
+				  "hide": false,
+				  "intervalFactor": 1,
+				  "legendFormat": "Token refresh {{result}}",
+				  "refId": "B"
+				}
+			  ],
+			  "thresholds": [],
+			  "timeFrom": null,
+			  "timeRegions": [],
+			  "timeShift": null,
+			  "title": "Admin API Requests [5m]",
+			  "tooltip": {
+				"shared": true,
+				"sort": 0,
+				"value_type": "individual"
+			  },
+			  "type": "graph",
+			  "xaxis": {
+				"buckets": null,
+				"mode": "time",
+				"name": null,
+				"show": true,
+				"values": []
+			  },
+			  "yaxes": [
+				{
+				  "decimals": 0,
+				  "format": "short",
+				  "label": "Requests",
+				  "logBase": 1,
+				  "max": null,
+				  "min": "0",
+				  "show": true
+				},
+				{
+				  "format": "short",
+				  "label": null,
+				  "logBase": 1,
+				  "max": null,
+				  "min": null,
+				  "show": false
+				}
+			  ],
+			  "yaxis": {
+				"align": false,
+				"alignLevel": null
+			  }
 			}
 		  ],
 		  "title": "Operator metrics",
@@ -14,21 +14,15 @@ func PostgresqlAWSPeriodicBackup(cr *v1alpha1.KeycloakBackup) *v1beta1.CronJob {
 		ObjectMeta: v12.ObjectMeta{
 			Name:      cr.Name,
 			Namespace: cr.Namespace,
-			Labels: map[string]string{
-				"app":       ApplicationName,
-				"component": PostgresqlBackupComponent,
-			},
+			Labels:    PostgresqlBackupLabels(cr),
 		},
 		Spec: v1beta1.CronJobSpec{
-			Schedule: cr.Spec.AWS.Schedule,
+			Schedule: PostgresqlBackupSchedule(cr),
 			JobTemplate: v1beta1.JobTemplateSpec{
 				ObjectMeta: v12.ObjectMeta{
 					Name:      cr.Name,
 					Namespace: cr.Namespace,
-					Labels: map[string]string{
-						"app":       ApplicationName,
-						"component": PostgresqlBackupComponent,
-					},
+					Labels:    PostgresqlBackupLabels(cr),
 				},
 				Spec: v13.JobSpec{
 					Template: v1.PodTemplateSpec{
@@ -53,7 +47,8 @@ func PostgresqlAWSPeriodicBackupSelector(cr *v1alpha1.KeycloakBackup) client.Obj
 
 func PostgresqlAWSPeriodicBackupReconciled(cr *v1alpha1.KeycloakBackup, currentState *v1beta1.CronJob) *v1beta1.CronJob {
 	reconciled := currentState.DeepCopy()
-	reconciled.Spec.Schedule = cr.Spec.AWS.Schedule
+	reconciled.Spec.Schedule = PostgresqlBackupSchedule(cr)
+	reconciled.Spec.JobTemplate.Labels = PostgresqlBackupLabels(cr)
 	reconciled.Spec.JobTemplate.Spec.Template.Spec.Containers = postgresqlAwsBackupCommonContainers(cr)
 	reconciled.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyNever
 	reconciled.Spec.JobTemplate.Spec.Template.Spec.ServiceAccountName = PostgresqlBackupServiceAccountName
@@ -8,7 +8,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func PostgresqlBackup(cr *v1alpha1.KeycloakBackup) *v13.Job {
+func PostgresqlBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v13.Job {
 	return &v13.Job{
 		ObjectMeta: v12.ObjectMeta{
 			Name:      cr.Name,
@@ -32,61 +32,7 @@ func PostgresqlBackup(cr *v1alpha1.KeycloakBackup) *v13.Job {
 						},
 					},
 					Containers: []v1.Container{
-						{
-							Name:    cr.Name,
-							Image:   Images.Images[PostgresqlImage],
-							Command: []string{"/bin/sh", "-c"},
-							Args:    []string{"pg_dump $POSTGRES_DB | tee /backup/backup.sql"},
-							Env: []v1.EnvVar{
-								{
-									Name: "POSTGRES_USER",
-									ValueFrom: &v1.EnvVarSource{
-										SecretKeyRef: &v1.SecretKeySelector{
-											LocalObjectReference: v1.LocalObjectReference{
-												Name: DatabaseSecretName,
-											},
-											Key: DatabaseSecretUsernameProperty,
-										},
-									},
-								},
-								{
-									Name: "PGUSER",
-									ValueFrom: &v1.EnvVarSource{
-										SecretKeyRef: &v1.SecretKeySelector{
-											LocalObjectReference: v1.LocalObjectReference{
-												Name: DatabaseSecretName,
-											},
-											Key: DatabaseSecretUsernameProperty,
-										},
-									},
-								},
-								{
-									Name: "PGPASSWORD",
-									ValueFrom: &v1.EnvVarSource{
-										SecretKeyRef: &v1.SecretKeySelector{
-											LocalObjectReference: v1.LocalObjectReference{
-												Name: DatabaseSecretName,
-											},
-											Key: DatabaseSecretPasswordProperty,
-										},
-									},
-								},
-								{
-									Name:  "POSTGRES_DB",
-									Value: PostgresqlDatabase,
-								},
-								{
-									Name:  "PGHOST",
-									Value: PostgresqlServiceName,
-								},
-							},
-							VolumeMounts: []v1.VolumeMount{
-								{
-									Name:      PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
-									MountPath: "/backup",
-								},
-							},
-						},
+						postgresqlBackupContainer(cr, keycloak),
 					},
 					RestartPolicy:      v1.RestartPolicyNever,
 					ServiceAccountName: PostgresqlBackupServiceAccountName,
@@ -103,7 +49,7 @@ func PostgresqlBackupSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
 	}
 }
 
-func PostgresqlBackupReconciled(cr *v1alpha1.KeycloakBackup, currentState *v13.Job) *v13.Job {
+func PostgresqlBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v13.Job) *v13.Job {
 	reconciled := currentState.DeepCopy()
 	reconciled.Spec.Template.Spec.Volumes = []v1.Volume{
 		{
@@ -116,63 +62,40 @@ func PostgresqlBackupReconciled(cr *v1alpha1.KeycloakBackup, currentState *v13.J
 		},
 	}
 	reconciled.Spec.Template.Spec.Containers = []v1.Container{
-		{
-			Name:    cr.Name,
-			Image:   Images.Images[PostgresqlImage],
-			Command: []string{"/bin/sh", "-c"},
-			Args:    []string{"pg_dump $POSTGRES_DB | tee /backup/backup.sql"},
-			Env: []v1.EnvVar{
-				{
-					Name: "POSTGRES_USER",
-					ValueFrom: &v1.EnvVarSource{
-						SecretKeyRef: &v1.SecretKeySelector{
-							LocalObjectReference: v1.LocalObjectReference{
-								Name: DatabaseSecretName,
-							},
-							Key: DatabaseSecretUsernameProperty,
-						},
-					},
-				},
-				{
-					Name: "PGUSER",
-					ValueFrom: &v1.EnvVarSource{
-						SecretKeyRef: &v1.SecretKeySelector{
-							LocalObjectReference: v1.LocalObjectReference{
-								Name: DatabaseSecretName,
-							},
-							Key: DatabaseSecretUsernameProperty,
-						},
-					},
-				},
-				{
-					Name: "PGPASSWORD",
-					ValueFrom: &v1.EnvVarSource{
-						SecretKeyRef: &v1.SecretKeySelector{
-							LocalObjectReference: v1.LocalObjectReference{
-								Name: DatabaseSecretName,
-							},
-							Key: DatabaseSecretPasswordProperty,
-						},
-					},
-				},
-				{
-					Name:  "POSTGRES_DB",
-					Value: PostgresqlDatabase,
-				},
-				{
-					Name:  "PGHOST",
-					Value: PostgresqlServiceName,
-				},
-			},
-			VolumeMounts: []v1.VolumeMount{
-				{
-					Name:      PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
-					MountPath: "/backup",
-				},
-			},
-		},
+		postgresqlBackupContainer(cr, keycloak),
 	}
 	reconciled.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyNever
 	reconciled.Spec.Template.Spec.ServiceAccountName = PostgresqlBackupServiceAccountName
 	return reconciled
 }
+
+// postgresqlBackupContainer returns the container dumping the database of the Keycloak instance into the claim
+// of the backup
+func postgresqlBackupContainer(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.Container {
+	container := v1.Container{
+		Name:    cr.Name,
+		Image:   DatabaseClientImage(keycloak),
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{databaseDumpCommand(keycloak) + " | tee /backup/backup.sql"},
+		Env: []v1.EnvVar{
+			postgresqlBackupSecretEnvVar("POSTGRES_USER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+			postgresqlBackupSecretEnvVar("PGUSER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+			postgresqlBackupSecretEnvVar("PGPASSWORD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+			postgresqlBackupSecretEnvVar("POSTGRES_DB", DatabaseSecretName, DatabaseSecretDatabaseProperty, false),
+			{
+				Name:  "PGHOST",
+				Value: PostgresqlServiceName,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      PostgresqlBackupPersistentVolumeName + "-" + cr.Name,
+				MountPath: "/backup",
+			},
+		},
+	}
+	if isMySQLDatabase(keycloak) {
+		container.Env = mysqlClientEnv()
+	}
+	return container
+}
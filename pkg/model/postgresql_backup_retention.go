@@ -0,0 +1,138 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Backups are sorted newest first by their timestamped names. A backup is kept if any rule keeps it, files
+	// sharing a timestamp belong to the same backup and are kept or deleted together. The retained ones are
+	// written to the termination message for the operator to report in the status. As termination messages
+	// are limited to 4096 bytes, only the 50 most recent ones are reported.
+	postgresqlBackupPruneScript = `list_backups | sort -r -k1,1 | awk -v prefix="$BACKUP_FILE_PREFIX" -v keepLast="${KEEP_LAST:-0}" -v keepDaily="${KEEP_DAILY:-0}" -v keepWeekly="${KEEP_WEEKLY:-0}" -v keepMonthly="${KEEP_MONTHLY:-0}" '
+function days(stamp,  y, m, d) {
+  y = substr(stamp, 1, 4) + 0; m = substr(stamp, 5, 2) + 0; d = substr(stamp, 7, 2) + 0
+  if (m < 3) { y--; m += 12 }
+  return 365 * y + int(y / 4) - int(y / 100) + int(y / 400) + int((153 * (m - 3) + 2) / 5) + d
+}
+match($1, prefix "[0-9]+T[0-9]+Z") {
+  backup = substr($1, RSTART + length(prefix), RLENGTH - length(prefix))
+  if (backup != last) {
+    last = backup; stamp = substr(backup, 1, 8)
+    day = days(stamp); week = int((day - days("19700105")) / 7); month = substr(stamp, 1, 6)
+    keep = keepLast + keepDaily + keepWeekly + keepMonthly == 0 || ++count <= keepLast
+    if (!(day in daily) && dailyCount < keepDaily) { daily[day] = 1; dailyCount++; keep = 1 }
+    if (!(week in weekly) && weeklyCount < keepWeekly) { weekly[week] = 1; weeklyCount++; keep = 1 }
+    if (!(month in monthly) && monthlyCount < keepMonthly) { monthly[month] = 1; monthlyCount++; keep = 1 }
+  }
+  print (keep ? "keep" : "delete"), $1, $2
+}' | while read -r action name size; do
+  if [ "$action" = "delete" ]; then
+    delete_backup "$name"
+  else
+    echo "$name $size"
+  fi
+done | awk "NR <= 50" > /dev/termination-log
+`
+)
+
+var backupArtifactTimestamp = regexp.MustCompile(`(?:` + PostgresqlBackupFilePrefix + `|` + KeycloakRealmBackupFilePrefix + `)(\d{8}T\d{6}Z)`)
+
+// ValidateKeycloakBackupRetention checks that retention and verification are only used where the operator
+// manages the backup Job.
+func ValidateKeycloakBackupRetention(cr *v1alpha1.KeycloakBackup) error {
+	if PostgresqlBackupDestination(cr) != nil {
+		return nil
+	}
+	switch {
+	case cr.Spec.Retention != nil:
+		return errors.Errorf("backup %v can't use retention with aws, use destination.s3 instead", cr.Name)
+	case cr.Spec.Verification.Enabled:
+		return errors.Errorf("backup %v can't use verification with aws, use destination.s3 instead", cr.Name)
+	}
+	return nil
+}
+
+func postgresqlBackupRetentionEnv(cr *v1alpha1.KeycloakBackup) []v1.EnvVar {
+	retention := cr.Spec.Retention
+	if retention == nil {
+		return nil
+	}
+
+	var env []v1.EnvVar
+	for _, rule := range []struct {
+		name  string
+		value int32
+	}{
+		{"KEEP_LAST", retention.KeepLast},
+		{"KEEP_DAILY", retention.KeepDaily},
+		{"KEEP_WEEKLY", retention.KeepWeekly},
+		{"KEEP_MONTHLY", retention.KeepMonthly},
+	} {
+		if rule.value > 0 {
+			env = append(env, v1.EnvVar{Name: rule.name, Value: fmt.Sprint(rule.value)})
+		}
+	}
+	return env
+}
+
+// PostgresqlBackupArtifacts returns the backups retained after the most recent successful backup pod,
+// as reported in the termination message of its backup container.
+func PostgresqlBackupArtifacts(cr *v1alpha1.KeycloakBackup, pods []v1.Pod) ([]v1alpha1.KeycloakBackupArtifact, bool) {
+	var latest *v1.ContainerStateTerminated
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != cr.Name || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+			if latest == nil || latest.FinishedAt.Before(&terminated.FinishedAt) {
+				latest = terminated
+			}
+		}
+	}
+	if latest == nil {
+		return nil, false
+	}
+
+	var artifacts []v1alpha1.KeycloakBackupArtifact
+	scanner := bufio.NewScanner(strings.NewReader(latest.Message))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		match := backupArtifactTimestamp.FindStringSubmatch(fields[0])
+		if match == nil {
+			continue
+		}
+		timestamp, err := time.Parse("20060102T150405Z", match[1])
+		if err != nil {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, v1alpha1.KeycloakBackupArtifact{
+			Name:      fields[0],
+			Timestamp: v12.NewTime(timestamp),
+			Size:      size,
+		})
+	}
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return artifacts[j].Timestamp.Before(&artifacts[i].Timestamp)
+	})
+	return artifacts, true
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlBackupRetention_testScheduledLocalBackup(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Schedule = "0 3 * * *"
+	cr.Spec.Retention = &v1alpha1.KeycloakBackupRetention{
+		KeepLast:    3,
+		KeepMonthly: 6,
+	}
+
+	//when
+	cronJob := PostgresqlDestinationPeriodicBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	assert.Equal(t, "0 3 * * *", cronJob.Spec.Schedule)
+	assert.Equal(t, "backup", cronJob.Spec.JobTemplate.Spec.Template.Labels[PostgresqlBackupLabel])
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, PostgresqlBackupPersistentVolumeName+"-backup", podSpec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	backup := podSpec.Containers[0]
+	assert.Contains(t, backup.Args[0], "delete_backup")
+	assert.Contains(t, backup.Env, v1.EnvVar{Name: "KEEP_LAST", Value: "3"})
+	assert.Contains(t, backup.Env, v1.EnvVar{Name: "KEEP_MONTHLY", Value: "6"})
+	assert.Empty(t, findEnvVar(backup.Env, "KEEP_DAILY").Name)
+}
+
+func TestPostgresqlBackupRetention_testLegacyBackups(t *testing.T) {
+	//given
+	local := &v1alpha1.KeycloakBackup{}
+	aws := &v1alpha1.KeycloakBackup{}
+	aws.Spec.AWS.CredentialsSecretName = "aws-secret"
+	aws.Spec.Schedule = "0 3 * * *"
+
+	//then
+	assert.Nil(t, PostgresqlBackupDestination(local))
+	assert.Nil(t, PostgresqlBackupDestination(aws))
+	assert.Equal(t, "0 3 * * *", PostgresqlAWSPeriodicBackup(aws).Spec.Schedule)
+	assert.NoError(t, ValidateKeycloakBackupRetention(aws))
+
+	aws.Spec.Retention = &v1alpha1.KeycloakBackupRetention{KeepLast: 3}
+	assert.Error(t, ValidateKeycloakBackupRetention(aws))
+}
+
+func TestPostgresqlBackupRetention_testArtifacts(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	pods := []v1.Pod{
+		backupPod("backup", 0, time.Date(2024, 3, 1, 3, 5, 0, 0, time.UTC), "keycloak-backup-20240301T030000Z.sql.gz 10\n"),
+		backupPod("backup", 0, time.Date(2024, 3, 2, 3, 5, 0, 0, time.UTC), "sso/keycloak-backup-20240301T030000Z.sql.gz 10\nsso/keycloak-backup-20240302T030000Z.sql.gz 20\n"),
+		backupPod("backup", 1, time.Date(2024, 3, 3, 3, 5, 0, 0, time.UTC), "upload failed"),
+		backupPod("dump", 0, time.Date(2024, 3, 3, 3, 5, 0, 0, time.UTC), ""),
+	}
+
+	//when
+	artifacts, ok := PostgresqlBackupArtifacts(cr, pods)
+
+	//then
+	assert.True(t, ok)
+	assert.Len(t, artifacts, 2)
+	assert.Equal(t, "sso/keycloak-backup-20240302T030000Z.sql.gz", artifacts[0].Name)
+	assert.Equal(t, int64(20), artifacts[0].Size)
+	assert.True(t, artifacts[0].Timestamp.Equal(&v12.Time{Time: time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC)}))
+	assert.Equal(t, "sso/keycloak-backup-20240301T030000Z.sql.gz", artifacts[1].Name)
+}
+
+func TestPostgresqlBackupRetention_testRealmArtifacts(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "realms"
+	cr.Spec.Mode = v1alpha1.BackupModeRealms
+	pods := []v1.Pod{
+		backupPod("realms", 0, time.Date(2024, 3, 2, 3, 5, 0, 0, time.UTC), "keycloak-realms-20240302T030000Z/master.json 10\nkeycloak-realms-20240302T030000Z/demo.json 20\nkeycloak-realms-20240301T030000Z/master.json 10\n"),
+	}
+
+	//when
+	artifacts, ok := PostgresqlBackupArtifacts(cr, pods)
+
+	//then
+	assert.True(t, ok)
+	assert.Len(t, artifacts, 3)
+	assert.Equal(t, "keycloak-realms-20240301T030000Z/master.json", artifacts[2].Name)
+}
+
+func TestPostgresqlBackupRetention_testNoSuccessfulRun(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+
+	//when
+	_, ok := PostgresqlBackupArtifacts(cr, []v1.Pod{{}})
+
+	//then
+	assert.False(t, ok)
+}
+
+func backupPod(container string, exitCode int32, finishedAt time.Time, message string) v1.Pod {
+	return v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: container,
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							ExitCode:   exitCode,
+							FinishedAt: v12.NewTime(finishedAt),
+							Message:    message,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	PostgresqlBackupHistoryLimit = 10
+
+	PostgresqlBackupVerificationDefaultQuery = "SELECT count(*) FROM realm"
+
+	postgresqlBackupVerificationName = "verify"
+	postgresqlBackupVerificationPath = "/verify"
+
+	// The dump is restored into a throwaway PostgreSQL instance only listening on a socket in the emptyDir. The
+	// image runs with an arbitrary user ID, which initdb needs a passwd entry for.
+	postgresqlBackupVerificationScript = `set -eo pipefail
+if ! whoami > /dev/null 2>&1; then
+  echo "postgres:x:$(id -u):$(id -g)::` + postgresqlBackupVerificationPath + `:/bin/sh" > ` + postgresqlBackupVerificationPath + `/passwd
+  export NSS_WRAPPER_PASSWD=` + postgresqlBackupVerificationPath + `/passwd NSS_WRAPPER_GROUP=/etc/group LD_PRELOAD=libnss_wrapper.so
+fi
+export PGDATA=` + postgresqlBackupVerificationPath + `/data PGHOST=` + postgresqlBackupVerificationPath + ` PGUSER=postgres PGDATABASE=postgres
+initdb --username=postgres --auth=trust > /dev/null
+pg_ctl start --wait --silent -o "-c listen_addresses='' -c unix_socket_directories=` + postgresqlBackupVerificationPath + `"
+psql -q -c "CREATE ROLE \"$DATABASE_USER\" LOGIN"
+gunzip -c ` + postgresqlBackupStagingPath + `/` + PostgresqlBackupFilePrefix + `*.sql.gz | psql -q -v ON_ERROR_STOP=1 > /dev/null
+result=$(psql -tA -c "$VERIFICATION_QUERY")
+pg_ctl stop --wait --silent -m fast
+if [ -z "$result" ] || [ "$result" = "0" ]; then
+  echo "verification query returned '$result'" > /dev/termination-log
+  exit 1
+fi
+`
+)
+
+func postgresqlBackupVerificationVolume() v1.Volume {
+	return v1.Volume{
+		Name: postgresqlBackupVerificationName,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
+// The verification runs as init container after the dump, a broken backup fails the Job before it gets stored
+func postgresqlBackupVerificationContainer(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.Container {
+	query := cr.Spec.Verification.Query
+	if query == "" {
+		query = PostgresqlBackupVerificationDefaultQuery
+	}
+
+	return v1.Container{
+		Name:    postgresqlBackupVerificationName,
+		Image:   PostgresqlClientImage(keycloak),
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{postgresqlBackupVerificationScript},
+		Env: []v1.EnvVar{
+			postgresqlBackupSecretEnvVar("DATABASE_USER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+			{
+				Name:  "VERIFICATION_QUERY",
+				Value: query,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      postgresqlBackupVolumeName,
+				MountPath: postgresqlBackupPath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      postgresqlBackupVerificationName,
+				MountPath: postgresqlBackupVerificationPath,
+			},
+		},
+	}
+}
+
+// PostgresqlBackupHistory merges the runs of the given backup Jobs into the history in the status of the
+// KeycloakBackup. Runs of Jobs which have been cleaned up are kept until they fall out of the bounded history.
+func PostgresqlBackupHistory(cr *v1alpha1.KeycloakBackup, jobs []v13.Job, pods []v1.Pod) []v1alpha1.KeycloakBackupRun {
+	runs := make(map[string]v1alpha1.KeycloakBackupRun)
+	for _, run := range cr.Status.History {
+		runs[run.JobName] = run
+	}
+	for _, job := range jobs {
+		runs[job.Name] = postgresqlBackupRun(cr, job, pods)
+	}
+
+	history := make([]v1alpha1.KeycloakBackupRun, 0, len(runs))
+	for _, run := range runs {
+		history = append(history, run)
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		switch {
+		case history[i].StartTime == nil:
+			return history[j].StartTime != nil || history[i].JobName > history[j].JobName
+		case history[j].StartTime == nil:
+			return false
+		case history[i].StartTime.Equal(history[j].StartTime):
+			return history[i].JobName > history[j].JobName
+		default:
+			return history[j].StartTime.Before(history[i].StartTime)
+		}
+	})
+
+	if len(history) > PostgresqlBackupHistoryLimit {
+		history = history[:PostgresqlBackupHistoryLimit]
+	}
+	return history
+}
+
+func postgresqlBackupRun(cr *v1alpha1.KeycloakBackup, job v13.Job, pods []v1.Pod) v1alpha1.KeycloakBackupRun {
+	run := v1alpha1.KeycloakBackupRun{
+		JobName:        job.Name,
+		Phase:          v1alpha1.BackupRunPhaseRunning,
+		StartTime:      job.Status.StartTime,
+		CompletionTime: job.Status.CompletionTime,
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case v13.JobComplete:
+			run.Phase = v1alpha1.BackupRunPhaseSucceeded
+		case v13.JobFailed:
+			run.Phase = v1alpha1.BackupRunPhaseFailed
+		}
+	}
+
+	var jobPods []v1.Pod
+	for _, pod := range pods {
+		if pod.Labels["job-name"] == job.Name {
+			jobPods = append(jobPods, pod)
+		}
+	}
+
+	// The newest retained backup is the one created by the run, realm backups consist of a file per realm
+	if artifacts, ok := PostgresqlBackupArtifacts(cr, jobPods); ok && len(artifacts) > 0 {
+		for _, artifact := range artifacts {
+			if artifact.Timestamp.Equal(&artifacts[0].Timestamp) {
+				run.Size += artifact.Size
+			}
+		}
+	}
+
+	if cr.Spec.Verification.Enabled {
+		for _, pod := range jobPods {
+			for _, status := range pod.Status.InitContainerStatuses {
+				if status.Name != postgresqlBackupVerificationName || status.State.Terminated == nil {
+					continue
+				}
+				if status.State.Terminated.ExitCode == 0 {
+					run.Verification = v1alpha1.BackupVerificationPassed
+				} else if run.Verification == "" {
+					run.Verification = v1alpha1.BackupVerificationFailed
+				}
+			}
+		}
+	}
+	return run
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v13 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPostgresqlBackupVerification_testVerificationContainer(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Verification.Enabled = true
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		S3: &v1alpha1.KeycloakBackupS3Destination{
+			Bucket:                "keycloak",
+			CredentialsSecretName: "s3-secret",
+		},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	initContainers := job.Spec.Template.Spec.InitContainers
+	assert.Len(t, initContainers, 2)
+	assert.Equal(t, "dump", initContainers[0].Name)
+	assert.Equal(t, "verify", initContainers[1].Name)
+	assert.Contains(t, initContainers[1].Env, v1.EnvVar{Name: "VERIFICATION_QUERY", Value: PostgresqlBackupVerificationDefaultQuery})
+	assert.Equal(t, "backup", job.Spec.Template.Labels[PostgresqlBackupLabel])
+}
+
+func TestPostgresqlBackupVerification_testNoVerificationByDefault(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	assert.Len(t, job.Spec.Template.Spec.InitContainers, 1)
+}
+
+func TestPostgresqlBackupVerification_testVerificationRequiresManagedJob(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Spec.Verification.Enabled = true
+
+	//then
+	assert.NotNil(t, PostgresqlBackupDestination(cr))
+	assert.NoError(t, ValidateKeycloakBackupRetention(cr))
+
+	cr.Spec.AWS.CredentialsSecretName = "aws-secret"
+	assert.Error(t, ValidateKeycloakBackupRetention(cr))
+}
+
+func TestPostgresqlBackupHistory_testRuns(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Verification.Enabled = true
+	start := time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC)
+	cr.Status.History = []v1alpha1.KeycloakBackupRun{
+		{JobName: "backup-1", Phase: v1alpha1.BackupRunPhaseSucceeded, StartTime: &v12.Time{Time: start.Add(-48 * time.Hour)}},
+		{JobName: "backup-2", Phase: v1alpha1.BackupRunPhaseRunning, StartTime: &v12.Time{Time: start.Add(-24 * time.Hour)}},
+	}
+	jobs := []v13.Job{
+		backupJob("backup-2", start.Add(-24*time.Hour), v13.JobFailed),
+		backupJob("backup-3", start, v13.JobComplete),
+	}
+	succeeded := backupPod("backup", 0, start.Add(5*time.Minute), "keycloak-backup-20240302T030000Z.sql.gz 2048\nkeycloak-backup-20240301T030000Z.sql.gz 1024\n")
+	succeeded.Labels = map[string]string{"job-name": "backup-3"}
+	succeeded.Status.InitContainerStatuses = []v1.ContainerStatus{verifyStatus(0)}
+	failed := v1.Pod{}
+	failed.Labels = map[string]string{"job-name": "backup-2"}
+	failed.Status.InitContainerStatuses = []v1.ContainerStatus{verifyStatus(1)}
+
+	//when
+	history := PostgresqlBackupHistory(cr, jobs, []v1.Pod{succeeded, failed})
+
+	//then
+	assert.Len(t, history, 3)
+	assert.Equal(t, "backup-3", history[0].JobName)
+	assert.Equal(t, v1alpha1.BackupRunPhaseSucceeded, history[0].Phase)
+	assert.Equal(t, int64(2048), history[0].Size)
+	assert.Equal(t, v1alpha1.BackupVerificationPassed, history[0].Verification)
+	assert.Equal(t, "backup-2", history[1].JobName)
+	assert.Equal(t, v1alpha1.BackupRunPhaseFailed, history[1].Phase)
+	assert.Equal(t, v1alpha1.BackupVerificationFailed, history[1].Verification)
+	assert.Equal(t, "backup-1", history[2].JobName)
+}
+
+func TestPostgresqlBackupHistory_testBounded(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	start := time.Date(2024, 3, 2, 3, 0, 0, 0, time.UTC)
+	var jobs []v13.Job
+	for i := 0; i < PostgresqlBackupHistoryLimit+5; i++ {
+		jobs = append(jobs, backupJob(start.Add(time.Duration(i)*time.Hour).Format("backup-150405"), start.Add(time.Duration(i)*time.Hour), v13.JobComplete))
+	}
+
+	//when
+	history := PostgresqlBackupHistory(cr, jobs, nil)
+
+	//then
+	assert.Len(t, history, PostgresqlBackupHistoryLimit)
+	assert.Equal(t, jobs[len(jobs)-1].Name, history[0].JobName)
+}
+
+func backupJob(name string, start time.Time, condition v13.JobConditionType) v13.Job {
+	job := v13.Job{}
+	job.Name = name
+	job.Status.StartTime = &v12.Time{Time: start}
+	job.Status.Conditions = []v13.JobCondition{
+		{
+			Type:   condition,
+			Status: v1.ConditionTrue,
+		},
+	}
+	return job
+}
+
+func verifyStatus(exitCode int32) v1.ContainerStatus {
+	return v1.ContainerStatus{
+		Name: "verify",
+		State: v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				ExitCode: exitCode,
+			},
+		},
+	}
+}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The API types of the Postgres operators aren't a dependency of the operator, their clusters are managed as
+// unstructured objects
+var (
+	CloudNativePGClusterGroupVersionKind = schema.GroupVersionKind{
+		Group:   "postgresql.cnpg.io",
+		Version: "v1",
+		Kind:    "Cluster",
+	}
+	ZalandoPostgresqlGroupVersionKind = schema.GroupVersionKind{
+		Group:   "acid.zalan.do",
+		Version: "v1",
+		Kind:    "postgresql",
+	}
+)
+
+const (
+	postgresqlClusterZalandoTeam = ApplicationName
+	postgresqlClusterReadyPhase  = "Cluster in healthy state"
+	postgresqlClusterRunning     = "Running"
+)
+
+func PostgresqlClusterGroupVersionKind(provider v1alpha1.PostgresqlProvider) schema.GroupVersionKind {
+	if provider == v1alpha1.PostgresqlProviderZalando {
+		return ZalandoPostgresqlGroupVersionKind
+	}
+	return CloudNativePGClusterGroupVersionKind
+}
+
+// PostgresqlCluster returns the highly available database cluster created through the given Postgres operator
+func PostgresqlCluster(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider) *unstructured.Unstructured {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(PostgresqlClusterGroupVersionKind(provider))
+	cluster.SetName(PostgresqlClusterName)
+	cluster.SetNamespace(cr.Namespace)
+	cluster.SetLabels(map[string]string{
+		"app":       ApplicationName,
+		"component": PostgresqlDeploymentComponent,
+	})
+	cluster.Object["spec"] = postgresqlClusterSpec(cr, provider)
+	return cluster
+}
+
+func PostgresqlClusterSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      PostgresqlClusterName,
+		Namespace: cr.Namespace,
+	}
+}
+
+// The spec is merged into the current one, both operators default fields of the spec which mustn't be dropped
+func PostgresqlClusterReconciled(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider, currentState *unstructured.Unstructured) *unstructured.Unstructured {
+	reconciled := currentState.DeepCopy()
+	spec, ok := reconciled.Object["spec"].(map[string]interface{})
+	if !ok {
+		spec = map[string]interface{}{}
+	}
+	for key, value := range postgresqlClusterSpec(cr, provider) {
+		if key == "bootstrap" {
+			// The bootstrap only applies to the creation of the cluster
+			continue
+		}
+		spec[key] = value
+	}
+	reconciled.Object["spec"] = spec
+	return reconciled
+}
+
+func postgresqlClusterSpec(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider) map[string]interface{} {
+	highAvailability := cr.Spec.PostgresDeploymentSpec.HighAvailability
+	instances := int64(PostgresqlClusterInstances)
+	if highAvailability.Instances > 0 {
+		instances = int64(highAvailability.Instances)
+	}
+	storageSize := highAvailability.StorageSize
+	if storageSize == "" {
+		storageSize = PostgresqlPersistentVolumeCapacity
+	}
+
+	if provider == v1alpha1.PostgresqlProviderZalando {
+		volume := map[string]interface{}{
+			"size": storageSize,
+		}
+		if cr.Spec.StorageClassName != nil {
+			volume["storageClass"] = *cr.Spec.StorageClassName
+		}
+		spec := map[string]interface{}{
+			"teamId":            postgresqlClusterZalandoTeam,
+			"numberOfInstances": instances,
+			"volume":            volume,
+			"users": map[string]interface{}{
+				PostgresqlUsername: []interface{}{},
+			},
+			"databases": map[string]interface{}{
+				PostgresqlDatabase: PostgresqlUsername,
+			},
+			"postgresql": map[string]interface{}{
+				"version": PostgresqlClusterVersion,
+			},
+		}
+		if resources := postgresqlClusterResources(cr); len(resources) > 0 {
+			spec["resources"] = resources
+		}
+		return spec
+	}
+
+	storage := map[string]interface{}{
+		"size": storageSize,
+	}
+	if cr.Spec.StorageClassName != nil {
+		storage["storageClass"] = *cr.Spec.StorageClassName
+	}
+	spec := map[string]interface{}{
+		"instances": instances,
+		"imageName": Images.Images[PostgresqlClusterImage],
+		"storage":   storage,
+		"bootstrap": map[string]interface{}{
+			"initdb": map[string]interface{}{
+				"database": PostgresqlDatabase,
+				"owner":    PostgresqlUsername,
+			},
+		},
+	}
+	if resources := postgresqlClusterResources(cr); len(resources) > 0 {
+		spec["resources"] = resources
+	}
+	return spec
+}
+
+func postgresqlClusterResources(cr *v1alpha1.Keycloak) map[string]interface{} {
+	requirements := getPostgresResources(cr)
+	resources := map[string]interface{}{}
+	if list := postgresqlClusterResourceList(requirements.Requests); len(list) > 0 {
+		resources["requests"] = list
+	}
+	if list := postgresqlClusterResourceList(requirements.Limits); len(list) > 0 {
+		resources["limits"] = list
+	}
+	return resources
+}
+
+func postgresqlClusterResourceList(list v1.ResourceList) map[string]interface{} {
+	resources := map[string]interface{}{}
+	for name, quantity := range list {
+		resources[string(name)] = quantity.String()
+	}
+	return resources
+}
+
+// PostgresqlClusterReadWriteServiceName returns the Service the Postgres operator points at the current primary
+func PostgresqlClusterReadWriteServiceName(provider v1alpha1.PostgresqlProvider) string {
+	if provider == v1alpha1.PostgresqlProviderZalando {
+		return PostgresqlClusterName
+	}
+	return PostgresqlClusterName + "-rw"
+}
+
+// PostgresqlClusterSecretSelector returns the secret the Postgres operator stores the credentials of the
+// Keycloak user in
+func PostgresqlClusterSecretSelector(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider) client.ObjectKey {
+	name := PostgresqlClusterName + "-app"
+	if provider == v1alpha1.PostgresqlProviderZalando {
+		name = fmt.Sprintf("%v.%v.credentials.%v.%v", PostgresqlUsername, PostgresqlClusterName,
+			ZalandoPostgresqlGroupVersionKind.Kind, ZalandoPostgresqlGroupVersionKind.Group)
+	}
+	return client.ObjectKey{
+		Name:      name,
+		Namespace: cr.Namespace,
+	}
+}
+
+// PostgresqlClusterService returns the keycloak-postgresql Service pointing at the read-write Service of the
+// cluster. Keycloak and the backup Jobs keep connecting to keycloak-postgresql, failovers are transparent to them.
+func PostgresqlClusterService(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      PostgresqlServiceName,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app": ApplicationName,
+			},
+		},
+		Spec: postgresqlClusterServiceSpec(cr, provider),
+	}
+}
+
+func PostgresqlClusterServiceReconciled(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider, currentState *v1.Service) *v1.Service {
+	reconciled := currentState.DeepCopy()
+	reconciled.Spec = postgresqlClusterServiceSpec(cr, provider)
+	return reconciled
+}
+
+func postgresqlClusterServiceSpec(cr *v1alpha1.Keycloak, provider v1alpha1.PostgresqlProvider) v1.ServiceSpec {
+	return v1.ServiceSpec{
+		Type:         v1.ServiceTypeExternalName,
+		ExternalName: fmt.Sprintf("%v.%v.svc.cluster.local", PostgresqlClusterReadWriteServiceName(provider), cr.Namespace),
+		Ports: []v1.ServicePort{
+			{
+				Port:       5432,
+				TargetPort: intstr.Parse("5432"),
+			},
+		},
+	}
+}
+
+// PostgresqlClusterDatabaseSecretReconciled copies the credentials generated by the Postgres operator into the
+// database secret
+func PostgresqlClusterDatabaseSecretReconciled(databaseSecret *v1.Secret, clusterSecret *v1.Secret) *v1.Secret {
+	reconciled := databaseSecret.DeepCopy()
+	if clusterSecret == nil {
+		return reconciled
+	}
+	if username, ok := clusterSecret.Data["username"]; ok {
+		reconciled.Data[DatabaseSecretUsernameProperty] = username
+	}
+	if password, ok := clusterSecret.Data["password"]; ok {
+		reconciled.Data[DatabaseSecretPasswordProperty] = password
+	}
+	reconciled.Data[DatabaseSecretVersionProperty] = []byte(PostgresqlClusterVersion)
+	return reconciled
+}
+
+// IsPostgresqlClusterReady reports whether the Postgres operator considers the cluster healthy
+func IsPostgresqlClusterReady(cluster *unstructured.Unstructured) bool {
+	if cluster == nil {
+		return false
+	}
+	if cluster.GroupVersionKind().GroupKind() == ZalandoPostgresqlGroupVersionKind.GroupKind() {
+		status, _, _ := unstructured.NestedString(cluster.Object, "status", "PostgresClusterStatus")
+		return status == postgresqlClusterRunning
+	}
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	return phase == postgresqlClusterReadyPhase
+}
+
+// PostgresqlClientImage returns the image the database clients of the backup, restore and clone Jobs run in.
+// Their pg_dump has to be at least as recent as the server, during a major version upgrade the one of the
+// new version dumps the previous database.
+func PostgresqlClientImage(cr *v1alpha1.Keycloak) string {
+	if cr != nil && !cr.Spec.ExternalDatabase.Enabled && cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled {
+		return Images.Images[PostgresqlClusterImage]
+	}
+	return PostgresqlVersionImage(PostgresqlVersion(cr))
+}
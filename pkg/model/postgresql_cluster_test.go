@@ -0,0 +1,113 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPostgresqlCluster_testCloudNativePGCluster(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.StorageClassName = &[]string{"fast"}[0]
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Instances = 2
+
+	//when
+	cluster := PostgresqlCluster(cr, v1alpha1.PostgresqlProviderCloudNativePG)
+
+	//then
+	assert.Equal(t, CloudNativePGClusterGroupVersionKind, cluster.GroupVersionKind())
+	assert.Equal(t, PostgresqlClusterName, cluster.GetName())
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	assert.Equal(t, int64(2), instances)
+	storageClass, _, _ := unstructured.NestedString(cluster.Object, "spec", "storage", "storageClass")
+	assert.Equal(t, "fast", storageClass)
+	owner, _, _ := unstructured.NestedString(cluster.Object, "spec", "bootstrap", "initdb", "owner")
+	assert.Equal(t, PostgresqlUsername, owner)
+	assert.Equal(t, "keycloak-db-app", PostgresqlClusterSecretSelector(cr, v1alpha1.PostgresqlProviderCloudNativePG).Name)
+}
+
+func TestPostgresqlCluster_testZalandoCluster(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.StorageSize = "10Gi"
+
+	//when
+	cluster := PostgresqlCluster(cr, v1alpha1.PostgresqlProviderZalando)
+
+	//then
+	assert.Equal(t, ZalandoPostgresqlGroupVersionKind, cluster.GroupVersionKind())
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "numberOfInstances")
+	assert.Equal(t, int64(PostgresqlClusterInstances), instances)
+	size, _, _ := unstructured.NestedString(cluster.Object, "spec", "volume", "size")
+	assert.Equal(t, "10Gi", size)
+	owner, _, _ := unstructured.NestedString(cluster.Object, "spec", "databases", PostgresqlDatabase)
+	assert.Equal(t, PostgresqlUsername, owner)
+	assert.Equal(t, "keycloak.keycloak-db.credentials.postgresql.acid.zalan.do", PostgresqlClusterSecretSelector(cr, v1alpha1.PostgresqlProviderZalando).Name)
+}
+
+func TestPostgresqlCluster_testReconciledKeepsDefaultedFields(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+	current := PostgresqlCluster(cr, v1alpha1.PostgresqlProviderCloudNativePG)
+	_ = unstructured.SetNestedField(current.Object, "primary", "spec", "primaryUpdateStrategy")
+	_ = unstructured.SetNestedField(current.Object, "app", "spec", "bootstrap", "initdb", "database")
+	cr.Spec.PostgresDeploymentSpec.HighAvailability.Instances = 5
+
+	//when
+	reconciled := PostgresqlClusterReconciled(cr, v1alpha1.PostgresqlProviderCloudNativePG, current)
+
+	//then
+	instances, _, _ := unstructured.NestedInt64(reconciled.Object, "spec", "instances")
+	assert.Equal(t, int64(5), instances)
+	strategy, _, _ := unstructured.NestedString(reconciled.Object, "spec", "primaryUpdateStrategy")
+	assert.Equal(t, "primary", strategy)
+	database, _, _ := unstructured.NestedString(reconciled.Object, "spec", "bootstrap", "initdb", "database")
+	assert.Equal(t, "app", database)
+}
+
+func TestPostgresqlCluster_testReadiness(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cloudNativePG := PostgresqlCluster(cr, v1alpha1.PostgresqlProviderCloudNativePG)
+	zalando := PostgresqlCluster(cr, v1alpha1.PostgresqlProviderZalando)
+
+	//when
+	_ = unstructured.SetNestedField(cloudNativePG.Object, "Cluster in healthy state", "status", "phase")
+	_ = unstructured.SetNestedField(zalando.Object, "Creating", "status", "PostgresClusterStatus")
+
+	//then
+	assert.True(t, IsPostgresqlClusterReady(cloudNativePG))
+	assert.False(t, IsPostgresqlClusterReady(zalando))
+	assert.False(t, IsPostgresqlClusterReady(nil))
+}
+
+func TestPostgresqlCluster_testBackupsUseClusterClients(t *testing.T) {
+	//given
+	keycloak := &v1alpha1.Keycloak{}
+	keycloak.Spec.PostgresDeploymentSpec.HighAvailability.Enabled = true
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Verification.Enabled = true
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, keycloak)
+	restore := KeycloakMigrationRestore(keycloak, "backup")
+
+	//then
+	for _, container := range job.Spec.Template.Spec.InitContainers {
+		assert.Equal(t, Images.Images[PostgresqlClusterImage], container.Image)
+	}
+	assert.Equal(t, Images.Images[PostgresqlClusterImage], restore.Spec.Template.Spec.Containers[0].Image)
+	assert.Contains(t, restore.Spec.Template.Spec.Containers[0].Env, v1.EnvVar{Name: "PGHOST", Value: PostgresqlServiceName})
+}
@@ -36,7 +36,7 @@ func getPostgresResources(cr *v1alpha1.Keycloak) v1.ResourceRequirements {
 	return requirements
 }
 
-func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployment {
+func PostgresqlDeployment(cr *v1alpha1.Keycloak, version string, isOpenshift bool) *v13.Deployment {
 	v13Deployment := &v13.Deployment{
 		ObjectMeta: v12.ObjectMeta{
 			Name:      PostgresqlDeploymentName,
@@ -66,7 +66,7 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 					Containers: []v1.Container{
 						{
 							Name:  PostgresqlDeploymentName,
-							Image: Images.Images[PostgresqlImage],
+							Image: PostgresqlVersionImage(version),
 							Ports: []v1.ContainerPort{
 								{
 									ContainerPort: 5432,
@@ -138,7 +138,7 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 							Name: PostgresqlPersistentVolumeName,
 							VolumeSource: v1.VolumeSource{
 								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
-									ClaimName: PostgresqlPersistentVolumeName,
+									ClaimName: PostgresqlPersistentVolumeClaimName(version),
 								},
 							},
 						},
@@ -152,16 +152,16 @@ func PostgresqlDeployment(cr *v1alpha1.Keycloak, isOpenshift bool) *v13.Deployme
 	}
 
 	if !isOpenshift {
-		v13Deployment.Spec.Template.Spec.InitContainers = getPostgresqlDeploymentInitContainer(cr)
+		v13Deployment.Spec.Template.Spec.InitContainers = getPostgresqlDeploymentInitContainer(cr, version)
 	}
 	return v13Deployment
 }
 
-func getPostgresqlDeploymentInitContainer(cr *v1alpha1.Keycloak) []v1.Container {
+func getPostgresqlDeploymentInitContainer(cr *v1alpha1.Keycloak, version string) []v1.Container {
 	return []v1.Container{
 		{
 			Name:  "init-pvc",
-			Image: Images.Images[PostgresqlImage],
+			Image: PostgresqlVersionImage(version),
 			SecurityContext: &v1.SecurityContext{
 				RunAsUser: pointer.Int64Ptr(0),
 			},
@@ -188,7 +188,7 @@ func PostgresqlDeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	}
 }
 
-func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Deployment) *v13.Deployment {
+func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, version string, currentState *v13.Deployment) *v13.Deployment {
 	reconciled := currentState.DeepCopy()
 	reconciled.ResourceVersion = currentState.ResourceVersion
 	reconciled.Spec.Strategy = v13.DeploymentStrategy{
@@ -197,7 +197,7 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 	reconciled.Spec.Template.Spec.Containers = []v1.Container{
 		{
 			Name:  PostgresqlDeploymentName,
-			Image: Images.Images[PostgresqlImage],
+			Image: PostgresqlVersionImage(version),
 			Ports: []v1.ContainerPort{
 				{
 					ContainerPort: 5432,
@@ -269,7 +269,7 @@ func PostgresqlDeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Dep
 			Name: PostgresqlPersistentVolumeName,
 			VolumeSource: v1.VolumeSource{
 				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
-					ClaimName: PostgresqlPersistentVolumeName,
+					ClaimName: PostgresqlPersistentVolumeClaimName(version),
 				},
 			},
 		},
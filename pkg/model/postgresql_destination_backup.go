@@ -0,0 +1,428 @@
+package model
+
+import (
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
+	v13 "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	PostgresqlBackupFilePrefix = "keycloak-backup-"
+
+	postgresqlBackupVolumeName      = "backup"
+	postgresqlBackupPath            = "/backup"
+	postgresqlBackupStorageName     = "backup-storage"
+	postgresqlBackupStoragePath     = "/storage"
+	postgresqlBackupCredentialsName = "backup-credentials"
+	postgresqlBackupCredentialsPath = "/credentials"
+
+	// Backups are staged in an emptyDir before they're stored, every file and directory in the staging
+	// directory is a separate backup named after its file prefix and timestamp
+	postgresqlBackupStagingPath = postgresqlBackupPath + "/out"
+	postgresqlBackupTimestamp   = "$(date -u +%Y%m%dT%H%M%SZ)"
+
+	// The dump command of the database vendor is appended
+	postgresqlDumpScript = `set -eo pipefail
+mkdir -p ` + postgresqlBackupStagingPath + `
+`
+	postgresqlDumpOutput = ` | gzip > ` + postgresqlBackupStagingPath + `/` + PostgresqlBackupFilePrefix + postgresqlBackupTimestamp + `.sql.gz
+`
+
+	// Every destination defines upload_backup, download_backup, list_backups, printing the name and size of
+	// all backups with the file prefix, and delete_backup
+	backupPVCFunctions = `upload_backup() {
+  mkdir -p "$(dirname "` + postgresqlBackupStoragePath + `/$1")"
+  cp "$1" "` + postgresqlBackupStoragePath + `/$1"
+}
+download_backup() {
+  cp "` + postgresqlBackupStoragePath + `/$1" "$2"
+}
+list_backups() (
+  cd ` + postgresqlBackupStoragePath + `
+  for file in "$BACKUP_FILE_PREFIX"* "$BACKUP_FILE_PREFIX"*/*; do
+    if [ -f "$file" ]; then echo "$file $(wc -c < "$file")"; fi
+  done
+)
+delete_backup() {
+  rm -f "` + postgresqlBackupStoragePath + `/$1"
+  rmdir "$(dirname "` + postgresqlBackupStoragePath + `/$1")" 2> /dev/null || true
+}
+`
+	backupS3Functions = `if [ "$S3_FORCE_PATH_STYLE" = "true" ]; then
+  aws configure set default.s3.addressing_style path
+fi
+upload_backup() {
+  aws s3 cp "$1" "s3://${S3_BUCKET}/${BACKUP_PREFIX}$1" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} > /dev/null
+}
+download_backup() {
+  aws s3 cp "s3://${S3_BUCKET}/$1" "$2" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} > /dev/null
+}
+list_backups() {
+  aws s3api list-objects-v2 --bucket "$S3_BUCKET" --prefix "${BACKUP_PREFIX}${BACKUP_FILE_PREFIX}" --query "Contents[].[Key,Size]" --output text ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} | grep "$BACKUP_FILE_PREFIX" || true
+}
+delete_backup() {
+  aws s3 rm "s3://${S3_BUCKET}/$1" ${S3_ENDPOINT:+--endpoint-url "$S3_ENDPOINT"} > /dev/null
+}
+`
+	backupGCSFunctions = `gcloud auth activate-service-account --key-file=` + postgresqlBackupCredentialsPath + `/service-account.json --quiet
+upload_backup() {
+  gsutil -q cp "$1" "gs://${GCS_BUCKET}/${BACKUP_PREFIX}$1"
+}
+download_backup() {
+  gsutil -q cp "$1" "$2"
+}
+list_backups() {
+  gsutil ls -l "gs://${GCS_BUCKET}/${BACKUP_PREFIX}${BACKUP_FILE_PREFIX}**" 2> /dev/null | awk -v prefix="$BACKUP_FILE_PREFIX" 'index($3, prefix) { print $3, $1 }' || true
+}
+delete_backup() {
+  gsutil -q rm "$1"
+}
+`
+	backupAzureFunctions = `upload_backup() {
+  az storage blob upload --container-name "$AZURE_STORAGE_CONTAINER" --name "${BACKUP_PREFIX}$1" --file "$1" --only-show-errors > /dev/null
+}
+download_backup() {
+  az storage blob download --container-name "$AZURE_STORAGE_CONTAINER" --name "$1" --file "$2" --only-show-errors > /dev/null
+}
+list_backups() {
+  az storage blob list --container-name "$AZURE_STORAGE_CONTAINER" --prefix "${BACKUP_PREFIX}${BACKUP_FILE_PREFIX}" --query "[].[name, properties.contentLength]" --output tsv --only-show-errors
+}
+delete_backup() {
+  az storage blob delete --container-name "$AZURE_STORAGE_CONTAINER" --name "$1" --only-show-errors
+}
+`
+
+	backupUploadScript = `cd ` + postgresqlBackupStagingPath + `
+for file in * */*; do
+  if [ -f "$file" ]; then upload_backup "$file"; fi
+done
+`
+)
+
+// PostgresqlDestinationBackup returns a one-time backup Job for the destination set in the KeycloakBackup.
+func PostgresqlDestinationBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v13.Job {
+	return &v13.Job{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    PostgresqlBackupLabels(cr),
+		},
+		Spec: v13.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Labels: PostgresqlBackupLabels(cr),
+				},
+				Spec: postgresqlDestinationBackupPodSpec(cr, keycloak),
+			},
+		},
+	}
+}
+
+func PostgresqlDestinationBackupSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      cr.Name,
+		Namespace: cr.Namespace,
+	}
+}
+
+func PostgresqlDestinationBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v13.Job) *v13.Job {
+	reconciled := currentState.DeepCopy()
+	reconciled.Spec.Template.Labels = PostgresqlBackupLabels(cr)
+	reconciled.Spec.Template.Spec = postgresqlDestinationBackupPodSpec(cr, keycloak)
+	return reconciled
+}
+
+// PostgresqlDestinationPeriodicBackup returns a CronJob creating a backup in the destination set in the
+// KeycloakBackup on its schedule.
+func PostgresqlDestinationPeriodicBackup(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) *v1beta1.CronJob {
+	return &v1beta1.CronJob{
+		ObjectMeta: v12.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+			Labels:    PostgresqlBackupLabels(cr),
+		},
+		Spec: v1beta1.CronJobSpec{
+			Schedule: PostgresqlBackupSchedule(cr),
+			JobTemplate: v1beta1.JobTemplateSpec{
+				ObjectMeta: v12.ObjectMeta{
+					Name:      cr.Name,
+					Namespace: cr.Namespace,
+					Labels:    PostgresqlBackupLabels(cr),
+				},
+				Spec: v13.JobSpec{
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: v12.ObjectMeta{
+							Labels: PostgresqlBackupLabels(cr),
+						},
+						Spec: postgresqlDestinationBackupPodSpec(cr, keycloak),
+					},
+				},
+			},
+		},
+	}
+}
+
+func PostgresqlDestinationPeriodicBackupSelector(cr *v1alpha1.KeycloakBackup) client.ObjectKey {
+	return client.ObjectKey{
+		Name:      cr.Name,
+		Namespace: cr.Namespace,
+	}
+}
+
+func PostgresqlDestinationPeriodicBackupReconciled(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak, currentState *v1beta1.CronJob) *v1beta1.CronJob {
+	reconciled := currentState.DeepCopy()
+	reconciled.Spec.Schedule = PostgresqlBackupSchedule(cr)
+	reconciled.Spec.JobTemplate.Spec.Template.Labels = PostgresqlBackupLabels(cr)
+	reconciled.Spec.JobTemplate.Spec.Template.Spec = postgresqlDestinationBackupPodSpec(cr, keycloak)
+	return reconciled
+}
+
+// PostgresqlDestinationBackupClaimName returns the claim backups are stored in for a Persistent Volume destination.
+// The claim created by the operator is the one used for local backups.
+func PostgresqlDestinationBackupClaimName(cr *v1alpha1.KeycloakBackup) string {
+	if claimName := PostgresqlBackupDestination(cr).PersistentVolumeClaim.ClaimName; claimName != "" {
+		return claimName
+	}
+	return PostgresqlBackupPersistentVolumeName + "-" + cr.Name
+}
+
+// PostgresqlBackupDestination returns the destination backups are stored in, or nil for the legacy one-time
+// local and AWS backups. Local backups with a schedule, retention or verification, and local realm backups, are
+// stored in the backup Persistent Volume like with a persistentVolumeClaim destination.
+func PostgresqlBackupDestination(cr *v1alpha1.KeycloakBackup) *v1alpha1.KeycloakBackupDestination {
+	switch {
+	case cr.Spec.Destination != nil:
+		return cr.Spec.Destination
+	case cr.Spec.AWS == (v1alpha1.KeycloakAWSSpec{}) && (cr.Spec.Schedule != "" || cr.Spec.Retention != nil || cr.Spec.Verification.Enabled || cr.Spec.Mode == v1alpha1.BackupModeRealms):
+		return &v1alpha1.KeycloakBackupDestination{
+			PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{},
+		}
+	default:
+		return nil
+	}
+}
+
+// PostgresqlBackupSchedule returns the schedule of periodic backups, falling back to the deprecated one of aws.
+func PostgresqlBackupSchedule(cr *v1alpha1.KeycloakBackup) string {
+	if cr.Spec.Schedule != "" {
+		return cr.Spec.Schedule
+	}
+	return cr.Spec.AWS.Schedule
+}
+
+func PostgresqlBackupLabels(cr *v1alpha1.KeycloakBackup) map[string]string {
+	return map[string]string{
+		"app":                 ApplicationName,
+		"component":           PostgresqlBackupComponent,
+		PostgresqlBackupLabel: cr.Name,
+	}
+}
+
+// ValidateKeycloakBackupDestination checks that exactly one destination is set and that it's complete.
+func ValidateKeycloakBackupDestination(cr *v1alpha1.KeycloakBackup) error {
+	destination := cr.Spec.Destination
+	if destination == nil {
+		return nil
+	}
+
+	count := 0
+	for _, set := range []bool{destination.S3 != nil, destination.GCS != nil, destination.Azure != nil, destination.PersistentVolumeClaim != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return errors.Errorf("backup %v needs exactly one of s3, gcs, azure or persistentVolumeClaim to be set in destination", cr.Name)
+	}
+
+	switch {
+	case destination.S3 != nil && (destination.S3.Bucket == "" || destination.S3.CredentialsSecretName == ""):
+		return errors.Errorf("backup %v needs bucket and credentialsSecretName to be set for s3", cr.Name)
+	case destination.GCS != nil && (destination.GCS.Bucket == "" || destination.GCS.CredentialsSecretName == ""):
+		return errors.Errorf("backup %v needs bucket and credentialsSecretName to be set for gcs", cr.Name)
+	case destination.Azure != nil && (destination.Azure.StorageAccount == "" || destination.Azure.Container == "" || destination.Azure.CredentialsSecretName == ""):
+		return errors.Errorf("backup %v needs storageAccount, container and credentialsSecretName to be set for azure", cr.Name)
+	}
+	return nil
+}
+
+// The dump is written to an emptyDir by an init container, optionally verified, and stored by the main container
+func postgresqlDestinationBackupPodSpec(cr *v1alpha1.KeycloakBackup, keycloak *v1alpha1.Keycloak) v1.PodSpec {
+	podSpec := backupDestinationPodSpec(cr, PostgresqlBackupFilePrefix)
+	podSpec.InitContainers = []v1.Container{
+		postgresqlDumpContainer(keycloak, "dump", postgresqlDumpScript+databaseDumpCommand(keycloak)+postgresqlDumpOutput),
+	}
+	if cr.Spec.Verification.Enabled {
+		podSpec.Volumes = append(podSpec.Volumes, postgresqlBackupVerificationVolume())
+		podSpec.InitContainers = append(podSpec.InitContainers, postgresqlBackupVerificationContainer(cr, keycloak))
+	}
+	return podSpec
+}
+
+// backupDestinationPodSpec returns the pod spec storing the staged backups in the destination and pruning the
+// older ones. The init containers staging the backups are added by the callers.
+func backupDestinationPodSpec(cr *v1alpha1.KeycloakBackup, filePrefix string) v1.PodSpec {
+	podSpec := v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				Name: postgresqlBackupVolumeName,
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{},
+				},
+			},
+		},
+		RestartPolicy:      v1.RestartPolicyNever,
+		ServiceAccountName: PostgresqlBackupServiceAccountName,
+	}
+
+	store := backupDestinationContainer(cr, &podSpec, cr.Name, backupUploadScript+postgresqlBackupPruneScript, filePrefix)
+	store.Env = append(store.Env, postgresqlBackupRetentionEnv(cr)...)
+	podSpec.Containers = []v1.Container{store}
+	return podSpec
+}
+
+// backupDestinationContainer returns a container with the CLI of the destination, running the script after the
+// functions of the destination have been defined. The volumes needed by the container are added to the pod spec.
+func backupDestinationContainer(cr *v1alpha1.KeycloakBackup, podSpec *v1.PodSpec, name string, script string, filePrefix string) v1.Container {
+	container := v1.Container{
+		Name:    name,
+		Command: []string{"/bin/sh", "-c"},
+		Env: []v1.EnvVar{
+			{
+				// The CLIs keep their configuration in the home directory
+				Name:  "HOME",
+				Value: postgresqlBackupPath,
+			},
+			{
+				Name:  "BACKUP_FILE_PREFIX",
+				Value: filePrefix,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      postgresqlBackupVolumeName,
+				MountPath: postgresqlBackupPath,
+			},
+		},
+	}
+
+	var functions string
+	destination := PostgresqlBackupDestination(cr)
+	switch {
+	case destination.PersistentVolumeClaim != nil:
+		functions = backupPVCFunctions
+		container.Image = Images.Images[PostgresqlImage]
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      postgresqlBackupStorageName,
+			MountPath: postgresqlBackupStoragePath,
+		})
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			Name: postgresqlBackupStorageName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+					ClaimName: PostgresqlDestinationBackupClaimName(cr),
+				},
+			},
+		})
+	case destination.S3 != nil:
+		functions = backupS3Functions
+		container.Image = Images.Images[BackupS3Image]
+		container.Env = append(container.Env,
+			v1.EnvVar{Name: "S3_BUCKET", Value: destination.S3.Bucket},
+			v1.EnvVar{Name: "S3_ENDPOINT", Value: destination.S3.Endpoint},
+			v1.EnvVar{Name: "BACKUP_PREFIX", Value: destination.S3.Prefix},
+			postgresqlBackupSecretEnvVar("AWS_ACCESS_KEY_ID", destination.S3.CredentialsSecretName, "AWS_ACCESS_KEY_ID", false),
+			postgresqlBackupSecretEnvVar("AWS_SECRET_ACCESS_KEY", destination.S3.CredentialsSecretName, "AWS_SECRET_ACCESS_KEY", false),
+		)
+		if destination.S3.ForcePathStyle {
+			container.Env = append(container.Env, v1.EnvVar{Name: "S3_FORCE_PATH_STYLE", Value: "true"})
+		}
+		if destination.S3.Region != "" {
+			container.Env = append(container.Env, v1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: destination.S3.Region})
+		}
+	case destination.GCS != nil:
+		functions = backupGCSFunctions
+		container.Image = Images.Images[BackupGCSImage]
+		container.Env = append(container.Env,
+			v1.EnvVar{Name: "GCS_BUCKET", Value: destination.GCS.Bucket},
+			v1.EnvVar{Name: "BACKUP_PREFIX", Value: destination.GCS.Prefix},
+		)
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      postgresqlBackupCredentialsName,
+			MountPath: postgresqlBackupCredentialsPath,
+			ReadOnly:  true,
+		})
+		podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+			Name: postgresqlBackupCredentialsName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: destination.GCS.CredentialsSecretName,
+				},
+			},
+		})
+	case destination.Azure != nil:
+		functions = backupAzureFunctions
+		container.Image = Images.Images[BackupAzureImage]
+		container.Env = append(container.Env,
+			v1.EnvVar{Name: "AZURE_STORAGE_ACCOUNT", Value: destination.Azure.StorageAccount},
+			v1.EnvVar{Name: "AZURE_STORAGE_CONTAINER", Value: destination.Azure.Container},
+			v1.EnvVar{Name: "BACKUP_PREFIX", Value: destination.Azure.Prefix},
+			postgresqlBackupSecretEnvVar("AZURE_STORAGE_KEY", destination.Azure.CredentialsSecretName, "AZURE_STORAGE_KEY", true),
+			postgresqlBackupSecretEnvVar("AZURE_STORAGE_SAS_TOKEN", destination.Azure.CredentialsSecretName, "AZURE_STORAGE_SAS_TOKEN", true),
+		)
+	}
+	container.Args = []string{"set -eo pipefail\n" + functions + script}
+	return container
+}
+
+func postgresqlDumpContainer(keycloak *v1alpha1.Keycloak, name string, script string) v1.Container {
+	container := v1.Container{
+		Name:    name,
+		Image:   DatabaseClientImage(keycloak),
+		Command: []string{"/bin/sh", "-c"},
+		Args:    []string{script},
+		Env: []v1.EnvVar{
+			postgresqlBackupSecretEnvVar("PGUSER", DatabaseSecretName, DatabaseSecretUsernameProperty, false),
+			postgresqlBackupSecretEnvVar("PGPASSWORD", DatabaseSecretName, DatabaseSecretPasswordProperty, false),
+			postgresqlBackupSecretEnvVar("POSTGRES_DB", DatabaseSecretName, DatabaseSecretDatabaseProperty, false),
+			{
+				Name:  "PGHOST",
+				Value: PostgresqlServiceName,
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				Name:      postgresqlBackupVolumeName,
+				MountPath: postgresqlBackupPath,
+			},
+		},
+	}
+	// The shell of the MySQL image doesn't support pipefail
+	if isMySQLDatabase(keycloak) {
+		container.Command = []string{"/bin/bash", "-c"}
+		container.Env = mysqlClientEnv()
+	}
+	return container
+}
+
+func postgresqlBackupSecretEnvVar(name string, secretName string, key string, optional bool) v1.EnvVar {
+	envVar := v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: secretName,
+				},
+				Key: key,
+			},
+		},
+	}
+	if optional {
+		envVar.ValueFrom.SecretKeyRef.Optional = &[]bool{true}[0]
+	}
+	return envVar
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPostgresqlDestinationBackup_testS3CompatibleEndpoint(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		S3: &v1alpha1.KeycloakBackupS3Destination{
+			Bucket:                "keycloak",
+			Endpoint:              "https://minio.example.com:9000",
+			ForcePathStyle:        true,
+			CredentialsSecretName: "minio-secret",
+		},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "dump", podSpec.InitContainers[0].Name)
+	assert.NotNil(t, podSpec.Volumes[0].EmptyDir)
+	upload := podSpec.Containers[0]
+	assert.Equal(t, Images.Images[BackupS3Image], upload.Image)
+	assert.Contains(t, upload.Args[0], "--endpoint-url")
+	assert.Contains(t, upload.Env, v1.EnvVar{Name: "S3_ENDPOINT", Value: "https://minio.example.com:9000"})
+	assert.Contains(t, upload.Env, v1.EnvVar{Name: "S3_FORCE_PATH_STYLE", Value: "true"})
+	assert.Equal(t, "minio-secret", findEnvVar(upload.Env, "AWS_ACCESS_KEY_ID").ValueFrom.SecretKeyRef.Name)
+}
+
+func TestPostgresqlDestinationBackup_testGCSCredentialsVolume(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		GCS: &v1alpha1.KeycloakBackupGCSDestination{
+			Bucket:                "keycloak",
+			CredentialsSecretName: "gcs-secret",
+		},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, Images.Images[BackupGCSImage], podSpec.Containers[0].Image)
+	assert.Equal(t, "gcs-secret", podSpec.Volumes[1].Secret.SecretName)
+	assert.Equal(t, postgresqlBackupCredentialsPath, podSpec.Containers[0].VolumeMounts[1].MountPath)
+}
+
+func TestPostgresqlDestinationBackup_testAzureOptionalCredentials(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		Azure: &v1alpha1.KeycloakBackupAzureDestination{
+			StorageAccount:        "account",
+			Container:             "keycloak",
+			CredentialsSecretName: "azure-secret",
+		},
+	}
+
+	//when
+	job := PostgresqlDestinationBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	upload := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, Images.Images[BackupAzureImage], upload.Image)
+	assert.True(t, *findEnvVar(upload.Env, "AZURE_STORAGE_KEY").ValueFrom.SecretKeyRef.Optional)
+	assert.True(t, *findEnvVar(upload.Env, "AZURE_STORAGE_SAS_TOKEN").ValueFrom.SecretKeyRef.Optional)
+}
+
+func TestPostgresqlDestinationBackup_testPersistentVolumeClaim(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Name = "backup"
+	cr.Spec.Schedule = "0 3 * * *"
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{
+		PersistentVolumeClaim: &v1alpha1.KeycloakBackupPVCDestination{},
+	}
+
+	//when
+	cronJob := PostgresqlDestinationPeriodicBackup(cr, &v1alpha1.Keycloak{})
+
+	//then
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, "0 3 * * *", cronJob.Spec.Schedule)
+	assert.Equal(t, "dump", podSpec.InitContainers[0].Name)
+	assert.Equal(t, PostgresqlBackupPersistentVolumeName+"-backup", podSpec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, Images.Images[PostgresqlImage], podSpec.Containers[0].Image)
+}
+
+func TestValidateKeycloakBackupDestination(t *testing.T) {
+	//given
+	cr := &v1alpha1.KeycloakBackup{}
+	cr.Spec.Destination = &v1alpha1.KeycloakBackupDestination{}
+
+	//then
+	assert.Error(t, ValidateKeycloakBackupDestination(cr))
+
+	cr.Spec.Destination.S3 = &v1alpha1.KeycloakBackupS3Destination{Bucket: "keycloak"}
+	assert.Error(t, ValidateKeycloakBackupDestination(cr))
+
+	cr.Spec.Destination.S3.CredentialsSecretName = "s3-secret"
+	assert.NoError(t, ValidateKeycloakBackupDestination(cr))
+
+	cr.Spec.Destination.PersistentVolumeClaim = &v1alpha1.KeycloakBackupPVCDestination{}
+	assert.Error(t, ValidateKeycloakBackupDestination(cr))
+}
+
+func findEnvVar(envs []v1.EnvVar, name string) v1.EnvVar {
+	for _, env := range envs {
+		if env.Name == name {
+			return env
+		}
+	}
+	return v1.EnvVar{}
+}
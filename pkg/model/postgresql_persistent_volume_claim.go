@@ -8,10 +8,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func PostgresqlPersistentVolumeClaim(cr *v1alpha1.Keycloak) *v1.PersistentVolumeClaim {
+func PostgresqlPersistentVolumeClaim(cr *v1alpha1.Keycloak, version string) *v1.PersistentVolumeClaim {
 	return &v1.PersistentVolumeClaim{
 		ObjectMeta: v12.ObjectMeta{
-			Name:      PostgresqlPersistentVolumeName,
+			Name:      PostgresqlPersistentVolumeClaimName(version),
 			Namespace: cr.Namespace,
 			Labels: map[string]string{
 				"app": ApplicationName,
@@ -28,9 +28,9 @@ func PostgresqlPersistentVolumeClaim(cr *v1alpha1.Keycloak) *v1.PersistentVolume
 	}
 }
 
-func PostgresqlPersistentVolumeClaimSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
+func PostgresqlPersistentVolumeClaimSelector(cr *v1alpha1.Keycloak, version string) client.ObjectKey {
 	return client.ObjectKey{
-		Name:      PostgresqlPersistentVolumeName,
+		Name:      PostgresqlPersistentVolumeClaimName(version),
 		Namespace: cr.Namespace,
 	}
 }
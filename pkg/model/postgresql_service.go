@@ -10,7 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func getSpec(dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
+func getSpec(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
 	spec := v1.ServiceSpec{}
 	isIPAddress := dbSecret != nil && dbSecret.Data[DatabaseSecretExternalAddressProperty] != nil && IsIP(dbSecret.Data[DatabaseSecretExternalAddressProperty])
 
@@ -34,8 +34,8 @@ func getSpec(dbSecret *v1.Secret, serviceTypeExternal bool) v1.ServiceSpec {
 
 	spec.Ports = []v1.ServicePort{
 		{
-			Port:       GetExternalDatabasePort(dbSecret),
-			TargetPort: intstr.Parse(fmt.Sprintf("%d", GetExternalDatabasePort(dbSecret))),
+			Port:       GetExternalDatabasePort(cr, dbSecret),
+			TargetPort: intstr.Parse(fmt.Sprintf("%d", GetExternalDatabasePort(cr, dbSecret))),
 		},
 	}
 
@@ -51,7 +51,7 @@ func PostgresqlService(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, serviceTypeEx
 				"app": ApplicationName,
 			},
 		},
-		Spec: getSpec(dbSecret, serviceTypeExternal),
+		Spec: getSpec(cr, dbSecret, serviceTypeExternal),
 	}
 }
 
@@ -62,7 +62,7 @@ func PostgresqlServiceSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 	}
 }
 
-func PostgresqlServiceReconciled(currentState *v1.Service, dbSecret *v1.Secret, serviceTypeExternal bool) *v1.Service {
+func PostgresqlServiceReconciled(cr *v1alpha1.Keycloak, currentState *v1.Service, dbSecret *v1.Secret, serviceTypeExternal bool) *v1.Service {
 	reconciled := currentState.DeepCopy()
 	if !serviceTypeExternal {
 		reconciled.Spec.Type = v1.ServiceTypeClusterIP
@@ -77,7 +77,7 @@ func PostgresqlServiceReconciled(currentState *v1.Service, dbSecret *v1.Secret,
 			},
 		}
 	} else {
-		reconciled.Spec = getSpec(dbSecret, serviceTypeExternal)
+		reconciled.Spec = getSpec(cr, dbSecret, serviceTypeExternal)
 	}
 	return reconciled
 }
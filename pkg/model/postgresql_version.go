@@ -0,0 +1,54 @@
+package model
+
+import (
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
+)
+
+var postgresqlVersionImages = map[string]string{
+	"12": PostgresqlImage12,
+	"13": PostgresqlImage13,
+	"15": PostgresqlImage15,
+}
+
+// PostgresqlVersion returns the major version the embedded database should run
+func PostgresqlVersion(cr *v1alpha1.Keycloak) string {
+	if cr == nil || cr.Spec.PostgresDeploymentSpec.PostgresVersion == "" {
+		return PostgresqlDefaultVersion
+	}
+	return cr.Spec.PostgresDeploymentSpec.PostgresVersion
+}
+
+// PostgresqlVersionImage returns the image of the major version of the embedded database
+func PostgresqlVersionImage(version string) string {
+	if image, ok := postgresqlVersionImages[version]; ok {
+		return Images.Images[image]
+	}
+	return Images.Images[PostgresqlImage]
+}
+
+// PostgresqlPersistentVolumeClaimName returns the claim the data of the major version is stored in. Every
+// upgrade restores the data into a new claim, the claim of the default version keeps its previous name.
+func PostgresqlPersistentVolumeClaimName(version string) string {
+	if version == PostgresqlDefaultVersion {
+		return PostgresqlPersistentVolumeName
+	}
+	return PostgresqlPersistentVolumeName + "-" + version
+}
+
+// SetPostgresqlDeploymentVersion switches the Deployment of the embedded database to the image and the claim
+// of the major version
+func SetPostgresqlDeploymentVersion(deployment *v13.Deployment, version string) {
+	podSpec := &deployment.Spec.Template.Spec
+	for i := range podSpec.InitContainers {
+		podSpec.InitContainers[i].Image = PostgresqlVersionImage(version)
+	}
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].Image = PostgresqlVersionImage(version)
+	}
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].PersistentVolumeClaim != nil {
+			podSpec.Volumes[i].PersistentVolumeClaim.ClaimName = PostgresqlPersistentVolumeClaimName(version)
+		}
+	}
+}
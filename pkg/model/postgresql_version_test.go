@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresqlVersion_testDeploymentOfVersion(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Spec.PostgresDeploymentSpec.PostgresVersion = "15"
+
+	//when
+	deployment := PostgresqlDeployment(cr, PostgresqlVersion(cr), false)
+	claim := PostgresqlPersistentVolumeClaim(cr, PostgresqlVersion(cr))
+
+	//then
+	assert.Equal(t, Images.Images[PostgresqlImage15], deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, PostgresqlPersistentVolumeName+"-15", claim.Name)
+	assert.Equal(t, claim.Name, deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+}
+
+func TestPostgresqlVersion_testDefaultVersionKeepsClaim(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+
+	//when
+	deployment := PostgresqlDeployment(cr, PostgresqlVersion(cr), false)
+	secret := DatabaseSecret(cr)
+
+	//then
+	assert.Equal(t, PostgresqlDefaultVersion, GetDatabaseVersion(secret))
+	assert.Equal(t, Images.Images[PostgresqlImage], deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, PostgresqlPersistentVolumeName, deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+}
+
+func TestPostgresqlVersion_testSetDeploymentVersion(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	deployment := PostgresqlDeployment(cr, PostgresqlDefaultVersion, false)
+
+	//when
+	SetPostgresqlDeploymentVersion(deployment, "13")
+
+	//then
+	for _, container := range deployment.Spec.Template.Spec.InitContainers {
+		assert.Equal(t, Images.Images[PostgresqlImage13], container.Image)
+	}
+	assert.Equal(t, Images.Images[PostgresqlImage13], deployment.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, PostgresqlPersistentVolumeName+"-13", deployment.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+}
@@ -1,109 +1,227 @@
 package model
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
 	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/pkg/errors"
 	v12 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func PrometheusRule(cr *v1alpha1.Keycloak) *monitoringv1.PrometheusRule {
-	rules := []monitoringv1.Rule{{
-		Alert: "KeycloakJavaNonHeapThresholdExceeded",
-		Annotations: map[string]string{
-			"message": `{{ printf "%0.0f" $value }}% nonheap usage of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }}.`,
-		},
-		Expr: intstr.FromString(`100 * jvm_memory_bytes_used{area="nonheap",namespace="` + cr.Namespace + `"} / jvm_memory_bytes_max{area="nonheap",namespace="` + cr.Namespace + `"} > 90`),
-		For:  "1m",
-		Labels: map[string]string{
-			"severity": "warning",
+const (
+	prometheusRuleGeneralGroup = "general.rules"
+	prometheusRuleCustomGroup  = "custom.rules"
+	// Replaced with the threshold of the alert in its expression and annotations
+	alertThresholdPlaceholder = "$threshold"
+)
+
+// keycloakAlert is an alert of the operator. Alerts without a default threshold can't be given one.
+type keycloakAlert struct {
+	rule             monitoringv1.Rule
+	defaultThreshold string
+}
+
+func keycloakAlerts(cr *v1alpha1.Keycloak) []keycloakAlert {
+	alerts := []keycloakAlert{{
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakJavaNonHeapThresholdExceeded",
+			Annotations: map[string]string{
+				"message": `{{ printf "%0.0f" $value }}% nonheap usage of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }}.`,
+			},
+			Expr: intstr.FromString(`100 * jvm_memory_bytes_used{area="nonheap",namespace="` + cr.Namespace + `"} / jvm_memory_bytes_max{area="nonheap",namespace="` + cr.Namespace + `"} > $threshold`),
+			For:  "1m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "90",
 	}, {
-		Alert: "KeycloakJavaGCTimePerMinuteScavenge",
-		Annotations: map[string]string{
-			"message": `Amount of time per minute spent on garbage collection of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }} exceeds 90%. This could indicate that the available heap memory is insufficient.`,
-		},
-		Expr: intstr.FromString(`increase(jvm_gc_collection_seconds_sum{gc="PS Scavenge",namespace="` + cr.Namespace + `"}[1m]) > 1 * 60 * 0.9`),
-		For:  "1m",
-		Labels: map[string]string{
-			"severity": "warning",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakJavaGCTimePerMinuteScavenge",
+			Annotations: map[string]string{
+				"message": `Amount of time per minute spent on garbage collection of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }} exceeds 90%. This could indicate that the available heap memory is insufficient.`,
+			},
+			Expr: intstr.FromString(`increase(jvm_gc_collection_seconds_sum{gc="PS Scavenge",namespace="` + cr.Namespace + `"}[1m]) > 1 * 60 * $threshold`),
+			For:  "1m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "0.9",
 	}, {
-		Alert: "KeycloakJavaGCTimePerMinuteMarkSweep",
-		Annotations: map[string]string{
-			"message": `Amount of time per minute spent on garbage collection of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }} exceeds 90%. This could indicate that the available heap memory is insufficient.`,
-		},
-		Expr: intstr.FromString(`increase(jvm_gc_collection_seconds_sum{gc="PS MarkSweep",namespace="` + cr.Namespace + `"}[1m]) > 1 * 60 * 0.9`),
-		For:  "1m",
-		Labels: map[string]string{
-			"severity": "warning",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakJavaGCTimePerMinuteMarkSweep",
+			Annotations: map[string]string{
+				"message": `Amount of time per minute spent on garbage collection of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }} exceeds 90%. This could indicate that the available heap memory is insufficient.`,
+			},
+			Expr: intstr.FromString(`increase(jvm_gc_collection_seconds_sum{gc="PS MarkSweep",namespace="` + cr.Namespace + `"}[1m]) > 1 * 60 * $threshold`),
+			For:  "1m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "0.9",
 	}, {
-		Alert: "KeycloakJavaDeadlockedThreads",
-		Annotations: map[string]string{
-			"message": `Number of threads in deadlock state of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }}`,
-		},
-		Expr: intstr.FromString(`jvm_threads_deadlocked{namespace="` + cr.Namespace + `"} > 0`),
-		For:  "1m",
-		Labels: map[string]string{
-			"severity": "warning",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakJavaDeadlockedThreads",
+			Annotations: map[string]string{
+				"message": `Number of threads in deadlock state of {{ $labels.area }} in pod {{ $labels.pod }}, namespace {{ $labels.namespace }}`,
+			},
+			Expr: intstr.FromString(`jvm_threads_deadlocked{namespace="` + cr.Namespace + `"} > $threshold`),
+			For:  "1m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "0",
 	}, {
-		Alert: "KeycloakLoginFailedThresholdExceeded",
-		Annotations: map[string]string{
-			"message": `More than 50 failed login attempts for realm {{ $labels.realm }}, provider {{ $labels.provider }}, namespace {{ $labels.namespace }} over the last 5 minutes. (Rate of {{ printf "%0f" $value }})`,
-		},
-		Expr: intstr.FromString(`rate(keycloak_failed_login_attempts{namespace="` + cr.Namespace + `"}[5m]) * 300 > 50`),
-		For:  "5m",
-		Labels: map[string]string{
-			"severity": "warning",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakLoginFailedThresholdExceeded",
+			Annotations: map[string]string{
+				"message": `More than $threshold failed login attempts for realm {{ $labels.realm }}, provider {{ $labels.provider }}, namespace {{ $labels.namespace }} over the last 5 minutes. (Rate of {{ printf "%0f" $value }})`,
+			},
+			Expr: intstr.FromString(`rate(keycloak_failed_login_attempts{namespace="` + cr.Namespace + `"}[5m]) * 300 > $threshold`),
+			For:  "5m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "50",
 	}, {
-		Alert: "KeycloakInstanceNotAvailable",
-		Annotations: map[string]string{
-			"message": `Keycloak instance in namespace {{ $labels.namespace }} has not been available for the last 5 minutes.`,
-		},
-		Expr: intstr.FromString(`(1 - absent(kube_pod_status_ready{namespace="` + cr.Namespace + `", condition="true"} * on (pod) group_left (label_component) kube_pod_labels{label_component="` + KeycloakDeploymentComponent + `", namespace="` + cr.Namespace + `"})) == 0`),
-		For:  "5m",
-		Labels: map[string]string{
-			"severity": "critical",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakInstanceNotAvailable",
+			Annotations: map[string]string{
+				"message": `Keycloak instance in namespace {{ $labels.namespace }} has not been available for the last 5 minutes.`,
+			},
+			Expr: intstr.FromString(`(1 - absent(kube_pod_status_ready{namespace="` + cr.Namespace + `", condition="true"} * on (pod) group_left (label_component) kube_pod_labels{label_component="` + KeycloakDeploymentComponent + `", namespace="` + cr.Namespace + `"})) == 0`),
+			For:  "5m",
+			Labels: map[string]string{
+				"severity": "critical",
+			},
 		},
 	}, {
-		Alert: "KeycloakAPIRequestDuration90PercThresholdExceeded",
-		Annotations: map[string]string{
-			"message": `More than 10% the RH SSO API endpoints in namespace {{ $labels.namespace }} are taking longer than 1s for the last 5 minutes.`,
-		},
-		Expr: intstr.FromString(`(sum(rate(keycloak_request_duration_bucket{le="1000.0", namespace="` + cr.Namespace + `"}[5m])) by (job) / sum(rate(keycloak_request_duration_count{namespace="` + cr.Namespace + `"}[5m])) by (job)) < 0.90`),
-		For:  "5m",
-		Labels: map[string]string{
-			"severity": "warning",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakAPIRequestDuration90PercThresholdExceeded",
+			Annotations: map[string]string{
+				"message": `More than 10% the RH SSO API endpoints in namespace {{ $labels.namespace }} are taking longer than 1s for the last 5 minutes.`,
+			},
+			Expr: intstr.FromString(`(sum(rate(keycloak_request_duration_bucket{le="1000.0", namespace="` + cr.Namespace + `"}[5m])) by (job) / sum(rate(keycloak_request_duration_count{namespace="` + cr.Namespace + `"}[5m])) by (job)) < $threshold`),
+			For:  "5m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
 		},
+		defaultThreshold: "0.90",
 	}, {
-		Alert: "KeycloakAPIRequestDuration99.5PercThresholdExceeded",
-		Annotations: map[string]string{
-			"message": `More than 0.5% of the RH SSO API endpoints in namespace {{ $labels.namespace }} are taking longer than 10s for the last 5 minutes.`,
-		},
-		Expr: intstr.FromString(`(sum(rate(keycloak_request_duration_bucket{le="10000.0", namespace="` + cr.Namespace + `"}[5m])) by (job) / sum(rate(keycloak_request_duration_count{namespace="` + cr.Namespace + `"}[5m])) by (job)) < 0.995`),
-		For:  "5m",
-		Labels: map[string]string{
-			"severity": "warning",
-		},
-	}}
-
-	if !cr.Spec.ExternalDatabase.Enabled {
-		rules = append(rules, monitoringv1.Rule{
-			Alert: "KeycloakDatabaseNotAvailable",
+		rule: monitoringv1.Rule{
+			Alert: "KeycloakAPIRequestDuration99.5PercThresholdExceeded",
 			Annotations: map[string]string{
-				"message": `RH SSO database in namespace {{ $labels.namespace }} is not available for the last 5 minutes.`,
+				"message": `More than 0.5% of the RH SSO API endpoints in namespace {{ $labels.namespace }} are taking longer than 10s for the last 5 minutes.`,
 			},
-			Expr: intstr.FromString(`(1 - absent(kube_pod_status_ready{namespace="` + cr.Namespace + `", condition="true"} * on (pod) group_left (label_component) kube_pod_labels{label_component="` + PostgresqlDeploymentComponent + `", namespace="` + cr.Namespace + `"})) == 0 `),
+			Expr: intstr.FromString(`(sum(rate(keycloak_request_duration_bucket{le="10000.0", namespace="` + cr.Namespace + `"}[5m])) by (job) / sum(rate(keycloak_request_duration_count{namespace="` + cr.Namespace + `"}[5m])) by (job)) < $threshold`),
 			For:  "5m",
 			Labels: map[string]string{
-				"severity": "critical",
+				"severity": "warning",
+			},
+		},
+		defaultThreshold: "0.995",
+	}}
+
+	if !cr.Spec.ExternalDatabase.Enabled {
+		alerts = append(alerts, keycloakAlert{
+			rule: monitoringv1.Rule{
+				Alert: "KeycloakDatabaseNotAvailable",
+				Annotations: map[string]string{
+					"message": `RH SSO database in namespace {{ $labels.namespace }} is not available for the last 5 minutes.`,
+				},
+				Expr: intstr.FromString(`(1 - absent(kube_pod_status_ready{namespace="` + cr.Namespace + `", condition="true"} * on (pod) group_left (label_component) kube_pod_labels{label_component="` + PostgresqlDeploymentComponent + `", namespace="` + cr.Namespace + `"})) == 0 `),
+				For:  "5m",
+				Labels: map[string]string{
+					"severity": "critical",
+				},
 			},
 		})
 	}
+	return alerts
+}
+
+// keycloakAlertRule applies the overrides of the monitoring section to an alert of the operator. The labels of
+// the alert are overridden by the common labels, then by the labels and the severity of the alert.
+func keycloakAlertRule(monitoring v1alpha1.KeycloakMonitoring, alert keycloakAlert) monitoringv1.Rule {
+	rule := alert.rule
+	override := monitoring.Alerts[rule.Alert]
+
+	threshold := alert.defaultThreshold
+	if override.Threshold != "" {
+		threshold = override.Threshold
+	}
+	rule.Expr = intstr.FromString(strings.ReplaceAll(rule.Expr.String(), alertThresholdPlaceholder, threshold))
+	if override.For != "" {
+		rule.For = override.For
+	}
+
+	rule.Labels = mergeAlertLabels(rule.Labels, monitoring.Labels, override.Labels)
+	if override.Severity != "" {
+		rule.Labels["severity"] = override.Severity
+	}
+
+	annotations := map[string]string{}
+	for key, value := range rule.Annotations {
+		annotations[key] = strings.ReplaceAll(value, alertThresholdPlaceholder, threshold)
+	}
+	for key, value := range override.Annotations {
+		annotations[key] = value
+	}
+	rule.Annotations = annotations
+	return rule
+}
+
+func mergeAlertLabels(labels ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, l := range labels {
+		for key, value := range l {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+func PrometheusRule(cr *v1alpha1.Keycloak) *monitoringv1.PrometheusRule {
+	monitoring := cr.Spec.Monitoring
+
+	var rules []monitoringv1.Rule
+	for _, alert := range keycloakAlerts(cr) {
+		if monitoring.Alerts[alert.rule.Alert].Disabled {
+			continue
+		}
+		rules = append(rules, keycloakAlertRule(monitoring, alert))
+	}
+	groups := []monitoringv1.RuleGroup{{
+		Name:  prometheusRuleGeneralGroup,
+		Rules: rules,
+	}}
+
+	if len(monitoring.CustomRules) > 0 {
+		var customRules []monitoringv1.Rule
+		for _, customRule := range monitoring.CustomRules {
+			customRules = append(customRules, monitoringv1.Rule{
+				Alert:       customRule.Alert,
+				Expr:        intstr.FromString(customRule.Expr),
+				For:         customRule.For,
+				Labels:      mergeAlertLabels(monitoring.Labels, customRule.Labels),
+				Annotations: customRule.Annotations,
+			})
+		}
+		groups = append(groups, monitoringv1.RuleGroup{
+			Name:  prometheusRuleCustomGroup,
+			Rules: customRules,
+		})
+	}
 
 	return &monitoringv1.PrometheusRule{
 		ObjectMeta: v12.ObjectMeta{
@@ -116,10 +234,7 @@ func PrometheusRule(cr *v1alpha1.Keycloak) *monitoringv1.PrometheusRule {
 			},
 		},
 		Spec: monitoringv1.PrometheusRuleSpec{
-			Groups: []monitoringv1.RuleGroup{{
-				Name:  "general.rules",
-				Rules: rules,
-			}},
+			Groups: groups,
 		},
 	}
 }
@@ -130,3 +245,72 @@ func PrometheusRuleSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 		Namespace: cr.Namespace,
 	}
 }
+
+// PrometheusRuleReconciled replaces the rule groups of the operator in the current PrometheusRule, keeping the
+// groups, labels and annotations added by others
+func PrometheusRuleReconciled(cr *v1alpha1.Keycloak, currentState *monitoringv1.PrometheusRule) *monitoringv1.PrometheusRule {
+	desired := PrometheusRule(cr)
+	reconciled := currentState.DeepCopy()
+	if reconciled.Labels == nil {
+		reconciled.Labels = map[string]string{}
+	}
+	for key, value := range desired.Labels {
+		reconciled.Labels[key] = value
+	}
+
+	groups := desired.Spec.Groups
+	for _, group := range currentState.Spec.Groups {
+		if group.Name != prometheusRuleGeneralGroup && group.Name != prometheusRuleCustomGroup {
+			groups = append(groups, group)
+		}
+	}
+	reconciled.Spec.Groups = groups
+	return reconciled
+}
+
+// ValidateKeycloakMonitoring checks that the overrides name alerts of the operator and that the custom rules
+// don't clash with them
+func ValidateKeycloakMonitoring(cr *v1alpha1.Keycloak) error {
+	monitoring := cr.Spec.Monitoring
+	alerts := map[string]keycloakAlert{}
+	var names []string
+	// The database alert only exists for the embedded database, but can be tuned regardless
+	embeddedDatabase := cr.DeepCopy()
+	embeddedDatabase.Spec.ExternalDatabase.Enabled = false
+	for _, alert := range keycloakAlerts(embeddedDatabase) {
+		alerts[alert.rule.Alert] = alert
+		names = append(names, alert.rule.Alert)
+	}
+	sort.Strings(names)
+
+	for name, override := range monitoring.Alerts {
+		alert, ok := alerts[name]
+		if !ok {
+			return errors.Errorf("monitoring.alerts has unknown alert %v, the alerts are %v", name, strings.Join(names, ", "))
+		}
+		if override.Threshold == "" {
+			continue
+		}
+		if alert.defaultThreshold == "" {
+			return errors.Errorf("alert %v has no threshold", name)
+		}
+		if _, err := strconv.ParseFloat(override.Threshold, 64); err != nil {
+			return errors.Errorf("the threshold %v of alert %v isn't a number", override.Threshold, name)
+		}
+	}
+
+	customNames := map[string]bool{}
+	for _, customRule := range monitoring.CustomRules {
+		if customRule.Alert == "" || customRule.Expr == "" {
+			return errors.Errorf("monitoring.customRules need an alert and an expr")
+		}
+		if _, ok := alerts[customRule.Alert]; ok {
+			return errors.Errorf("custom rule %v has the name of an alert of the operator, override the alert in monitoring.alerts instead", customRule.Alert)
+		}
+		if customNames[customRule.Alert] {
+			return errors.Errorf("custom rule %v is listed more than once", customRule.Alert)
+		}
+		customNames[customRule.Alert] = true
+	}
+	return nil
+}
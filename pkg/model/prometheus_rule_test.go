@@ -0,0 +1,147 @@
+package model
+
+import (
+	"testing"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusRule_testDefaults(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+
+	//when
+	prometheusRule := PrometheusRule(cr)
+
+	//then
+	assert.Len(t, prometheusRule.Spec.Groups, 1)
+	rules := prometheusRule.Spec.Groups[0].Rules
+	assert.Len(t, rules, 9)
+	loginFailed := findAlertRule(rules, "KeycloakLoginFailedThresholdExceeded")
+	assert.Equal(t, `rate(keycloak_failed_login_attempts{namespace="keycloak"}[5m]) * 300 > 50`, loginFailed.Expr.String())
+	assert.Contains(t, loginFailed.Annotations["message"], "More than 50 failed login attempts")
+	assert.Equal(t, "5m", loginFailed.For)
+	assert.Equal(t, map[string]string{"severity": "warning"}, loginFailed.Labels)
+	assert.Contains(t, findAlertRule(rules, "KeycloakJavaGCTimePerMinuteScavenge").Expr.String(), "> 1 * 60 * 0.9")
+}
+
+func TestPrometheusRule_testOverrides(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	cr.Spec.ExternalDatabase.Enabled = true
+	cr.Spec.Monitoring = v1alpha1.KeycloakMonitoring{
+		Labels: map[string]string{"team": "sre", "severity": "info"},
+		Alerts: map[string]v1alpha1.KeycloakAlertOverride{
+			"KeycloakLoginFailedThresholdExceeded": {
+				Threshold:   "100",
+				For:         "10m",
+				Severity:    "critical",
+				Labels:      map[string]string{"team": "iam"},
+				Annotations: map[string]string{"runbook_url": "https://runbooks.example.com/keycloak"},
+			},
+			"KeycloakJavaDeadlockedThreads": {Disabled: true},
+		},
+		CustomRules: []v1alpha1.KeycloakAlertRule{{
+			Alert: "KeycloakTooManySessions",
+			Expr:  `sum(keycloak_sessions) > 10000`,
+			For:   "15m",
+		}},
+	}
+
+	//when
+	prometheusRule := PrometheusRule(cr)
+
+	//then
+	rules := prometheusRule.Spec.Groups[0].Rules
+	assert.Len(t, rules, 7)
+	assert.Nil(t, findAlertRule(rules, "KeycloakJavaDeadlockedThreads"))
+	loginFailed := findAlertRule(rules, "KeycloakLoginFailedThresholdExceeded")
+	assert.Equal(t, `rate(keycloak_failed_login_attempts{namespace="keycloak"}[5m]) * 300 > 100`, loginFailed.Expr.String())
+	assert.Contains(t, loginFailed.Annotations["message"], "More than 100 failed login attempts")
+	assert.Equal(t, "https://runbooks.example.com/keycloak", loginFailed.Annotations["runbook_url"])
+	assert.Equal(t, "10m", loginFailed.For)
+	assert.Equal(t, map[string]string{"severity": "critical", "team": "iam"}, loginFailed.Labels)
+	assert.Equal(t, map[string]string{"severity": "info", "team": "sre"}, findAlertRule(rules, "KeycloakInstanceNotAvailable").Labels)
+
+	assert.Equal(t, prometheusRuleCustomGroup, prometheusRule.Spec.Groups[1].Name)
+	customRule := prometheusRule.Spec.Groups[1].Rules[0]
+	assert.Equal(t, "KeycloakTooManySessions", customRule.Alert)
+	assert.Equal(t, `sum(keycloak_sessions) > 10000`, customRule.Expr.String())
+	assert.Equal(t, "sre", customRule.Labels["team"])
+}
+
+func TestPrometheusRule_testReconciledKeepsForeignGroups(t *testing.T) {
+	//given
+	cr := &v1alpha1.Keycloak{}
+	cr.Namespace = "keycloak"
+	currentState := PrometheusRule(cr)
+	currentState.ResourceVersion = "42"
+	currentState.Labels["owner"] = "sre"
+	currentState.Spec.Groups = append(currentState.Spec.Groups,
+		monitoringv1.RuleGroup{Name: prometheusRuleCustomGroup},
+		monitoringv1.RuleGroup{Name: "sre.rules", Rules: []monitoringv1.Rule{{Alert: "KeycloakSLOBurnRate"}}})
+	cr.Spec.Monitoring.Alerts = map[string]v1alpha1.KeycloakAlertOverride{
+		"KeycloakInstanceNotAvailable": {For: "2m"},
+	}
+
+	//when
+	reconciled := PrometheusRuleReconciled(cr, currentState)
+
+	//then
+	assert.Equal(t, "42", reconciled.ResourceVersion)
+	assert.Equal(t, "sre", reconciled.Labels["owner"])
+	assert.Equal(t, "alert-rules", reconciled.Labels["role"])
+	assert.Len(t, reconciled.Spec.Groups, 2)
+	assert.Equal(t, prometheusRuleGeneralGroup, reconciled.Spec.Groups[0].Name)
+	assert.Equal(t, "2m", findAlertRule(reconciled.Spec.Groups[0].Rules, "KeycloakInstanceNotAvailable").For)
+	assert.Equal(t, "sre.rules", reconciled.Spec.Groups[1].Name)
+}
+
+func TestPrometheusRule_testValidation(t *testing.T) {
+	//given
+	unknownAlert := &v1alpha1.Keycloak{}
+	unknownAlert.Spec.Monitoring.Alerts = map[string]v1alpha1.KeycloakAlertOverride{"KeycloakDown": {Disabled: true}}
+	noThreshold := &v1alpha1.Keycloak{}
+	noThreshold.Spec.Monitoring.Alerts = map[string]v1alpha1.KeycloakAlertOverride{"KeycloakInstanceNotAvailable": {Threshold: "1"}}
+	invalidThreshold := &v1alpha1.Keycloak{}
+	invalidThreshold.Spec.Monitoring.Alerts = map[string]v1alpha1.KeycloakAlertOverride{"KeycloakLoginFailedThresholdExceeded": {Threshold: "many"}}
+	clashingCustomRule := &v1alpha1.Keycloak{}
+	clashingCustomRule.Spec.Monitoring.CustomRules = []v1alpha1.KeycloakAlertRule{{Alert: "KeycloakInstanceNotAvailable", Expr: "up == 0"}}
+	noExpr := &v1alpha1.Keycloak{}
+	noExpr.Spec.Monitoring.CustomRules = []v1alpha1.KeycloakAlertRule{{Alert: "KeycloakTooManySessions"}}
+	valid := &v1alpha1.Keycloak{}
+	valid.Spec.ExternalDatabase.Enabled = true
+	valid.Spec.Monitoring.Alerts = map[string]v1alpha1.KeycloakAlertOverride{
+		"KeycloakDatabaseNotAvailable":                        {Disabled: true},
+		"KeycloakAPIRequestDuration99.5PercThresholdExceeded": {Threshold: "0.99"},
+	}
+
+	//when
+	unknownAlertErr := ValidateKeycloakMonitoring(unknownAlert)
+	noThresholdErr := ValidateKeycloakMonitoring(noThreshold)
+	invalidThresholdErr := ValidateKeycloakMonitoring(invalidThreshold)
+	clashingCustomRuleErr := ValidateKeycloakMonitoring(clashingCustomRule)
+	noExprErr := ValidateKeycloakMonitoring(noExpr)
+	validErr := ValidateKeycloakMonitoring(valid)
+
+	//then
+	assert.Error(t, unknownAlertErr)
+	assert.Error(t, noThresholdErr)
+	assert.Error(t, invalidThresholdErr)
+	assert.Error(t, clashingCustomRuleErr)
+	assert.Error(t, noExprErr)
+	assert.NoError(t, validErr)
+}
+
+func findAlertRule(rules []monitoringv1.Rule, alert string) *monitoringv1.Rule {
+	for i := range rules {
+		if rules[i].Alert == alert {
+			return &rules[i]
+		}
+	}
+	return nil
+}
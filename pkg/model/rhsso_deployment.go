@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
 	v13 "k8s.io/api/apps/v1"
@@ -15,20 +16,16 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		// Database settings
 		{
 			Name:  "DB_SERVICE_PREFIX_MAPPING",
-			Value: PostgresqlServiceName + "=DB",
+			Value: rhssoDatabaseServiceMapping(cr) + "=DB",
 		},
 		{
 			Name:  "TX_DATABASE_PREFIX_MAPPING",
-			Value: PostgresqlServiceName + "=DB",
+			Value: rhssoDatabaseServiceMapping(cr) + "=DB",
 		},
 		{
 			Name:  "DB_JNDI",
 			Value: "java:jboss/datasources/KeycloakDS",
 		},
-		{
-			Name:  "DB_SCHEMA",
-			Value: "public",
-		},
 		{
 			Name: "DB_USERNAME",
 			ValueFrom: &v1.EnvVarSource{
@@ -55,24 +52,10 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			Name:  "DB_DATABASE",
 			Value: GetExternalDatabaseName(dbSecret),
 		},
-		// Discovery settings
-		{
-			Name:  "JGROUPS_PING_PROTOCOL",
-			Value: "dns.DNS_PING",
-		},
-		{
-			Name:  "OPENSHIFT_DNS_PING_SERVICE_NAME",
-			Value: KeycloakDiscoveryServiceName + "." + cr.Namespace + ".svc.cluster.local",
-		},
-		// Cache settings
-		{
-			Name:  "CACHE_OWNERS_COUNT",
-			Value: "2",
-		},
-		{
-			Name:  "CACHE_OWNERS_AUTH_SESSIONS_COUNT",
-			Value: "2",
-		},
+	}
+	// Discovery and cache settings
+	env = append(env, rhssoClusteringEnv(cr)...)
+	env = append(env, []v1.EnvVar{
 		{
 			Name: "SSO_ADMIN_USERNAME",
 			ValueFrom: &v1.EnvVarSource{
@@ -103,16 +86,23 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 			Name:  "STATISTICS_ENABLED",
 			Value: "TRUE",
 		},
+	}...)
+
+	if schema := getDatabaseSchema(cr); schema != "" {
+		env = append(env, v1.EnvVar{
+			Name:  "DB_SCHEMA",
+			Value: schema,
+		})
 	}
 
 	if cr.Spec.ExternalDatabase.Enabled {
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_HOST"),
+			Name:  rhssoServiceEnvVar(cr, "SERVICE_HOST"),
 			Value: PostgresqlServiceName + "." + cr.Namespace + ".svc.cluster.local",
 		})
 		env = append(env, v1.EnvVar{
-			Name:  GetServiceEnvVar("SERVICE_PORT"),
-			Value: fmt.Sprintf("%v", GetExternalDatabasePort(dbSecret)),
+			Name:  rhssoServiceEnvVar(cr, "SERVICE_PORT"),
+			Value: fmt.Sprintf("%v", GetExternalDatabasePort(cr, dbSecret)),
 		})
 	}
 
@@ -121,16 +111,31 @@ func getRHSSOEnv(cr *v1alpha1.Keycloak, dbSecret *v1.Secret) []v1.EnvVar {
 		env = MergeEnvs(cr.Spec.KeycloakDeploymentSpec.Experimental.Env, env)
 	}
 
-	env = RHSSOSslEnvVariables(dbSecret, env)
+	env = RHSSOSslEnvVariables(cr, dbSecret, env)
 
 	return env
 }
 
-func RHSSOSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
+func RHSSOSslEnvVariables(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 	if dbSecret != nil {
 		sslMode := string(dbSecret.Data[DatabaseSecretSslModeProperty])
 
-		if sslMode != "" {
+		// The PostgreSQL driver reads the CA certificate from the certificate path by default
+		if sslMode != "" && GetDatabaseVendor(cr) != v1alpha1.DatabaseVendorPostgres {
+			for _, param := range databaseConnectionParams(GetDatabaseVendor(cr), sslMode, RhssoCertificatePath) {
+				property := strings.SplitN(param, "=", 2)
+				env = append(env,
+					v1.EnvVar{
+						Name:  RhssoDatabaseXAConnectionParamsProperty + "_" + property[0],
+						Value: property[1],
+					},
+					v1.EnvVar{
+						Name:  RhssoDatabaseNONXAConnectionParamsProperty + "_" + property[0],
+						Value: property[1],
+					},
+				)
+			}
+		} else if sslMode != "" {
 			// append env variable
 			env = append(env,
 				v1.EnvVar{
@@ -148,6 +153,10 @@ func RHSSOSslEnvVariables(dbSecret *v1.Secret, env []v1.EnvVar) []v1.EnvVar {
 }
 
 func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
+	volumeMounts := append(KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath), KeycloakWritableVolumeMounts(cr, RhssoStandalonePath)...)
+	volumeMounts = append(volumeMounts, KeycloakThemeVolumeMounts(cr, RhssoThemesPath)...)
+	volumeMounts = append(volumeMounts, KeycloakClusteringVolumeMounts(cr, RhssoPostconfigurePath)...)
+
 	podLabels := AddPodLabels(cr, GetLabelsSelector())
 	podAnnotations := cr.Spec.KeycloakDeploymentSpec.PodAnnotations
 	rhssoStatefulSet := &v13.StatefulSet{
@@ -170,10 +179,12 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 					Annotations: podAnnotations,
 				},
 				Spec: v1.PodSpec{
-					Volumes:        KeycloakVolumes(cr, dbSSLSecret),
-					InitContainers: KeycloakExtensionsInitContainers(cr),
-					Affinity:       KeycloakPodAffinity(cr),
-					Containers: []v1.Container{
+					Volumes:          KeycloakVolumes(cr, dbSSLSecret),
+					InitContainers:   KeycloakInitContainers(cr, Images.Images[RHSSOImage], RhssoStandalonePath),
+					Affinity:         KeycloakPodAffinity(cr),
+					SecurityContext:  KeycloakPodSecurityContext(cr),
+					ImagePullSecrets: KeycloakImagePullSecrets(cr),
+					Containers: addSidecarsFromKeycloakCR(cr, []v1.Container{
 						{
 							Name:  KeycloakDeploymentName,
 							Image: Images.Images[RHSSOImage],
@@ -200,12 +211,13 @@ func RHSSODeployment(cr *v1alpha1.Keycloak, dbSecret *v1.Secret, dbSSLSecret *v1
 							Env:             getRHSSOEnv(cr, dbSecret),
 							Args:            cr.Spec.KeycloakDeploymentSpec.Experimental.Args,
 							Command:         cr.Spec.KeycloakDeploymentSpec.Experimental.Command,
-							VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath),
+							VolumeMounts:    volumeMounts,
 							Resources:       getResources(cr),
 							ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+							SecurityContext: KeycloakContainerSecurityContext(cr),
 						},
-					},
-					ServiceAccountName: cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName,
+					}),
+					ServiceAccountName: KeycloakServiceAccount(cr),
 				},
 			},
 		},
@@ -228,6 +240,10 @@ func RHSSODeploymentSelector(cr *v1alpha1.Keycloak) client.ObjectKey {
 }
 
 func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.StatefulSet, dbSecret *v1.Secret, dbSSLSecret *v1.Secret) *v13.StatefulSet {
+	volumeMounts := append(KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath), KeycloakWritableVolumeMounts(cr, RhssoStandalonePath)...)
+	volumeMounts = append(volumeMounts, KeycloakThemeVolumeMounts(cr, RhssoThemesPath)...)
+	volumeMounts = append(volumeMounts, KeycloakClusteringVolumeMounts(cr, RhssoPostconfigurePath)...)
+
 	reconciled := currentState.DeepCopy()
 
 	reconciled.ObjectMeta.Labels = AddPodLabels(cr, reconciled.ObjectMeta.Labels)
@@ -235,14 +251,14 @@ func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Stateful
 	reconciled.Spec.Template.ObjectMeta.Labels = AddPodLabels(cr, reconciled.Spec.Template.ObjectMeta.Labels)
 	reconciled.Spec.Template.ObjectMeta.Annotations = AddPodAnnotations(cr, reconciled.Spec.Template.ObjectMeta.Annotations)
 	reconciled.Spec.Selector.MatchLabels = GetLabelsSelector()
-	reconciled.Spec.Template.Spec.ServiceAccountName = cr.Spec.KeycloakDeploymentSpec.Experimental.ServiceAccountName
+	reconciled.Spec.Template.Spec.ServiceAccountName = KeycloakServiceAccount(cr)
 
 	reconciled.ResourceVersion = currentState.ResourceVersion
 	if !cr.Spec.DisableReplicasSyncing {
 		reconciled.Spec.Replicas = SanitizeNumberOfReplicas(cr.Spec.Instances, false)
 	}
 	reconciled.Spec.Template.Spec.Volumes = KeycloakVolumes(cr, dbSSLSecret)
-	reconciled.Spec.Template.Spec.Containers = []v1.Container{
+	reconciled.Spec.Template.Spec.Containers = addSidecarsFromKeycloakCR(cr, []v1.Container{
 		{
 			Name:    KeycloakDeploymentName,
 			Image:   Images.Images[RHSSOImage],
@@ -266,15 +282,18 @@ func RHSSODeploymentReconciled(cr *v1alpha1.Keycloak, currentState *v13.Stateful
 					Protocol:      "TCP",
 				},
 			},
-			VolumeMounts:    KeycloakVolumeMounts(cr, RhssoExtensionPath, dbSSLSecret, RhssoCertificatePath),
+			VolumeMounts:    volumeMounts,
 			LivenessProbe:   livenessProbe(),
 			ReadinessProbe:  readinessProbe(),
 			Env:             getRHSSOEnv(cr, dbSecret),
 			Resources:       getResources(cr),
 			ImagePullPolicy: cr.Spec.KeycloakDeploymentSpec.ImagePullPolicy,
+			SecurityContext: KeycloakContainerSecurityContext(cr),
 		},
-	}
-	reconciled.Spec.Template.Spec.InitContainers = KeycloakExtensionsInitContainers(cr)
+	})
+	reconciled.Spec.Template.Spec.InitContainers = KeycloakInitContainers(cr, Images.Images[RHSSOImage], RhssoStandalonePath)
+	reconciled.Spec.Template.Spec.SecurityContext = KeycloakPodSecurityContext(cr)
+	reconciled.Spec.Template.Spec.ImagePullSecrets = KeycloakImagePullSecrets(cr)
 	if cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity != nil {
 		reconciled.Spec.Template.Spec.Affinity = cr.Spec.KeycloakDeploymentSpec.Experimental.Affinity
 	}
@@ -47,3 +47,23 @@ func TestRHSSODeployment_testServiceAccountSetExperimental(t *testing.T) {
 func TestRHSSODeployment_testServiceAccountReconciledSetExperimental(t *testing.T) {
 	testServiceAccountReconciledSet(t, RHSSODeployment, RHSSODeploymentReconciled)
 }
+
+func TestRHSSODeployment_testDefaultSecurityContext(t *testing.T) {
+	testDefaultSecurityContext(t, RHSSODeployment, RhssoStandalonePath)
+}
+
+func TestRHSSODeployment_testSecurityContextSet(t *testing.T) {
+	testSecurityContextSet(t, RHSSODeployment)
+}
+
+func TestRHSSODeploymentReconciled_testSecurityContextReconciled(t *testing.T) {
+	testSecurityContextReconciled(t, RHSSODeployment, RHSSODeploymentReconciled)
+}
+
+func TestRHSSODeployment_testSidecarsAndInitContainers(t *testing.T) {
+	testSidecarsAndInitContainers(t, RHSSODeployment)
+}
+
+func TestRHSSODeploymentReconciled_testSidecarsAndInitContainersReconciled(t *testing.T) {
+	testSidecarsAndInitContainersReconciled(t, RHSSODeployment, RHSSODeploymentReconciled)
+}
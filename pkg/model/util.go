@@ -10,6 +10,7 @@ import (
 	"unicode"
 
 	"github.com/keycloak/keycloak-operator/pkg/apis/keycloak/v1alpha1"
+	v13 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -116,15 +117,23 @@ func GetExternalDatabaseName(secret *v1.Secret) string {
 	return string(name)
 }
 
-func GetExternalDatabasePort(secret *v1.Secret) int32 {
+// GetDatabaseVersion returns the major version of the database the data in the volume has been written by
+func GetDatabaseVersion(secret *v1.Secret) string {
+	if secret == nil || len(secret.Data[DatabaseSecretVersionProperty]) == 0 {
+		return PostgresqlDefaultVersion
+	}
+	return string(secret.Data[DatabaseSecretVersionProperty])
+}
+
+func GetExternalDatabasePort(cr *v1alpha1.Keycloak, secret *v1.Secret) int32 {
 	if secret == nil {
-		return PostgresDefaultPort
+		return GetDatabaseDefaultPort(GetDatabaseVendor(cr))
 	}
 
 	port := secret.Data[DatabaseSecretExternalPortProperty]
 	parsed, err := strconv.ParseInt(string(port), 10, 32)
 	if err != nil {
-		return PostgresDefaultPort
+		return GetDatabaseDefaultPort(GetDatabaseVendor(cr))
 	}
 	return int32(parsed)
 }
@@ -274,3 +283,39 @@ func AddPodAnnotations(cr *v1alpha1.Keycloak, annotations map[string]string) map
 
 	return mergedAnnotations
 }
+
+// setPodTemplateAnnotation sets the annotation on the pod template, or removes it if value is empty.
+// The annotations are copied first as they might be shared with the CR.
+func setPodTemplateAnnotation(statefulSet *v13.StatefulSet, key string, value string) {
+	_, annotated := statefulSet.Spec.Template.Annotations[key]
+	if !annotated && value == "" {
+		return
+	}
+
+	annotations := map[string]string{}
+	for k, v := range statefulSet.Spec.Template.Annotations {
+		annotations[k] = v
+	}
+	if value == "" {
+		delete(annotations, key)
+	} else {
+		annotations[key] = value
+	}
+	statefulSet.Spec.Template.Annotations = annotations
+}
+
+// KeycloakContextRoot returns the context root of unmanaged external instances, the others are served at /auth
+func KeycloakContextRoot(kc v1alpha1.Keycloak) string {
+	if kc.Spec.External.Enabled && kc.Spec.Unmanaged {
+		return kc.Spec.External.ContextRoot
+	}
+	return ""
+}
+
+// KeycloakPath returns the path of Keycloak served at url below the context root
+func KeycloakPath(url string, contextRoot string) string {
+	if contextRoot != "" {
+		return url + contextRoot
+	}
+	return url + "/auth/"
+}
@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sort"
@@ -420,7 +421,7 @@ func keycloakClientDefaultRolesTest(t *testing.T, framework *test.Framework, ctx
 }
 
 func getClientRoleID(authenticatedClient common.KeycloakInterface, clientName, roleName string) (string, error) {
-	retrievedRoles, err := authenticatedClient.ListClientRoles(clientName, realmName)
+	retrievedRoles, err := authenticatedClient.ListClientRoles(context.TODO(), clientName, realmName)
 	if err != nil {
 		return "", err
 	}
@@ -438,7 +439,7 @@ func getRole(retrievedRoles []keycloakv1alpha1.RoleRepresentation, roleName stri
 
 func waitForClientRoles(t *testing.T, framework *test.Framework, keycloakCR keycloakv1alpha1.Keycloak, clientCR *keycloakv1alpha1.KeycloakClient, expected []keycloakv1alpha1.RoleRepresentation) error {
 	return WaitForConditionWithClient(t, framework, keycloakCR, func(authenticatedClient common.KeycloakInterface) error {
-		roles, err := authenticatedClient.ListClientRoles(clientCR.Spec.Client.ID, realmName)
+		roles, err := authenticatedClient.ListClientRoles(context.TODO(), clientCR.Spec.Client.ID, realmName)
 		if err != nil {
 			return err
 		}
@@ -476,12 +477,12 @@ func waitForDefaultClientRoles(t *testing.T, framework *test.Framework, keycloak
 	return WaitForConditionWithClient(t, framework, keycloakCR, func(authenticatedClient common.KeycloakInterface) error {
 		fail := false
 
-		realm, err := authenticatedClient.GetRealm(realmName)
+		realm, err := authenticatedClient.GetRealm(context.TODO(), realmName)
 		if err != nil {
 			return err
 		}
 
-		defaultRoles, err := authenticatedClient.ListRealmRoleClientRoleComposites(realmName, realm.Spec.Realm.DefaultRole.ID, clientCR.Spec.Client.ID)
+		defaultRoles, err := authenticatedClient.ListRealmRoleClientRoleComposites(context.TODO(), realmName, realm.Spec.Realm.DefaultRole.ID, clientCR.Spec.Client.ID)
 		if err != nil {
 			return err
 		}
@@ -635,7 +636,7 @@ func keycloakClientScopeMappingsTest(t *testing.T, framework *test.Framework, ct
 		return err
 	}
 
-	retrievedMappings, err := authenticatedClient.ListScopeMappings(clientName, realmName)
+	retrievedMappings, err := authenticatedClient.ListScopeMappings(context.TODO(), clientName, realmName)
 	if err != nil {
 		return err
 	}
@@ -703,13 +704,13 @@ func keycloakClientServiceAccountRealmRolesTest(t *testing.T, framework *test.Fr
 
 func assertServiceAccountRoles(t *testing.T, framework *test.Framework, keycloakCR keycloakv1alpha1.Keycloak, clientID string, expectedRealmRoles []string, expectedClientRoles map[string][]string) {
 	err := WaitForConditionWithClient(t, framework, keycloakCR, func(authenticatedClient common.KeycloakInterface) error {
-		serviceAccountUser, err := authenticatedClient.GetServiceAccountUser(realmName, clientID)
+		serviceAccountUser, err := authenticatedClient.GetServiceAccountUser(context.TODO(), realmName, clientID)
 		if err != nil {
 			return err
 		}
 
 		// get realm role names
-		actualRealmRoles, err := authenticatedClient.ListUserRealmRoles(realmName, serviceAccountUser.ID)
+		actualRealmRoles, err := authenticatedClient.ListUserRealmRoles(context.TODO(), realmName, serviceAccountUser.ID)
 		if err != nil {
 			return err
 		}
@@ -721,7 +722,7 @@ func assertServiceAccountRoles(t *testing.T, framework *test.Framework, keycloak
 		// get role names for all specified clients
 		var actualClientRolesNames = map[string][]string{}
 		for k := range expectedClientRoles {
-			roles, err := authenticatedClient.ListUserClientRoles(realmName, k, serviceAccountUser.ID)
+			roles, err := authenticatedClient.ListUserClientRoles(context.TODO(), realmName, k, serviceAccountUser.ID)
 			if err != nil {
 				return err
 			}
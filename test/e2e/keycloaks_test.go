@@ -219,14 +219,14 @@ func deployPostgreSQLWithSSLon(secretWithSSLCertForPostgres *v1.Secret, cr *keyc
 		MountPath: "/opt/app-root/src/postgresql-cfg/",
 	}
 
-	pvc := model.PostgresqlPersistentVolumeClaim(cr)
+	pvc := model.PostgresqlPersistentVolumeClaim(cr, model.PostgresqlDefaultVersion)
 	// changing the name as apparently another one is created by the operator
 	pvc.Name = externalPostgresClaim
 	err = f.Client.Create(context.TODO(), pvc, &framework.CleanupOptions{TestContext: ctx, Timeout: cleanupTimeout, RetryInterval: cleanupRetryInterval})
 	if err != nil {
 		return nil, err
 	}
-	postgresql := model.PostgresqlDeployment(cr, false)
+	postgresql := model.PostgresqlDeployment(cr, model.PostgresqlDefaultVersion, false)
 	//postgresql.Spec.Template.Spec.Containers[0].Image = "postgres:10.5-alpine"
 	postgresql.Spec.Template.Spec.Volumes = append(postgresql.Spec.Template.Spec.Volumes, volume, volumeConfig)
 	postgresql.Spec.Template.Spec.Containers[0].VolumeMounts = append(postgresql.Spec.Template.Spec.Containers[0].VolumeMounts, volumeMount, volumeMountConfig)